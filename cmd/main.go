@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/levion-studio/paybazaar/internal/config"
 	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/jobs"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/routes"
 	"github.com/levion-studio/paybazaar/pkg"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
 )
 
 func main() {
@@ -26,17 +30,62 @@ func run() error {
 	}
 	defer db.Close()
 
+	rechargeKit := newRechargeKitClient(cfg)
+	registry := providers.NewRegistry(
+		providers.NewRechargeKit(rechargeKit),
+	)
+	if cfg.UseAEPSSimulator {
+		if cfg.ServerEnv == "production" {
+			log.Println("AEPS_SIMULATOR is ignored in production")
+		} else {
+			log.Println("using the aeps simulator")
+			registry.Register(providers.NewAEPSSimulator())
+		}
+	}
+	providerRouter := providers.NewRouter(db, registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := jobs.NewScheduler()
+	defer scheduler.Wait()
+	defer cancel()
+
+	scheduler.Every(ctx, "reconciliation", cfg.ReconciliationInterval, jobs.Reconciliation(db))
+	scheduler.Every(ctx, "idempotency cleanup", cfg.IdempotencyCleanupInterval, jobs.IdempotencyCleanup(db))
+	scheduler.Every(ctx, "status check", cfg.StatusCheckInterval, jobs.StatusCheck(db, providerRouter.Registry()))
+	scheduler.Every(ctx, "commision release", cfg.CommisionReleaseInterval, jobs.CommisionRelease(db))
+
 	jwtUtils := pkg.NewJwtUtils(pkg.JwtConfig{
 		SecretKey: cfg.SecretKey,
 		Expiry:    cfg.Expiry,
 	})
 
 	router := routes.NewRoutes(routes.Config{
-		ServerENV:   cfg.ServerEnv,
-		JWTUtils:    jwtUtils,
-		Database:    db,
-		RechargeKit: &cfg.RechargeKitConfig,
+		ServerENV:         cfg.ServerEnv,
+		TrustedProxies:    cfg.TrustedProxies,
+		JWTUtils:          jwtUtils,
+		Database:          db,
+		RechargeKit:       &cfg.RechargeKitConfig,
+		RechargeKitClient: rechargeKit,
+		ProviderRouter:    providerRouter,
 	})
 
 	return router.Router.Start(cfg.ServerPort)
 }
+
+func newRechargeKitClient(cfg *config.Config) *rechargekit.Client {
+	if cfg.UseFake {
+		if cfg.ServerEnv == "production" {
+			log.Println("RKIT_FAKE is ignored in production")
+		} else {
+			fake := rechargekit.NewFakeServer()
+			log.Printf("using fake rechargekit at %s", fake.URL())
+			return fake.Client()
+		}
+	}
+	return rechargekit.New(rechargekit.Config{
+		RechargeBaseURL: cfg.RechargeBaseURL,
+		PrimaryBaseURL:  cfg.PrimaryBaseURL,
+		APIToken:        cfg.APIToken,
+		Timeout:         cfg.RechargeKitConfig.Timeout,
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,11 +14,17 @@ type Config struct {
 	DatabaseConfig
 	JwtConfig
 	RechargeKitConfig
+	AEPSConfig
+	JobsConfig
 }
 
 type ServerConfig struct {
 	ServerPort string
 	ServerEnv  string
+	// TrustedProxies are the CIDR ranges of the load balancers in front of
+	// the server. X-Forwarded-For is only honoured when the request comes
+	// from one of them.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -30,7 +37,30 @@ type JwtConfig struct {
 }
 
 type RechargeKitConfig struct {
-	APIToken string
+	RechargeBaseURL string
+	PrimaryBaseURL  string
+	APIToken        string
+	Timeout         time.Duration
+	// UseFake runs RechargeKit in-process instead of calling the real API,
+	// for local development. It is ignored in production.
+	UseFake bool
+	// CallbackSecret authenticates status callbacks from RechargeKit.
+	CallbackSecret string
+	// CallbackAllowedIPs restricts callbacks to these addresses when set.
+	CallbackAllowedIPs []string
+}
+
+type AEPSConfig struct {
+	// UseAEPSSimulator registers the in-process AEPS simulator as a
+	// provider, for local development. It is ignored in production.
+	UseAEPSSimulator bool
+}
+
+type JobsConfig struct {
+	ReconciliationInterval     time.Duration
+	IdempotencyCleanupInterval time.Duration
+	StatusCheckInterval        time.Duration
+	CommisionReleaseInterval   time.Duration
 }
 
 func Load() *Config {
@@ -39,8 +69,9 @@ func Load() *Config {
 	}
 	return &Config{
 		ServerConfig: ServerConfig{
-			ServerPort: os.Getenv("SERVER_PORT"),
-			ServerEnv:  os.Getenv("SERVER_ENV"),
+			ServerPort:     os.Getenv("SERVER_PORT"),
+			ServerEnv:      os.Getenv("SERVER_ENV"),
+			TrustedProxies: listEnv("TRUSTED_PROXIES"),
 		},
 		DatabaseConfig: DatabaseConfig{
 			DatabaseURL: os.Getenv("DATABASE_URL"),
@@ -50,7 +81,48 @@ func Load() *Config {
 			Expiry:    24 * time.Hour,
 		},
 		RechargeKitConfig: RechargeKitConfig{
-			APIToken: os.Getenv("RKIT_API_TOKEN"),
+			RechargeBaseURL:    os.Getenv("RKIT_RECHARGE_BASE_URL"),
+			PrimaryBaseURL:     os.Getenv("RKIT_PRIMARY_BASE_URL"),
+			APIToken:           os.Getenv("RKIT_API_TOKEN"),
+			Timeout:            durationEnv("RKIT_TIMEOUT", 20*time.Second),
+			UseFake:            os.Getenv("RKIT_FAKE") == "true",
+			CallbackSecret:     os.Getenv("RKIT_CALLBACK_SECRET"),
+			CallbackAllowedIPs: listEnv("RKIT_CALLBACK_IPS"),
 		},
+		AEPSConfig: AEPSConfig{
+			UseAEPSSimulator: os.Getenv("AEPS_SIMULATOR") == "true",
+		},
+		JobsConfig: JobsConfig{
+			ReconciliationInterval:     durationEnv("RECONCILIATION_INTERVAL", 24*time.Hour),
+			IdempotencyCleanupInterval: durationEnv("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+			StatusCheckInterval:        durationEnv("STATUS_CHECK_INTERVAL", time.Minute),
+			CommisionReleaseInterval:   durationEnv("COMMISION_RELEASE_INTERVAL", time.Hour),
+		},
+	}
+}
+
+// durationEnv parses a duration such as "6h" from the environment, falling
+// back to def when the variable is unset or invalid.
+func durationEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// listEnv splits a comma separated environment variable, ignoring blanks.
+func listEnv(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
 	}
+	return list
 }
@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
@@ -218,82 +219,13 @@ func (db *Database) UpdateAdminWalletQuery(
 	}
 	defer tx.Rollback(ctx)
 
-	var beforeBalance float64
-	getBalanceQuery := `
-		SELECT admin_wallet_balance
-		FROM admins
-		WHERE admin_id = @admin_id;
-	`
-
-	if err := tx.QueryRow(
-		ctx,
-		getBalanceQuery,
-		pgx.NamedArgs{
-			"admin_id": req.AdminID,
-		},
-	).Scan(&beforeBalance); err != nil {
-		return fmt.Errorf("failed to fetch admin wallet balance")
-	}
-
-	afterBalance := beforeBalance + req.Amount
-
-	updateWalletQuery := `
-		UPDATE admins
-		SET admin_wallet_balance = @after_balance,
-		    updated_at = NOW()
-		WHERE admin_id = @admin_id;
-	`
-
-	tag, err := tx.Exec(
-		ctx,
-		updateWalletQuery,
-		pgx.NamedArgs{
-			"admin_id":      req.AdminID,
-			"after_balance": afterBalance,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update admin wallet")
-	}
-
-	if tag.RowsAffected() == 0 {
-		return fmt.Errorf("invalid admin id or admin not found")
-	}
-
-	insertTransactionQuery := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		);
-	`
-
-	if _, err := tx.Exec(
-		ctx,
-		insertTransactionQuery,
-		pgx.NamedArgs{
-			"user_id":            req.AdminID,
-			"reference_id":       req.AdminID,
-			"credit_amount":      req.Amount,
-			"before_balance":     beforeBalance,
-			"after_balance":      afterBalance,
-			"transaction_reason": "TOPUP",
-			"remarks":            "Admin wallet topup",
-		},
-	); err != nil {
-		return fmt.Errorf("failed to insert wallet transaction")
+	// Top-ups bring money into the platform, so they are funded from the
+	// external funding account.
+	journal := ledger.NewJournal(req.AdminID, "TOPUP", "Admin wallet topup").
+		Debit(ledger.Funding, req.Amount, "").
+		Credit(ledger.User(req.AdminID), req.Amount, "")
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
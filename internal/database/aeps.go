@@ -0,0 +1,443 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+// AEPS works the other way round from the other services: the customer's
+// bank pays the platform through the provider, and the retailer, who hands
+// the customer cash or sells to it, is credited on success. The amount goes
+// to the retailer's settlement wallet, from which it is settled to the main
+// wallet or the bank (see aeps_settlement.go). Nothing is reserved, so a
+// failed AEPS transaction has nothing to return, and one the provider
+// reverses after success is refunded like any other (see refund.go). Cash
+// withdrawals also earn the hierarchy the AEPS commission, funded by the
+// commission pool so that a short admin wallet never holds back the
+// retailer's credit. Only the last four digits of the Aadhaar number
+// are stored.
+
+func aadhaarLastDigits(aadhaarNumber string) string {
+	return aadhaarNumber[max(len(aadhaarNumber)-4, 0):]
+}
+
+// CreateAEPSTransactionQuery records a cash withdrawal or Aadhaar Pay
+// before it is sent to the provider, with the commission a cash withdrawal
+// will pay, and returns its transaction id.
+func (db *Database) CreateAEPSTransactionQuery(
+	ctx context.Context,
+	req models.AEPSTransactionRequestModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
+	}
+	quote := &models.TransactionQuoteModel{Service: "AEPS"}
+	if req.TransactionType == "CASH_WITHDRAWAL" {
+		if quote, err = quoteTransaction(ctx, tx, "AEPS", req.RetailerID, 0, req.Amount); err != nil {
+			return "", err
+		}
+	}
+	commision := quote.Commision
+
+	query := `
+		INSERT INTO aeps_transactions (
+			partner_request_id,
+			retailer_id,
+			transaction_type,
+			customer_mobile_number,
+			aadhaar_last_digits,
+			bank_iin,
+			bank_name,
+			amount,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision,
+			transaction_status
+		) VALUES (
+			@partner_request_id,
+			@retailer_id,
+			@transaction_type,
+			@customer_mobile_number,
+			@aadhaar_last_digits,
+			@bank_iin,
+			@bank_name,
+			@amount,
+			@md_commision,
+			@dis_commision,
+			@retailer_commision,
+			@status
+		)
+		RETURNING aeps_transaction_id::TEXT;
+	`
+	var transactionID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id":     req.PartnerRequestID,
+		"retailer_id":            req.RetailerID,
+		"transaction_type":       req.TransactionType,
+		"customer_mobile_number": req.CustomerMobileNumber,
+		"aadhaar_last_digits":    aadhaarLastDigits(req.AadhaarNumber),
+		"bank_iin":               req.BankIIN,
+		"bank_name":              req.BankName,
+		"amount":                 req.Amount,
+		"md_commision":           commision.MasterDistributorCommision,
+		"dis_commision":          commision.DistributorCommision,
+		"retailer_commision":     commision.RetailerCommision,
+		"status":                 txstate.Initiated,
+	}).Scan(&transactionID); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "AEPS", transactionID, txstate.Initiated, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+	if err := accruePendingCommisions(ctx, tx, transactionID, quote); err != nil {
+		return "", err
+	}
+	return transactionID, tx.Commit(ctx)
+}
+
+// RecordAEPSEnquiryQuery records a balance enquiry or mini statement with
+// the provider's answer. Enquiries move no money, so they are stored with
+// their final status.
+func (db *Database) RecordAEPSEnquiryQuery(
+	ctx context.Context,
+	req models.AEPSTransactionRequestModel,
+	provider string,
+	res *models.AEPSEnquiryResponseModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var balance *models.Money
+	if res.Status == txstate.Success {
+		balance = &res.AccountBalance
+	}
+
+	query := `
+		INSERT INTO aeps_transactions (
+			partner_request_id,
+			operator_transaction_id,
+			order_id,
+			retailer_id,
+			transaction_type,
+			customer_mobile_number,
+			aadhaar_last_digits,
+			bank_iin,
+			bank_name,
+			account_balance,
+			provider,
+			message,
+			transaction_status
+		) VALUES (
+			@partner_request_id,
+			@operator_transaction_id,
+			@order_id,
+			@retailer_id,
+			@transaction_type,
+			@customer_mobile_number,
+			@aadhaar_last_digits,
+			@bank_iin,
+			@bank_name,
+			@account_balance,
+			@provider,
+			@message,
+			@status
+		)
+		RETURNING aeps_transaction_id::TEXT;
+	`
+	var transactionID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id":      req.PartnerRequestID,
+		"operator_transaction_id": res.OperatorTransactionID,
+		"order_id":                res.OrderID,
+		"retailer_id":             req.RetailerID,
+		"transaction_type":        req.TransactionType,
+		"customer_mobile_number":  req.CustomerMobileNumber,
+		"aadhaar_last_digits":     aadhaarLastDigits(req.AadhaarNumber),
+		"bank_iin":                req.BankIIN,
+		"bank_name":               req.BankName,
+		"account_balance":         balance,
+		"provider":                provider,
+		"message":                 res.Message,
+		"status":                  res.Status,
+	}).Scan(&transactionID); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "AEPS", transactionID, res.Status, txstate.Provider(provider)); err != nil {
+		return "", err
+	}
+	return transactionID, tx.Commit(ctx)
+}
+
+// SettleAEPSTransactionQuery applies the provider's answer to a cash
+// withdrawal or Aadhaar Pay. On SUCCESS the retailer's settlement wallet is
+// credited the amount owed by the provider, and on a cash withdrawal the
+// hierarchy's main wallets their commission; on FAILED the accrued
+// commission is cancelled and on PENDING only the provider references are
+// stored.
+func (db *Database) SettleAEPSTransactionQuery(
+	ctx context.Context,
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := lockAEPSTransaction(ctx, tx, transactionID)
+	if err != nil {
+		return err
+	}
+	if status == t.status && status != txstate.Pending {
+		return nil
+	}
+	if status != t.status {
+		if err := txstate.Check(t.status, status, actor); err != nil {
+			return err
+		}
+	}
+
+	switch status {
+	case "SUCCESS":
+		remarks := fmt.Sprintf("AEPS cash withdrawal for %s", t.customerMobileNumber)
+		if t.transactionType == "AADHAAR_PAY" {
+			remarks = fmt.Sprintf("Aadhaar Pay from %s", t.customerMobileNumber)
+		}
+		journal := ledger.NewJournal(transactionID, "AEPS", remarks).
+			Debit(ledger.ProviderFloat, t.amount, "AEPS amount owed by provider").
+			Credit(ledger.Settlement(t.retailerID), t.amount, remarks)
+
+		total := t.retailerCommision + t.disCommision + t.mdCommision
+		if total > 0 {
+			commisionRemarks := fmt.Sprintf("Commission for Retailer: %s", t.retailerID)
+			journal.Debit(ledger.CommisionPool, total, commisionRemarks)
+			for _, c := range []struct {
+				userID    string
+				commision models.Money
+			}{
+				{t.retailerID, t.retailerCommision},
+				{t.disID, t.disCommision},
+				{t.mdID, t.mdCommision},
+			} {
+				if err := creditCommision(ctx, tx, journal, "AEPS", transactionID, c.userID, c.commision, commisionRemarks); err != nil {
+					return err
+				}
+			}
+		}
+		debits, credits := journal.Totals()
+		commisionPoolEntry(journal, debits-credits)
+		if _, err := ledger.Post(ctx, tx, journal); err != nil {
+			return err
+		}
+	case "FAILED":
+		if err := cancelPendingCommisions(ctx, tx, "AEPS", transactionID); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid aeps transaction status")
+	}
+
+	query := `
+		UPDATE aeps_transactions
+		SET order_id = COALESCE(NULLIF(@order_id, ''), order_id),
+			operator_transaction_id = COALESCE(NULLIF(@operator_transaction_id, ''), operator_transaction_id),
+			message = COALESCE(NULLIF(@message, ''), message),
+			updated_at = NOW()
+		WHERE aeps_transaction_id = @transaction_id;
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"transaction_id":          transactionID,
+		"order_id":                orderID,
+		"operator_transaction_id": operatorTransactionID,
+		"message":                 reason,
+	}); err != nil {
+		return err
+	}
+
+	if status != t.status {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       "AEPS",
+			TransactionID: transactionID,
+			From:          t.status,
+			To:            status,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+type lockedAEPSTransaction struct {
+	retailerID           string
+	transactionType      string
+	customerMobileNumber string
+	amount               models.Money
+	mdCommision          models.Money
+	disCommision         models.Money
+	retailerCommision    models.Money
+	status               string
+	mdID                 string
+	disID                string
+}
+
+// lockAEPSTransaction locks an AEPS transaction row and loads the
+// retailer's hierarchy, which funds and is credited the AEPS commission.
+func lockAEPSTransaction(ctx context.Context, tx pgx.Tx, transactionID string) (*lockedAEPSTransaction, error) {
+	query := `
+		SELECT
+			t.retailer_id,
+			t.transaction_type,
+			t.customer_mobile_number,
+			t.amount,
+			t.master_distributor_commision,
+			t.distributor_commision,
+			t.retailer_commision,
+			t.transaction_status,
+			m.master_distributor_id,
+			d.distributor_id
+		FROM aeps_transactions t
+		JOIN retailers r
+			ON r.retailer_id = t.retailer_id
+		JOIN distributors d
+			ON d.distributor_id = r.distributor_id
+		JOIN master_distributors m
+			ON m.master_distributor_id = d.master_distributor_id
+		WHERE t.aeps_transaction_id = @transaction_id::UUID
+		FOR UPDATE OF t;
+	`
+	var t lockedAEPSTransaction
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(
+		&t.retailerID,
+		&t.transactionType,
+		&t.customerMobileNumber,
+		&t.amount,
+		&t.mdCommision,
+		&t.disCommision,
+		&t.retailerCommision,
+		&t.status,
+		&t.mdID,
+		&t.disID,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid aeps transaction id")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetAEPSTransactionsQuery lists AEPS transactions, newest first, of one
+// retailer or of everyone when retailerID is empty.
+func (db *Database) GetAEPSTransactionsQuery(
+	ctx context.Context,
+	retailerID string,
+	limit, offset int,
+) ([]models.GetAEPSTransactionsResponseModel, error) {
+	query := `
+		SELECT
+			t.aeps_transaction_id,
+			t.partner_request_id,
+			t.operator_transaction_id,
+			t.order_id,
+			t.retailer_id,
+			r.retailer_name,
+			r.retailer_business_name,
+			t.transaction_type,
+			t.customer_mobile_number,
+			t.aadhaar_last_digits,
+			t.bank_iin,
+			t.bank_name,
+			t.amount,
+			t.account_balance,
+			t.master_distributor_commision,
+			t.distributor_commision,
+			t.retailer_commision,
+			t.provider,
+			t.message,
+			w.before_balance,
+			w.after_balance,
+			t.transaction_status,
+			t.created_at,
+			t.updated_at
+		FROM aeps_transactions t
+		JOIN retailers r
+			ON r.retailer_id = t.retailer_id
+		LEFT JOIN wallet_transactions w
+			ON w.user_id = t.retailer_id
+			AND w.wallet_type = 'SETTLEMENT'
+			AND w.reference_id = t.aeps_transaction_id::TEXT
+			AND w.transaction_reason = 'AEPS'
+		WHERE (@retailer_id = '' OR t.retailer_id = @retailer_id)
+		ORDER BY t.created_at DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"retailer_id": retailerID,
+		"limit":       limit,
+		"offset":      offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.GetAEPSTransactionsResponseModel{}
+	for rows.Next() {
+		var t models.GetAEPSTransactionsResponseModel
+		if err := rows.Scan(
+			&t.AEPSTransactionID,
+			&t.PartnerRequestID,
+			&t.OperatorTransactionID,
+			&t.OrderID,
+			&t.RetailerID,
+			&t.RetailerName,
+			&t.RetailerBusinessName,
+			&t.TransactionType,
+			&t.CustomerMobileNumber,
+			&t.AadhaarLastDigits,
+			&t.BankIIN,
+			&t.BankName,
+			&t.Amount,
+			&t.AccountBalance,
+			&t.MasterDistributorCommision,
+			&t.DistributorCommision,
+			&t.RetailerCommision,
+			&t.Provider,
+			&t.Message,
+			&t.BeforeBalance,
+			&t.AfterBalance,
+			&t.TransactionStatus,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
@@ -0,0 +1,785 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+// AEPS money waits in the retailer's settlement wallet until the retailer
+// settles it. A WALLET settlement moves it to the main wallet at once. A
+// BANK settlement is debited into suspense and stays INITIATED until an
+// admin approves it, after which it is paid out to one of the retailer's
+// verified bank accounts like a payout and settled by the provider's
+// answer, status checks or callbacks; a rejected or failed one goes back to
+// the settlement wallet. The flat charge of either kind is credited to the
+// retailer's admin.
+
+func (db *Database) GetAEPSSettlementSettingsQuery(ctx context.Context) (*models.AEPSSettlementSettingsModel, error) {
+	query := `
+		SELECT wallet_transfer_charge, bank_settlement_charge, updated_at
+		FROM aeps_settlement_settings
+		WHERE setting_id = 1;
+	`
+	var s models.AEPSSettlementSettingsModel
+	if err := db.pool.QueryRow(ctx, query).Scan(
+		&s.WalletTransferCharge,
+		&s.BankSettlementCharge,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *Database) UpdateAEPSSettlementSettingsQuery(
+	ctx context.Context,
+	req models.UpdateAEPSSettlementSettingsRequestModel,
+) error {
+	query := `
+		UPDATE aeps_settlement_settings
+		SET wallet_transfer_charge = @wallet_transfer_charge,
+			bank_settlement_charge = @bank_settlement_charge,
+			updated_at = NOW()
+		WHERE setting_id = 1;
+	`
+	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"wallet_transfer_charge": req.WalletTransferCharge,
+		"bank_settlement_charge": req.BankSettlementCharge,
+	}); err != nil {
+		return fmt.Errorf("failed to update aeps settlement settings")
+	}
+	return nil
+}
+
+// aepsSettlementCharge reads the charge of a WALLET or BANK settlement.
+func aepsSettlementCharge(ctx context.Context, q querier, settlementType string) (models.Money, error) {
+	query := `
+		SELECT
+			CASE @settlement_type
+				WHEN 'WALLET' THEN wallet_transfer_charge
+				ELSE bank_settlement_charge
+			END
+		FROM aeps_settlement_settings
+		WHERE setting_id = 1;
+	`
+	var charge models.Money
+	if err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"settlement_type": settlementType,
+	}).Scan(&charge); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return charge, nil
+}
+
+func (db *Database) CreateSettlementAccountQuery(
+	ctx context.Context,
+	req models.CreateSettlementAccountRequestModel,
+) (int64, error) {
+	query := `
+		INSERT INTO retailer_settlement_accounts (
+			retailer_id,
+			account_holder_name,
+			bank_name,
+			account_number,
+			ifsc_code
+		) VALUES (
+			@retailer_id,
+			@account_holder_name,
+			@bank_name,
+			@account_number,
+			@ifsc_code
+		)
+		ON CONFLICT (retailer_id, account_number, ifsc_code) DO NOTHING
+		RETURNING settlement_account_id;
+	`
+	var accountID int64
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"retailer_id":         req.RetailerID,
+		"account_holder_name": req.AccountHolderName,
+		"bank_name":           req.BankName,
+		"account_number":      req.AccountNumber,
+		"ifsc_code":           req.IFSCCode,
+	}).Scan(&accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("settlement account already added")
+		}
+		return 0, err
+	}
+	return accountID, nil
+}
+
+func (db *Database) GetSettlementAccountsByRetailerIDQuery(
+	ctx context.Context,
+	retailerID string,
+) ([]models.SettlementAccountModel, error) {
+	query := `
+		SELECT
+			settlement_account_id,
+			retailer_id,
+			account_holder_name,
+			bank_name,
+			account_number,
+			ifsc_code,
+			is_verified,
+			verified_by,
+			verified_at,
+			created_at,
+			updated_at
+		FROM retailer_settlement_accounts
+		WHERE retailer_id = @retailer_id
+		ORDER BY created_at DESC;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"retailer_id": retailerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.SettlementAccountModel{}
+	for rows.Next() {
+		var a models.SettlementAccountModel
+		if err := rows.Scan(
+			&a.SettlementAccountID,
+			&a.RetailerID,
+			&a.AccountHolderName,
+			&a.BankName,
+			&a.AccountNumber,
+			&a.IFSCCode,
+			&a.IsVerified,
+			&a.VerifiedBy,
+			&a.VerifiedAt,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (db *Database) VerifySettlementAccountQuery(
+	ctx context.Context,
+	accountID int64,
+	adminID string,
+) error {
+	query := `
+		UPDATE retailer_settlement_accounts
+		SET is_verified = TRUE,
+			verified_by = @admin_id,
+			verified_at = NOW(),
+			updated_at = NOW()
+		WHERE settlement_account_id = @settlement_account_id;
+	`
+	res, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_account_id": accountID,
+		"admin_id":              adminID,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("settlement account not found")
+	}
+	return nil
+}
+
+// DeleteSettlementAccountQuery removes one of the retailer's accounts.
+// Settlements already paid to it keep a copy of its details.
+func (db *Database) DeleteSettlementAccountQuery(
+	ctx context.Context,
+	accountID int64,
+	retailerID string,
+) error {
+	query := `
+		DELETE FROM retailer_settlement_accounts
+		WHERE settlement_account_id = @settlement_account_id
+		AND retailer_id = @retailer_id;
+	`
+	res, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_account_id": accountID,
+		"retailer_id":           retailerID,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("settlement account not found")
+	}
+	return nil
+}
+
+// SettleAEPSToWalletQuery moves amount from the retailer's settlement
+// wallet to its main wallet, taking the wallet transfer charge on top, and
+// returns the settlement id.
+func (db *Database) SettleAEPSToWalletQuery(
+	ctx context.Context,
+	req models.AEPSWalletSettlementRequestModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
+	}
+	charge, err := aepsSettlementCharge(ctx, tx, "WALLET")
+	if err != nil {
+		return "", err
+	}
+	h, err := getRetailerHierarchy(ctx, tx, req.RetailerID)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO aeps_settlements (
+			partner_request_id,
+			retailer_id,
+			settlement_type,
+			amount,
+			charge,
+			transaction_status
+		) VALUES (
+			gen_random_uuid(),
+			@retailer_id,
+			'WALLET',
+			@amount,
+			@charge,
+			@status
+		)
+		RETURNING aeps_settlement_id::TEXT;
+	`
+	var settlementID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"retailer_id": req.RetailerID,
+		"amount":      req.Amount,
+		"charge":      charge,
+		"status":      txstate.Success,
+	}).Scan(&settlementID); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "AEPS_SETTLEMENT", settlementID, txstate.Success, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+
+	journal := ledger.NewJournal(settlementID, "AEPS_SETTLEMENT", "AEPS settlement to main wallet").
+		Debit(ledger.Settlement(req.RetailerID), req.Amount+charge, "").
+		Credit(ledger.User(req.RetailerID), req.Amount, "").
+		Credit(ledger.User(h.adminID), charge, fmt.Sprintf("AEPS settlement charge from %s", req.RetailerID))
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		if errors.Is(err, ledger.ErrInsufficientBalance) {
+			return "", models.Rejectf("insufficient settlement wallet balance")
+		}
+		return "", err
+	}
+	return settlementID, tx.Commit(ctx)
+}
+
+// RequestAEPSBankSettlementQuery records a settlement to one of the
+// retailer's verified bank accounts, reserving the amount and the bank
+// settlement charge from the settlement wallet until an admin approves or
+// rejects it, and returns the settlement id.
+func (db *Database) RequestAEPSBankSettlementQuery(
+	ctx context.Context,
+	req models.AEPSBankSettlementRequestModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
+	}
+	charge, err := aepsSettlementCharge(ctx, tx, "BANK")
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO aeps_settlements (
+			partner_request_id,
+			retailer_id,
+			settlement_type,
+			settlement_account_id,
+			account_holder_name,
+			bank_name,
+			account_number,
+			ifsc_code,
+			transfer_type,
+			amount,
+			charge,
+			transaction_status
+		)
+		SELECT
+			@partner_request_id,
+			retailer_id,
+			'BANK',
+			settlement_account_id,
+			account_holder_name,
+			bank_name,
+			account_number,
+			ifsc_code,
+			@transfer_type,
+			@amount,
+			@charge,
+			@status
+		FROM retailer_settlement_accounts
+		WHERE settlement_account_id = @settlement_account_id
+		AND retailer_id = @retailer_id
+		AND is_verified
+		RETURNING aeps_settlement_id::TEXT;
+	`
+	var settlementID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id":    req.PartnerRequestID,
+		"retailer_id":           req.RetailerID,
+		"settlement_account_id": req.SettlementAccountID,
+		"transfer_type":         req.TransferType,
+		"amount":                req.Amount,
+		"charge":                charge,
+		"status":                txstate.Initiated,
+	}).Scan(&settlementID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", models.Rejectf("settlement account not found or not verified")
+		}
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "AEPS_SETTLEMENT", settlementID, txstate.Initiated, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+
+	journal := ledger.NewJournal(settlementID, "AEPS_SETTLEMENT", "AEPS settlement to bank").
+		Debit(ledger.Settlement(req.RetailerID), req.Amount+charge, "").
+		Credit(ledger.Suspense, req.Amount+charge, "")
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		if errors.Is(err, ledger.ErrInsufficientBalance) {
+			return "", models.Rejectf("insufficient settlement wallet balance")
+		}
+		return "", err
+	}
+	return settlementID, tx.Commit(ctx)
+}
+
+// ApproveAEPSSettlementQuery records an admin's approval of a bank
+// settlement and returns it, ready to be sent to the payout provider.
+func (db *Database) ApproveAEPSSettlementQuery(
+	ctx context.Context,
+	settlementID string,
+	adminID string,
+) (*models.AEPSSettlementModel, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockAEPSSettlement(ctx, tx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if s.settlementType != "BANK" || s.status != txstate.Initiated || s.approved {
+		return nil, fmt.Errorf("settlement is not awaiting approval")
+	}
+
+	query := `
+		UPDATE aeps_settlements
+		SET approved_by = @admin_id,
+			approved_at = NOW(),
+			updated_at = NOW()
+		WHERE aeps_settlement_id = @settlement_id;
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_id": settlementID,
+		"admin_id":      adminID,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return db.GetAEPSSettlementByIDQuery(ctx, settlementID)
+}
+
+// RejectAEPSSettlementQuery fails a bank settlement that has not been
+// approved and returns its reserved amount and charge to the settlement
+// wallet.
+func (db *Database) RejectAEPSSettlementQuery(
+	ctx context.Context,
+	settlementID string,
+	adminID string,
+	remarks string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockAEPSSettlement(ctx, tx, settlementID)
+	if err != nil {
+		return err
+	}
+	if s.settlementType != "BANK" || s.status != txstate.Initiated || s.approved {
+		return fmt.Errorf("settlement is not awaiting approval")
+	}
+	if _, err := refundAEPSSettlement(ctx, tx, s, "AEPS settlement rejected"); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE aeps_settlements
+		SET remarks = @remarks
+		WHERE aeps_settlement_id = @settlement_id;
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_id": settlementID,
+		"remarks":       remarks,
+	}); err != nil {
+		return err
+	}
+	if err := txstate.Transition(ctx, tx, txstate.Change{
+		Service:       "AEPS_SETTLEMENT",
+		TransactionID: settlementID,
+		From:          s.status,
+		To:            txstate.Failed,
+		Actor:         txstate.Admin(adminID),
+		Reason:        remarks,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SettleAEPSSettlementQuery applies the payout provider's answer to an
+// approved bank settlement. On SUCCESS suspense pays the provider and the
+// admin's charge, on FAILED the reservation goes back to the settlement
+// wallet and on PENDING only the provider references are stored.
+func (db *Database) SettleAEPSSettlementQuery(
+	ctx context.Context,
+	settlementID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := lockAEPSSettlement(ctx, tx, settlementID)
+	if err != nil {
+		return err
+	}
+	if s.settlementType != "BANK" {
+		return fmt.Errorf("wallet settlements are not sent to a provider")
+	}
+	if status == s.status && status != txstate.Pending {
+		return nil
+	}
+	if status != s.status {
+		if err := txstate.Check(s.status, status, actor); err != nil {
+			return err
+		}
+	}
+
+	switch status {
+	case "SUCCESS":
+		journal := ledger.NewJournal(settlementID, "AEPS_SETTLEMENT", fmt.Sprintf("AEPS settlement of %s paid to bank", s.retailerID)).
+			Debit(ledger.Suspense, s.amount+s.charge, "").
+			Credit(ledger.ProviderFloat, s.amount, "AEPS settlement sent to provider").
+			Credit(ledger.User(s.adminID), s.charge, fmt.Sprintf("AEPS settlement charge from %s", s.retailerID))
+		if _, err := ledger.Post(ctx, tx, journal); err != nil {
+			return err
+		}
+	case "FAILED":
+		if _, err := refundAEPSSettlement(ctx, tx, s, fmt.Sprintf("Refund of AEPS settlement %s", settlementID)); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid aeps settlement status")
+	}
+
+	query := `
+		UPDATE aeps_settlements
+		SET order_id = COALESCE(NULLIF(@order_id, ''), order_id),
+			operator_transaction_id = COALESCE(NULLIF(@operator_transaction_id, ''), operator_transaction_id),
+			updated_at = NOW()
+		WHERE aeps_settlement_id = @settlement_id;
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_id":           settlementID,
+		"order_id":                orderID,
+		"operator_transaction_id": operatorTransactionID,
+	}); err != nil {
+		return err
+	}
+
+	if status != s.status {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       "AEPS_SETTLEMENT",
+			TransactionID: settlementID,
+			From:          s.status,
+			To:            status,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// refundAEPSSettlement returns a bank settlement's reservation to the
+// settlement wallet it was taken from.
+func refundAEPSSettlement(ctx context.Context, tx pgx.Tx, s *lockedAEPSSettlement, remarks string) (int64, error) {
+	journal := ledger.NewJournal(s.settlementID, "AEPS_SETTLEMENT_REFUND", remarks).
+		Debit(ledger.Suspense, s.amount+s.charge, "").
+		Credit(ledger.Settlement(s.retailerID), s.amount+s.charge, "")
+	return ledger.Post(ctx, tx, journal)
+}
+
+// refundSentAEPSSettlement is how RefundTransactionQuery refunds a bank
+// settlement, always in full and back to the settlement wallet. A pending
+// one still holds its reservation, which is released; a paid one that the
+// provider reversed has the provider give back the amount and the admin
+// the charge.
+func refundSentAEPSSettlement(
+	ctx context.Context,
+	tx pgx.Tx,
+	settlementID string,
+	amount models.Money,
+	actor txstate.Actor,
+	reason string,
+) (*models.TransactionRefundModel, error) {
+	s, err := lockAEPSSettlement(ctx, tx, settlementID)
+	if err != nil {
+		return nil, err
+	}
+	if s.settlementType != "BANK" {
+		return nil, fmt.Errorf("wallet settlements are not sent to a provider")
+	}
+	if err := txstate.Check(s.status, txstate.Refund, actor); err != nil {
+		return nil, err
+	}
+	if amount != 0 && amount != s.amount {
+		return nil, fmt.Errorf("an aeps settlement can only be refunded in full")
+	}
+
+	remarks := fmt.Sprintf("Refund of AEPS settlement %s", settlementID)
+	var journalID int64
+	if s.status == txstate.Pending {
+		journalID, err = refundAEPSSettlement(ctx, tx, s, remarks)
+	} else {
+		journalID, err = ledger.Post(ctx, tx, ledger.NewJournal(settlementID, "AEPS_SETTLEMENT_REFUND", remarks).
+			Debit(ledger.ProviderFloat, s.amount, "AEPS settlement reversed by provider").
+			Debit(ledger.User(s.adminID), s.charge, fmt.Sprintf("Refund of AEPS settlement charge to %s", s.retailerID)).
+			Credit(ledger.Settlement(s.retailerID), s.amount+s.charge, remarks))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := insertRefund(ctx, tx, &serviceTransaction{
+		service:       "AEPS_SETTLEMENT",
+		transactionID: settlementID,
+		retailerID:    s.retailerID,
+		amount:        s.amount,
+		status:        s.status,
+	}, s.amount, journalID, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := txstate.Transition(ctx, tx, txstate.Change{
+		Service:       "AEPS_SETTLEMENT",
+		TransactionID: settlementID,
+		From:          s.status,
+		To:            txstate.Refund,
+		Actor:         actor,
+		Reason:        reason,
+	}); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+type lockedAEPSSettlement struct {
+	settlementID   string
+	retailerID     string
+	settlementType string
+	amount         models.Money
+	charge         models.Money
+	approved       bool
+	status         string
+	adminID        string
+}
+
+// lockAEPSSettlement locks a settlement row and loads the admin its charge
+// is credited to.
+func lockAEPSSettlement(ctx context.Context, tx pgx.Tx, settlementID string) (*lockedAEPSSettlement, error) {
+	query := `
+		SELECT
+			s.retailer_id,
+			s.settlement_type,
+			s.amount,
+			s.charge,
+			s.approved_at IS NOT NULL,
+			s.transaction_status,
+			m.admin_id
+		FROM aeps_settlements s
+		JOIN retailers r
+			ON r.retailer_id = s.retailer_id
+		JOIN distributors d
+			ON d.distributor_id = r.distributor_id
+		JOIN master_distributors m
+			ON m.master_distributor_id = d.master_distributor_id
+		WHERE s.aeps_settlement_id = @settlement_id::UUID
+		FOR UPDATE OF s;
+	`
+	s := lockedAEPSSettlement{settlementID: settlementID}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"settlement_id": settlementID,
+	}).Scan(
+		&s.retailerID,
+		&s.settlementType,
+		&s.amount,
+		&s.charge,
+		&s.approved,
+		&s.status,
+		&s.adminID,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid aeps settlement id")
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+const aepsSettlementQuery = `
+	SELECT
+		s.aeps_settlement_id::TEXT,
+		s.partner_request_id::TEXT,
+		s.operator_transaction_id,
+		s.order_id,
+		s.retailer_id,
+		r.retailer_name,
+		r.retailer_business_name,
+		r.retailer_phone,
+		s.settlement_type,
+		s.settlement_account_id,
+		s.account_holder_name,
+		s.bank_name,
+		s.account_number,
+		s.ifsc_code,
+		s.transfer_type,
+		s.amount,
+		s.charge,
+		s.provider,
+		s.remarks,
+		s.approved_by,
+		s.approved_at,
+		s.transaction_status,
+		s.created_at,
+		s.updated_at
+	FROM aeps_settlements s
+	JOIN retailers r
+		ON r.retailer_id = s.retailer_id`
+
+func scanAEPSSettlement(row pgx.Row) (*models.AEPSSettlementModel, error) {
+	var s models.AEPSSettlementModel
+	if err := row.Scan(
+		&s.AEPSSettlementID,
+		&s.PartnerRequestID,
+		&s.OperatorTransactionID,
+		&s.OrderID,
+		&s.RetailerID,
+		&s.RetailerName,
+		&s.RetailerBusinessName,
+		&s.RetailerPhone,
+		&s.SettlementType,
+		&s.SettlementAccountID,
+		&s.AccountHolderName,
+		&s.BankName,
+		&s.AccountNumber,
+		&s.IFSCCode,
+		&s.TransferType,
+		&s.Amount,
+		&s.Charge,
+		&s.Provider,
+		&s.Remarks,
+		&s.ApprovedBy,
+		&s.ApprovedAt,
+		&s.TransactionStatus,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("aeps settlement not found")
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *Database) GetAEPSSettlementByIDQuery(
+	ctx context.Context,
+	settlementID string,
+) (*models.AEPSSettlementModel, error) {
+	return scanAEPSSettlement(db.pool.QueryRow(ctx, aepsSettlementQuery+`
+		WHERE s.aeps_settlement_id = @settlement_id::UUID;`, pgx.NamedArgs{
+		"settlement_id": settlementID,
+	}))
+}
+
+// GetAEPSSettlementsQuery lists settlements, newest first. An empty
+// retailerID or status matches all.
+func (db *Database) GetAEPSSettlementsQuery(
+	ctx context.Context,
+	retailerID, status string,
+	limit, offset int,
+) ([]models.AEPSSettlementModel, error) {
+	rows, err := db.pool.Query(ctx, aepsSettlementQuery+`
+		WHERE (@retailer_id = '' OR s.retailer_id = @retailer_id)
+		AND (@status = '' OR s.transaction_status = @status)
+		ORDER BY s.created_at DESC
+		LIMIT @limit OFFSET @offset;`, pgx.NamedArgs{
+		"retailer_id": retailerID,
+		"status":      status,
+		"limit":       limit,
+		"offset":      offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settlements := []models.AEPSSettlementModel{}
+	for rows.Next() {
+		s, err := scanAEPSSettlement(rows)
+		if err != nil {
+			return nil, err
+		}
+		settlements = append(settlements, *s)
+	}
+	return settlements, rows.Err()
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func TestAEPSSettlements(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	withdrawal, err := db.CreateAEPSTransactionQuery(ctx, aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000001", "CASH_WITHDRAWAL", models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("CreateAEPSTransactionQuery: %v", err)
+	}
+	if err := db.SettleAEPSTransactionQuery(ctx, withdrawal, "SUCCESS", "ORDER1", "RRN1", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleAEPSTransactionQuery: %v", err)
+	}
+	if err := db.UpdateAEPSSettlementSettingsQuery(ctx, models.UpdateAEPSSettlementSettingsRequestModel{
+		WalletTransferCharge: models.Rupees(5),
+		BankSettlementCharge: models.Rupees(10),
+	}); err != nil {
+		t.Fatalf("UpdateAEPSSettlementSettingsQuery: %v", err)
+	}
+
+	// The charge comes on top of the amount.
+	if _, err := db.SettleAEPSToWalletQuery(ctx, models.AEPSWalletSettlementRequestModel{
+		RetailerID: h.RetailerID,
+		Amount:     models.Rupees(1000),
+	}); !models.IsRejection(err) {
+		t.Fatalf("settling more than the settlement wallet: err = %v, want a rejection", err)
+	}
+	if _, err := db.SettleAEPSToWalletQuery(ctx, models.AEPSWalletSettlementRequestModel{
+		RetailerID: h.RetailerID,
+		Amount:     models.Rupees(300),
+	}); err != nil {
+		t.Fatalf("SettleAEPSToWalletQuery: %v", err)
+	}
+	for _, c := range []struct {
+		name string
+		got  models.Money
+		want models.Money
+	}{
+		{"main wallet", dbtest.Balance(t, conn, h.RetailerID), models.Rupees(300)},
+		{"settlement wallet", dbtest.SettlementBalance(t, conn, h.RetailerID), models.Rupees(695)},
+		{"admin", dbtest.Balance(t, conn, h.AdminID), models.Rupees(5)},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+
+	accountID, err := db.CreateSettlementAccountQuery(ctx, models.CreateSettlementAccountRequestModel{
+		RetailerID:        h.RetailerID,
+		AccountHolderName: "RAMESH KUMAR",
+		BankName:          "HDFC Bank",
+		AccountNumber:     "50100000000001",
+		IFSCCode:          "HDFC0000001",
+	})
+	if err != nil {
+		t.Fatalf("CreateSettlementAccountQuery: %v", err)
+	}
+	bankRequest := func(partnerRequestID string) models.AEPSBankSettlementRequestModel {
+		return models.AEPSBankSettlementRequestModel{
+			RetailerID:          h.RetailerID,
+			SettlementAccountID: accountID,
+			Amount:              models.Rupees(400),
+			TransferType:        "IMPS",
+			PartnerRequestID:    partnerRequestID,
+		}
+	}
+	if _, err := db.RequestAEPSBankSettlementQuery(ctx, bankRequest("00000000-0000-0000-0000-000000000002")); !models.IsRejection(err) {
+		t.Fatalf("settling to an unverified account: err = %v, want a rejection", err)
+	}
+	if err := db.VerifySettlementAccountQuery(ctx, accountID, h.AdminID); err != nil {
+		t.Fatalf("VerifySettlementAccountQuery: %v", err)
+	}
+
+	rejected, err := db.RequestAEPSBankSettlementQuery(ctx, bankRequest("00000000-0000-0000-0000-000000000003"))
+	if err != nil {
+		t.Fatalf("RequestAEPSBankSettlementQuery: %v", err)
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != models.Rupees(285) {
+		t.Errorf("settlement wallet while awaiting approval = %s, want 285.00", got)
+	}
+	if err := db.RejectAEPSSettlementQuery(ctx, rejected, h.AdminID, "account closed"); err != nil {
+		t.Fatalf("RejectAEPSSettlementQuery: %v", err)
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != models.Rupees(695) {
+		t.Errorf("settlement wallet after the rejection = %s, want 695.00", got)
+	}
+	if _, err := db.ApproveAEPSSettlementQuery(ctx, rejected, h.AdminID); err == nil {
+		t.Error("approved a rejected settlement")
+	}
+
+	paid, err := db.RequestAEPSBankSettlementQuery(ctx, bankRequest("00000000-0000-0000-0000-000000000004"))
+	if err != nil {
+		t.Fatalf("RequestAEPSBankSettlementQuery: %v", err)
+	}
+	if _, err := db.ApproveAEPSSettlementQuery(ctx, paid, h.AdminID); err != nil {
+		t.Fatalf("ApproveAEPSSettlementQuery: %v", err)
+	}
+	for _, status := range []string{"PENDING", "SUCCESS"} {
+		if err := db.SettleAEPSSettlementQuery(ctx, paid, status, "ORDER2", "UTR2", txstate.Provider("TEST"), ""); err != nil {
+			t.Fatalf("SettleAEPSSettlementQuery(%s): %v", status, err)
+		}
+	}
+	for _, c := range []struct {
+		name string
+		got  models.Money
+		want models.Money
+	}{
+		{"settlement wallet", dbtest.SettlementBalance(t, conn, h.RetailerID), models.Rupees(285)},
+		{"admin", dbtest.Balance(t, conn, h.AdminID), models.Rupees(15)},
+		{"suspense", dbtest.SystemBalance(t, conn, "SUSPENSE"), 0},
+		{"provider float", dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"), models.Rupees(-600)},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s after paying the settlement = %s, want %s", c.name, c.got, c.want)
+		}
+	}
+
+	// A settlement the bank sends back returns to the settlement wallet.
+	if _, err := db.RefundTransactionQuery(ctx, "AEPS_SETTLEMENT", paid, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != models.Rupees(695) {
+		t.Errorf("settlement wallet after the refund = %s, want 695.00", got)
+	}
+	if got := dbtest.Balance(t, conn, h.AdminID); got != models.Rupees(5) {
+		t.Errorf("admin after the refund = %s, want 5.00", got)
+	}
+}
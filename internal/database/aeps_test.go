@@ -0,0 +1,226 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func aepsRequest(retailerID, partnerRequestID, transactionType string, amount models.Money) models.AEPSTransactionRequestModel {
+	return models.AEPSTransactionRequestModel{
+		RetailerID:           retailerID,
+		TransactionType:      transactionType,
+		CustomerMobileNumber: "9876543210",
+		AadhaarNumber:        "123456789012",
+		BankIIN:              "607094",
+		BankName:             "State Bank of India",
+		Amount:               amount,
+		Latitude:             "28.6139",
+		Longitude:            "77.2090",
+		PidData:              "<PidData/>",
+		PartnerRequestID:     partnerRequestID,
+	}
+}
+
+func aepsStatus(t *testing.T, conn *pgx.Conn, transactionID string) string {
+	t.Helper()
+	var status string
+	if err := conn.QueryRow(context.Background(), `
+		SELECT transaction_status
+		FROM aeps_transactions
+		WHERE aeps_transaction_id::TEXT = $1;
+	`, transactionID).Scan(&status); err != nil {
+		t.Fatalf("aeps_transactions: %v", err)
+	}
+	return status
+}
+
+// createAEPSSlab pays ₹10 on every cash withdrawal, half of it to the
+// retailer.
+func createAEPSSlab(t *testing.T, db *Database) {
+	t.Helper()
+	if _, err := db.CreateCommisionSlabQuery(context.Background(), models.CreateCommisionSlabRequestModel{
+		Service:                    "AEPS",
+		CommisionType:              "FLAT",
+		FlatCommision:              models.Rupees(10),
+		AdminCommision:             rate(t, "0.2"),
+		MasterDistributorCommision: rate(t, "0.1"),
+		DistributorCommision:       rate(t, "0.2"),
+		RetailerCommision:          rate(t, "0.5"),
+	}); err != nil {
+		t.Fatalf("CreateCommisionSlabQuery: %v", err)
+	}
+}
+
+func TestAEPSCashWithdrawal(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	createAEPSSlab(t, db)
+
+	failed, err := db.CreateAEPSTransactionQuery(ctx, aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000001", "CASH_WITHDRAWAL", models.Rupees(500)))
+	if err != nil {
+		t.Fatalf("CreateAEPSTransactionQuery: %v", err)
+	}
+	if err := db.SettleAEPSTransactionQuery(ctx, failed, "FAILED", "", "", txstate.Provider("TEST"), "declined"); err != nil {
+		t.Fatalf("SettleAEPSTransactionQuery: %v", err)
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != 0 {
+		t.Errorf("settlement balance after a failed withdrawal = %s, want 0.00", got)
+	}
+	for userID, status := range commisionStatuses(t, conn, failed) {
+		if status != "CANCELLED" {
+			t.Errorf("%s commission on a failed withdrawal is %s, want CANCELLED", userID, status)
+		}
+	}
+
+	succeeded, err := db.CreateAEPSTransactionQuery(ctx, aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000002", "CASH_WITHDRAWAL", models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("CreateAEPSTransactionQuery: %v", err)
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != 0 {
+		t.Errorf("settlement balance before settling = %s, want 0.00", got)
+	}
+	var lastDigits string
+	if err := conn.QueryRow(ctx, "SELECT aadhaar_last_digits FROM aeps_transactions WHERE aeps_transaction_id::TEXT = $1", succeeded).Scan(&lastDigits); err != nil {
+		t.Fatalf("aeps_transactions: %v", err)
+	}
+	if lastDigits != "9012" {
+		t.Errorf("stored Aadhaar digits = %q, want %q", lastDigits, "9012")
+	}
+
+	if err := db.SettleAEPSTransactionQuery(ctx, succeeded, "SUCCESS", "ORDER1", "RRN1", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleAEPSTransactionQuery: %v", err)
+	}
+	for _, c := range []struct {
+		userID string
+		want   models.Money
+	}{
+		// 5 commission less 0.10 TDS; the amount is in the settlement
+		// wallet.
+		{h.RetailerID, 490 * models.Paisa},
+		{h.DistributorID, 196 * models.Paisa},
+		{h.MasterDistributorID, 98 * models.Paisa},
+		{h.AdminID, 0},
+	} {
+		if got := dbtest.Balance(t, conn, c.userID); got != c.want {
+			t.Errorf("%s balance = %s, want %s", c.userID, got, c.want)
+		}
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != models.Rupees(1000) {
+		t.Errorf("settlement balance = %s, want 1000.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"); got != models.Rupees(-1000) {
+		t.Errorf("provider float = %s, want -1000.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "COMMISION_POOL"); got != models.Rupees(-8) {
+		t.Errorf("commission pool = %s, want -8.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "TDS_PAYABLE"); got != 16*models.Paisa {
+		t.Errorf("TDS payable = %s, want 0.16", got)
+	}
+
+	pay, err := db.CreateAEPSTransactionQuery(ctx, aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000003", "AADHAAR_PAY", models.Rupees(200)))
+	if err != nil {
+		t.Fatalf("CreateAEPSTransactionQuery: %v", err)
+	}
+	if err := db.SettleAEPSTransactionQuery(ctx, pay, "SUCCESS", "ORDER2", "RRN2", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleAEPSTransactionQuery: %v", err)
+	}
+	// Aadhaar Pay earns no commission.
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != models.Rupees(1200) {
+		t.Errorf("settlement balance after Aadhaar Pay = %s, want 1200.00", got)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != 490*models.Paisa {
+		t.Errorf("retailer balance after Aadhaar Pay = %s, want 4.90", got)
+	}
+}
+
+func TestAEPSReversalTakesBackTheCredit(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	createAEPSSlab(t, db)
+
+	transactionID, err := db.CreateAEPSTransactionQuery(ctx, aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000001", "CASH_WITHDRAWAL", models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("CreateAEPSTransactionQuery: %v", err)
+	}
+	if err := db.SettleAEPSTransactionQuery(ctx, transactionID, "SUCCESS", "ORDER1", "RRN1", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleAEPSTransactionQuery: %v", err)
+	}
+
+	// The retailer has already moved 900 of the 1000 to its main wallet.
+	if _, err := db.SettleAEPSToWalletQuery(ctx, models.AEPSWalletSettlementRequestModel{
+		RetailerID: h.RetailerID,
+		Amount:     models.Rupees(900),
+	}); err != nil {
+		t.Fatalf("SettleAEPSToWalletQuery: %v", err)
+	}
+
+	if _, err := db.RefundTransactionQuery(ctx, "AEPS", transactionID, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+
+	for _, c := range []struct {
+		userID string
+		want   models.Money
+	}{
+		{h.RetailerID, models.Rupees(900)},
+		{h.DistributorID, 0},
+		{h.MasterDistributorID, 0},
+	} {
+		if got := dbtest.Balance(t, conn, c.userID); got != c.want {
+			t.Errorf("%s balance after the reversal = %s, want %s", c.userID, got, c.want)
+		}
+	}
+	if got := dbtest.SettlementBalance(t, conn, h.RetailerID); got != 0 {
+		t.Errorf("settlement balance after the reversal = %s, want 0.00", got)
+	}
+	for _, account := range []string{"PROVIDER_FLOAT", "COMMISION_POOL", "TDS_PAYABLE"} {
+		if got := dbtest.SystemBalance(t, conn, account); got != 0 {
+			t.Errorf("%s after the reversal = %s, want 0.00", account, got)
+		}
+	}
+	if got := dbtest.SystemBalance(t, conn, "RECEIVABLE"); got != models.Rupees(-900) {
+		t.Errorf("receivable = %s, want -900.00", got)
+	}
+	if status := aepsStatus(t, conn, transactionID); status != txstate.Refund {
+		t.Errorf("status = %s, want %s", status, txstate.Refund)
+	}
+}
+
+func TestAEPSEnquiryIsStoredWithItsFinalStatus(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	req := aepsRequest(h.RetailerID, "00000000-0000-0000-0000-000000000001", "BALANCE_ENQUIRY", 0)
+	transactionID, err := db.RecordAEPSEnquiryQuery(ctx, req, "TEST", &models.AEPSEnquiryResponseModel{
+		Status:                "SUCCESS",
+		Message:               "Enquiry successful",
+		OrderID:               "ORDER1",
+		OperatorTransactionID: "RRN1",
+		AccountBalance:        models.Rupees(2500),
+	})
+	if err != nil {
+		t.Fatalf("RecordAEPSEnquiryQuery: %v", err)
+	}
+	var balance models.Money
+	if err := conn.QueryRow(ctx, "SELECT account_balance FROM aeps_transactions WHERE aeps_transaction_id::TEXT = $1", transactionID).Scan(&balance); err != nil {
+		t.Fatalf("aeps_transactions: %v", err)
+	}
+	if balance != models.Rupees(2500) {
+		t.Errorf("account balance = %s, want 2500.00", balance)
+	}
+	if status := aepsStatus(t, conn, transactionID); status != txstate.Success {
+		t.Errorf("status = %s, want %s", status, txstate.Success)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != 0 {
+		t.Errorf("retailer balance after an enquiry = %s, want 0.00", got)
+	}
+}
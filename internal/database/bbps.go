@@ -2,1013 +2,632 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
-func (db *Database) CreatePostpaidMobileRechargeSuccessOrPendingQuery(
-	ctx context.Context,
-	req models.CreatePostpaidMobileRechargeAPIRequestModel,
-	txn models.GetPostpaidMobileRechargeAPIResponseModel,
-	status string,
-) error {
-	tx, err := db.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	getRetailerWalletBalanceQuery := `
-		SELECT retailer_wallet_balance 
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerWalletBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerBeforeBalance); err != nil {
-		return err
-	}
+// Bill payments of every category go through the same flow. What differs
+// between billers, the customer parameters a bill is identified by and
+// whether it has to be fetched first, is kept in the biller registry.
 
-	deductFromRetailerWalletQuery := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, deductFromRetailerWalletQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-		"amount":      req.Amount,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToPostpaidMobileRechargeTable := `
-		INSERT INTO mobile_recharge_postpaid (
-			retailer_id,
-			partner_request_id,
-			order_id,
-			operator_transaction_id,
-			mobile_number,
-			operator_code,
-			amount,
-			circle_code,
-			circle_name,
-			operator_name,
-			recharge_type,
-			recharge_status,
-			commision
-		) VALUES (
-			@retailer_id,
-			@partner_request_id,
-			@order_id,
-			@operator_transaction_id,
-			@mobile_number,
-			@operator_code,
-			@amount,
-			@circle_code,
-			@circle_name,
-			@operator_name,
-			@recharge_type,
-			@recharge_status,
-			@commision 
-		)
-		RETURNING postpaid_recharge_transaction_id;
-	`
-	var transactionId int
-	if err := tx.QueryRow(ctx, insertToPostpaidMobileRechargeTable, pgx.NamedArgs{
-		"retailer_id":             req.RetailerID,
-		"partner_request_id":      req.PartnerRequestID,
-		"order_id":                txn.OrderID,
-		"operator_transaction_id": txn.OperatorTransactionID,
-		"mobile_number":           req.MobileNumber,
-		"operator_code":           req.OperatorCode,
-		"amount":                  req.Amount,
-		"circle_code":             req.OperatorCircle,
-		"circle_name":             req.CircleName,
-		"operator_name":           req.OperatorName,
-		"recharge_type":           fmt.Sprintf("%d", 1),
-		"recharge_status":         status,
-		"commision":               0,
-	}).Scan(&transactionId); err != nil {
-		return err
-	}
-
-	insertToWalletTransactions := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			before_balance,
-			after_balance,
-			debit_amount,
-			credit_amount,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@before_balance,
-			@after_balance,
-			@debit_amount,
-			@credit_amount,
-			@transaction_reason,
-			@remarks
-		);
+func (db *Database) GetBBPSCategoriesQuery(
+	ctx context.Context,
+) ([]models.BBPSCategoryModel, error) {
+	query := `
+		SELECT category_code, category_name
+		FROM bbps_categories
+		WHERE is_active
+		ORDER BY category_name;
 	`
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       fmt.Sprintf("%d", transactionId),
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"debit_amount":       req.Amount,
-		"credit_amount":      0,
-		"transaction_reason": "POSTPAID_MOBILE_RECHARGE",
-		"remarks":            fmt.Sprintf("Postpaid mobile recharge to: %s", req.MobileNumber),
-	}); err != nil {
-		return err
+	rows, err := db.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
 	}
-	return tx.Commit(ctx)
-}
+	defer rows.Close()
 
-func (db *Database) CreatePostpaidMobileRechargeFailureQuery(
-	ctx context.Context,
-	req models.CreatePostpaidMobileRechargeAPIRequestModel,
-	txn models.GetPostpaidMobileRechargeAPIResponseModel,
-) error {
-	insertToPostpaidMobileRechargeTable := `
-		INSERT INTO mobile_recharge_postpaid (
-			retailer_id,
-			partner_request_id,
-			order_id,
-			operator_transaction_id,
-			mobile_number,
-			operator_code,
-			amount,
-			circle_code,
-			circle_name,
-			operator_name,
-			recharge_type,
-			recharge_status,
-			commision
-		) VALUES (
-			@retailer_id,
-			@partner_request_id,
-			@order_id,
-			@operator_transaction_id,
-			@mobile_number,
-			@operator_code,
-			@amount,
-			@circle_code,
-			@circle_name,
-			@operator_name,
-			@recharge_type,
-			@recharge_status,
-			@commision 
-		);
-	`
-	if _, err := db.pool.Exec(ctx, insertToPostpaidMobileRechargeTable, pgx.NamedArgs{
-		"retailer_id":             req.RetailerID,
-		"partner_request_id":      req.PartnerRequestID,
-		"order_id":                txn.OrderID,
-		"operator_transaction_id": txn.OperatorTransactionID,
-		"mobile_number":           req.MobileNumber,
-		"operator_code":           req.OperatorCode,
-		"amount":                  req.Amount,
-		"circle_code":             req.OperatorCircle,
-		"circle_name":             req.CircleName,
-		"operator_name":           req.OperatorName,
-		"recharge_type":           fmt.Sprintf("%d", 1),
-		"recharge_status":         "FAILED",
-		"commision":               0,
-	}); err != nil {
-		return err
+	categories := []models.BBPSCategoryModel{}
+	for rows.Next() {
+		var c models.BBPSCategoryModel
+		if err := rows.Scan(&c.CategoryCode, &c.CategoryName); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
 	}
-	return nil
+	return categories, rows.Err()
 }
 
-func (db *Database) GetAllPostpaidMobileRechargeQuery(
+// GetBBPSBillersQuery lists the billers of a category with their
+// parameters, leaving out inactive billers unless includeInactive is set.
+func (db *Database) GetBBPSBillersQuery(
 	ctx context.Context,
-	limit int,
-	offset int,
-) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error) {
-
+	categoryCode string,
+	includeInactive bool,
+) ([]models.BBPSBillerModel, error) {
 	query := `
 		SELECT
-			m.postpaid_recharge_transaction_id,
-			m.retailer_id,
-			r.retailer_name,
-			r.retailer_business_name,
-			m.partner_request_id,
-			m.operator_transaction_id,
-			m.order_id,
-			m.mobile_number,
-			m.operator_code,
-			m.amount,
-			w.before_balance,
-			w.after_balance,
-			m.circle_code,
-			m.circle_name,
-			m.operator_name,
-			m.recharge_type,
-			m.recharge_status,
-			m.commision,
-			m.created_at
-		FROM mobile_recharge_postpaid m
-		JOIN retailers r
-			ON r.retailer_id = m.retailer_id
-		JOIN wallet_transactions w
-			ON w.user_id = m.retailer_id
-			AND w.reference_id = m.postpaid_recharge_transaction_id::TEXT
-			AND w.transaction_reason = 'POSTPAID_MOBILE_RECHARGE'
-		ORDER BY m.created_at DESC
-		LIMIT $1 OFFSET $2;
+			biller_id,
+			biller_name,
+			category_code,
+			operator_code,
+			fetch_required,
+			is_active,
+			created_at,
+			updated_at
+		FROM bbps_billers
+		WHERE category_code = @category_code
+		AND (is_active OR @include_inactive)
+		ORDER BY biller_name;
 	`
-
-	rows, err := db.pool.Query(ctx, query, limit, offset)
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"category_code":    categoryCode,
+		"include_inactive": includeInactive,
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	history := make([]models.GetPostpaidMobileRechargeHistoryResponseModel, 0)
-
+	billers := []models.BBPSBillerModel{}
 	for rows.Next() {
-		var item models.GetPostpaidMobileRechargeHistoryResponseModel
-
-		err := rows.Scan(
-			&item.PostpaidRechargeTransactionID,
-			&item.RetailerID,
-			&item.RetailerName,
-			&item.RetailerBusinessName,
-			&item.PartnerRequestID,
-			&item.OperatorTransactionID,
-			&item.OrderID,
-			&item.MobileNumber,
-			&item.OperatorCode,
-			&item.Amount,
-			&item.BeforeBalance,
-			&item.AfterBalance,
-			&item.CircleCode,
-			&item.CircleName,
-			&item.OperatorName,
-			&item.RechargeType,
-			&item.RechargeStatus,
-			&item.Commission,
-			&item.CreatedAt,
-		)
-		if err != nil {
+		var b models.BBPSBillerModel
+		if err := rows.Scan(
+			&b.BillerID,
+			&b.BillerName,
+			&b.CategoryCode,
+			&b.OperatorCode,
+			&b.FetchRequired,
+			&b.IsActive,
+			&b.CreatedAt,
+			&b.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
-		if item.RechargeStatus == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(item.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			fmt.Println(newStatus)
-			if newStatus != "PENDING" {
-				if err := db.UpdatePostpaidMobileRechargeStatus(ctx, item.PostpaidRechargeTransactionID, newStatus); err != nil {
-					return nil, err
-				}
-				item.RechargeStatus = newStatus
-			}
-		}
-
-		history = append(history, item)
+		billers = append(billers, b)
 	}
-
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return history, nil
+	ids := make([]string, len(billers))
+	for i, b := range billers {
+		ids[i] = b.BillerID
+	}
+	params, err := getBBPSBillerParams(ctx, db.pool, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range billers {
+		billers[i].Params = params[billers[i].BillerID]
+	}
+	return billers, nil
 }
 
-func (db *Database) UpdatePostpaidMobileRechargeStatus(
+func (db *Database) GetBBPSBillerByIDQuery(
 	ctx context.Context,
-	transactionId int,
-	status string,
-) error {
+	billerID string,
+) (*models.BBPSBillerModel, error) {
 	query := `
-		UPDATE mobile_recharge_postpaid
-		SET recharge_status = @status
-		WHERE postpaid_recharge_transaction_id = @transaction_id;
+		SELECT
+			biller_id,
+			biller_name,
+			category_code,
+			operator_code,
+			fetch_required,
+			is_active,
+			created_at,
+			updated_at
+		FROM bbps_billers
+		WHERE biller_id = @biller_id;
 	`
-	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
-		"status":         status,
-		"transaction_id": transactionId,
-	}); err != nil {
-		return err
+	var b models.BBPSBillerModel
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"biller_id": billerID,
+	}).Scan(
+		&b.BillerID,
+		&b.BillerName,
+		&b.CategoryCode,
+		&b.OperatorCode,
+		&b.FetchRequired,
+		&b.IsActive,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.Rejectf("biller not found")
+		}
+		return nil, err
 	}
-	return nil
+
+	params, err := getBBPSBillerParams(ctx, db.pool, []string{billerID})
+	if err != nil {
+		return nil, err
+	}
+	b.Params = params[billerID]
+	return &b, nil
 }
 
-func (db *Database) GetPostpaidMobileRechargeByRetailerIDQuery(
+// getBBPSBillerParams returns the parameters of the billers by biller id,
+// in the order the biller takes them.
+func getBBPSBillerParams(
 	ctx context.Context,
-	retailerID string,
-	limit int,
-	offset int,
-) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error) {
-
+	q querier,
+	billerIDs []string,
+) (map[string][]models.BBPSBillerParamModel, error) {
 	query := `
 		SELECT
-			m.postpaid_recharge_transaction_id,
-			m.retailer_id,
-			r.retailer_name,
-			r.retailer_business_name,
-			m.partner_request_id,
-			m.operator_transaction_id,
-			m.order_id,
-			m.mobile_number,
-			m.operator_code,
-			m.amount,
-			w.before_balance,
-			w.after_balance,
-			m.circle_code,
-			m.circle_name,
-			m.operator_name,
-			m.recharge_type,
-			m.recharge_status,
-			m.commision,
-			m.created_at
-		FROM mobile_recharge_postpaid m
-		JOIN retailers r
-			ON r.retailer_id = m.retailer_id
-		JOIN wallet_transactions w
-			ON w.user_id = m.retailer_id
-			AND w.reference_id = m.postpaid_recharge_transaction_id::TEXT
-			AND w.transaction_reason = 'POSTPAID_MOBILE_RECHARGE'
-		WHERE m.retailer_id = $1
-		ORDER BY m.created_at DESC
-		LIMIT $2 OFFSET $3;
+			biller_id,
+			param_name,
+			display_name,
+			is_optional,
+			min_length,
+			max_length,
+			regex
+		FROM bbps_biller_params
+		WHERE biller_id = ANY (@biller_ids)
+		ORDER BY biller_id, position;
 	`
-
-	rows, err := db.pool.Query(ctx, query, retailerID, limit, offset)
+	rows, err := q.Query(ctx, query, pgx.NamedArgs{
+		"biller_ids": billerIDs,
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	history := make([]models.GetPostpaidMobileRechargeHistoryResponseModel, 0)
-
+	params := make(map[string][]models.BBPSBillerParamModel)
 	for rows.Next() {
-		var item models.GetPostpaidMobileRechargeHistoryResponseModel
-
-		err := rows.Scan(
-			&item.PostpaidRechargeTransactionID,
-			&item.RetailerID,
-			&item.RetailerName,
-			&item.RetailerBusinessName,
-			&item.PartnerRequestID,
-			&item.OperatorTransactionID,
-			&item.OrderID,
-			&item.MobileNumber,
-			&item.OperatorCode,
-			&item.Amount,
-			&item.BeforeBalance,
-			&item.AfterBalance,
-			&item.CircleCode,
-			&item.CircleName,
-			&item.OperatorName,
-			&item.RechargeType,
-			&item.RechargeStatus,
-			&item.Commission,
-			&item.CreatedAt,
+		var (
+			billerID string
+			p        models.BBPSBillerParamModel
 		)
-		if err != nil {
+		if err := rows.Scan(
+			&billerID,
+			&p.ParamName,
+			&p.DisplayName,
+			&p.IsOptional,
+			&p.MinLength,
+			&p.MaxLength,
+			&p.Regex,
+		); err != nil {
 			return nil, err
 		}
-		if item.RechargeStatus == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(item.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdatePostpaidMobileRechargeStatus(ctx, item.PostpaidRechargeTransactionID, newStatus); err != nil {
-					return nil, err
-				}
-				item.RechargeStatus = newStatus
-			}
-		}
-
-		history = append(history, item)
+		params[billerID] = append(params[billerID], p)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return history, nil
+	return params, rows.Err()
 }
 
-func (db *Database) RefundPostpaidMobileRechargeQuery(
+func (db *Database) CreateBBPSBillerQuery(
 	ctx context.Context,
-	transactionId int,
+	req models.CreateBBPSBillerRequestModel,
 ) error {
-
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	getTransactionDetails := `
-		UPDATE mobile_recharge_postpaid
-		SET recharge_status = @status
-		WHERE postpaid_recharge_transaction_id = @transaction_id
-		RETURNING retailer_id, amount;
-	`
-	var (
-		retailerID string
-		amount     float64
-	)
-	if err := tx.QueryRow(ctx, getTransactionDetails, pgx.NamedArgs{
-		"status":         "REFUND",
-		"transaction_id": transactionId,
-	}).Scan(
-		&retailerID,
-		&amount,
-	); err != nil {
-		return err
-	}
-
-	getRetailerBeforeBalanceQuery := `
-		SELECT retailer_wallet_balance 
-		FROM retailers
-		WHERE retailer_id = @retailer_id
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": retailerID,
-	}).Scan(
-		&retailerBeforeBalance,
-	); err != nil {
-		return err
-	}
-
-	updateRetailersWalletAndGetAfterBalanceQuery := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance + @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailersWalletAndGetAfterBalanceQuery, pgx.NamedArgs{
-		"amount":      amount,
-		"retailer_id": retailerID,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			debit_amount,
-			credit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
+	query := `
+		INSERT INTO bbps_billers (
+			biller_id,
+			biller_name,
+			category_code,
+			operator_code,
+			fetch_required
 		) VALUES (
-			@user_id,
-			@reference_id,
-			@debit_amount,
-			@credit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks 
+			@biller_id,
+			@biller_name,
+			@category_code,
+			@operator_code,
+			@fetch_required
 		);
 	`
-	if _, err := tx.Exec(ctx, insertToWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            retailerID,
-		"reference_id":       fmt.Sprintf("%d", transactionId),
-		"debit_amount":       0,
-		"credit_amount":      amount,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "POSTPAID_MOBILE_RECHARGE_REFUND",
-		"remarks":            fmt.Sprintf("Refunded %d transaction to %s", transactionId, retailerID),
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"biller_id":      req.BillerID,
+		"biller_name":    req.BillerName,
+		"category_code":  req.CategoryCode,
+		"operator_code":  req.OperatorCode,
+		"fetch_required": req.FetchRequired,
 	}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return fmt.Errorf("biller %s already exists", req.BillerID)
+			case "23503":
+				return fmt.Errorf("invalid category %s", req.CategoryCode)
+			}
+		}
+		return err
+	}
+	if err := replaceBBPSBillerParams(ctx, tx, req.BillerID, req.Params); err != nil {
 		return err
 	}
-
 	return tx.Commit(ctx)
 }
 
-func (db *Database) CreateElectricityBillPaymentSuccessOrPendingQuery(
+func (db *Database) UpdateBBPSBillerQuery(
 	ctx context.Context,
-	req models.CreateElectricityBillPaymentRequestModel,
-	txn models.GetElectricityBillPaymentAPIResponseModel,
-	status string,
+	req models.UpdateBBPSBillerRequestModel,
 ) error {
-
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	getRetailerWalletBeforeBalanceQuery := `
-		SELECT retailer_wallet_balance
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
+	query := `
+		UPDATE bbps_billers
+		SET biller_name = COALESCE(@biller_name, biller_name),
+			operator_code = COALESCE(@operator_code, operator_code),
+			fetch_required = COALESCE(@fetch_required, fetch_required),
+			is_active = COALESCE(@is_active, is_active),
+			updated_at = NOW()
+		WHERE biller_id = @biller_id;
 	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerWalletBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerBeforeBalance); err != nil {
+	res, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"biller_id":      req.BillerID,
+		"biller_name":    req.BillerName,
+		"operator_code":  req.OperatorCode,
+		"fetch_required": req.FetchRequired,
+		"is_active":      req.IsActive,
+	})
+	if err != nil {
 		return err
 	}
-
-	updateRetailerWalletAndGetAfterBalanceQuery := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailerWalletAndGetAfterBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-		"amount":      req.Amount,
-	}).Scan(&retailerAfterBalance); err != nil {
-		return err
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("biller not found")
 	}
+	if len(req.Params) > 0 {
+		if err := replaceBBPSBillerParams(ctx, tx, req.BillerID, req.Params); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
 
-	insertToElectricityBillTransactionsQuery := `
-		INSERT INTO electricity_bill_payments (
-			retailer_id,
-			order_id,
-			operator_transaction_id,
-			partner_request_id,
-			customer_id,
-			amount,
-			operator_code,
-			operator_name,
-			customer_email,
-			commision,
-			transaction_status
-		) VALUES (
-			@retailer_id,
-			@order_id,
-			@operator_transaction_id,
-			@partner_request_id,
-			@customer_id,
-			@amount,
-			@operator_code,
-			@operator_name,
-			@customer_email,
-			@commision,
-			@transaction_status
-		)
-		RETURNING electricity_bill_transaction_id;
-	`
-	var transactionId int
-	if err := tx.QueryRow(ctx, insertToElectricityBillTransactionsQuery, pgx.NamedArgs{
-		"retailer_id":             req.RetailerID,
-		"order_id":                txn.OrderID,
-		"operator_transaction_id": txn.OperatorTransactionID,
-		"partner_request_id":      txn.PartnerRequestID,
-		"customer_id":             req.CustomerID,
-		"customer_email":          req.CustomerEmail,
-		"amount":                  req.Amount,
-		"operator_code":           req.OperatorCode,
-		"operator_name":           req.OperatorName,
-		"commision":               0,
-		"transaction_status":      status,
-	}).Scan(&transactionId); err != nil {
+// replaceBBPSBillerParams stores params as the biller's parameters, in the
+// order given.
+func replaceBBPSBillerParams(
+	ctx context.Context,
+	tx pgx.Tx,
+	billerID string,
+	params []models.BBPSBillerParamModel,
+) error {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM bbps_biller_params
+		WHERE biller_id = @biller_id;
+	`, pgx.NamedArgs{
+		"biller_id": billerID,
+	}); err != nil {
 		return err
 	}
 
-	insertIntoWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			before_balance,
-			after_balance,
-			credit_amount,
-			debit_amount,
-			transaction_reason,
-			remarks
+	query := `
+		INSERT INTO bbps_biller_params (
+			biller_id,
+			param_name,
+			display_name,
+			is_optional,
+			min_length,
+			max_length,
+			regex,
+			position
 		) VALUES (
-			@user_id,
-			@reference_id,
-			@before_balance,
-			@after_balance,
-			@credit_amount,
-			@debit_amount,
-			@transaction_reason,
-			@remarks
+			@biller_id,
+			@param_name,
+			@display_name,
+			@is_optional,
+			@min_length,
+			@max_length,
+			@regex,
+			@position
 		);
 	`
-
-	if _, err := tx.Exec(ctx, insertIntoWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       fmt.Sprintf("%d", transactionId),
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"credit_amount":      0,
-		"debit_amount":       req.Amount,
-		"transaction_reason": "ELECTRICITY_BILL",
-		"remarks":            fmt.Sprintf("electricity bill paid to: %s", req.CustomerID),
-	}); err != nil {
-		return err
+	for i, p := range params {
+		if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+			"biller_id":    billerID,
+			"param_name":   p.ParamName,
+			"display_name": p.DisplayName,
+			"is_optional":  p.IsOptional,
+			"min_length":   p.MinLength,
+			"max_length":   p.MaxLength,
+			"regex":        p.Regex,
+			"position":     i + 1,
+		}); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return fmt.Errorf("duplicate parameter %s", p.ParamName)
+			}
+			return err
+		}
 	}
-	return tx.Commit(ctx)
+	return nil
 }
 
-func (db *Database) CreateElectricityBillPaymentFailureQuery(
+// ReserveBBPSTransactionQuery records a pending bill payment and reserves
+// its amount, less the retailer's commission plus the TDS on it, from the
+// retailer wallet. It returns the BBPS transaction id.
+func (db *Database) ReserveBBPSTransactionQuery(
 	ctx context.Context,
-	req models.CreateElectricityBillPaymentRequestModel,
-	txn models.GetElectricityBillPaymentAPIResponseModel,
-) error {
-	insertToElectricityBillTransactionsQuery := `
-		INSERT INTO electricity_bill_payments (
-			retailer_id,
-			order_id,
-			operator_transaction_id,
+	req models.BBPSBillPaymentRequestModel,
+	biller *models.BBPSBillerModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
+	}
+	quote, err := quoteTransaction(ctx, tx, "BBPS", req.RetailerID, biller.OperatorCode, req.Amount)
+	if err != nil {
+		return "", err
+	}
+	commision, debit := quote.Commision.RetailerCommision, quote.Debit
+
+	query := `
+		INSERT INTO bbps_transactions (
 			partner_request_id,
-			customer_id,
-			amount,
+			retailer_id,
+			biller_id,
+			biller_name,
+			category_code,
 			operator_code,
-			operator_name,
+			customer_params,
 			customer_email,
+			amount,
 			commision,
 			transaction_status
 		) VALUES (
-			@retailer_id,
-			@order_id,
-			@operator_transaction_id,
 			@partner_request_id,
-			@customer_id,
-			@amount,
+			@retailer_id,
+			@biller_id,
+			@biller_name,
+			@category_code,
 			@operator_code,
-			@operator_name,
+			@customer_params,
 			@customer_email,
+			@amount,
 			@commision,
-			@transaction_status
+			@status
 		)
-		RETURNING electricity_bill_transaction_id;
+		RETURNING bbps_transaction_id::TEXT;
 	`
-	if _, err := db.pool.Exec(ctx, insertToElectricityBillTransactionsQuery, pgx.NamedArgs{
-		"retailer_id":             req.RetailerID,
-		"order_id":                txn.OrderID,
-		"operator_transaction_id": txn.OperatorTransactionID,
-		"partner_request_id":      txn.PartnerRequestID,
-		"customer_id":             req.CustomerID,
-		"customer_email":          req.CustomerEmail,
-		"amount":                  req.Amount,
-		"operator_code":           req.OperatorCode,
-		"operator_name":           req.OperatorName,
-		"commision":               0,
-		"transaction_status":      "FAILED",
-	}); err != nil {
-		return err
-	}
-	return nil
+	var transactionID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id": req.PartnerRequestID,
+		"retailer_id":        req.RetailerID,
+		"biller_id":          biller.BillerID,
+		"biller_name":        biller.BillerName,
+		"category_code":      biller.CategoryCode,
+		"operator_code":      biller.OperatorCode,
+		"customer_params":    req.CustomerParams,
+		"customer_email":     req.CustomerEmail,
+		"amount":             req.Amount,
+		"commision":          commision,
+		"status":             txstate.Initiated,
+	}).Scan(&transactionID); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "BBPS", transactionID, txstate.Initiated, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+
+	remarks := fmt.Sprintf("Bill paid to %s", biller.BillerName)
+	if err := reserveWallet(ctx, tx, "BBPS", transactionID, req.RetailerID, debit, remarks); err != nil {
+		return "", err
+	}
+	return transactionID, tx.Commit(ctx)
 }
 
-func (db *Database) GetElectricityOperatorsQuery(
+// SettleBBPSTransactionQuery applies the provider's answer to a pending
+// bill payment and stores the provider references.
+func (db *Database) SettleBBPSTransactionQuery(
 	ctx context.Context,
-) ([]models.GetElectricityOperatorResponseModel, error) {
-	query := `
-		SELECT
-			operator_name,
-			operator_code
-		FROM electricity_operators;
-	`
-
-	res, err := db.pool.Query(ctx, query)
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) error {
+	tx, err := db.pool.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer res.Close()
+	defer tx.Rollback(ctx)
 
-	var operators []models.GetElectricityOperatorResponseModel
-	for res.Next() {
-		var operator models.GetElectricityOperatorResponseModel
-		if err := res.Scan(
-			&operator.OperatorName,
-			&operator.OperatorCode,
-		); err != nil {
-			return nil, err
+	lockQuery := `
+		SELECT retailer_id, amount, commision, transaction_status
+		FROM bbps_transactions
+		WHERE bbps_transaction_id = @transaction_id::UUID
+		FOR UPDATE;
+	`
+	var (
+		retailerID    string
+		amount        models.Money
+		commision     models.Money
+		currentStatus string
+	)
+	if err := tx.QueryRow(ctx, lockQuery, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(&retailerID, &amount, &commision, &currentStatus); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("invalid transaction id")
+		}
+		return err
+	}
+	if status == currentStatus && status != txstate.Pending {
+		return nil
+	}
+	if status != currentStatus {
+		if err := txstate.Check(currentStatus, status, actor); err != nil {
+			return err
 		}
-		operators = append(operators, operator)
 	}
-	return operators, res.Err()
-}
 
-func (db *Database) GetAllElectricityBillPaymentTransactionsQuery(
-	ctx context.Context,
-	offset, limit int,
-) ([]models.GetElectricityBillHistoryResponseModel, error) {
+	if err := settleProviderPayment(ctx, tx, "BBPS", transactionID, retailerID, amount, commision, status); err != nil {
+		return err
+	}
 
-	query := `
-		SELECT
-			e.electricity_bill_transaction_id,
-			e.retailer_id,
-			r.retailer_name,
-			r.retailer_business_name,
-			e.order_id,
-			e.operator_transaction_id,
-			e.partner_request_id,
-			e.customer_id,
-			e.amount,
-			w.before_balance,
-			w.after_balance,
-			e.operator_code,
-			e.operator_name,
-			e.customer_email,
-			e.commision,
-			e.transaction_status,
-			e.created_at
-		FROM electricity_bill_payments e
-		JOIN retailers r
-			ON r.retailer_id = e.retailer_id
-		JOIN wallet_transactions w
-			ON w.user_id = e.retailer_id
-			AND w.reference_id = e.electricity_bill_transaction_id::TEXT
-			AND w.transaction_reason = 'ELECTRICITY_BILL'
-		ORDER BY e.created_at DESC
-		LIMIT @limit OFFSET @offset;
+	updateQuery := `
+		UPDATE bbps_transactions
+		SET order_id = COALESCE(NULLIF(@order_id, ''), order_id),
+			operator_transaction_id = COALESCE(NULLIF(@operator_transaction_id, ''), operator_transaction_id),
+			updated_at = NOW()
+		WHERE bbps_transaction_id = @transaction_id::UUID;
 	`
-
-	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
-		"limit":  limit,
-		"offset": offset,
-	})
-	if err != nil {
-		return nil, err
+	if _, err := tx.Exec(ctx, updateQuery, pgx.NamedArgs{
+		"order_id":                orderID,
+		"operator_transaction_id": operatorTransactionID,
+		"transaction_id":          transactionID,
+	}); err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	var transactions []models.GetElectricityBillHistoryResponseModel
-
-	for rows.Next() {
-		var tx models.GetElectricityBillHistoryResponseModel
+	if status != currentStatus {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       "BBPS",
+			TransactionID: transactionID,
+			From:          currentStatus,
+			To:            status,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
 
-		err := rows.Scan(
-			&tx.ElectricityBillTransactionID,
-			&tx.RetailerID,
-			&tx.RetailerName,
-			&tx.RetailerBusinessName,
-			&tx.OrderID,
-			&tx.OperatorTransactionID,
-			&tx.PartnerRequestID,
-			&tx.CustomerID,
-			&tx.Amount,
-			&tx.BeforeBalance,
-			&tx.AfterBalance,
-			&tx.OperatorID, // operator_code
-			&tx.OperatorName,
-			&tx.CustomerEmail,
-			&tx.Commision,
-			&tx.TransactionStatus,
-			&tx.CreatedAt,
-		)
+// settleProviderPayment settles a bill payment: SUCCESS pays the
+// reservation to the provider and funds the retailer's commission, FAILED
+// gives the retailer its money back and PENDING leaves the reservation in
+// place.
+func settleProviderPayment(
+	ctx context.Context,
+	tx pgx.Tx,
+	service, referenceID, retailerID string,
+	amount models.Money,
+	commision models.Money,
+	status string,
+) error {
+	switch status {
+	case "SUCCESS":
+		r, err := lockReservation(ctx, tx, service, referenceID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if tx.TransactionStatus == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(tx.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateElectricityBillStatusByTransactionID(ctx, tx.ElectricityBillTransactionID, newStatus); err != nil {
-					return nil, err
-				}
-				tx.TransactionStatus = newStatus
+		if r == nil {
+			return fmt.Errorf("no reservation found for %s transaction %s", service, referenceID)
+		}
+		journal := ledger.NewJournal(referenceID, service, fmt.Sprintf("Transaction %s paid to provider", referenceID)).
+			Credit(ledger.ProviderFloat, amount, "")
+		if commision > 0 {
+			if err := fundCommision(ctx, tx, journal, r, retailerID, &models.CommisionSplitModel{RetailerCommision: commision}); err != nil {
+				return err
 			}
 		}
-
-		transactions = append(transactions, tx)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
+		return settleReservation(ctx, tx, r, journal)
+	case "FAILED":
+		return refundFailed(ctx, tx, &serviceTransaction{service, referenceID, retailerID, amount, txstate.Pending})
+	case "PENDING":
+		return nil
+	default:
+		return fmt.Errorf("invalid transaction status")
 	}
-
-	return transactions, nil
 }
 
-func (db *Database) GetElectricityBillPaymentTransactionsByRetailerIDQuery(
+// GetBBPSTransactionsQuery lists bill payments, newest first, optionally
+// only those of one retailer or category.
+func (db *Database) GetBBPSTransactionsQuery(
 	ctx context.Context,
-	retailerID string,
-	offset, limit int,
-) ([]models.GetElectricityBillHistoryResponseModel, error) {
-
+	retailerID, categoryCode string,
+	limit, offset int,
+) ([]models.GetBBPSTransactionsResponseModel, error) {
 	query := `
 		SELECT
-			e.electricity_bill_transaction_id,
-			e.retailer_id,
+			t.bbps_transaction_id,
+			t.partner_request_id,
+			t.operator_transaction_id,
+			t.order_id,
+			t.retailer_id,
 			r.retailer_name,
 			r.retailer_business_name,
-			e.order_id,
-			e.operator_transaction_id,
-			e.partner_request_id,
-			e.customer_id,
-			e.amount,
+			t.biller_id,
+			t.biller_name,
+			t.category_code,
+			t.operator_code,
+			t.customer_params,
+			t.customer_email,
+			t.amount,
+			t.commision,
 			w.before_balance,
 			w.after_balance,
-			e.operator_code,
-			e.operator_name,
-			e.customer_email,
-			e.commision,
-			e.transaction_status,
-			e.created_at
-		FROM electricity_bill_payments e
+			t.provider,
+			t.transaction_status,
+			t.created_at,
+			t.updated_at
+		FROM bbps_transactions t
 		JOIN retailers r
-			ON r.retailer_id = e.retailer_id
-		JOIN wallet_transactions w
-			ON w.user_id = e.retailer_id
-			AND w.reference_id = e.electricity_bill_transaction_id::TEXT
-			AND w.transaction_reason = 'ELECTRICITY_BILL'
-		WHERE e.retailer_id = $1
-		ORDER BY e.created_at DESC
-		LIMIT $2 OFFSET $3;
+			ON r.retailer_id = t.retailer_id
+		LEFT JOIN wallet_transactions w
+			ON w.user_id = t.retailer_id
+			AND w.reference_id = t.bbps_transaction_id::TEXT
+			AND w.transaction_reason = 'BBPS'
+		WHERE (@retailer_id = '' OR t.retailer_id = @retailer_id)
+		AND (@category_code = '' OR t.category_code = @category_code)
+		ORDER BY t.created_at DESC
+		LIMIT @limit OFFSET @offset;
 	`
-
-	rows, err := db.pool.Query(ctx, query, retailerID, limit, offset)
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"retailer_id":   retailerID,
+		"category_code": categoryCode,
+		"limit":         limit,
+		"offset":        offset,
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var transactions []models.GetElectricityBillHistoryResponseModel
-
+	transactions := []models.GetBBPSTransactionsResponseModel{}
 	for rows.Next() {
-		var tx models.GetElectricityBillHistoryResponseModel
-
-		err := rows.Scan(
-			&tx.ElectricityBillTransactionID,
-			&tx.RetailerID,
-			&tx.RetailerName,
-			&tx.RetailerBusinessName,
-			&tx.OrderID,
-			&tx.OperatorTransactionID,
-			&tx.PartnerRequestID,
-			&tx.CustomerID,
-			&tx.Amount,
-			&tx.BeforeBalance,
-			&tx.AfterBalance,
-			&tx.OperatorID,
-			&tx.OperatorName,
-			&tx.CustomerEmail,
-			&tx.Commision,
-			&tx.TransactionStatus,
-			&tx.CreatedAt,
-		)
-		if err != nil {
+		var t models.GetBBPSTransactionsResponseModel
+		if err := rows.Scan(
+			&t.BBPSTransactionID,
+			&t.PartnerRequestID,
+			&t.OperatorTransactionID,
+			&t.OrderID,
+			&t.RetailerID,
+			&t.RetailerName,
+			&t.RetailerBusinessName,
+			&t.BillerID,
+			&t.BillerName,
+			&t.CategoryCode,
+			&t.OperatorCode,
+			&t.CustomerParams,
+			&t.CustomerEmail,
+			&t.Amount,
+			&t.Commision,
+			&t.BeforeBalance,
+			&t.AfterBalance,
+			&t.Provider,
+			&t.TransactionStatus,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
-		if tx.TransactionStatus == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(tx.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateElectricityBillStatusByTransactionID(ctx, tx.ElectricityBillTransactionID, newStatus); err != nil {
-					return nil, err
-				}
-				tx.TransactionStatus = newStatus
-			}
-		}
-
-		transactions = append(transactions, tx)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
+		transactions = append(transactions, t)
 	}
-
-	return transactions, nil
-}
-
-func (db *Database) UpdateElectricityBillStatusByTransactionID(
-	ctx context.Context,
-	electricityBillTransactionID int,
-	status string,
-) error {
-
-	query := `
-		UPDATE electricity_bill_payments
-		SET
-			transaction_status = $1
-		WHERE electricity_bill_transaction_id = $2;
-	`
-
-	_, err := db.pool.Exec(
-		ctx,
-		query,
-		status,
-		electricityBillTransactionID,
-	)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (db *Database) RefundElectricityBillPaymentQuery(
-	ctx context.Context,
-	transactionId int,
-) error {
-
-	tx, err := db.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	getTransactionDetailsQuery := `
-		UPDATE electricity_bill_payments
-		SET transaction_status = @status
-		WHERE electricity_bill_transaction_id = @transaction_id
-		RETURNING retailer_id, amount;
-	`
-	var (
-		retailerId string
-		amount     float64
-	)
-	if err := tx.QueryRow(ctx, getTransactionDetailsQuery, pgx.NamedArgs{
-		"transaction_id": transactionId,
-		"status":         "REFUND",
-	}).Scan(
-		&retailerId,
-		&amount,
-	); err != nil {
-		return err
-	}
-
-	getRetailerWalletBalanceQuery := `
-		SELECT retailer_wallet_balance 
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerWalletBalanceQuery, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(&retailerBeforeBalance); err != nil {
-		return err
-	}
-
-	updateRetailerWalletAndGetAfterBalanceQuery := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance + @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailerWalletAndGetAfterBalanceQuery, pgx.NamedArgs{
-		"retailer_id": retailerId,
-		"amount":      amount,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			debit_amount,
-			credit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@debit_amount,
-			@credit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks 
-		);
-	`
-	if _, err := tx.Exec(ctx, insertToWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            retailerId,
-		"reference_id":       fmt.Sprintf("%d", transactionId),
-		"debit_amount":       0,
-		"credit_amount":      amount,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "ELECTRICITY_BILL_REFUND",
-		"remarks":            fmt.Sprintf("transaction %d refunded to %s", transactionId, retailerId),
-	}); err != nil {
-		return err
-	}
-	return tx.Commit(ctx)
+	return transactions, rows.Err()
 }
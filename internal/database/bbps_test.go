@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func bbpsPayment(retailerID, partnerRequestID string, amount models.Money) models.BBPSBillPaymentRequestModel {
+	return models.BBPSBillPaymentRequestModel{
+		RetailerID:       retailerID,
+		BillerID:         "GAS_40",
+		CustomerParams:   map[string]string{"consumer_number": "1234567", "bp_number": "BP01"},
+		Amount:           amount,
+		PartnerRequestID: partnerRequestID,
+	}
+}
+
+func TestBBPSBillers(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	dbtest.Seed(t, conn)
+
+	if err := db.CreateBBPSBillerQuery(ctx, models.CreateBBPSBillerRequestModel{
+		BillerID:     "GAS_40",
+		BillerName:   "Indraprastha Gas",
+		CategoryCode: "GAS",
+		OperatorCode: 40,
+		Params: []models.BBPSBillerParamModel{
+			{ParamName: "consumer_number", DisplayName: "Consumer Number", MinLength: 7},
+			{ParamName: "bp_number", DisplayName: "BP Number", IsOptional: true},
+		},
+	}); err != nil {
+		t.Fatalf("CreateBBPSBillerQuery: %v", err)
+	}
+	if err := db.CreateBBPSBillerQuery(ctx, models.CreateBBPSBillerRequestModel{
+		BillerID:     "TOLL_1",
+		BillerName:   "Toll",
+		CategoryCode: "TOLL",
+		OperatorCode: 1,
+		Params:       []models.BBPSBillerParamModel{{ParamName: "vehicle", DisplayName: "Vehicle"}},
+	}); err == nil {
+		t.Error("created a biller in an unknown category")
+	}
+
+	biller, err := db.GetBBPSBillerByIDQuery(ctx, "GAS_40")
+	if err != nil {
+		t.Fatalf("GetBBPSBillerByIDQuery: %v", err)
+	}
+	if !biller.IsActive || len(biller.Params) != 2 || biller.Params[0].ParamName != "consumer_number" || biller.Params[1].ParamName != "bp_number" {
+		t.Errorf("biller = %+v, want it active with its parameters in order", biller)
+	}
+
+	inactive := false
+	if err := db.UpdateBBPSBillerQuery(ctx, models.UpdateBBPSBillerRequestModel{
+		BillerID: "GAS_40",
+		IsActive: &inactive,
+		Params:   []models.BBPSBillerParamModel{{ParamName: "customer_id", DisplayName: "Customer ID"}},
+	}); err != nil {
+		t.Fatalf("UpdateBBPSBillerQuery: %v", err)
+	}
+	if biller, err = db.GetBBPSBillerByIDQuery(ctx, "GAS_40"); err != nil {
+		t.Fatalf("GetBBPSBillerByIDQuery: %v", err)
+	}
+	if biller.IsActive || biller.BillerName != "Indraprastha Gas" || len(biller.Params) != 1 || biller.Params[0].ParamName != "customer_id" {
+		t.Errorf("updated biller = %+v, want it inactive with only customer_id", biller)
+	}
+
+	if _, err := db.GetBBPSBillerByIDQuery(ctx, "GAS_999"); !models.IsRejection(err) {
+		t.Errorf("unknown biller: err = %v, want a rejection", err)
+	}
+}
+
+func TestBBPSBillPayment(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(1000))
+
+	if err := db.CreateBBPSBillerQuery(ctx, models.CreateBBPSBillerRequestModel{
+		BillerID:     "GAS_40",
+		BillerName:   "Indraprastha Gas",
+		CategoryCode: "GAS",
+		OperatorCode: 40,
+		Params: []models.BBPSBillerParamModel{
+			{ParamName: "consumer_number", DisplayName: "Consumer Number"},
+			{ParamName: "bp_number", DisplayName: "BP Number", IsOptional: true},
+		},
+	}); err != nil {
+		t.Fatalf("CreateBBPSBillerQuery: %v", err)
+	}
+	biller, err := db.GetBBPSBillerByIDQuery(ctx, "GAS_40")
+	if err != nil {
+		t.Fatalf("GetBBPSBillerByIDQuery: %v", err)
+	}
+	// 1% on bills; only the retailer's half is paid.
+	if _, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+		Service:              "BBPS",
+		CommisionType:        "PERCENTAGE",
+		PercentCommision:     rate(t, "1"),
+		AdminCommision:       rate(t, "0.25"),
+		DistributorCommision: rate(t, "0.25"),
+		RetailerCommision:    rate(t, "0.5"),
+	}); err != nil {
+		t.Fatalf("CreateCommisionSlabQuery: %v", err)
+	}
+
+	failed, err := db.ReserveBBPSTransactionQuery(ctx, bbpsPayment(h.RetailerID, "00000000-0000-0000-0000-000000000001", models.Rupees(200)), biller)
+	if err != nil {
+		t.Fatalf("ReserveBBPSTransactionQuery: %v", err)
+	}
+	if err := db.SettleBBPSTransactionQuery(ctx, failed, "FAILED", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleBBPSTransactionQuery: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(1000) {
+		t.Errorf("retailer balance after a failed payment = %s, want 1000.00", got)
+	}
+
+	paid, err := db.ReserveBBPSTransactionQuery(ctx, bbpsPayment(h.RetailerID, "00000000-0000-0000-0000-000000000002", models.Rupees(800)), biller)
+	if err != nil {
+		t.Fatalf("ReserveBBPSTransactionQuery: %v", err)
+	}
+	// 800 less the 4 commission plus 0.08 TDS on it.
+	want := models.Rupees(204) - 8*models.Paisa
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != want {
+		t.Errorf("retailer balance after reserving = %s, want %s", got, want)
+	}
+	for _, status := range []string{"PENDING", "SUCCESS"} {
+		if err := db.SettleBBPSTransactionQuery(ctx, paid, status, "ORDER1", "TXN1", txstate.Provider("TEST"), ""); err != nil {
+			t.Fatalf("SettleBBPSTransactionQuery(%s): %v", status, err)
+		}
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != want {
+		t.Errorf("retailer balance after paying = %s, want %s", got, want)
+	}
+	if got := dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"); got != models.Rupees(800) {
+		t.Errorf("provider float = %s, want 800.00", got)
+	}
+	for _, userID := range []string{h.DistributorID, h.MasterDistributorID} {
+		if got := dbtest.Balance(t, conn, userID); got != 0 {
+			t.Errorf("%s earned %s on a bill payment, want nothing", userID, got)
+		}
+	}
+
+	if _, err := db.RefundTransactionQuery(ctx, "BBPS", paid, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(1000) {
+		t.Errorf("retailer balance after the refund = %s, want 1000.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"); got != 0 {
+		t.Errorf("provider float after the refund = %s, want 0.00", got)
+	}
+}
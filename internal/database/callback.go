@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// InsertProviderCallbackQuery keeps every provider callback with what was
+// done with it, so notifications can be audited and replayed.
+func (db *Database) InsertProviderCallbackQuery(
+	ctx context.Context,
+	callback models.ProviderCallbackModel,
+) error {
+	query := `
+		INSERT INTO provider_callbacks (
+			provider,
+			partner_request_id,
+			provider_status,
+			payload,
+			source_ip,
+			service,
+			transaction_id,
+			callback_result
+		) VALUES (
+			@provider,
+			@partner_request_id,
+			@provider_status,
+			@payload,
+			@source_ip,
+			@service,
+			@transaction_id,
+			@callback_result
+		);
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"provider":           callback.Provider,
+		"partner_request_id": callback.PartnerRequestID,
+		"provider_status":    callback.ProviderStatus,
+		"payload":            string(callback.Payload),
+		"source_ip":          callback.SourceIP,
+		"service":            callback.Service,
+		"transaction_id":     callback.TransactionID,
+		"callback_result":    callback.CallbackResult,
+	})
+	return err
+}
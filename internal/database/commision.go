@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
@@ -9,10 +10,29 @@ import (
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
+// CreateCommisionQuery stores a commission split after checking it against
+// the splits of the user's parent and children.
 func (db *Database) CreateCommisionQuery(
 	ctx context.Context,
 	req models.CreateCommisionRequestModel,
 ) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCommisionSplit(ctx, tx, &models.GetCommisionResponseModel{
+		UserID:                     req.UserID,
+		Service:                    req.Service,
+		TotalCommision:             req.TotalCommision,
+		AdminCommision:             req.AdminCommision,
+		MasterDistributorCommision: req.MasterDistributorCommision,
+		DistributorCommision:       req.DistributorCommision,
+		RetailerCommision:          req.RetailerCommision,
+	}); err != nil {
+		return err
+	}
 
 	query := `
 		INSERT INTO commisions (
@@ -34,7 +54,7 @@ func (db *Database) CreateCommisionQuery(
 		);
 	`
 
-	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
 		"user_id":               req.UserID,
 		"service":               req.Service,
 		"total_commision":       req.TotalCommision,
@@ -47,7 +67,7 @@ func (db *Database) CreateCommisionQuery(
 		return fmt.Errorf("failed to create commision")
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (db *Database) GetCommisionDetailsByCommisionIDQuery(
@@ -197,40 +217,74 @@ func (db *Database) GetCommisionByUserIDAndServiceQuery(
 	return &commision, nil
 }
 
+// UpdateCommisionQuery changes the fields of a commission split that are
+// set in req. The resulting split is checked like a new one.
 func (db *Database) UpdateCommisionQuery(
 	ctx context.Context,
 	req models.UpdateCommisionRequestModel,
 ) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var c models.GetCommisionResponseModel
+	if err := scanCommisionRow(tx.QueryRow(ctx, `
+		SELECT `+commisionRowColumns+`
+		FROM commisions c
+		WHERE c.commision_id = @commision_id
+		FOR UPDATE;
+	`, pgx.NamedArgs{"commision_id": req.CommisionID}), &c); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("invalid commision id or commision is not found")
+		}
+		return err
+	}
+	for _, f := range []struct {
+		dst *models.Rate
+		src *models.Rate
+	}{
+		{&c.TotalCommision, req.TotalCommision},
+		{&c.AdminCommision, req.AdminCommision},
+		{&c.MasterDistributorCommision, req.MasterDistributorCommision},
+		{&c.DistributorCommision, req.DistributorCommision},
+		{&c.RetailerCommision, req.RetailerCommision},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	if err := checkCommisionSplit(ctx, tx, &c); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE commisions
-		SET admin_commision = @admin_commision,
+		SET total_commision = @total_commision,
+		admin_commision = @admin_commision,
 		master_distributor_commision = @md_commision,
 		distributor_commision = @distributor_commision,
 		retailer_commision = @retailer_commision,
 		updated_at = NOW()
 		WHERE commision_id = @commision_id;
 	`
-	res, err := db.pool.Exec(
+	if _, err := tx.Exec(
 		ctx,
 		query,
 		pgx.NamedArgs{
-			"admin_commision":              req.AdminCommision,
-			"md_commision": req.MasterDistributorCommision,
-			"distributor_commision":        req.DistributorCommision,
-			"retailer_commision":           req.RetailerCommision,
-			"commision_id":                 req.CommisionID,
+			"total_commision":       c.TotalCommision,
+			"admin_commision":       c.AdminCommision,
+			"md_commision":          c.MasterDistributorCommision,
+			"distributor_commision": c.DistributorCommision,
+			"retailer_commision":    c.RetailerCommision,
+			"commision_id":          req.CommisionID,
 		},
-	)
-
-	if err != nil {
+	); err != nil {
 		return fmt.Errorf("failed to update commision")
 	}
 
-	if res.RowsAffected() == 0 {
-		return fmt.Errorf("invalid commision id or commision is not found")
-	}
-
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (db *Database) DeleteCommisionQuery(
@@ -262,10 +316,12 @@ func (db *Database) GetAllTDSCommisionQuery(
 	query := `
 		SELECT
 			tds_commision_id,
+			service,
 			transaction_id,
 			user_id,
 			user_name,
 			commision,
+			tds_rate,
 			tds,
 			paid_commision,
 			pan_number,
@@ -288,10 +344,12 @@ func (db *Database) GetAllTDSCommisionQuery(
 		var r models.GetTDSCommisionResponseModel
 		if err := rows.Scan(
 			&r.TDSCommisionID,
+			&r.Service,
 			&r.TransactionID,
 			&r.UserID,
 			&r.UserName,
 			&r.Commision,
+			&r.TDSRate,
 			&r.TDS,
 			&r.PaidCommision,
 			&r.PANNumber,
@@ -315,10 +373,12 @@ func (db *Database) GetTDSCommisionByUserIDQuery(
 	query := `
 		SELECT
 			tds_commision_id,
+			service,
 			transaction_id,
 			user_id,
 			user_name,
 			commision,
+			tds_rate,
 			tds,
 			paid_commision,
 			pan_number,
@@ -342,10 +402,12 @@ func (db *Database) GetTDSCommisionByUserIDQuery(
 		var r models.GetTDSCommisionResponseModel
 		if err := rows.Scan(
 			&r.TDSCommisionID,
+			&r.Service,
 			&r.TransactionID,
 			&r.UserID,
 			&r.UserName,
 			&r.Commision,
+			&r.TDSRate,
 			&r.TDS,
 			&r.PaidCommision,
 			&r.PANNumber,
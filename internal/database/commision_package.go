@@ -0,0 +1,525 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Commission packages are managed by admins and master distributors. The
+// queries take the id of the master distributor acting as ownerID and an
+// empty ownerID for admins: a master distributor only changes its own
+// packages, assigns its own or an admin's packages, and only to users in
+// its downline.
+
+// downlineCondition restricts a user_id column to the downline of
+// @owner_id, or not at all for an empty owner.
+const downlineCondition = `(
+			@owner_id = ''
+			OR user_id IN (
+				SELECT distributor_id
+				FROM distributors
+				WHERE master_distributor_id = @owner_id
+				UNION ALL
+				SELECT r.retailer_id
+				FROM retailers r
+				JOIN distributors d ON d.distributor_id = r.distributor_id
+				WHERE d.master_distributor_id = @owner_id
+			)
+		)`
+
+// assignedCommisionPackage returns the package assigned to a user, if any.
+func assignedCommisionPackage(ctx context.Context, q querier, userID string) (*int64, error) {
+	query := `
+		SELECT package_id
+		FROM commision_package_assignments
+		WHERE user_id = @user_id;
+	`
+	var packageID int64
+	err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&packageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &packageID, nil
+}
+
+func (db *Database) CreateCommisionPackageQuery(
+	ctx context.Context,
+	req models.CreateCommisionPackageRequestModel,
+	createdBy string,
+) (int64, error) {
+	query := `
+		INSERT INTO commision_packages (
+			package_name,
+			description,
+			created_by
+		) VALUES (
+			@package_name,
+			@description,
+			@created_by
+		)
+		RETURNING package_id;
+	`
+	var packageID int64
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"package_name": req.PackageName,
+		"description":  req.Description,
+		"created_by":   createdBy,
+	}).Scan(&packageID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, fmt.Errorf("a package with this name already exists")
+		}
+		log.Println(err)
+		return 0, fmt.Errorf("failed to create commision package")
+	}
+	return packageID, nil
+}
+
+func (db *Database) UpdateCommisionPackageQuery(
+	ctx context.Context,
+	req models.UpdateCommisionPackageRequestModel,
+	ownerID string,
+) error {
+	query := `
+		UPDATE commision_packages
+		SET package_name = COALESCE(@package_name, package_name),
+			description = COALESCE(@description, description),
+			updated_at = NOW()
+		WHERE package_id = @package_id
+		AND (@owner_id = '' OR created_by = @owner_id);
+	`
+	tag, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"package_id":   req.PackageID,
+		"package_name": req.PackageName,
+		"description":  req.Description,
+		"owner_id":     ownerID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("a package with this name already exists")
+		}
+		log.Println(err)
+		return fmt.Errorf("failed to update commision package")
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("invalid package id or package not found")
+	}
+	return nil
+}
+
+// DeleteCommisionPackageQuery deletes a package and its slabs. A package
+// still assigned to users cannot be deleted.
+func (db *Database) DeleteCommisionPackageQuery(
+	ctx context.Context,
+	packageID int64,
+	ownerID string,
+) error {
+	tag, err := db.pool.Exec(ctx, `
+		DELETE FROM commision_packages
+		WHERE package_id = @package_id
+		AND (@owner_id = '' OR created_by = @owner_id);
+	`, pgx.NamedArgs{
+		"package_id": packageID,
+		"owner_id":   ownerID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("package is assigned to users")
+		}
+		return fmt.Errorf("failed to delete commision package")
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("invalid package id or package not found")
+	}
+	return nil
+}
+
+// CheckCommisionPackageOwnerQuery fails unless ownerID may change the
+// package's slabs.
+func (db *Database) CheckCommisionPackageOwnerQuery(
+	ctx context.Context,
+	packageID int64,
+	ownerID string,
+) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM commision_packages
+			WHERE package_id = @package_id
+			AND (@owner_id = '' OR created_by = @owner_id)
+		);
+	`
+	var ok bool
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"package_id": packageID,
+		"owner_id":   ownerID,
+	}).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid package id or package not found")
+	}
+	return nil
+}
+
+const commisionPackageColumns = `
+			p.package_id,
+			p.package_name,
+			p.description,
+			p.created_by,
+			(
+				SELECT COUNT(*)
+				FROM commision_package_assignments a
+				WHERE a.package_id = p.package_id
+			),
+			p.created_at,
+			p.updated_at`
+
+func scanCommisionPackage(row pgx.Row) (*models.GetCommisionPackageResponseModel, error) {
+	var p models.GetCommisionPackageResponseModel
+	if err := row.Scan(
+		&p.PackageID,
+		&p.PackageName,
+		&p.Description,
+		&p.CreatedBy,
+		&p.AssignedUsers,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetCommisionPackageByIDQuery returns a package ownerID may assign, with
+// its slabs.
+func (db *Database) GetCommisionPackageByIDQuery(
+	ctx context.Context,
+	packageID int64,
+	ownerID string,
+) (*models.GetCommisionPackageResponseModel, error) {
+	query := `SELECT` + commisionPackageColumns + `
+		FROM commision_packages p
+		WHERE p.package_id = @package_id
+		AND (
+			@owner_id = ''
+			OR p.created_by = @owner_id
+			OR p.created_by IN (SELECT admin_id FROM admins)
+		);
+	`
+	p, err := scanCommisionPackage(db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"package_id": packageID,
+		"owner_id":   ownerID,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch commision package")
+	}
+	p.Slabs, err = db.GetCommisionSlabsQuery(ctx, "", "", packageID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetCommisionPackagesQuery lists the packages ownerID may assign: its own
+// and the admins', or all of them for an admin.
+func (db *Database) GetCommisionPackagesQuery(
+	ctx context.Context,
+	ownerID string,
+	limit, offset int,
+) ([]models.GetCommisionPackageResponseModel, error) {
+	query := `SELECT` + commisionPackageColumns + `
+		FROM commision_packages p
+		WHERE @owner_id = ''
+		OR p.created_by = @owner_id
+		OR p.created_by IN (SELECT admin_id FROM admins)
+		ORDER BY p.package_name
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"owner_id": ownerID,
+		"limit":    limit,
+		"offset":   offset,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, fmt.Errorf("failed to fetch commision packages")
+	}
+	defer rows.Close()
+
+	var packages []models.GetCommisionPackageResponseModel
+	for rows.Next() {
+		p, err := scanCommisionPackage(rows)
+		if err != nil {
+			log.Println(err)
+			return nil, fmt.Errorf("failed to fetch commision packages")
+		}
+		packages = append(packages, *p)
+	}
+	return packages, rows.Err()
+}
+
+// AssignCommisionPackageQuery assigns a package to users, replacing their
+// current package.
+func (db *Database) AssignCommisionPackageQuery(
+	ctx context.Context,
+	req models.AssignCommisionPackageRequestModel,
+	assignedBy string,
+	ownerID string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkAssignablePackage(ctx, tx, req.PackageID, ownerID); err != nil {
+		return err
+	}
+
+	// Every user must exist below the admin and, for a master distributor,
+	// in its downline.
+	checkQuery := `
+		SELECT u.user_id
+		FROM unnest(@user_ids::TEXT[]) AS u (user_id)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM master_distributors WHERE master_distributor_id = u.user_id
+			UNION ALL
+			SELECT 1 FROM distributors WHERE distributor_id = u.user_id
+			UNION ALL
+			SELECT 1 FROM retailers WHERE retailer_id = u.user_id
+		)
+		OR NOT ` + downlineCondition + `
+		LIMIT 1;
+	`
+	var invalid string
+	err = tx.QueryRow(ctx, checkQuery, pgx.NamedArgs{
+		"user_ids": req.UserIDs,
+		"owner_id": ownerID,
+	}).Scan(&invalid)
+	if err == nil {
+		return fmt.Errorf("user %s not found", invalid)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	query := `
+		INSERT INTO commision_package_assignments (
+			user_id,
+			package_id,
+			assigned_by
+		)
+		SELECT DISTINCT u.user_id, @package_id, @assigned_by
+		FROM unnest(@user_ids::TEXT[]) AS u (user_id)
+		ON CONFLICT (user_id) DO UPDATE
+		SET package_id = EXCLUDED.package_id,
+			assigned_by = EXCLUDED.assigned_by,
+			assigned_at = NOW();
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"user_ids":    req.UserIDs,
+		"package_id":  req.PackageID,
+		"assigned_by": assignedBy,
+	}); err != nil {
+		log.Println(err)
+		return fmt.Errorf("failed to assign commision package")
+	}
+	return tx.Commit(ctx)
+}
+
+// UnassignCommisionPackageQuery removes the package of users, who then
+// fall back to their hierarchy and the defaults.
+func (db *Database) UnassignCommisionPackageQuery(
+	ctx context.Context,
+	req models.UnassignCommisionPackageRequestModel,
+	ownerID string,
+) (int64, error) {
+	query := `
+		DELETE FROM commision_package_assignments
+		WHERE user_id = ANY (@user_ids)
+		AND ` + downlineCondition + `;
+	`
+	tag, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"user_ids": req.UserIDs,
+		"owner_id": ownerID,
+	})
+	if err != nil {
+		log.Println(err)
+		return 0, fmt.Errorf("failed to unassign commision package")
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ReassignCommisionPackageQuery moves the users of one package to another
+// and returns how many were moved. A master distributor only moves the
+// users in its downline.
+func (db *Database) ReassignCommisionPackageQuery(
+	ctx context.Context,
+	req models.ReassignCommisionPackageRequestModel,
+	assignedBy string,
+	ownerID string,
+) (int64, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkAssignablePackage(ctx, tx, req.ToPackageID, ownerID); err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE commision_package_assignments
+		SET package_id = @to_package_id,
+			assigned_by = @assigned_by,
+			assigned_at = NOW()
+		WHERE package_id = @from_package_id
+		AND ` + downlineCondition + `;
+	`
+	tag, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"from_package_id": req.FromPackageID,
+		"to_package_id":   req.ToPackageID,
+		"assigned_by":     assignedBy,
+		"owner_id":        ownerID,
+	})
+	if err != nil {
+		log.Println(err)
+		return 0, fmt.Errorf("failed to reassign commision package")
+	}
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+// checkAssignablePackage fails unless ownerID may assign the package: its
+// own or an admin's.
+func checkAssignablePackage(ctx context.Context, tx pgx.Tx, packageID int64, ownerID string) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM commision_packages
+			WHERE package_id = @package_id
+			AND (
+				@owner_id = ''
+				OR created_by = @owner_id
+				OR created_by IN (SELECT admin_id FROM admins)
+			)
+		);
+	`
+	var ok bool
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"package_id": packageID,
+		"owner_id":   ownerID,
+	}).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid package id or package not found")
+	}
+	return nil
+}
+
+// IsInDownlineQuery reports whether userID is the user itself or below
+// ancestorID in the hierarchy.
+func (db *Database) IsInDownlineQuery(ctx context.Context, ancestorID, userID string) (bool, error) {
+	query := `
+		SELECT @user_id = @ancestor_id OR EXISTS (
+			SELECT 1
+			FROM distributors
+			WHERE distributor_id = @user_id
+			AND master_distributor_id = @ancestor_id
+			UNION ALL
+			SELECT 1
+			FROM retailers r
+			JOIN distributors d ON d.distributor_id = r.distributor_id
+			WHERE r.retailer_id = @user_id
+			AND (r.distributor_id = @ancestor_id OR d.master_distributor_id = @ancestor_id)
+		);
+	`
+	var ok bool
+	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"ancestor_id": ancestorID,
+		"user_id":     userID,
+	}).Scan(&ok)
+	return ok, err
+}
+
+// GetEffectiveCommisionQuery lists, for a user and each member of the
+// hierarchy above it, the overrides and package that make up the user's
+// commission, in the order resolveCommision applies them.
+func (db *Database) GetEffectiveCommisionQuery(
+	ctx context.Context,
+	userID string,
+) (*models.EffectiveCommisionModel, error) {
+	table, err := ledger.WalletTable(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []string
+	switch table {
+	case "retailer":
+		h, err := getRetailerHierarchy(ctx, db.pool, userID)
+		if err != nil {
+			return nil, err
+		}
+		chain = []string{userID, h.distributorID, h.masterDistributorID}
+	case "distributor":
+		var masterDistributorID string
+		if err := db.pool.QueryRow(ctx, `
+			SELECT master_distributor_id
+			FROM distributors
+			WHERE distributor_id = @distributor_id;
+		`, pgx.NamedArgs{"distributor_id": userID}).Scan(&masterDistributorID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("distributor not found")
+			}
+			return nil, err
+		}
+		chain = []string{userID, masterDistributorID}
+	case "master_distributor":
+		chain = []string{userID}
+	}
+
+	effective := models.EffectiveCommisionModel{
+		UserID: userID,
+		Levels: make([]models.EffectiveCommisionLevelModel, 0, len(chain)),
+	}
+	for _, id := range chain {
+		level := models.EffectiveCommisionLevelModel{UserID: id}
+		if level.Slabs, err = db.GetCommisionSlabsQuery(ctx, "", id, 0, 0, 0); err != nil {
+			return nil, err
+		}
+		if level.Commisions, err = db.GetCommisionsByUserIDQuery(ctx, id); err != nil {
+			return nil, err
+		}
+		packageID, err := assignedCommisionPackage(ctx, db.pool, id)
+		if err != nil {
+			return nil, err
+		}
+		if packageID != nil {
+			if level.Package, err = db.GetCommisionPackageByIDQuery(ctx, *packageID, ""); err != nil {
+				return nil, err
+			}
+		}
+		effective.Levels = append(effective.Levels, level)
+	}
+	if effective.DefaultSlabs, err = db.GetCommisionSlabsQuery(ctx, "", "default", 0, 0, 0); err != nil {
+		return nil, err
+	}
+	return &effective, nil
+}
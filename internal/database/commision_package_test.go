@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestCommisionPackageAppliesBelowOverrides(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	createPackage := func(name string, commision models.Money) int64 {
+		t.Helper()
+		packageID, err := db.CreateCommisionPackageQuery(ctx, models.CreateCommisionPackageRequestModel{
+			PackageName: name,
+		}, h.AdminID)
+		if err != nil {
+			t.Fatalf("CreateCommisionPackageQuery: %v", err)
+		}
+		if _, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+			PackageID:         &packageID,
+			Service:           "PAYOUT",
+			CommisionType:     "FLAT",
+			FlatCommision:     commision,
+			RetailerCommision: models.RateOne,
+		}); err != nil {
+			t.Fatalf("CreateCommisionSlabQuery: %v", err)
+		}
+		return packageID
+	}
+	assign := func(packageID int64, userID string) {
+		t.Helper()
+		if err := db.AssignCommisionPackageQuery(ctx, models.AssignCommisionPackageRequestModel{
+			PackageID: packageID,
+			UserIDs:   []string{userID},
+		}, h.AdminID, ""); err != nil {
+			t.Fatalf("AssignCommisionPackageQuery: %v", err)
+		}
+	}
+	total := func() models.Money {
+		t.Helper()
+		split, err := commisionSplit(ctx, db, "PAYOUT", h.RetailerID, 0, models.Rupees(1000))
+		if err != nil {
+			t.Fatalf("resolveCommision: %v", err)
+		}
+		return split.TotalCommision
+	}
+
+	silver := createPackage("Silver", models.Rupees(15))
+	gold := createPackage("Gold", models.Rupees(20))
+
+	// The distributor's package beats the default 1.2%.
+	assign(silver, h.DistributorID)
+	if got := total(); got != models.Rupees(15) {
+		t.Errorf("commission with the distributor's package = %s, want 15.00", got)
+	}
+
+	// The retailer's own package comes before its distributor's, and the
+	// retailer's override before its package.
+	assign(gold, h.RetailerID)
+	if got := total(); got != models.Rupees(20) {
+		t.Errorf("commission with the retailer's package = %s, want 20.00", got)
+	}
+	userID := h.RetailerID
+	slabID, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+		UserID:            &userID,
+		Service:           "PAYOUT",
+		CommisionType:     "FLAT",
+		FlatCommision:     models.Rupees(8),
+		RetailerCommision: models.RateOne,
+	})
+	if err != nil {
+		t.Fatalf("CreateCommisionSlabQuery: %v", err)
+	}
+	if got := total(); got != models.Rupees(8) {
+		t.Errorf("commission with the retailer's override = %s, want 8.00", got)
+	}
+	if err := db.DeleteCommisionSlabQuery(ctx, slabID); err != nil {
+		t.Fatalf("DeleteCommisionSlabQuery: %v", err)
+	}
+
+	// Without its package the retailer falls back to the distributor's.
+	if _, err := db.UnassignCommisionPackageQuery(ctx, models.UnassignCommisionPackageRequestModel{
+		UserIDs: []string{h.RetailerID},
+	}, ""); err != nil {
+		t.Fatalf("UnassignCommisionPackageQuery: %v", err)
+	}
+	if got := total(); got != models.Rupees(15) {
+		t.Errorf("commission after unassigning = %s, want 15.00", got)
+	}
+
+	moved, err := db.ReassignCommisionPackageQuery(ctx, models.ReassignCommisionPackageRequestModel{
+		FromPackageID: silver,
+		ToPackageID:   gold,
+	}, h.AdminID, "")
+	if err != nil {
+		t.Fatalf("ReassignCommisionPackageQuery: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("reassigned %d users, want 1", moved)
+	}
+	if got := total(); got != models.Rupees(20) {
+		t.Errorf("commission after reassigning = %s, want 20.00", got)
+	}
+}
+
+func TestCommisionPackageAssignmentStaysInTheDownline(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	packageID, err := db.CreateCommisionPackageQuery(ctx, models.CreateCommisionPackageRequestModel{
+		PackageName: "Downline",
+	}, h.MasterDistributorID)
+	if err != nil {
+		t.Fatalf("CreateCommisionPackageQuery: %v", err)
+	}
+	req := models.AssignCommisionPackageRequestModel{
+		PackageID: packageID,
+		UserIDs:   []string{h.RetailerID},
+	}
+	if err := db.AssignCommisionPackageQuery(ctx, req, h.MasterDistributorID, h.MasterDistributorID); err != nil {
+		t.Fatalf("assigning to the downline: %v", err)
+	}
+	for _, userID := range []string{h.MasterDistributorID, "R999999"} {
+		req.UserIDs = []string{userID}
+		if err := db.AssignCommisionPackageQuery(ctx, req, h.MasterDistributorID, h.MasterDistributorID); err == nil {
+			t.Errorf("assigning to %s, outside the downline, succeeded", userID)
+		}
+	}
+}
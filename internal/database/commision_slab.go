@@ -0,0 +1,502 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// querier is the read side shared by the pool and a transaction, so the
+// commission of a transaction can be worked out inside or outside one.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// commisionRule is a slab, or a percentage row of commisions, ready to be
+// applied to an amount.
+type commisionRule struct {
+	commisionType string
+	flat          models.Money
+	percent       models.Rate
+	min           models.Money
+	max           *models.Money
+	admin         models.Rate
+	md            models.Rate
+	dis           models.Rate
+	retailer      models.Rate
+}
+
+// split computes the commission on amount and divides it over the
+// hierarchy. The admin share comes last so it takes up the rounding.
+func (r *commisionRule) split(amount models.Money) *models.CommisionSplitModel {
+	total := r.flat
+	if r.commisionType == "PERCENTAGE" {
+		total = amount.Percent(r.percent)
+	}
+	if total < r.min {
+		total = r.min
+	}
+	if r.max != nil && total > *r.max {
+		total = *r.max
+	}
+
+	shares := total.Split(r.retailer, r.dis, r.md, r.admin)
+	return &models.CommisionSplitModel{
+		TotalCommision:             total,
+		RetailerCommision:          shares[0],
+		DistributorCommision:       shares[1],
+		MasterDistributorCommision: shares[2],
+		AdminCommision:             shares[3],
+	}
+}
+
+// retailerHierarchy is the chain of users above a retailer.
+type retailerHierarchy struct {
+	distributorID       string
+	masterDistributorID string
+	adminID             string
+}
+
+func getRetailerHierarchy(ctx context.Context, q querier, retailerID string) (*retailerHierarchy, error) {
+	query := `
+		SELECT d.distributor_id, md.master_distributor_id, md.admin_id
+		FROM retailers r
+		JOIN distributors d ON d.distributor_id = r.distributor_id
+		JOIN master_distributors md ON md.master_distributor_id = d.master_distributor_id
+		WHERE r.retailer_id = @retailer_id;
+	`
+	var h retailerHierarchy
+	if err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"retailer_id": retailerID,
+	}).Scan(&h.distributorID, &h.masterDistributorID, &h.adminID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("retailer not found")
+		}
+		return nil, err
+	}
+	return &h, nil
+}
+
+// resolveCommision finds the commission rule for a transaction and applies
+// it. The retailer's own configuration wins, then its distributor's, then
+// its master distributor's. At each level the user's overrides come first
+// (a slab covering the amount, then the user's percentage in commisions),
+// then the slabs of the package assigned to the user. The slabs without a
+// user or package are the defaults. It returns nil when nothing applies.
+func resolveCommision(
+	ctx context.Context,
+	q querier,
+	service string,
+	retailerID string,
+	operatorCode int,
+	amount models.Money,
+) (*models.CommisionSplitModel, error) {
+	h, err := getRetailerHierarchy(ctx, q, retailerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range []string{retailerID, h.distributorID, h.masterDistributorID} {
+		rule, err := findCommisionSlab(ctx, q, service, &userID, nil, operatorCode, amount)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			rule, err = findUserCommision(ctx, q, service, userID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if rule == nil {
+			packageID, err := assignedCommisionPackage(ctx, q, userID)
+			if err != nil {
+				return nil, err
+			}
+			if packageID != nil {
+				rule, err = findCommisionSlab(ctx, q, service, nil, packageID, operatorCode, amount)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if rule != nil {
+			return rule.split(amount), nil
+		}
+	}
+
+	rule, err := findCommisionSlab(ctx, q, service, nil, nil, operatorCode, amount)
+	if err != nil || rule == nil {
+		return nil, err
+	}
+	return rule.split(amount), nil
+}
+
+// findCommisionSlab returns the slab of a user or a package (or the default
+// slab when both are nil) that covers amount and took effect last,
+// preferring a slab for the operator over one for all operators.
+func findCommisionSlab(
+	ctx context.Context,
+	q querier,
+	service string,
+	userID *string,
+	packageID *int64,
+	operatorCode int,
+	amount models.Money,
+) (*commisionRule, error) {
+	query := `
+		SELECT
+			commision_type,
+			flat_commision,
+			percent_commision,
+			min_commision,
+			max_commision,
+			admin_commision,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision
+		FROM commision_slabs
+		WHERE service = @service
+		AND user_id IS NOT DISTINCT FROM @user_id
+		AND package_id IS NOT DISTINCT FROM @package_id
+		AND (operator_code IS NULL OR operator_code = @operator_code)
+		AND min_amount <= @amount
+		AND (max_amount IS NULL OR max_amount >= @amount)
+		AND effective_from <= NOW()
+		ORDER BY operator_code IS NULL, effective_from DESC, slab_id DESC
+		LIMIT 1;
+	`
+	var r commisionRule
+	err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"service":       service,
+		"user_id":       userID,
+		"package_id":    packageID,
+		"operator_code": operatorCode,
+		"amount":        amount,
+	}).Scan(
+		&r.commisionType,
+		&r.flat,
+		&r.percent,
+		&r.min,
+		&r.max,
+		&r.admin,
+		&r.md,
+		&r.dis,
+		&r.retailer,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// findUserCommision returns a user's percentage from commisions.
+func findUserCommision(ctx context.Context, q querier, service, userID string) (*commisionRule, error) {
+	query := `
+		SELECT
+			total_commision,
+			admin_commision,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision
+		FROM commisions
+		WHERE user_id = @user_id
+		AND service = @service;
+	`
+	r := commisionRule{commisionType: "PERCENTAGE"}
+	err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+		"service": service,
+	}).Scan(
+		&r.percent,
+		&r.admin,
+		&r.md,
+		&r.dis,
+		&r.retailer,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// fundCommision completes the settlement journal of a recharge or bill
+// payment, whose commission the commission pool funds out of the
+// provider's margin, as on every other service. The retailer got its share
+// as a discount, so only the TDS on it is withheld; the distributor and
+// master distributor are credited theirs net of TDS. The pool takes
+// whatever the reservation and the journal leave unbalanced. The admin's
+// own share is not moved.
+func fundCommision(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	r *reservation,
+	retailerID string,
+	split *models.CommisionSplitModel,
+) error {
+	h, err := getRetailerHierarchy(ctx, tx, retailerID)
+	if err != nil {
+		return err
+	}
+	remarks := fmt.Sprintf("Commission for Retailer: %s", retailerID)
+	if err := withholdCommisionTDS(ctx, tx, j, r.Service, r.ReferenceID, retailerID, split.RetailerCommision); err != nil {
+		return err
+	}
+	if err := creditCommision(ctx, tx, j, r.Service, r.ReferenceID, h.distributorID, split.DistributorCommision, remarks); err != nil {
+		return err
+	}
+	if err := creditCommision(ctx, tx, j, r.Service, r.ReferenceID, h.masterDistributorID, split.MasterDistributorCommision, remarks); err != nil {
+		return err
+	}
+	debits, credits := j.Totals()
+	commisionPoolEntry(j, r.Amount+debits-credits)
+	return nil
+}
+
+func (db *Database) CreateCommisionSlabQuery(
+	ctx context.Context,
+	req models.CreateCommisionSlabRequestModel,
+) (int64, error) {
+	query := `
+		INSERT INTO commision_slabs (
+			user_id,
+			package_id,
+			service,
+			operator_code,
+			min_amount,
+			max_amount,
+			commision_type,
+			flat_commision,
+			percent_commision,
+			min_commision,
+			max_commision,
+			admin_commision,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision,
+			effective_from
+		) VALUES (
+			@user_id,
+			@package_id,
+			@service,
+			@operator_code,
+			@min_amount,
+			@max_amount,
+			@commision_type,
+			@flat_commision,
+			@percent_commision,
+			@min_commision,
+			@max_commision,
+			@admin_commision,
+			@md_commision,
+			@distributor_commision,
+			@retailer_commision,
+			COALESCE(@effective_from, NOW())
+		)
+		RETURNING slab_id;
+	`
+	var slabID int64
+	if err := db.pool.QueryRow(ctx, query, commisionSlabArgs(req)).Scan(&slabID); err != nil {
+		log.Println(err)
+		return 0, fmt.Errorf("failed to create commision slab")
+	}
+	return slabID, nil
+}
+
+func (db *Database) UpdateCommisionSlabQuery(
+	ctx context.Context,
+	req models.UpdateCommisionSlabRequestModel,
+) error {
+	query := `
+		UPDATE commision_slabs
+		SET user_id = @user_id,
+			package_id = @package_id,
+			service = @service,
+			operator_code = @operator_code,
+			min_amount = @min_amount,
+			max_amount = @max_amount,
+			commision_type = @commision_type,
+			flat_commision = @flat_commision,
+			percent_commision = @percent_commision,
+			min_commision = @min_commision,
+			max_commision = @max_commision,
+			admin_commision = @admin_commision,
+			master_distributor_commision = @md_commision,
+			distributor_commision = @distributor_commision,
+			retailer_commision = @retailer_commision,
+			effective_from = COALESCE(@effective_from, effective_from),
+			updated_at = NOW()
+		WHERE slab_id = @slab_id;
+	`
+	args := commisionSlabArgs(req.CreateCommisionSlabRequestModel)
+	args["slab_id"] = req.SlabID
+	tag, err := db.pool.Exec(ctx, query, args)
+	if err != nil {
+		log.Println(err)
+		return fmt.Errorf("failed to update commision slab")
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("invalid slab id or slab not found")
+	}
+	return nil
+}
+
+func commisionSlabArgs(req models.CreateCommisionSlabRequestModel) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"user_id":               req.UserID,
+		"package_id":            req.PackageID,
+		"service":               req.Service,
+		"operator_code":         req.OperatorCode,
+		"min_amount":            req.MinAmount,
+		"max_amount":            req.MaxAmount,
+		"commision_type":        req.CommisionType,
+		"flat_commision":        req.FlatCommision,
+		"percent_commision":     req.PercentCommision,
+		"min_commision":         req.MinCommision,
+		"max_commision":         req.MaxCommision,
+		"admin_commision":       req.AdminCommision,
+		"md_commision":          req.MasterDistributorCommision,
+		"distributor_commision": req.DistributorCommision,
+		"retailer_commision":    req.RetailerCommision,
+		"effective_from":        req.EffectiveFrom,
+	}
+}
+
+func (db *Database) DeleteCommisionSlabQuery(ctx context.Context, slabID int64) error {
+	tag, err := db.pool.Exec(ctx, `
+		DELETE FROM commision_slabs
+		WHERE slab_id = @slab_id;
+	`, pgx.NamedArgs{"slab_id": slabID})
+	if err != nil {
+		return fmt.Errorf("failed to delete commision slab")
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("invalid slab id or slab not found")
+	}
+	return nil
+}
+
+const commisionSlabColumns = `
+			slab_id,
+			user_id,
+			package_id,
+			service,
+			operator_code,
+			min_amount,
+			max_amount,
+			commision_type,
+			flat_commision,
+			percent_commision,
+			min_commision,
+			max_commision,
+			admin_commision,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision,
+			effective_from,
+			created_at,
+			updated_at`
+
+func scanCommisionSlab(row pgx.Row) (*models.GetCommisionSlabResponseModel, error) {
+	var s models.GetCommisionSlabResponseModel
+	if err := row.Scan(
+		&s.SlabID,
+		&s.UserID,
+		&s.PackageID,
+		&s.Service,
+		&s.OperatorCode,
+		&s.MinAmount,
+		&s.MaxAmount,
+		&s.CommisionType,
+		&s.FlatCommision,
+		&s.PercentCommision,
+		&s.MinCommision,
+		&s.MaxCommision,
+		&s.AdminCommision,
+		&s.MasterDistributorCommision,
+		&s.DistributorCommision,
+		&s.RetailerCommision,
+		&s.EffectiveFrom,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *Database) GetCommisionSlabByIDQuery(
+	ctx context.Context,
+	slabID int64,
+) (*models.GetCommisionSlabResponseModel, error) {
+	query := `SELECT` + commisionSlabColumns + `
+		FROM commision_slabs
+		WHERE slab_id = @slab_id;
+	`
+	s, err := scanCommisionSlab(db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"slab_id": slabID,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch commision slab")
+	}
+	return s, nil
+}
+
+// GetCommisionSlabsQuery lists slabs, optionally of one service and of one
+// user or package. The user "default" selects the slabs without a user or
+// package.
+func (db *Database) GetCommisionSlabsQuery(
+	ctx context.Context,
+	service string,
+	userID string,
+	packageID int64,
+	limit, offset int,
+) ([]models.GetCommisionSlabResponseModel, error) {
+	query := `SELECT` + commisionSlabColumns + `
+		FROM commision_slabs
+		WHERE (@service = '' OR service = @service)
+		AND (
+			@user_id = ''
+			OR (@user_id = 'default' AND user_id IS NULL AND package_id IS NULL)
+			OR user_id = @user_id
+		)
+		AND (@package_id = 0 OR package_id = @package_id)
+		ORDER BY service, user_id NULLS FIRST, operator_code NULLS FIRST, min_amount, effective_from DESC
+		LIMIT NULLIF(@limit, 0) OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"service":    service,
+		"user_id":    userID,
+		"package_id": packageID,
+		"limit":      limit,
+		"offset":     offset,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, fmt.Errorf("failed to fetch commision slabs")
+	}
+	defer rows.Close()
+
+	var slabs []models.GetCommisionSlabResponseModel
+	for rows.Next() {
+		s, err := scanCommisionSlab(rows)
+		if err != nil {
+			log.Println(err)
+			return nil, fmt.Errorf("failed to fetch commision slabs")
+		}
+		slabs = append(slabs, *s)
+	}
+	return slabs, rows.Err()
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func rate(t *testing.T, s string) models.Rate {
+	t.Helper()
+	r, err := models.ParseRate(s)
+	if err != nil {
+		t.Fatalf("ParseRate(%q): %v", s, err)
+	}
+	return r
+}
+
+// commisionSplit resolves the commission on a transaction, zero when none
+// is configured.
+func commisionSplit(
+	ctx context.Context,
+	db *Database,
+	service, retailerID string,
+	operatorCode int,
+	amount models.Money,
+) (*models.CommisionSplitModel, error) {
+	split, err := resolveCommision(ctx, db.pool, service, retailerID, operatorCode, amount)
+	if err != nil || split != nil {
+		return split, err
+	}
+	return &models.CommisionSplitModel{}, nil
+}
+
+func TestCommisionRuleSplit(t *testing.T) {
+	maxCommision := models.Rupees(25)
+	payout := commisionRule{
+		commisionType: "PERCENTAGE",
+		percent:       rate(t, "1.2"),
+		admin:         rate(t, "0.25"),
+		md:            rate(t, "0.05"),
+		dis:           rate(t, "0.2"),
+		retailer:      rate(t, "0.5"),
+	}
+	clamped := payout
+	clamped.percent = rate(t, "1")
+	clamped.min = models.Rupees(5)
+	clamped.max = &maxCommision
+	flat := payout
+	flat.commisionType = "FLAT"
+	flat.flat = models.Rupees(7)
+
+	tests := []struct {
+		name   string
+		rule   commisionRule
+		amount models.Money
+		want   models.CommisionSplitModel
+	}{
+		{"percentage", payout, models.Rupees(1000), models.CommisionSplitModel{
+			TotalCommision:             models.Rupees(12),
+			RetailerCommision:          models.Rupees(6),
+			DistributorCommision:       240 * models.Paisa,
+			MasterDistributorCommision: 60 * models.Paisa,
+			AdminCommision:             models.Rupees(3),
+		}},
+		{"minimum", clamped, models.Rupees(10), models.CommisionSplitModel{
+			TotalCommision:             models.Rupees(5),
+			RetailerCommision:          250 * models.Paisa,
+			DistributorCommision:       models.Rupees(1),
+			MasterDistributorCommision: 25 * models.Paisa,
+			AdminCommision:             125 * models.Paisa,
+		}},
+		{"maximum", clamped, models.Rupees(100000), models.CommisionSplitModel{
+			TotalCommision:             models.Rupees(25),
+			RetailerCommision:          1250 * models.Paisa,
+			DistributorCommision:       models.Rupees(5),
+			MasterDistributorCommision: 125 * models.Paisa,
+			AdminCommision:             625 * models.Paisa,
+		}},
+		{"flat", flat, models.Rupees(100000), models.CommisionSplitModel{
+			TotalCommision:             models.Rupees(7),
+			RetailerCommision:          350 * models.Paisa,
+			DistributorCommision:       140 * models.Paisa,
+			MasterDistributorCommision: 35 * models.Paisa,
+			AdminCommision:             175 * models.Paisa,
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.split(tt.amount); *got != tt.want {
+			t.Errorf("%s: split(%s) = %+v, want %+v", tt.name, tt.amount, *got, tt.want)
+		}
+	}
+}
+
+func TestResolveCommisionPrefersTheRetailersOwnSlab(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	flatSlab := func(userID string, maxAmount models.Money, commision models.Money, effectiveFrom time.Time) {
+		t.Helper()
+		if _, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+			UserID:               &userID,
+			Service:              "PAYOUT",
+			MaxAmount:            &maxAmount,
+			CommisionType:        "FLAT",
+			FlatCommision:        commision,
+			AdminCommision:       rate(t, "0.5"),
+			DistributorCommision: rate(t, "0.5"),
+			EffectiveFrom:        &effectiveFrom,
+		}); err != nil {
+			t.Fatalf("CreateCommisionSlabQuery: %v", err)
+		}
+	}
+	total := func(amount models.Money) models.Money {
+		t.Helper()
+		split, err := commisionSplit(ctx, db, "PAYOUT", h.RetailerID, 0, amount)
+		if err != nil {
+			t.Fatalf("resolveCommision: %v", err)
+		}
+		return split.TotalCommision
+	}
+
+	// Only the default payout slab, 1.2%.
+	if got := total(models.Rupees(1000)); got != models.Rupees(12) {
+		t.Errorf("default commission = %s, want 12.00", got)
+	}
+
+	// A distributor slab applies within its range; above it the default
+	// does.
+	now := time.Now()
+	flatSlab(h.DistributorID, models.Rupees(5000), models.Rupees(10), now.Add(-time.Hour))
+	if got := total(models.Rupees(1000)); got != models.Rupees(10) {
+		t.Errorf("distributor slab commission = %s, want 10.00", got)
+	}
+	if got := total(models.Rupees(6000)); got != models.Rupees(72) {
+		t.Errorf("commission above the distributor slab = %s, want 72.00", got)
+	}
+
+	// The retailer's own slab wins, the latest one in effect.
+	flatSlab(h.RetailerID, models.Rupees(5000), models.Rupees(8), now.Add(-time.Hour))
+	flatSlab(h.RetailerID, models.Rupees(5000), models.Rupees(9), now.Add(-time.Minute))
+	flatSlab(h.RetailerID, models.Rupees(5000), models.Rupees(99), now.Add(24*time.Hour))
+	if got := total(models.Rupees(1000)); got != models.Rupees(9) {
+		t.Errorf("retailer slab commission = %s, want 9.00", got)
+	}
+
+	split, err := commisionSplit(ctx, db, "DMT", h.RetailerID, 0, models.Rupees(1000))
+	if err != nil {
+		t.Fatalf("resolveCommision: %v", err)
+	}
+	if *split != (models.CommisionSplitModel{}) {
+		t.Errorf("unconfigured commission = %+v, want zero", *split)
+	}
+}
+
+func TestRechargeCommisionPrefersTheOperatorsSlab(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	retailerCommision := func(operatorCode int, amount models.Money) models.Money {
+		t.Helper()
+		split, err := commisionSplit(ctx, db, "MOBILE_RECHARGE", h.RetailerID, operatorCode, amount)
+		if err != nil {
+			t.Fatalf("resolveCommision: %v", err)
+		}
+		return split.RetailerCommision
+	}
+
+	// The default recharge slab: ₹1 above ₹99.
+	if got := retailerCommision(1, models.Rupees(99)); got != 0 {
+		t.Errorf("commission on 99 = %s, want 0.00", got)
+	}
+	if got := retailerCommision(1, models.Rupees(100)); got != models.Rupees(1) {
+		t.Errorf("commission on 100 = %s, want 1.00", got)
+	}
+
+	operatorCode := 1
+	if _, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+		Service:              "MOBILE_RECHARGE",
+		OperatorCode:         &operatorCode,
+		CommisionType:        "PERCENTAGE",
+		PercentCommision:     rate(t, "4"),
+		AdminCommision:       rate(t, "0.25"),
+		DistributorCommision: rate(t, "0.25"),
+		RetailerCommision:    rate(t, "0.5"),
+	}); err != nil {
+		t.Fatalf("CreateCommisionSlabQuery: %v", err)
+	}
+	split, err := commisionSplit(ctx, db, "MOBILE_RECHARGE", h.RetailerID, 1, models.Rupees(100))
+	if err != nil {
+		t.Fatalf("resolveCommision: %v", err)
+	}
+	want := models.CommisionSplitModel{
+		TotalCommision:       models.Rupees(4),
+		RetailerCommision:    models.Rupees(2),
+		DistributorCommision: models.Rupees(1),
+		AdminCommision:       models.Rupees(1),
+	}
+	if *split != want {
+		t.Errorf("operator 1 commission = %+v, want %+v", *split, want)
+	}
+	if got := retailerCommision(2, models.Rupees(100)); got != models.Rupees(1) {
+		t.Errorf("operator 2 commission = %s, want 1.00", got)
+	}
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// A row of commisions gives a user a total commission rate, split into
+// shares of it for each level of the hierarchy. The shares must add up to
+// exactly one, and the part of the rate a row passes to its user's level
+// and below (its margin) cannot exceed the margin of the row of the user's
+// parent for the same service: a distributor cannot hand its retailers
+// more than it gets itself.
+
+// commisionParents pairs every user with its parent in the hierarchy.
+const commisionParents = `
+	WITH parents (user_id, parent_id) AS (
+		SELECT retailer_id, distributor_id FROM retailers
+		UNION ALL
+		SELECT distributor_id, master_distributor_id FROM distributors
+		UNION ALL
+		SELECT master_distributor_id, admin_id FROM master_distributors
+	)`
+
+const commisionRowColumns = `
+	c.commision_id,
+	c.user_id,
+	c.service,
+	c.total_commision,
+	c.admin_commision,
+	c.master_distributor_commision,
+	c.distributor_commision,
+	c.retailer_commision,
+	c.created_at,
+	c.updated_at`
+
+func scanCommisionRow(row pgx.Row, c *models.GetCommisionResponseModel) error {
+	return row.Scan(
+		&c.CommisionID,
+		&c.UserID,
+		&c.Service,
+		&c.TotalCommision,
+		&c.AdminCommision,
+		&c.MasterDistributorCommision,
+		&c.DistributorCommision,
+		&c.RetailerCommision,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+// commisionMargin returns the rate a row passes to its user's level and
+// below, scaled by RateOne so that it is exact.
+func commisionMargin(c *models.GetCommisionResponseModel) (int64, error) {
+	table, err := ledger.WalletTable(c.UserID)
+	if err != nil {
+		return 0, err
+	}
+	share := c.RetailerCommision
+	switch table {
+	case "admin":
+		share = models.RateOne
+	case "master_distributor":
+		share += c.MasterDistributorCommision + c.DistributorCommision
+	case "distributor":
+		share += c.DistributorCommision
+	}
+	return int64(c.TotalCommision) * int64(share), nil
+}
+
+func formatMargin(margin int64) string {
+	return models.Rate(margin/int64(models.RateOne)).String() + "%"
+}
+
+// commisionSplitProblems lists the rules a row breaks, measured against
+// its parent's row when there is one.
+func commisionSplitProblems(c, parent *models.GetCommisionResponseModel) []string {
+	var problems []string
+	if c.AdminCommision < 0 || c.MasterDistributorCommision < 0 || c.DistributorCommision < 0 || c.RetailerCommision < 0 {
+		problems = append(problems, "commision shares cannot be negative")
+	}
+	shares := c.AdminCommision + c.MasterDistributorCommision + c.DistributorCommision + c.RetailerCommision
+	if shares != models.RateOne {
+		problems = append(problems, fmt.Sprintf("commision shares add up to %s instead of 1", shares))
+	}
+	margin, err := commisionMargin(c)
+	if err != nil {
+		return append(problems, fmt.Sprintf("user %s: %s", c.UserID, err))
+	}
+	if parent == nil {
+		return problems
+	}
+	parentMargin, err := commisionMargin(parent)
+	if err != nil {
+		return append(problems, fmt.Sprintf("parent %s: %s", parent.UserID, err))
+	}
+	if margin > parentMargin {
+		problems = append(problems, fmt.Sprintf(
+			"margin of %s exceeds the margin of %s of parent %s",
+			formatMargin(margin), formatMargin(parentMargin), parent.UserID,
+		))
+	}
+	return problems
+}
+
+// checkCommisionSplit validates a row that is about to be stored against
+// the row of its user's parent and the rows of its user's children.
+func checkCommisionSplit(ctx context.Context, q querier, c *models.GetCommisionResponseModel) error {
+	args := pgx.NamedArgs{
+		"user_id": c.UserID,
+		"service": c.Service,
+	}
+
+	parentQuery := commisionParents + `
+		SELECT ` + commisionRowColumns + `
+		FROM parents p
+		JOIN commisions c ON c.user_id = p.parent_id AND c.service = @service
+		WHERE p.user_id = @user_id;
+	`
+	var parent *models.GetCommisionResponseModel
+	var row models.GetCommisionResponseModel
+	err := scanCommisionRow(q.QueryRow(ctx, parentQuery, args), &row)
+	switch {
+	case err == nil:
+		parent = &row
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	if problems := commisionSplitProblems(c, parent); len(problems) > 0 {
+		return errors.New(problems[0])
+	}
+
+	childrenQuery := commisionParents + `
+		SELECT ` + commisionRowColumns + `
+		FROM parents p
+		JOIN commisions c ON c.user_id = p.user_id AND c.service = @service
+		WHERE p.parent_id = @user_id;
+	`
+	rows, err := q.Query(ctx, childrenQuery, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	margin, err := commisionMargin(c)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var child models.GetCommisionResponseModel
+		if err := scanCommisionRow(rows, &child); err != nil {
+			return err
+		}
+		childMargin, err := commisionMargin(&child)
+		if err != nil {
+			return err
+		}
+		if childMargin > margin {
+			return fmt.Errorf(
+				"margin of %s is below the margin of %s of child %s",
+				formatMargin(margin), formatMargin(childMargin), child.UserID,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// GetCommisionAuditQuery lists the rows of commisions that break the split
+// rules, such as rows stored before they were enforced.
+func (db *Database) GetCommisionAuditQuery(
+	ctx context.Context,
+) ([]models.CommisionAuditModel, error) {
+	query := commisionParents + `
+		SELECT ` + commisionRowColumns + `,
+			p.commision_id,
+			p.user_id,
+			p.service,
+			p.total_commision,
+			p.admin_commision,
+			p.master_distributor_commision,
+			p.distributor_commision,
+			p.retailer_commision,
+			p.created_at,
+			p.updated_at
+		FROM commisions c
+		LEFT JOIN parents pa ON pa.user_id = c.user_id
+		LEFT JOIN commisions p ON p.user_id = pa.parent_id AND p.service = c.service
+		ORDER BY c.commision_id;
+	`
+	rows, err := db.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audit := []models.CommisionAuditModel{}
+	for rows.Next() {
+		var (
+			c models.GetCommisionResponseModel
+			p struct {
+				commisionID                             *int64
+				userID, service                         *string
+				total, admin, md, distributor, retailer *models.Rate
+				createdAt, updatedAt                    *time.Time
+			}
+		)
+		if err := rows.Scan(
+			&c.CommisionID,
+			&c.UserID,
+			&c.Service,
+			&c.TotalCommision,
+			&c.AdminCommision,
+			&c.MasterDistributorCommision,
+			&c.DistributorCommision,
+			&c.RetailerCommision,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&p.commisionID,
+			&p.userID,
+			&p.service,
+			&p.total,
+			&p.admin,
+			&p.md,
+			&p.distributor,
+			&p.retailer,
+			&p.createdAt,
+			&p.updatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		var parent *models.GetCommisionResponseModel
+		if p.commisionID != nil {
+			parent = &models.GetCommisionResponseModel{
+				CommisionID:                *p.commisionID,
+				UserID:                     *p.userID,
+				Service:                    *p.service,
+				TotalCommision:             *p.total,
+				AdminCommision:             *p.admin,
+				MasterDistributorCommision: *p.md,
+				DistributorCommision:       *p.distributor,
+				RetailerCommision:          *p.retailer,
+				CreatedAt:                  *p.createdAt,
+				UpdatedAt:                  *p.updatedAt,
+			}
+		}
+		if problems := commisionSplitProblems(&c, parent); len(problems) > 0 {
+			audit = append(audit, models.CommisionAuditModel{
+				Commision:       c,
+				ParentCommision: parent,
+				Problems:        problems,
+			})
+		}
+	}
+	return audit, rows.Err()
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func commisionRow(t *testing.T, userID, total, admin, md, distributor, retailer string) *models.GetCommisionResponseModel {
+	t.Helper()
+	return &models.GetCommisionResponseModel{
+		UserID:                     userID,
+		Service:                    "PAYOUT",
+		TotalCommision:             rate(t, total),
+		AdminCommision:             rate(t, admin),
+		MasterDistributorCommision: rate(t, md),
+		DistributorCommision:       rate(t, distributor),
+		RetailerCommision:          rate(t, retailer),
+	}
+}
+
+func TestCommisionSplitProblems(t *testing.T) {
+	// The distributor passes 0.4 of 2% down to its level: a margin of 0.8%.
+	distributor := commisionRow(t, "D000001", "2", "0.5", "0.1", "0.2", "0.2")
+
+	tests := []struct {
+		name   string
+		c      *models.GetCommisionResponseModel
+		parent *models.GetCommisionResponseModel
+		want   []string
+	}{
+		{"no parent", distributor, nil, nil},
+		{"equal margin", distributor, commisionRow(t, "M000001", "1", "0.2", "0.3", "0.3", "0.2"), nil},
+		{"above the parent", distributor, commisionRow(t, "M000001", "1", "0.4", "0.2", "0.2", "0.2"), []string{
+			"margin of 0.8% exceeds the margin of 0.6% of parent M000001",
+		}},
+		{"shares", commisionRow(t, "R000001", "1", "0.5", "0.1", "0.1", "0.2"), nil, []string{
+			"commision shares add up to 0.9 instead of 1",
+		}},
+		{"negative share", commisionRow(t, "R000001", "1", "1.1", "0", "0", "-0.1"), nil, []string{
+			"commision shares cannot be negative",
+		}},
+	}
+	for _, tt := range tests {
+		if got := commisionSplitProblems(tt.c, tt.parent); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: problems = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCommisionSplitIsCheckedAgainstParentAndChildren(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+
+	create := func(c *models.GetCommisionResponseModel) error {
+		return db.CreateCommisionQuery(ctx, models.CreateCommisionRequestModel{
+			UserID:                     c.UserID,
+			Service:                    c.Service,
+			TotalCommision:             c.TotalCommision,
+			AdminCommision:             c.AdminCommision,
+			MasterDistributorCommision: c.MasterDistributorCommision,
+			DistributorCommision:       c.DistributorCommision,
+			RetailerCommision:          c.RetailerCommision,
+		})
+	}
+
+	// A margin of 0.6% for the master distributor.
+	if err := create(commisionRow(t, h.MasterDistributorID, "1", "0.4", "0.2", "0.2", "0.2")); err != nil {
+		t.Fatalf("master distributor: %v", err)
+	}
+	if err := create(commisionRow(t, h.DistributorID, "2", "0.5", "0.1", "0.2", "0.2")); err == nil {
+		t.Error("a distributor margin of 0.8% was accepted under 0.6%")
+	}
+	if err := create(commisionRow(t, h.DistributorID, "1", "0.5", "0.1", "0.2", "0.2")); err != nil {
+		t.Fatalf("distributor within the margin: %v", err)
+	}
+
+	// The master distributor cannot cut its margin below its child's 0.4%.
+	md, err := db.GetCommisionByUserIDAndServiceQuery(ctx, h.MasterDistributorID, "PAYOUT")
+	if err != nil {
+		t.Fatalf("GetCommisionByUserIDAndServiceQuery: %v", err)
+	}
+	half := rate(t, "0.5")
+	if err := db.UpdateCommisionQuery(ctx, models.UpdateCommisionRequestModel{
+		CommisionID:    md.CommisionID,
+		TotalCommision: &half,
+	}); err == nil {
+		t.Error("cutting the master distributor's margin to 0.3% succeeded")
+	}
+
+	// Rows stored before the rules are reported by the audit.
+	if _, err := conn.Exec(ctx, `
+		INSERT INTO commisions (
+			user_id, service, total_commision, admin_commision,
+			master_distributor_commision, distributor_commision, retailer_commision
+		) VALUES ($1, 'PAYOUT', 5, 0, 0, 0, 1);
+	`, h.RetailerID); err != nil {
+		t.Fatalf("insert commision: %v", err)
+	}
+	audit, err := db.GetCommisionAuditQuery(ctx)
+	if err != nil {
+		t.Fatalf("GetCommisionAuditQuery: %v", err)
+	}
+	if len(audit) != 1 || audit[0].Commision.UserID != h.RetailerID || audit[0].ParentCommision == nil {
+		t.Fatalf("audit = %+v, want the retailer's row measured against the distributor's", audit)
+	}
+	want := []string{"margin of 5% exceeds the margin of 0.4% of parent " + h.DistributorID}
+	if !reflect.DeepEqual(audit[0].Problems, want) {
+		t.Errorf("audit problems = %q, want %q", audit[0].Problems, want)
+	}
+}
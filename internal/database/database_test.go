@@ -0,0 +1,21 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+)
+
+// newTestDatabase returns a Database on a fresh, migrated schema and a
+// direct connection to the same schema for setting up and checking rows.
+func newTestDatabase(t *testing.T) (*Database, *pgx.Conn) {
+	t.Helper()
+	databaseURL, conn := dbtest.Open(t)
+	db, err := NewDatabaseConnection(Config{DatabaseURL: databaseURL})
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db, conn
+}
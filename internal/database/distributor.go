@@ -273,6 +273,12 @@ func (db *Database) UpdateDistributorDetailsQuery(
 	req models.UpdateDistributorDetailsRequestModel,
 ) error {
 
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE distributors
 		SET
@@ -295,12 +301,11 @@ func (db *Database) UpdateDistributorDetailsQuery(
 			distributor_gst_number = COALESCE(@gst_number, distributor_gst_number),
 
 			distributor_documents_url = COALESCE(@documents_url, distributor_documents_url),
-			distributor_wallet_balance = COALESCE(@distributor_wallet_balance , distributor_wallet_balance),
 			updated_at = NOW()
 		WHERE distributor_id = @distributor_id;
 	`
 
-	tag, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+	tag, err := tx.Exec(ctx, query, pgx.NamedArgs{
 		"distributor_id":    req.DistributorID,
 		"distributor_name":  req.DistributorName,
 		"distributor_email": req.DistributorEmail,
@@ -321,7 +326,6 @@ func (db *Database) UpdateDistributorDetailsQuery(
 		"gst_number":    req.GSTNumber,
 
 		"documents_url": req.DocumentsURL,
-		"distributor_wallet_balance": req.WalletBalance,
 	})
 
 	if err != nil {
@@ -330,7 +334,13 @@ func (db *Database) UpdateDistributorDetailsQuery(
 	if tag.RowsAffected() == 0 {
 		return fmt.Errorf("invalid distributor id or distributor not found")
 	}
-	return nil
+	if req.WalletBalance != nil {
+		if err := setWalletBalance(ctx, tx, req.DistributorID, *req.WalletBalance); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (db *Database) GetDistributorsByAdminIDQuery(
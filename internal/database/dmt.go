@@ -2,8 +2,13 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 func (db *Database) GetRetailerAadharNumberForDMTQuery(
@@ -25,3 +30,527 @@ func (db *Database) GetRetailerAadharNumberForDMTQuery(
 	}
 	return aadharNumber, nil
 }
+
+// ReserveDMTTransactionQuery checks a money transfer against the remitter's
+// limits, records it to the beneficiary as the provider verified it and
+// reserves the amount plus the DMT charge net of the retailer's own
+// commission, and the TDS on that commission, from the retailer wallet. It
+// returns the DMT transaction id.
+func (db *Database) ReserveDMTTransactionQuery(
+	ctx context.Context,
+	req models.DMTTransactionRequestModel,
+) (string, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
+	}
+	if err := checkDMTRemitterLimits(ctx, tx, req.MobileNumber, req.RetailerID, req.Amount); err != nil {
+		return "", err
+	}
+	beneficiary, err := getDMTBeneficiary(ctx, tx, req.MobileNumber, req.BeneficiaryID)
+	if err != nil {
+		return "", err
+	}
+	quote, err := quoteTransaction(ctx, tx, "DMT", req.RetailerID, 0, req.Amount)
+	if err != nil {
+		return "", err
+	}
+	commision := quote.Commision
+
+	query := `
+		INSERT INTO dmt_transactions (
+			partner_request_id,
+			operator_transaction_id,
+			order_id,
+			retailer_id,
+			mobile_number,
+			beneficiary_id,
+			beneficiary_name,
+			bank_name,
+			account_number,
+			ifsc_code,
+			amount,
+			transfer_type,
+			admin_commision,
+			master_distributor_commision,
+			distributor_commision,
+			retailer_commision,
+			transaction_status
+		) VALUES (
+			@partner_request_id,
+			'',
+			'',
+			@retailer_id,
+			@mobile_number,
+			@beneficiary_id,
+			@beneficiary_name,
+			@bank_name,
+			@account_number,
+			@ifsc_code,
+			@amount,
+			@transfer_type,
+			@admin_commision,
+			@md_commision,
+			@dis_commision,
+			@retailer_commision,
+			@status
+		)
+		RETURNING dmt_transaction_id::TEXT;
+	`
+	var transactionID string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id": req.PartnerRequestID,
+		"retailer_id":        req.RetailerID,
+		"mobile_number":      req.MobileNumber,
+		"beneficiary_id":     req.BeneficiaryID,
+		"beneficiary_name":   beneficiary.BeneficiaryName,
+		"bank_name":          beneficiary.BankName,
+		"account_number":     beneficiary.AccountNumber,
+		"ifsc_code":          beneficiary.IFSCCode,
+		"amount":             req.Amount,
+		"transfer_type":      req.TransferType,
+		"admin_commision":    commision.AdminCommision,
+		"md_commision":       commision.MasterDistributorCommision,
+		"dis_commision":      commision.DistributorCommision,
+		"retailer_commision": commision.RetailerCommision,
+		"status":             txstate.Initiated,
+	}).Scan(&transactionID); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "DMT", transactionID, txstate.Initiated, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+	if err := accruePendingCommisions(ctx, tx, transactionID, quote); err != nil {
+		return "", err
+	}
+
+	remarks := fmt.Sprintf("Money transfer to %s", req.AccountNumber)
+	if err := reserveWallet(ctx, tx, "DMT", transactionID, req.RetailerID, quote.Debit, remarks); err != nil {
+		return "", err
+	}
+	return transactionID, tx.Commit(ctx)
+}
+
+// SettleDMTTransactionQuery applies the provider's answer to a money
+// transfer. On SUCCESS the reservation pays the provider and the hierarchy
+// commissions, on FAILED it is returned to the retailer and on PENDING only
+// the provider references are stored.
+func (db *Database) SettleDMTTransactionQuery(
+	ctx context.Context,
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := lockDMTTransaction(ctx, tx, transactionID)
+	if err != nil {
+		return err
+	}
+	if status == t.status && status != txstate.Pending {
+		return nil
+	}
+	if status != t.status {
+		if err := txstate.Check(t.status, status, actor); err != nil {
+			return err
+		}
+	}
+
+	switch status {
+	case "SUCCESS":
+		r, err := lockReservation(ctx, tx, "DMT", transactionID)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			return fmt.Errorf("no reservation found for dmt transaction %s", transactionID)
+		}
+		remarks := fmt.Sprintf("DMT commission credited from %s", t.retailerID)
+		journal := ledger.NewJournal(transactionID, "DMT", remarks).
+			Credit(ledger.ProviderFloat, t.amount, "Money transfer sent to provider")
+		for _, c := range []struct {
+			userID    string
+			commision models.Money
+		}{
+			{t.adminID, t.adminCommision},
+			{t.mdID, t.mdCommision},
+			{t.disID, t.disCommision},
+		} {
+			if err := creditCommision(ctx, tx, journal, "DMT", transactionID, c.userID, c.commision, remarks); err != nil {
+				return err
+			}
+		}
+		if err := withholdCommisionTDS(ctx, tx, journal, "DMT", transactionID, t.retailerID, t.retailerCommision); err != nil {
+			return err
+		}
+		_, credits := journal.Totals()
+		commisionPoolEntry(journal, r.Amount-credits)
+
+		if err := settleReservation(ctx, tx, r, journal); err != nil {
+			return err
+		}
+	case "FAILED":
+		if err := refundFailed(ctx, tx, &serviceTransaction{"DMT", transactionID, t.retailerID, t.amount, t.status}); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid dmt transaction status")
+	}
+
+	query := `
+		UPDATE dmt_transactions
+		SET order_id = COALESCE(NULLIF(@order_id, ''), order_id),
+			operator_transaction_id = COALESCE(NULLIF(@operator_transaction_id, ''), operator_transaction_id),
+			updated_at = NOW()
+		WHERE dmt_transaction_id = @transaction_id;
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"transaction_id":          transactionID,
+		"order_id":                orderID,
+		"operator_transaction_id": operatorTransactionID,
+	}); err != nil {
+		return err
+	}
+
+	if status != t.status {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       "DMT",
+			TransactionID: transactionID,
+			From:          t.status,
+			To:            status,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+type lockedDMTTransaction struct {
+	retailerID        string
+	amount            models.Money
+	adminCommision    models.Money
+	mdCommision       models.Money
+	disCommision      models.Money
+	retailerCommision models.Money
+	status            string
+	adminID           string
+	mdID              string
+	disID             string
+}
+
+// lockDMTTransaction locks a DMT transaction row and loads the retailer's
+// hierarchy, which is credited the DMT commissions.
+func lockDMTTransaction(ctx context.Context, tx pgx.Tx, transactionID string) (*lockedDMTTransaction, error) {
+	query := `
+		SELECT
+			t.retailer_id,
+			t.amount,
+			t.admin_commision,
+			t.master_distributor_commision,
+			t.distributor_commision,
+			t.retailer_commision,
+			t.transaction_status,
+			a.admin_id,
+			m.master_distributor_id,
+			d.distributor_id
+		FROM dmt_transactions t
+		JOIN retailers r
+			ON r.retailer_id = t.retailer_id
+		JOIN distributors d
+			ON d.distributor_id = r.distributor_id
+		JOIN master_distributors m
+			ON m.master_distributor_id = d.master_distributor_id
+		JOIN admins a
+			ON a.admin_id = m.admin_id
+		WHERE t.dmt_transaction_id = @transaction_id::UUID
+		FOR UPDATE OF t;
+	`
+	var t lockedDMTTransaction
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(
+		&t.retailerID,
+		&t.amount,
+		&t.adminCommision,
+		&t.mdCommision,
+		&t.disCommision,
+		&t.retailerCommision,
+		&t.status,
+		&t.adminID,
+		&t.mdID,
+		&t.disID,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid dmt transaction id")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (db *Database) GetAllDMTTransactionsQuery(
+	ctx context.Context,
+	limit, offset int,
+) ([]models.GetAllDMTTransactionsResponseModel, error) {
+	query := `
+		SELECT
+			t.dmt_transaction_id,
+			t.operator_transaction_id,
+			t.partner_request_id,
+			t.order_id,
+			t.retailer_id,
+			r.retailer_name,
+			r.retailer_business_name,
+			t.mobile_number,
+			t.beneficiary_id,
+			t.beneficiary_name,
+			t.bank_name,
+			t.account_number,
+			t.ifsc_code,
+			t.amount,
+			t.transfer_type,
+			t.admin_commision,
+			t.master_distributor_commision,
+			t.distributor_commision,
+			t.retailer_commision,
+			t.provider,
+			w.before_balance,
+			w.after_balance,
+			t.transaction_status,
+			t.created_at,
+			t.updated_at
+		FROM dmt_transactions t
+		JOIN retailers r
+			ON r.retailer_id = t.retailer_id
+		LEFT JOIN wallet_transactions w
+			ON w.user_id = t.retailer_id
+			AND w.reference_id = t.dmt_transaction_id::TEXT
+			AND w.transaction_reason = 'DMT'
+		ORDER BY t.created_at DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.GetAllDMTTransactionsResponseModel{}
+	for rows.Next() {
+		var t models.GetAllDMTTransactionsResponseModel
+		if err := rows.Scan(
+			&t.DMTTransactionID,
+			&t.OperatorTransactionID,
+			&t.PartnerRequestID,
+			&t.OrderID,
+			&t.RetailerID,
+			&t.RetailerName,
+			&t.RetailerBusinessName,
+			&t.MobileNumber,
+			&t.BeneficiaryID,
+			&t.BeneficiaryName,
+			&t.BankName,
+			&t.AccountNumber,
+			&t.IFSCCode,
+			&t.Amount,
+			&t.TransferType,
+			&t.AdminCommision,
+			&t.MasterDistributorCommision,
+			&t.DistributorCommision,
+			&t.RetailerCommision,
+			&t.Provider,
+			&t.BeforeBalance,
+			&t.AfterBalance,
+			&t.TransactionStatus,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+func (db *Database) GetDMTTransactionsByRetailerIDQuery(
+	ctx context.Context,
+	retailerID string,
+	limit, offset int,
+) ([]models.GetRetailerDMTTransactionsResponseModel, error) {
+	query := `
+		SELECT
+			t.dmt_transaction_id,
+			t.operator_transaction_id,
+			t.partner_request_id,
+			t.order_id,
+			t.retailer_id,
+			t.mobile_number,
+			t.beneficiary_id,
+			t.beneficiary_name,
+			t.bank_name,
+			t.account_number,
+			t.ifsc_code,
+			t.amount,
+			t.transfer_type,
+			t.retailer_commision,
+			w.before_balance,
+			w.after_balance,
+			t.transaction_status,
+			t.created_at,
+			t.updated_at
+		FROM dmt_transactions t
+		LEFT JOIN wallet_transactions w
+			ON w.user_id = t.retailer_id
+			AND w.reference_id = t.dmt_transaction_id::TEXT
+			AND w.transaction_reason = 'DMT'
+		WHERE t.retailer_id = @retailer_id
+		ORDER BY t.created_at DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"retailer_id": retailerID,
+		"limit":       limit,
+		"offset":      offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.GetRetailerDMTTransactionsResponseModel{}
+	for rows.Next() {
+		var t models.GetRetailerDMTTransactionsResponseModel
+		if err := rows.Scan(
+			&t.DMTTransactionID,
+			&t.OperatorTransactionID,
+			&t.PartnerRequestID,
+			&t.OrderID,
+			&t.RetailerID,
+			&t.MobileNumber,
+			&t.BeneficiaryID,
+			&t.BeneficiaryName,
+			&t.BankName,
+			&t.AccountNumber,
+			&t.IFSCCode,
+			&t.Amount,
+			&t.TransferType,
+			&t.RetailerCommision,
+			&t.BeforeBalance,
+			&t.AfterBalance,
+			&t.TransactionStatus,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// RecordDMTBeneficiaryQuery records a beneficiary the provider has verified
+// with the details the bank returned for it, replacing an earlier
+// verification.
+func (db *Database) RecordDMTBeneficiaryQuery(
+	ctx context.Context,
+	b models.DMTBeneficiaryModel,
+) error {
+	query := `
+		INSERT INTO dmt_beneficiaries (
+			mobile_number,
+			beneficiary_id,
+			retailer_id,
+			beneficiary_name,
+			bank_name,
+			account_number,
+			ifsc_code
+		) VALUES (
+			@mobile_number,
+			@beneficiary_id,
+			@retailer_id,
+			@beneficiary_name,
+			@bank_name,
+			@account_number,
+			@ifsc_code
+		)
+		ON CONFLICT (mobile_number, beneficiary_id) DO UPDATE
+		SET retailer_id = EXCLUDED.retailer_id,
+			beneficiary_name = EXCLUDED.beneficiary_name,
+			bank_name = EXCLUDED.bank_name,
+			account_number = EXCLUDED.account_number,
+			ifsc_code = EXCLUDED.ifsc_code,
+			verified_at = NOW();
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"mobile_number":    b.MobileNumber,
+		"beneficiary_id":   b.BeneficiaryID,
+		"retailer_id":      b.RetailerID,
+		"beneficiary_name": b.BeneficiaryName,
+		"bank_name":        b.BankName,
+		"account_number":   b.AccountNumber,
+		"ifsc_code":        b.IFSCCode,
+	})
+	return err
+}
+
+// getDMTBeneficiary returns a remitter's beneficiary as the provider
+// verified it. Transfers to a beneficiary that has not been verified are
+// refused.
+func getDMTBeneficiary(
+	ctx context.Context,
+	tx pgx.Tx,
+	mobileNumber, beneficiaryID string,
+) (*models.DMTBeneficiaryModel, error) {
+	query := `
+		SELECT
+			mobile_number,
+			beneficiary_id,
+			retailer_id,
+			beneficiary_name,
+			bank_name,
+			account_number,
+			ifsc_code,
+			verified_at
+		FROM dmt_beneficiaries
+		WHERE mobile_number = @mobile_number
+		AND beneficiary_id = @beneficiary_id;
+	`
+	var b models.DMTBeneficiaryModel
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"mobile_number":  mobileNumber,
+		"beneficiary_id": beneficiaryID,
+	}).Scan(
+		&b.MobileNumber,
+		&b.BeneficiaryID,
+		&b.RetailerID,
+		&b.BeneficiaryName,
+		&b.BankName,
+		&b.AccountNumber,
+		&b.IFSCCode,
+		&b.VerifiedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, models.Rejectf("beneficiary is not verified")
+		}
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Remitters are registered with the DMT provider, which only knows them by
+// mobile number. The local registry adds their KYC level, which sets how
+// much they may send per transfer and per calendar month (IST), and the
+// retailer that registered them. What a remitter has sent in the month is
+// read from its transfers, less refunds, so failed and refunded transfers
+// give the limit back.
+
+const dmtRemitterQuery = `
+	SELECT
+		d.mobile_number,
+		d.remitter_name,
+		d.kyc_level,
+		d.retailer_id,
+		l.per_transaction_limit,
+		l.monthly_limit,
+		(
+			SELECT COALESCE(SUM(t.amount - COALESCE(rf.refunded, 0)), 0)
+			FROM dmt_transactions t
+			LEFT JOIN LATERAL (
+				SELECT SUM(amount) AS refunded
+				FROM transaction_refunds
+				WHERE service = 'DMT'
+				AND transaction_id = t.dmt_transaction_id::TEXT
+			) rf ON TRUE
+			WHERE t.mobile_number = d.mobile_number
+			AND t.transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS')
+			AND t.created_at >= DATE_TRUNC('month', NOW() AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata'
+		),
+		d.created_at,
+		d.updated_at
+	FROM dmt_remitters d
+	JOIN dmt_remitter_limits l
+		ON l.kyc_level = d.kyc_level
+	WHERE d.mobile_number = @mobile_number`
+
+func scanDMTRemitter(row pgx.Row) (*models.DMTRemitterModel, error) {
+	var r models.DMTRemitterModel
+	if err := row.Scan(
+		&r.MobileNumber,
+		&r.RemitterName,
+		&r.KYCLevel,
+		&r.RetailerID,
+		&r.PerTransactionLimit,
+		&r.MonthlyLimit,
+		&r.MonthlyTransferred,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("remitter not found")
+		}
+		return nil, err
+	}
+	r.MonthlyRemaining = max(r.MonthlyLimit-r.MonthlyTransferred, 0)
+	return &r, nil
+}
+
+// checkDMTRemitterLimits locks the remitter sending a transfer, registering
+// it at the minimum KYC level when it was registered with the provider
+// before the local registry existed, and checks the transfer against its
+// caps. The lock is held until the transfer is recorded, so concurrent
+// transfers of a remitter cannot overrun its monthly limit together.
+func checkDMTRemitterLimits(
+	ctx context.Context,
+	tx pgx.Tx,
+	mobileNumber, retailerID string,
+	amount models.Money,
+) error {
+	insertQuery := `
+		INSERT INTO dmt_remitters (mobile_number, retailer_id)
+		VALUES (@mobile_number, @retailer_id)
+		ON CONFLICT (mobile_number) DO NOTHING;
+	`
+	args := pgx.NamedArgs{
+		"mobile_number": mobileNumber,
+		"retailer_id":   retailerID,
+	}
+	if _, err := tx.Exec(ctx, insertQuery, args); err != nil {
+		return err
+	}
+
+	r, err := scanDMTRemitter(tx.QueryRow(ctx, dmtRemitterQuery+" FOR UPDATE OF d;", args))
+	if err != nil {
+		return err
+	}
+	if amount > r.PerTransactionLimit {
+		return models.Rejectf("amount exceeds the remitter's per transaction limit of %s", r.PerTransactionLimit)
+	}
+	if amount > r.MonthlyRemaining {
+		return models.Rejectf("amount exceeds the remitter's remaining monthly limit of %s", r.MonthlyRemaining)
+	}
+	return nil
+}
+
+// RegisterDMTRemitterQuery records a remitter whose wallet the provider has
+// verified through Aadhaar eKYC, which gives it the full KYC level. A
+// remitter keeps the retailer that registered it first.
+func (db *Database) RegisterDMTRemitterQuery(
+	ctx context.Context,
+	mobileNumber, remitterName, retailerID string,
+) error {
+	query := `
+		INSERT INTO dmt_remitters (
+			mobile_number,
+			remitter_name,
+			kyc_level,
+			retailer_id
+		) VALUES (
+			@mobile_number,
+			@remitter_name,
+			'FULL',
+			@retailer_id
+		)
+		ON CONFLICT (mobile_number) DO UPDATE
+		SET remitter_name = COALESCE(NULLIF(EXCLUDED.remitter_name, ''), dmt_remitters.remitter_name),
+			kyc_level = 'FULL',
+			retailer_id = COALESCE(dmt_remitters.retailer_id, EXCLUDED.retailer_id),
+			updated_at = NOW();
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"mobile_number": mobileNumber,
+		"remitter_name": remitterName,
+		"retailer_id":   retailerID,
+	})
+	return err
+}
+
+// GetDMTRemitterQuery returns the remitter registered for a mobile number.
+// With a retailerID, only a remitter that retailer registered is returned.
+func (db *Database) GetDMTRemitterQuery(
+	ctx context.Context,
+	mobileNumber, retailerID string,
+) (*models.DMTRemitterModel, error) {
+	return scanDMTRemitter(db.pool.QueryRow(ctx, dmtRemitterQuery+`
+		AND (@retailer_id = '' OR d.retailer_id = @retailer_id);`, pgx.NamedArgs{
+		"mobile_number": mobileNumber,
+		"retailer_id":   retailerID,
+	}))
+}
+
+func (db *Database) UpdateDMTRemitterQuery(
+	ctx context.Context,
+	mobileNumber string,
+	req models.UpdateDMTRemitterRequestModel,
+) error {
+	query := `
+		UPDATE dmt_remitters
+		SET remitter_name = COALESCE(@remitter_name, remitter_name),
+			kyc_level = COALESCE(@kyc_level, kyc_level),
+			updated_at = NOW()
+		WHERE mobile_number = @mobile_number;
+	`
+	res, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"mobile_number": mobileNumber,
+		"remitter_name": req.RemitterName,
+		"kyc_level":     req.KYCLevel,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("remitter not found")
+	}
+	return nil
+}
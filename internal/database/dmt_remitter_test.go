@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func TestDMTRemitterLimits(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100000))
+	if err := db.RecordDMTBeneficiaryQuery(ctx, models.DMTBeneficiaryModel{
+		MobileNumber:    "9876543210",
+		BeneficiaryID:   "B1",
+		RetailerID:      h.RetailerID,
+		BeneficiaryName: "RAMESH KUMAR",
+		BankName:        "HDFC Bank",
+		AccountNumber:   "50100000000001",
+		IFSCCode:        "HDFC0000001",
+	}); err != nil {
+		t.Fatalf("RecordDMTBeneficiaryQuery: %v", err)
+	}
+
+	n := 0
+	transfer := func(amount models.Money) (string, error) {
+		n++
+		return db.ReserveDMTTransactionQuery(ctx, dmtRequest(h.RetailerID, fmt.Sprintf("00000000-0000-0000-0000-%012d", n), amount))
+	}
+	remaining := func() models.Money {
+		t.Helper()
+		r, err := db.GetDMTRemitterQuery(ctx, "9876543210", h.RetailerID)
+		if err != nil {
+			t.Fatalf("GetDMTRemitterQuery: %v", err)
+		}
+		return r.MonthlyRemaining
+	}
+
+	// An unknown remitter is registered at the minimum KYC level: 5000 a
+	// transfer, 25000 a month.
+	if _, err := transfer(models.Rupees(5001)); !models.IsRejection(err) {
+		t.Fatalf("transfer above the per transaction limit: err = %v, want a rejection", err)
+	}
+	var ids []string
+	for range 5 {
+		id, err := transfer(models.Rupees(5000))
+		if err != nil {
+			t.Fatalf("ReserveDMTTransactionQuery: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if got := remaining(); got != 0 {
+		t.Errorf("remaining after 25000 = %s, want 0.00", got)
+	}
+	if _, err := transfer(models.Rupees(1)); !models.IsRejection(err) {
+		t.Fatalf("transfer above the monthly limit: err = %v, want a rejection", err)
+	}
+
+	// Failed and refunded transfers give the limit back.
+	if err := db.SettleDMTTransactionQuery(ctx, ids[0], "FAILED", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleDMTTransactionQuery: %v", err)
+	}
+	if err := db.SettleDMTTransactionQuery(ctx, ids[1], "SUCCESS", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleDMTTransactionQuery: %v", err)
+	}
+	if _, err := db.RefundTransactionQuery(ctx, "DMT", ids[1], models.Rupees(2000), txstate.Provider("TEST"), "partial"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+	if got := remaining(); got != models.Rupees(7000) {
+		t.Errorf("remaining after a failure and a refund = %s, want 7000.00", got)
+	}
+
+	// Last month's transfers do not count.
+	if _, err := conn.Exec(ctx, `
+		UPDATE dmt_transactions
+		SET created_at = DATE_TRUNC('month', NOW() AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata' - INTERVAL '1 minute'
+		WHERE dmt_transaction_id = $1;
+	`, ids[2]); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+	if got := remaining(); got != models.Rupees(12000) {
+		t.Errorf("remaining without last month's transfer = %s, want 12000.00", got)
+	}
+
+	// Full KYC raises the caps.
+	if err := db.RegisterDMTRemitterQuery(ctx, "9876543210", "Ramesh Kumar", h.RetailerID); err != nil {
+		t.Fatalf("RegisterDMTRemitterQuery: %v", err)
+	}
+	if _, err := transfer(models.Rupees(25000)); err != nil {
+		t.Errorf("full KYC transfer of 25000: %v", err)
+	}
+
+	// Another retailer cannot look the remitter up.
+	other := dbtest.AddRetailer(t, conn, h.DistributorID)
+	if _, err := db.GetDMTRemitterQuery(ctx, "9876543210", other); err == nil {
+		t.Error("another retailer read the remitter")
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func dmtRequest(retailerID, partnerRequestID string, amount models.Money) models.DMTTransactionRequestModel {
+	return models.DMTTransactionRequestModel{
+		RetailerID:       retailerID,
+		MobileNumber:     "9876543210",
+		TransferType:     "IMPS",
+		Amount:           amount,
+		BeneficiaryID:    "B1",
+		AccountNumber:    "99999999999999",
+		OTP:              "123456",
+		StateResp:        "state",
+		PartnerRequestID: partnerRequestID,
+	}
+}
+
+func TestDMTTransfer(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(5000))
+
+	req := dmtRequest(h.RetailerID, "00000000-0000-0000-0000-000000000001", models.Rupees(1000))
+	if _, err := db.ReserveDMTTransactionQuery(ctx, req); !models.IsRejection(err) {
+		t.Fatalf("transfer to an unverified beneficiary: err = %v, want a rejection", err)
+	}
+
+	if err := db.RecordDMTBeneficiaryQuery(ctx, models.DMTBeneficiaryModel{
+		MobileNumber:    "9876543210",
+		BeneficiaryID:   "B1",
+		RetailerID:      h.RetailerID,
+		BeneficiaryName: "RAMESH KUMAR",
+		BankName:        "HDFC Bank",
+		AccountNumber:   "50100000000001",
+		IFSCCode:        "HDFC0000001",
+	}); err != nil {
+		t.Fatalf("RecordDMTBeneficiaryQuery: %v", err)
+	}
+	// A ₹10 charge on transfers, half of it back to the retailer.
+	if _, err := db.CreateCommisionSlabQuery(ctx, models.CreateCommisionSlabRequestModel{
+		Service:                    "DMT",
+		CommisionType:              "FLAT",
+		FlatCommision:              models.Rupees(10),
+		AdminCommision:             rate(t, "0.2"),
+		MasterDistributorCommision: rate(t, "0.1"),
+		DistributorCommision:       rate(t, "0.2"),
+		RetailerCommision:          rate(t, "0.5"),
+	}); err != nil {
+		t.Fatalf("CreateCommisionSlabQuery: %v", err)
+	}
+
+	succeeded, err := db.ReserveDMTTransactionQuery(ctx, req)
+	if err != nil {
+		t.Fatalf("ReserveDMTTransactionQuery: %v", err)
+	}
+	// 1000 + 10 charge - 5 commission + 0.10 TDS on it.
+	if got, want := dbtest.Balance(t, conn, h.RetailerID), models.Rupees(3995)-10*models.Paisa; got != want {
+		t.Errorf("retailer balance after reserving = %s, want %s", got, want)
+	}
+	var accountNumber string
+	if err := conn.QueryRow(ctx, "SELECT account_number FROM dmt_transactions WHERE dmt_transaction_id = $1", succeeded).Scan(&accountNumber); err != nil {
+		t.Fatalf("dmt_transactions: %v", err)
+	}
+	if accountNumber != "50100000000001" {
+		t.Errorf("transfer sent to %s, want the verified account", accountNumber)
+	}
+
+	failed, err := db.ReserveDMTTransactionQuery(ctx, dmtRequest(h.RetailerID, "00000000-0000-0000-0000-000000000002", models.Rupees(500)))
+	if err != nil {
+		t.Fatalf("ReserveDMTTransactionQuery: %v", err)
+	}
+	if err := db.SettleDMTTransactionQuery(ctx, failed, "FAILED", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleDMTTransactionQuery: %v", err)
+	}
+	if err := db.SettleDMTTransactionQuery(ctx, succeeded, "SUCCESS", "ORDER1", "UTR1", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleDMTTransactionQuery: %v", err)
+	}
+
+	if got, want := dbtest.Balance(t, conn, h.RetailerID), models.Rupees(3995)-10*models.Paisa; got != want {
+		t.Errorf("retailer balance after settling = %s, want %s", got, want)
+	}
+	if got := dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"); got != models.Rupees(1000) {
+		t.Errorf("provider float = %s, want 1000.00", got)
+	}
+	for _, c := range []struct {
+		userID string
+		want   models.Money
+	}{
+		{h.DistributorID, 196 * models.Paisa},
+		{h.MasterDistributorID, 98 * models.Paisa},
+		{h.AdminID, models.Rupees(2)},
+	} {
+		if got := dbtest.Balance(t, conn, c.userID); got != c.want {
+			t.Errorf("%s commission = %s, want %s", c.userID, got, c.want)
+		}
+	}
+
+	if _, err := db.RefundTransactionQuery(ctx, "DMT", succeeded, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(5000) {
+		t.Errorf("retailer balance after the refund = %s, want 5000.00", got)
+	}
+}
@@ -2,10 +2,13 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 func (db *Database) GetAllDTHOperatorsQuery(
@@ -37,171 +40,61 @@ func (db *Database) GetAllDTHOperatorsQuery(
 	return operators, res.Err()
 }
 
-func (db *Database) CreateDTHRechargeSuccessOrPendingQuery(
+// ReserveDTHRechargeQuery records a pending DTH recharge and reserves its
+// cost from the retailer wallet, with the retailer's commission taken off
+// like on mobile recharges.
+func (db *Database) ReserveDTHRechargeQuery(
 	ctx context.Context,
 	req models.CreateDTHRechargeRequestModel,
-) error {
-	if req.Amount <= 99 {
-		return db.dthRechargeWithoutCommision(ctx, req)
-	}
-	return db.dthRechargeWithCommision(ctx, req)
-}
-
-func (db *Database) CreateDTHRechargeFailedQuery(
-	ctx context.Context,
-	req models.CreateDTHRechargeRequestModel,
-) error {
-	insertToDthRechargeTable := `
-		INSERT INTO dth_recharge (
-			retailer_id,
-			partner_request_id,
-			customer_id,
-			operator_name,
-			operator_code,
-			amount,
-			commision,
-			status
-		) VALUES (
-			@retailer_id,
-			@partner_request_id,
-			@customer_id,
-			@operator_name,
-			@operator_code,
-			@amount,
-			@commision,
-			@status
-		)
-		RETURNING dth_transaction_id AS transaction_id;
-	`
-	if _, err := db.pool.Exec(ctx, insertToDthRechargeTable, pgx.NamedArgs{
-		"retailer_id":        req.RetailerID,
-		"partner_request_id": req.PartnerRequestID,
-		"customer_id":        req.CustomerID,
-		"operator_name":      req.OperatorName,
-		"operator_code":      req.OperatorCode,
-		"amount":             req.Amount,
-		"commision":          0,
-		"status":             req.Status,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) dthRechargeWithoutCommision(
-	ctx context.Context,
-	req models.CreateDTHRechargeRequestModel,
-) error {
+) (string, error) {
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer tx.Rollback(ctx)
 
-	getRetailerBeforeBalanceQuery := `
-		SELECT retailer_wallet_balance AS retailer_before_balance
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerBeforeBalance); err != nil {
-		return err
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
 	}
 
-	deductRetailerAmountAndGetAfterBalance := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance AS retailer_after_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, deductRetailerAmountAndGetAfterBalance, pgx.NamedArgs{
-		"amount":      req.Amount,
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerAfterBalance); err != nil {
-		return err
+	quote, err := quoteTransaction(ctx, tx, "DTH_RECHARGE", req.RetailerID, req.OperatorCode, req.Amount)
+	if err != nil {
+		return "", err
 	}
+	split := &quote.Commision
+	commision := split.RetailerCommision
 
-	insertToDthRechargeTable := `
-		INSERT INTO dth_recharge (
-			retailer_id,
-			partner_request_id,
-			customer_id,
-			operator_name,
-			operator_code,
-			amount,
-			commision,
-			status
-		) VALUES (
-			@retailer_id,
-			@partner_request_id,
-			@customer_id,
-			@operator_name,
-			@operator_code,
-			@amount,
-			@commision,
-			@status
-		)
-		RETURNING dth_transaction_id AS transaction_id;
-	`
-	var transactionID int
-	if err := tx.QueryRow(ctx, insertToDthRechargeTable, pgx.NamedArgs{
-		"retailer_id":        req.RetailerID,
-		"partner_request_id": req.PartnerRequestID,
-		"customer_id":        req.CustomerID,
-		"operator_name":      req.OperatorName,
-		"operator_code":      req.OperatorCode,
-		"amount":             req.Amount,
-		"commision":          0,
-		"status":             req.Status,
-	}).Scan(&transactionID); err != nil {
-		return err
+	req.Status = txstate.Initiated
+	transactionID, err := insertDTHRecharge(ctx, tx, req, split)
+	if err != nil {
+		return "", err
 	}
-
-	insertToRetailerWalletTransactionsTable := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		);
-	`
-	if _, err := tx.Exec(ctx, insertToRetailerWalletTransactionsTable, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       fmt.Sprintf("%d", transactionID),
-		"debit_amount":       req.Amount,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "DTH_RECHARGE",
-		"remarks":            fmt.Sprintf("DTH Recharge to: %s", req.CustomerID),
-	}); err != nil {
-		return err
+	if err := txstate.Created(ctx, tx, "DTH_RECHARGE", transactionID, req.Status, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+	if err := accruePendingCommisions(ctx, tx, transactionID, quote); err != nil {
+		return "", err
 	}
 
-	// CRITICAL: Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return err
+	remarks := fmt.Sprintf("DTH Recharge to: %s", req.CustomerID)
+	if commision > 0 {
+		remarks = fmt.Sprintf("DTH Recharge to: %s (Commission: ₹%s)", req.CustomerID, commision)
+	}
+	if err := reserveWallet(ctx, tx, "DTH_RECHARGE", transactionID, req.RetailerID, quote.Debit, remarks); err != nil {
+		return "", err
 	}
 
-	return nil
+	return transactionID, tx.Commit(ctx)
 }
 
-func (db *Database) dthRechargeWithCommision(
+// SettleDTHRechargeQuery applies the provider's answer to a pending DTH
+// recharge, like SettleMobileRechargeQuery.
+func (db *Database) SettleDTHRechargeQuery(
 	ctx context.Context,
-	req models.CreateDTHRechargeRequestModel,
+	transactionID string,
+	status string,
+	actor txstate.Actor,
+	reason string,
 ) error {
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
@@ -209,75 +102,64 @@ func (db *Database) dthRechargeWithCommision(
 	}
 	defer tx.Rollback(ctx)
 
-	getAdminBeforeBalanceQuery := `
-		SELECT ad.admin_wallet_balance, ad.admin_id
-		FROM retailers AS r
-		LEFT JOIN distributors AS d
-    		ON r.distributor_id = d.distributor_id
-		LEFT JOIN master_distributors AS md
-    		ON d.master_distributor_id = md.master_distributor_id
-		LEFT JOIN admins AS ad
-    		ON md.admin_id = ad.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-	var adminBeforeBalance float64
-	var adminID string
-	if err := tx.QueryRow(ctx, getAdminBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&adminBeforeBalance, &adminID); err != nil {
+	recharge, err := lockDTHRecharge(ctx, tx, transactionID)
+	if err != nil {
 		return err
 	}
-
-	// Deduct commission from admin wallet
-	deductAdminAmountAndGetAfterBalanceQuery := `
-		UPDATE admins AS ad
-		SET admin_wallet_balance = admin_wallet_balance - @commision
-		FROM retailers AS r
-		LEFT JOIN distributors AS d
-    		ON r.distributor_id = d.distributor_id
-		LEFT JOIN master_distributors AS md
-    		ON d.master_distributor_id = md.master_distributor_id
-		WHERE r.retailer_id = @retailer_id
-    		AND md.admin_id = ad.admin_id
-		RETURNING ad.admin_wallet_balance AS admin_after_balance;
-	`
-	var adminAfterBalance float64
-	if err := tx.QueryRow(ctx, deductAdminAmountAndGetAfterBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-		"commision":   1,
-	}).Scan(&adminAfterBalance); err != nil {
+	if status == recharge.status {
+		return nil
+	}
+	if err := txstate.Check(recharge.status, status, actor); err != nil {
 		return err
 	}
 
-	getRetailerBeforeBalanceQuery := `
-		SELECT retailer_wallet_balance AS retailer_before_balance
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerBeforeBalance); err != nil {
-		return err
+	switch status {
+	case "SUCCESS":
+		r, err := lockReservation(ctx, tx, "DTH_RECHARGE", transactionID)
+		if err != nil {
+			return err
+		}
+		// Recharges created before reservations were paid in full at
+		// creation.
+		if r != nil {
+			journal := ledger.NewJournal(transactionID, "DTH_RECHARGE", fmt.Sprintf("DTH Recharge to: %s", recharge.customerID)).
+				Credit(ledger.ProviderFloat, recharge.amount, "")
+			if err := fundCommision(ctx, tx, journal, r, recharge.retailerID, &recharge.commision); err != nil {
+				return err
+			}
+			if err := settleReservation(ctx, tx, r, journal); err != nil {
+				return err
+			}
+		}
+	case "FAILED":
+		if err := refundFailed(ctx, tx, &serviceTransaction{"DTH_RECHARGE", transactionID, recharge.retailerID, recharge.amount, recharge.status}); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid recharge status")
 	}
 
-	// Deduct (amount - commission) from retailer
-	// Retailer pays less because they get commission benefit
-	deductAmountFromRetailerQuery := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance AS retailer_after_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, deductAmountFromRetailerQuery, pgx.NamedArgs{
-		"amount":      req.Amount - 1, // Amount minus commission
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerAfterBalance); err != nil {
+	if err := txstate.Transition(ctx, tx, txstate.Change{
+		Service:       "DTH_RECHARGE",
+		TransactionID: transactionID,
+		From:          recharge.status,
+		To:            status,
+		Actor:         actor,
+		Reason:        reason,
+	}); err != nil {
 		return err
 	}
+	return tx.Commit(ctx)
+}
 
-	insertToDthRechargeTableQuery := `
+func insertDTHRecharge(
+	ctx context.Context,
+	tx pgx.Tx,
+	req models.CreateDTHRechargeRequestModel,
+	split *models.CommisionSplitModel,
+) (string, error) {
+	insertToDthRechargeTable := `
 		INSERT INTO dth_recharge (
 			retailer_id,
 			partner_request_id,
@@ -286,6 +168,8 @@ func (db *Database) dthRechargeWithCommision(
 			operator_code,
 			amount,
 			commision,
+			distributor_commision,
+			master_distributor_commision,
 			status
 		) VALUES (
 			@retailer_id,
@@ -295,94 +179,64 @@ func (db *Database) dthRechargeWithCommision(
 			@operator_code,
 			@amount,
 			@commision,
+			@dis_commision,
+			@md_commision,
 			@status
 		)
-		RETURNING dth_transaction_id AS transaction_id;
+		RETURNING dth_transaction_id::TEXT AS transaction_id;
 	`
-	var transactionID int
-	if err := tx.QueryRow(ctx, insertToDthRechargeTableQuery, pgx.NamedArgs{
+	var transactionID string
+	if err := tx.QueryRow(ctx, insertToDthRechargeTable, pgx.NamedArgs{
 		"retailer_id":        req.RetailerID,
 		"partner_request_id": req.PartnerRequestID,
 		"customer_id":        req.CustomerID,
 		"operator_name":      req.OperatorName,
 		"operator_code":      req.OperatorCode,
 		"amount":             req.Amount,
-		"commision":          1,
+		"commision":          split.RetailerCommision,
+		"dis_commision":      split.DistributorCommision,
+		"md_commision":       split.MasterDistributorCommision,
 		"status":             req.Status,
 	}).Scan(&transactionID); err != nil {
-		return err
+		return "", err
 	}
+	return transactionID, nil
+}
 
-	// Record admin's commission deduction
-	insertToAdminWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-    		user_id,
-    		reference_id,
-    		debit_amount,
-    		before_balance,
-    		after_balance,
-    		transaction_reason,
-    		remarks
-		) VALUES (
-    		@user_id,
-    		@reference_id,
-    		@debit_amount,
-    		@before_balance,
-    		@after_balance,
-    		@transaction_reason,
-    		@remarks
-		);
-	`
-	if _, err := tx.Exec(ctx, insertToAdminWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            adminID,
-		"reference_id":       fmt.Sprintf("%d", transactionID),
-		"debit_amount":       1,
-		"before_balance":     adminBeforeBalance,
-		"after_balance":      adminAfterBalance,
-		"transaction_reason": "DTH_RECHARGE",
-		"remarks":            fmt.Sprintf("Commission for Retailer: %s", req.RetailerID),
-	}); err != nil {
-		return err
-	}
+type lockedDTHRecharge struct {
+	transactionID string
+	retailerID    string
+	customerID    string
+	amount        models.Money
+	commision     models.CommisionSplitModel
+	status        string
+}
 
-	// Record retailer's transaction (debit for recharge amount minus commission received)
-	insertToRetailerWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-    		user_id,
-    		reference_id,
-    		debit_amount,
-    		before_balance,
-    		after_balance,
-    		transaction_reason,
-    		remarks
-		) VALUES (
-    		@user_id,
-    		@reference_id,
-    		@debit_amount,
-    		@before_balance,
-    		@after_balance,
-    		@transaction_reason,
-    		@remarks
-		);
+func lockDTHRecharge(ctx context.Context, tx pgx.Tx, transactionID string) (*lockedDTHRecharge, error) {
+	query := `
+		SELECT retailer_id, customer_id, amount, commision, distributor_commision, master_distributor_commision, status
+		FROM dth_recharge
+		WHERE dth_transaction_id = @transaction_id::BIGINT
+		FOR UPDATE;
 	`
-	if _, err := tx.Exec(ctx, insertToRetailerWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       fmt.Sprintf("%d", transactionID),
-		"debit_amount":       req.Amount - 1, // Net amount paid by retailer
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "DTH_RECHARGE",
-		"remarks":            fmt.Sprintf("DTH Recharge to: %s (Commission: ₹1)", req.CustomerID),
-	}); err != nil {
-		return err
-	}
-
-	// CRITICAL: Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return err
+	m := lockedDTHRecharge{transactionID: transactionID}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(
+		&m.retailerID,
+		&m.customerID,
+		&m.amount,
+		&m.commision.RetailerCommision,
+		&m.commision.DistributorCommision,
+		&m.commision.MasterDistributorCommision,
+		&m.status,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid transaction id")
+		}
+		return nil, err
 	}
-
-	return nil
+	return &m, nil
 }
 
 func (db *Database) GetAllDTHRechargesQuery(
@@ -445,18 +299,6 @@ func (db *Database) GetAllDTHRechargesQuery(
 		); err != nil {
 			return nil, err
 		}
-		if recharge.Status == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(recharge.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateDTHRechargeStatus(ctx, newStatus, recharge.DTHTransactionID); err != nil {
-					return nil, err
-				}
-				recharge.Status = newStatus
-			}
-		}
 		history = append(history, recharge)
 	}
 	return history, res.Err()
@@ -525,190 +367,7 @@ func (db *Database) GetDTHRechargesByRetailerIDQuery(
 		); err != nil {
 			return nil, err
 		}
-		if recharge.Status == "PENDING" {
-			newStatus, err := db.DTHRechargeStatusCheck(recharge.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateDTHRechargeStatus(ctx, newStatus, recharge.DTHTransactionID); err != nil {
-					return nil, err
-				}
-				recharge.Status = newStatus
-			}
-		}
 		history = append(history, recharge)
 	}
 	return history, res.Err()
 }
-
-func (db *Database) UpdateDTHRechargeStatus(
-	ctx context.Context,
-	status string,
-	transactionID int,
-) error {
-	query := `
-		UPDATE dth_recharge 
-		SET status = @status
-		WHERE dth_transaction_id = @transaction_id;
-	`
-	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
-		"status":         status,
-		"transaction_id": transactionID,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) DTHRechargeRefundQuery(
-	ctx context.Context,
-	transactionId string,
-) error {
-	tx, err := db.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	getDetailsQuery := `
-		SELECT retailer_id, amount, commision
-		FROM dth_recharge
-		WHERE dth_transaction_id = @transaction_id::BIGINT;
-	`
-	var (
-		retailerId string
-		amount     float64
-		commision  float64
-	)
-	if err := tx.QueryRow(ctx, getDetailsQuery, pgx.NamedArgs{
-		"transaction_id": transactionId,
-	}).Scan(
-		&retailerId,
-		&amount,
-		&commision,
-	); err != nil {
-		return err
-	}
-
-	getUserDetails := `
-		SELECT r.retailer_wallet_balance, a.admin_id, a.admin_wallet_balance
-		FROM retailers r
-		JOIN distributors d
-			ON d.distributor_id = r.distributor_id
-		JOIN master_distributors m
-			ON m.master_distributor_id = d.master_distributor_id
-		JOIN admins a
-			ON a.admin_id = m.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-	var (
-		retailerBeforeBalance float64
-		adminId               string
-		adminBeforeBalance    float64
-	)
-	if err := tx.QueryRow(ctx, getUserDetails, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(
-		&retailerBeforeBalance,
-		&adminId,
-		&adminBeforeBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToWalletTransactions := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		);
-	`
-
-	if commision > 0 {
-		updateAdminWallet := `
-			UPDATE admins
-			SET admin_wallet_balance = admin_wallet_balance + @commision
-			WHERE admin_id = @admin_id
-			RETURNING admin_wallet_balance;
-		`
-		var adminAfterBalance float64
-		if err := tx.QueryRow(ctx, updateAdminWallet, pgx.NamedArgs{
-			"admin_id":  adminId,
-			"commision": commision,
-		}).Scan(
-			&adminAfterBalance,
-		); err != nil {
-			return err
-		}
-
-		if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-			"user_id":            adminId,
-			"reference_id":       transactionId,
-			"credit_amount":      commision,
-			"debit_amount":       0,
-			"before_balance":     adminBeforeBalance,
-			"after_balance":      adminAfterBalance,
-			"transaction_reason": "DTH_RECHARGE_REFUND",
-			"remarks":            fmt.Sprintf("Refund from %s", retailerId),
-		}); err != nil {
-			return err
-		}
-	}
-
-	updateRetailerWallet := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance + @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailerWallet, pgx.NamedArgs{
-		"retailer_id": retailerId,
-		"amount":      amount,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            retailerId,
-		"reference_id":       transactionId,
-		"credit_amount":      amount,
-		"debit_amount":       0,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "DTH_RECHARGE_REFUND",
-		"remarks":            fmt.Sprintf("Refund of transaction %s", transactionId),
-	}); err != nil {
-		return err
-	}
-
-	query := `
-		UPDATE dth_recharge
-		SET status = 'REFUND'
-		WHERE dth_transaction_id = @transaction_id;
-	`
-
-	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
-		"transaction_id": transactionId,
-	}); err != nil {
-		return err
-	}
-
-	return tx.Commit(ctx)
-}
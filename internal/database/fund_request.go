@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
@@ -338,12 +339,13 @@ func (db *Database) AcceptFundRequestQuery(
 	var fr struct {
 		RequesterID string
 		RequestToID string
-		Amount      float64
+		Amount      models.Money
 		Status      string
+		Remarks     string
 	}
 
 	err = tx.QueryRow(ctx, `
-		SELECT requester_id, request_to_id, amount, request_status
+		SELECT requester_id, request_to_id, amount, request_status, remarks
 		FROM fund_requests
 		WHERE fund_request_id=@id
 		FOR UPDATE;
@@ -352,6 +354,7 @@ func (db *Database) AcceptFundRequestQuery(
 		&fr.RequestToID,
 		&fr.Amount,
 		&fr.Status,
+		&fr.Remarks,
 	)
 	if err != nil {
 		return err
@@ -361,71 +364,10 @@ func (db *Database) AcceptFundRequestQuery(
 		return errors.New("already processed")
 	}
 
-	senderTable, err := db.getUserTable(fr.RequestToID)
-	if err != nil {
-		return err
-	}
-	receiverTable, err := db.getUserTable(fr.RequesterID)
-	if err != nil {
-		return err
-	}
-
-	var senderBalance, receiverBalance float64
-
-	// 🔒 Lock sender
-	err = tx.QueryRow(ctx, fmt.Sprintf(`
-		SELECT %s_wallet_balance
-		FROM %ss
-		WHERE %s_id=@id
-		FOR UPDATE`,
-		senderTable, senderTable, senderTable),
-		pgx.NamedArgs{"id": fr.RequestToID},
-	).Scan(&senderBalance)
-	if err != nil {
-		return err
-	}
-
-	if senderBalance < fr.Amount {
-		return errors.New("insufficient balance")
-	}
-
-	// 🔒 Lock receiver
-	err = tx.QueryRow(ctx, fmt.Sprintf(`
-		SELECT %s_wallet_balance
-		FROM %ss
-		WHERE %s_id=@id
-		FOR UPDATE`,
-		receiverTable, receiverTable, receiverTable),
-		pgx.NamedArgs{"id": fr.RequesterID},
-	).Scan(&receiverBalance)
-	if err != nil {
-		return err
-	}
-
-	senderAfter := senderBalance - fr.Amount
-	receiverAfter := receiverBalance + fr.Amount
-
-	// Update sender
-	_, err = tx.Exec(ctx, fmt.Sprintf(`
-		UPDATE %ss
-		SET %s_wallet_balance=@b
-		WHERE %s_id=@id`,
-		senderTable, senderTable, senderTable),
-		pgx.NamedArgs{"b": senderAfter, "id": fr.RequestToID},
-	)
-	if err != nil {
-		return err
-	}
-
-	// Update receiver
-	_, err = tx.Exec(ctx, fmt.Sprintf(`
-		UPDATE %ss
-		SET %s_wallet_balance=@b
-		WHERE %s_id=@id`,
-		receiverTable, receiverTable, receiverTable),
-		pgx.NamedArgs{"b": receiverAfter, "id": fr.RequesterID},
-	)
-	if err != nil {
+	journal := ledger.NewJournal(fmt.Sprintf("%d", fundRequestID), "FUND_REQUEST", fr.Remarks).
+		Debit(ledger.User(fr.RequestToID), fr.Amount, fmt.Sprintf("Fund request accepted for %s", fr.RequesterID)).
+		Credit(ledger.User(fr.RequesterID), fr.Amount, fmt.Sprintf("Fund request accepted by %s", fr.RequestToID))
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
 		return err
 	}
 
@@ -446,23 +388,3 @@ func (db *Database) AcceptFundRequestQuery(
 
 	return tx.Commit(ctx)
 }
-
-
-func (db *Database) getUserTable(userID string) (string, error) {
-	if len(userID) == 0 {
-		return "", errors.New("invalid user id")
-	}
-
-	switch userID[0] {
-	case 'A':
-		return "admin", nil
-	case 'M':
-		return "master_distributor", nil
-	case 'D':
-		return "distributor", nil
-	case 'R':
-		return "retailer", nil
-	default:
-		return "", errors.New("unknown user type")
-	}
-}
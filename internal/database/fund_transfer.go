@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
@@ -19,49 +20,6 @@ func (db *Database) CreateFundTransferQuery(
 	}
 	defer tx.Rollback(ctx)
 
-	fromTable, err := db.getUserTable(req.FromID)
-	if err != nil {
-		return err
-	}
-
-	toTable, err := db.getUserTable(req.ToID)
-	if err != nil {
-		return err
-	}
-
-	getBalance := func(table, id string) (float64, error) {
-		query := fmt.Sprintf(`
-			SELECT %s_wallet_balance
-			FROM %ss
-			WHERE %s_id = @id
-			FOR UPDATE
-		`, table, table, table)
-
-		var bal float64
-		err := tx.QueryRow(ctx, query, pgx.NamedArgs{
-			"id": id,
-		}).Scan(&bal)
-
-		return bal, err
-	}
-
-	fromBefore, err := getBalance(fromTable, req.FromID)
-	if err != nil {
-		return err
-	}
-
-	toBefore, err := getBalance(toTable, req.ToID)
-	if err != nil {
-		return err
-	}
-
-	if fromBefore < req.Amount {
-		return fmt.Errorf("insufficient wallet balance")
-	}
-
-	fromAfter := fromBefore - req.Amount
-	toAfter := toBefore + req.Amount
-
 	var transferID int64
 	insertTransferQuery := `
 		INSERT INTO fund_transfers (
@@ -93,80 +51,10 @@ func (db *Database) CreateFundTransferQuery(
 
 	refID := fmt.Sprintf("%d", transferID)
 
-	updateWallet := func(table, id string, bal float64) error {
-		query := fmt.Sprintf(`
-			UPDATE %ss
-			SET %s_wallet_balance = @bal,
-			    updated_at = NOW()
-			WHERE %s_id = @id
-		`, table, table, table)
-
-		_, err := tx.Exec(ctx, query, pgx.NamedArgs{
-			"id":  id,
-			"bal": bal,
-		})
-		return err
-	}
-
-	if err := updateWallet(fromTable, req.FromID, fromAfter); err != nil {
-		return err
-	}
-
-	if err := updateWallet(toTable, req.ToID, toAfter); err != nil {
-		return err
-	}
-
-	insertTxn := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@uid,
-			@ref,
-			@credit,
-			@debit,
-			@before,
-			@after,
-			'FUND_TRANSFER',
-			@remarks
-		);
-	`
-
-	// Debit → FROM user
-	_, err = tx.Exec(ctx, insertTxn, pgx.NamedArgs{
-		"uid":    req.FromID,
-		"ref":    refID,
-		"credit": nil,
-		"debit":  req.Amount,
-		"before": fromBefore,
-		"after":  fromAfter,
-		"remarks": fmt.Sprintf(
-			"Fund transfer to %s", req.ToID,
-		),
-	})
-	if err != nil {
-		return err
-	}
-
-	// Credit → TO user
-	_, err = tx.Exec(ctx, insertTxn, pgx.NamedArgs{
-		"uid":    req.ToID,
-		"ref":    refID,
-		"credit": req.Amount,
-		"debit":  nil,
-		"before": toBefore,
-		"after":  toAfter,
-		"remarks": fmt.Sprintf(
-			"Fund transfer from %s", req.FromID,
-		),
-	})
-	if err != nil {
+	journal := ledger.NewJournal(refID, "FUND_TRANSFER", req.Remarks).
+		Debit(ledger.User(req.FromID), req.Amount, fmt.Sprintf("Fund transfer to %s", req.ToID)).
+		Credit(ledger.User(req.ToID), req.Amount, fmt.Sprintf("Fund transfer from %s", req.FromID))
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
 		return err
 	}
 
@@ -376,4 +264,4 @@ func (db *Database) GetFundTransfersByToIDQuery(
 	}
 
 	return results, rows.Err()
-}
\ No newline at end of file
+}
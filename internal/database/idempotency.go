@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// ClaimIdempotencyKeyQuery stores the key as IN_PROGRESS for the caller. It
+// returns nil when the key was claimed, or the existing record when another
+// request already holds it. Expired keys are claimed again.
+func (db *Database) ClaimIdempotencyKeyQuery(
+	ctx context.Context,
+	scope, key, requestHash string,
+	ttl time.Duration,
+) (*models.IdempotencyKeyModel, error) {
+	claimQuery := `
+		INSERT INTO idempotency_keys (
+			scope,
+			idempotency_key,
+			request_hash,
+			key_status,
+			expires_at
+		) VALUES (
+			@scope,
+			@idempotency_key,
+			@request_hash,
+			'IN_PROGRESS',
+			NOW() + make_interval(secs => @ttl_seconds)
+		)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			key_status = 'IN_PROGRESS',
+			response_status = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		RETURNING idempotency_key;
+	`
+	var claimed string
+	err := db.pool.QueryRow(ctx, claimQuery, pgx.NamedArgs{
+		"scope":           scope,
+		"idempotency_key": key,
+		"request_hash":    requestHash,
+		"ttl_seconds":     ttl.Seconds(),
+	}).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	existingQuery := `
+		SELECT
+			scope,
+			idempotency_key,
+			request_hash,
+			key_status,
+			response_status,
+			response_body
+		FROM idempotency_keys
+		WHERE scope = @scope
+		AND idempotency_key = @idempotency_key;
+	`
+	var record models.IdempotencyKeyModel
+	if err := db.pool.QueryRow(ctx, existingQuery, pgx.NamedArgs{
+		"scope":           scope,
+		"idempotency_key": key,
+	}).Scan(
+		&record.Scope,
+		&record.IdempotencyKey,
+		&record.RequestHash,
+		&record.KeyStatus,
+		&record.ResponseStatus,
+		&record.ResponseBody,
+	); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// CompleteIdempotencyKeyQuery stores the response of the request that
+// claimed the key.
+func (db *Database) CompleteIdempotencyKeyQuery(
+	ctx context.Context,
+	scope, key string,
+	responseStatus int,
+	responseBody []byte,
+) error {
+	query := `
+		UPDATE idempotency_keys
+		SET key_status = 'COMPLETED',
+			response_status = @response_status,
+			response_body = @response_body
+		WHERE scope = @scope
+		AND idempotency_key = @idempotency_key;
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"scope":           scope,
+		"idempotency_key": key,
+		"response_status": responseStatus,
+		"response_body":   responseBody,
+	})
+	return err
+}
+
+// ReleaseIdempotencyKeyQuery forgets a claimed key so the request can be
+// retried with it, used when the request ended without a response worth
+// replaying.
+func (db *Database) ReleaseIdempotencyKeyQuery(
+	ctx context.Context,
+	scope, key string,
+) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE scope = @scope
+		AND idempotency_key = @idempotency_key
+		AND key_status = 'IN_PROGRESS';
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"scope":           scope,
+		"idempotency_key": key,
+	})
+	return err
+}
+
+// DeleteExpiredIdempotencyKeysQuery removes keys past their expiry.
+func (db *Database) DeleteExpiredIdempotencyKeysQuery(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE expires_at < NOW();
+	`
+	tag, err := db.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
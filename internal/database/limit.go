@@ -140,7 +140,7 @@ func (db *Database) GetAllLimitsQuery(
 func (db *Database) GetLimitAmountByRetailerIDAndServiceQuery(
 	ctx context.Context,
 	retailerId, service string,
-) (float64, error) {
+) (models.Money, error) {
 	query := `
 		SELECT limit_amount
 		FROM transaction_limit
@@ -148,7 +148,7 @@ func (db *Database) GetLimitAmountByRetailerIDAndServiceQuery(
 		AND service = @service;
 	`
 
-	var limit float64
+	var limit models.Money
 	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
 		"retailer_id": retailerId,
 		"service":     service,
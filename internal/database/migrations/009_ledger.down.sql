@@ -0,0 +1,30 @@
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT'
+    )
+) NOT VALID;
+
+ALTER TABLE wallet_transactions
+DROP COLUMN IF EXISTS journal_id;
+
+DROP VIEW IF EXISTS system_account_balances;
+
+DROP TABLE IF EXISTS ledger_entries;
+
+DROP TABLE IF EXISTS ledger_journals;
+
+DROP TABLE IF EXISTS system_accounts;
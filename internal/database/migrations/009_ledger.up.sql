@@ -0,0 +1,96 @@
+CREATE TABLE
+    IF NOT EXISTS system_accounts (
+        account_code TEXT PRIMARY KEY,
+        account_name TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    system_accounts (account_code, account_name)
+VALUES
+    ('PROVIDER_FLOAT', 'Provider float'),
+    ('COMMISION_POOL', 'Commission pool'),
+    ('TDS_PAYABLE', 'TDS payable'),
+    ('FUNDING', 'External funding')
+ON CONFLICT (account_code) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS ledger_journals (
+        journal_id BIGSERIAL PRIMARY KEY,
+        reference_id TEXT NOT NULL,
+        journal_reason TEXT NOT NULL,
+        remarks TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE TABLE
+    IF NOT EXISTS ledger_entries (
+        ledger_entry_id BIGSERIAL PRIMARY KEY,
+        journal_id BIGINT NOT NULL REFERENCES ledger_journals (journal_id) ON DELETE CASCADE,
+        account_type TEXT NOT NULL CHECK (account_type IN ('USER', 'SYSTEM')),
+        account_id TEXT NOT NULL,
+        debit_amount NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (debit_amount >= 0),
+        credit_amount NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (credit_amount >= 0),
+        before_balance NUMERIC(20, 2),
+        after_balance NUMERIC(20, 2),
+        remarks TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CHECK ((debit_amount = 0) <> (credit_amount = 0)),
+        CONSTRAINT ledger_entries_wallet_balances_check CHECK (
+            account_type = 'SYSTEM'
+            OR (
+                before_balance IS NOT NULL
+                AND after_balance IS NOT NULL
+            )
+        )
+    );
+
+-- System accounts are not locked or updated by postings: their balance is
+-- the sum of their ledger entries.
+CREATE OR REPLACE VIEW system_account_balances AS
+SELECT
+    s.account_code,
+    s.account_name,
+    COALESCE(SUM(e.credit_amount - e.debit_amount), 0)::NUMERIC(20, 2) AS balance
+FROM system_accounts s
+LEFT JOIN ledger_entries e
+    ON e.account_type = 'SYSTEM'
+    AND e.account_id = s.account_code
+GROUP BY s.account_code, s.account_name;
+
+ALTER TABLE wallet_transactions
+ADD COLUMN IF NOT EXISTS journal_id BIGINT REFERENCES ledger_journals (journal_id);
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT'
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_journals_reference_id ON ledger_journals (reference_id);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_journal_id ON ledger_entries (journal_id);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries (account_type, account_id);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_journal_id ON wallet_transactions (journal_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_wallet_transactions_user_id;
+
+DROP TABLE IF EXISTS reconciliation_breaks;
+
+DROP TABLE IF EXISTS reconciliation_runs;
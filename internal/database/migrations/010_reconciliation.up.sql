@@ -0,0 +1,33 @@
+CREATE TABLE
+    IF NOT EXISTS reconciliation_runs (
+        run_id BIGSERIAL PRIMARY KEY,
+        triggered_by TEXT NOT NULL,
+        run_status TEXT NOT NULL CHECK (run_status IN ('RUNNING', 'COMPLETED', 'FAILED')),
+        wallets_checked INT NOT NULL DEFAULT 0,
+        transactions_checked BIGINT NOT NULL DEFAULT 0,
+        breaks_found INT NOT NULL DEFAULT 0,
+        error_message TEXT,
+        started_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        finished_at TIMESTAMPTZ
+    );
+
+CREATE TABLE
+    IF NOT EXISTS reconciliation_breaks (
+        break_id BIGSERIAL PRIMARY KEY,
+        run_id BIGINT NOT NULL REFERENCES reconciliation_runs (run_id) ON DELETE CASCADE,
+        user_id TEXT NOT NULL,
+        break_type TEXT NOT NULL CHECK (
+            break_type IN ('CHAIN_BREAK', 'AMOUNT_MISMATCH', 'BALANCE_MISMATCH')
+        ),
+        reference_id TEXT,
+        wallet_transaction_id BIGINT,
+        expected_amount NUMERIC(20, 2) NOT NULL,
+        actual_amount NUMERIC(20, 2) NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_run_id ON reconciliation_breaks (run_id);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_breaks_user_id ON reconciliation_breaks (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_user_id ON wallet_transactions (user_id, wallet_transaction_id);
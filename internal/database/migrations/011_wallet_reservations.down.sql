@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS wallet_reservations;
+
+DELETE FROM system_accounts
+WHERE account_code = 'SUSPENSE';
@@ -0,0 +1,22 @@
+INSERT INTO
+    system_accounts (account_code, account_name)
+VALUES
+    ('SUSPENSE', 'Reserved for in-flight transactions')
+ON CONFLICT (account_code) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS wallet_reservations (
+        reservation_id BIGSERIAL PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        service TEXT NOT NULL,
+        reference_id TEXT NOT NULL,
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        reservation_status TEXT NOT NULL CHECK (
+            reservation_status IN ('RESERVED', 'SETTLED', 'RELEASED')
+        ),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_reservation_reference UNIQUE (service, reference_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_wallet_reservations_user_id ON wallet_reservations (user_id, reservation_status);
@@ -0,0 +1,17 @@
+CREATE TABLE
+    IF NOT EXISTS wallet_holds (
+        hold_id BIGSERIAL PRIMARY KEY,
+        user_id TEXT NOT NULL,
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        hold_type TEXT NOT NULL CHECK (
+            hold_type IN ('DISPUTE', 'COMPLIANCE', 'OTHER')
+        ),
+        remarks TEXT NOT NULL,
+        hold_status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (hold_status IN ('ACTIVE', 'RELEASED')),
+        placed_by TEXT NOT NULL,
+        released_by TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        released_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS idx_wallet_holds_user_id ON wallet_holds (user_id, hold_status);
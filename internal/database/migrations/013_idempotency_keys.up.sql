@@ -0,0 +1,14 @@
+CREATE TABLE
+    IF NOT EXISTS idempotency_keys (
+        scope TEXT NOT NULL,
+        idempotency_key TEXT NOT NULL,
+        request_hash TEXT NOT NULL,
+        key_status TEXT NOT NULL CHECK (key_status IN ('IN_PROGRESS', 'COMPLETED')),
+        response_status INT,
+        response_body BYTEA,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        expires_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (scope, idempotency_key)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_electricity_bill_payments_pending;
+
+DROP INDEX IF EXISTS idx_mobile_recharge_postpaid_pending;
+
+DROP INDEX IF EXISTS idx_dth_recharge_pending;
+
+DROP INDEX IF EXISTS idx_mobile_recharge_pending;
+
+DROP INDEX IF EXISTS idx_payout_transactions_pending;
+
+DROP TABLE IF EXISTS status_checks;
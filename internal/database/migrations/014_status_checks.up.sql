@@ -0,0 +1,31 @@
+CREATE TABLE
+    IF NOT EXISTS status_checks (
+        service TEXT NOT NULL,
+        transaction_id TEXT NOT NULL,
+        attempts INT NOT NULL DEFAULT 0,
+        last_status TEXT,
+        last_error TEXT,
+        last_checked_at TIMESTAMPTZ,
+        next_check_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        PRIMARY KEY (service, transaction_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_payout_transactions_pending ON payout_transactions (created_at)
+WHERE
+    payout_transaction_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_mobile_recharge_pending ON mobile_recharge (created_at)
+WHERE
+    status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_dth_recharge_pending ON dth_recharge (created_at)
+WHERE
+    status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_mobile_recharge_postpaid_pending ON mobile_recharge_postpaid (created_at)
+WHERE
+    recharge_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_electricity_bill_payments_pending ON electricity_bill_payments (created_at)
+WHERE
+    transaction_status = 'PENDING';
@@ -0,0 +1,15 @@
+CREATE TABLE
+    IF NOT EXISTS provider_callbacks (
+        callback_id BIGSERIAL PRIMARY KEY,
+        provider TEXT NOT NULL,
+        partner_request_id TEXT NOT NULL,
+        provider_status INT NOT NULL,
+        payload JSONB NOT NULL,
+        source_ip TEXT NOT NULL,
+        service TEXT,
+        transaction_id TEXT,
+        callback_result TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_provider_callbacks_partner_request_id ON provider_callbacks (partner_request_id);
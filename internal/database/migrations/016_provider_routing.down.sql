@@ -0,0 +1,18 @@
+ALTER TABLE electricity_bill_payments
+DROP COLUMN IF EXISTS provider;
+
+ALTER TABLE mobile_recharge_postpaid
+DROP COLUMN IF EXISTS provider;
+
+ALTER TABLE dth_recharge
+DROP COLUMN IF EXISTS provider;
+
+ALTER TABLE mobile_recharge
+DROP COLUMN IF EXISTS provider;
+
+ALTER TABLE payout_transactions
+DROP COLUMN IF EXISTS provider;
+
+DROP INDEX IF EXISTS idx_provider_routes_service;
+
+DROP TABLE IF EXISTS provider_routes;
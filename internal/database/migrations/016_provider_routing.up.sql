@@ -0,0 +1,42 @@
+CREATE TABLE
+    IF NOT EXISTS provider_routes (
+        route_id BIGSERIAL PRIMARY KEY,
+        service TEXT NOT NULL CHECK (
+            service IN (
+                'PAYOUT',
+                'MOBILE_RECHARGE',
+                'DTH_RECHARGE',
+                'POSTPAID_MOBILE_RECHARGE',
+                'ELECTRICITY_BILL',
+                'DMT'
+            )
+        ),
+        provider TEXT NOT NULL,
+        operator_code INTEGER,
+        min_amount NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        max_amount NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        min_balance NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        priority INTEGER NOT NULL DEFAULT 0,
+        is_active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_provider_routes_service ON provider_routes (service, priority)
+WHERE
+    is_active;
+
+ALTER TABLE payout_transactions
+ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'RECHARGEKIT';
+
+ALTER TABLE mobile_recharge
+ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'RECHARGEKIT';
+
+ALTER TABLE dth_recharge
+ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'RECHARGEKIT';
+
+ALTER TABLE mobile_recharge_postpaid
+ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'RECHARGEKIT';
+
+ALTER TABLE electricity_bill_payments
+ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'RECHARGEKIT';
@@ -0,0 +1,73 @@
+DROP INDEX IF EXISTS idx_transaction_status_history_transaction;
+
+DROP TABLE IF EXISTS transaction_status_history;
+
+DROP INDEX IF EXISTS idx_electricity_bill_payments_initiated;
+
+DROP INDEX IF EXISTS idx_mobile_recharge_postpaid_initiated;
+
+DROP INDEX IF EXISTS idx_dth_recharge_initiated;
+
+DROP INDEX IF EXISTS idx_mobile_recharge_initiated;
+
+DROP INDEX IF EXISTS idx_payout_transactions_initiated;
+
+UPDATE electricity_bill_payments
+SET transaction_status = 'PENDING'
+WHERE transaction_status = 'INITIATED';
+
+ALTER TABLE electricity_bill_payments
+DROP CONSTRAINT IF EXISTS electricity_bill_payments_transaction_status_check;
+
+ALTER TABLE electricity_bill_payments
+ADD CONSTRAINT electricity_bill_payments_transaction_status_check CHECK (
+    transaction_status IN ('PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+UPDATE mobile_recharge_postpaid
+SET recharge_status = 'PENDING'
+WHERE recharge_status = 'INITIATED';
+
+ALTER TABLE mobile_recharge_postpaid
+DROP CONSTRAINT IF EXISTS mobile_recharge_postpaid_recharge_status_check;
+
+ALTER TABLE mobile_recharge_postpaid
+ADD CONSTRAINT mobile_recharge_postpaid_recharge_status_check CHECK (
+    recharge_status IN ('PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+UPDATE dth_recharge
+SET status = 'PENDING'
+WHERE status = 'INITIATED';
+
+ALTER TABLE dth_recharge
+DROP CONSTRAINT IF EXISTS dth_recharge_status_check;
+
+ALTER TABLE dth_recharge
+ADD CONSTRAINT dth_recharge_status_check CHECK (
+    status IN ('SUCCESS', 'PENDING', 'FAILED', 'REFUND')
+);
+
+UPDATE mobile_recharge
+SET status = 'PENDING'
+WHERE status = 'INITIATED';
+
+ALTER TABLE mobile_recharge
+DROP CONSTRAINT IF EXISTS mobile_recharge_status_check;
+
+ALTER TABLE mobile_recharge
+ADD CONSTRAINT mobile_recharge_status_check CHECK (
+    status IN ('SUCCESS', 'FAILED', 'PENDING', 'REFUND')
+);
+
+UPDATE payout_transactions
+SET payout_transaction_status = 'PENDING'
+WHERE payout_transaction_status = 'INITIATED';
+
+ALTER TABLE payout_transactions
+DROP CONSTRAINT IF EXISTS payout_transactions_payout_transaction_status_check;
+
+ALTER TABLE payout_transactions
+ADD CONSTRAINT payout_transactions_payout_transaction_status_check CHECK (
+    payout_transaction_status IN ('SUCCESS', 'PENDING', 'FAILED', 'REFUND')
+);
@@ -0,0 +1,76 @@
+ALTER TABLE payout_transactions
+DROP CONSTRAINT IF EXISTS payout_transactions_payout_transaction_status_check;
+
+ALTER TABLE payout_transactions
+ADD CONSTRAINT payout_transactions_payout_transaction_status_check CHECK (
+    payout_transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+ALTER TABLE mobile_recharge
+DROP CONSTRAINT IF EXISTS mobile_recharge_status_check;
+
+ALTER TABLE mobile_recharge
+ADD CONSTRAINT mobile_recharge_status_check CHECK (
+    status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+ALTER TABLE dth_recharge
+DROP CONSTRAINT IF EXISTS dth_recharge_status_check;
+
+ALTER TABLE dth_recharge
+ADD CONSTRAINT dth_recharge_status_check CHECK (
+    status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+ALTER TABLE mobile_recharge_postpaid
+DROP CONSTRAINT IF EXISTS mobile_recharge_postpaid_recharge_status_check;
+
+ALTER TABLE mobile_recharge_postpaid
+ADD CONSTRAINT mobile_recharge_postpaid_recharge_status_check CHECK (
+    recharge_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+ALTER TABLE electricity_bill_payments
+DROP CONSTRAINT IF EXISTS electricity_bill_payments_transaction_status_check;
+
+ALTER TABLE electricity_bill_payments
+ADD CONSTRAINT electricity_bill_payments_transaction_status_check CHECK (
+    transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_transactions_initiated ON payout_transactions (created_at)
+WHERE
+    payout_transaction_status = 'INITIATED';
+
+CREATE INDEX IF NOT EXISTS idx_mobile_recharge_initiated ON mobile_recharge (created_at)
+WHERE
+    status = 'INITIATED';
+
+CREATE INDEX IF NOT EXISTS idx_dth_recharge_initiated ON dth_recharge (created_at)
+WHERE
+    status = 'INITIATED';
+
+CREATE INDEX IF NOT EXISTS idx_mobile_recharge_postpaid_initiated ON mobile_recharge_postpaid (created_at)
+WHERE
+    recharge_status = 'INITIATED';
+
+CREATE INDEX IF NOT EXISTS idx_electricity_bill_payments_initiated ON electricity_bill_payments (created_at)
+WHERE
+    transaction_status = 'INITIATED';
+
+CREATE TABLE
+    IF NOT EXISTS transaction_status_history (
+        history_id BIGSERIAL PRIMARY KEY,
+        service TEXT NOT NULL,
+        transaction_id TEXT NOT NULL,
+        from_status TEXT,
+        to_status TEXT NOT NULL,
+        actor_type TEXT NOT NULL CHECK (
+            actor_type IN ('SYSTEM', 'PROVIDER', 'ADMIN', 'RETAILER')
+        ),
+        actor_id TEXT,
+        reason TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_transaction_status_history_transaction ON transaction_status_history (service, transaction_id, history_id);
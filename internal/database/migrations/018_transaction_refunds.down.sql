@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_ledger_receivables_account;
+
+DROP TABLE IF EXISTS ledger_receivables;
+
+DELETE FROM system_accounts
+WHERE account_code = 'RECEIVABLE';
+
+DROP INDEX IF EXISTS idx_transaction_refunds_transaction;
+
+DROP TABLE IF EXISTS transaction_refunds;
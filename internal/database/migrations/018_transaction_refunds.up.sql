@@ -0,0 +1,34 @@
+CREATE TABLE
+    IF NOT EXISTS transaction_refunds (
+        refund_id BIGSERIAL PRIMARY KEY,
+        service TEXT NOT NULL,
+        transaction_id TEXT NOT NULL,
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        journal_id BIGINT NOT NULL REFERENCES ledger_journals (journal_id),
+        actor_type TEXT NOT NULL CHECK (
+            actor_type IN ('SYSTEM', 'PROVIDER', 'ADMIN', 'RETAILER')
+        ),
+        actor_id TEXT,
+        reason TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_transaction_refunds_transaction ON transaction_refunds (service, transaction_id);
+
+INSERT INTO
+    system_accounts (account_code, account_name)
+VALUES
+    ('RECEIVABLE', 'Owed by users after refund clawbacks')
+ON CONFLICT (account_code) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS ledger_receivables (
+        receivable_id BIGSERIAL PRIMARY KEY,
+        journal_id BIGINT NOT NULL REFERENCES ledger_journals (journal_id),
+        account_type TEXT NOT NULL,
+        account_id TEXT NOT NULL,
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_ledger_receivables_account ON ledger_receivables (account_type, account_id);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_tds_commision_transaction;
+
+DROP INDEX IF EXISTS idx_tds_commision_user_id;
+
+ALTER TABLE tds_commision
+DROP CONSTRAINT IF EXISTS tds_commision_status_check;
+
+ALTER TABLE tds_commision
+DROP COLUMN IF EXISTS tds_rate;
+
+ALTER TABLE tds_commision
+DROP COLUMN IF EXISTS service;
+
+DROP TABLE IF EXISTS tds_settings;
@@ -0,0 +1,29 @@
+CREATE TABLE
+    IF NOT EXISTS tds_settings (
+        setting_id INT PRIMARY KEY DEFAULT 1 CHECK (setting_id = 1),
+        tds_rate NUMERIC(7, 4) NOT NULL DEFAULT 2 CHECK (tds_rate BETWEEN 0 AND 100),
+        no_pan_tds_rate NUMERIC(7, 4) NOT NULL DEFAULT 20 CHECK (no_pan_tds_rate BETWEEN 0 AND 100),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    tds_settings (setting_id)
+VALUES
+    (1)
+ON CONFLICT (setting_id) DO NOTHING;
+
+ALTER TABLE tds_commision
+ADD COLUMN IF NOT EXISTS service TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tds_commision
+ADD COLUMN IF NOT EXISTS tds_rate NUMERIC(7, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE tds_commision
+DROP CONSTRAINT IF EXISTS tds_commision_status_check;
+
+ALTER TABLE tds_commision
+ADD CONSTRAINT tds_commision_status_check CHECK (status IN ('DEDUCTED', 'REVERSED'));
+
+CREATE INDEX IF NOT EXISTS idx_tds_commision_user_id ON tds_commision (user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_tds_commision_transaction ON tds_commision (service, transaction_id);
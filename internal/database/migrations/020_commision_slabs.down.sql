@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_commision_slabs_lookup;
+
+DROP TABLE IF EXISTS commision_slabs;
@@ -0,0 +1,66 @@
+CREATE TABLE
+    IF NOT EXISTS commision_slabs (
+        slab_id BIGSERIAL PRIMARY KEY,
+        user_id TEXT,
+        service TEXT NOT NULL CHECK (
+            service IN (
+                'PAYOUT',
+                'DMT',
+                'BBPS',
+                'MOBILE_RECHARGE',
+                'DTH_RECHARGE'
+            )
+        ),
+        min_amount NUMERIC(20, 2) NOT NULL CHECK (min_amount >= 0),
+        max_amount NUMERIC(20, 2) CHECK (max_amount >= min_amount),
+        commision_type TEXT NOT NULL CHECK (commision_type IN ('FLAT', 'PERCENTAGE')),
+        flat_commision NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (flat_commision >= 0),
+        percent_commision NUMERIC(7, 4) NOT NULL DEFAULT 0 CHECK (percent_commision BETWEEN 0 AND 100),
+        min_commision NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (min_commision >= 0),
+        max_commision NUMERIC(20, 2) CHECK (max_commision >= min_commision),
+        admin_commision NUMERIC(7, 4) NOT NULL,
+        master_distributor_commision NUMERIC(7, 4) NOT NULL,
+        distributor_commision NUMERIC(7, 4) NOT NULL,
+        retailer_commision NUMERIC(7, 4) NOT NULL,
+        effective_from TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_commision_slabs_lookup ON commision_slabs (service, user_id, effective_from);
+
+-- The payout charge that used to be hardcoded: 1.2%, split 0.5 to the
+-- retailer, 0.2 to the distributor, 0.05 to the master distributor and
+-- 0.25 to the admin.
+INSERT INTO
+    commision_slabs (
+        service,
+        min_amount,
+        commision_type,
+        percent_commision,
+        admin_commision,
+        master_distributor_commision,
+        distributor_commision,
+        retailer_commision,
+        effective_from
+    )
+SELECT
+    'PAYOUT',
+    0,
+    'PERCENTAGE',
+    1.2,
+    0.25,
+    0.05,
+    0.2,
+    0.5,
+    '-infinity'
+WHERE
+    NOT EXISTS (
+        SELECT
+            1
+        FROM
+            commision_slabs
+        WHERE
+            service = 'PAYOUT'
+            AND user_id IS NULL
+    );
@@ -0,0 +1,31 @@
+DELETE FROM commision_slabs
+WHERE
+    operator_code IS NOT NULL;
+
+ALTER TABLE dth_recharge
+DROP COLUMN IF EXISTS master_distributor_commision;
+
+ALTER TABLE dth_recharge
+DROP COLUMN IF EXISTS distributor_commision;
+
+ALTER TABLE mobile_recharge
+DROP COLUMN IF EXISTS master_distributor_commision;
+
+ALTER TABLE mobile_recharge
+DROP COLUMN IF EXISTS distributor_commision;
+
+ALTER TABLE commision_slabs
+DROP CONSTRAINT IF EXISTS commision_slabs_operator_code_check;
+
+ALTER TABLE commision_slabs
+DROP COLUMN IF EXISTS operator_code;
+
+DELETE FROM commisions
+WHERE
+    service IN ('MOBILE_RECHARGE', 'DTH_RECHARGE');
+
+ALTER TABLE commisions
+DROP CONSTRAINT IF EXISTS commisions_service_check;
+
+ALTER TABLE commisions
+ADD CONSTRAINT commisions_service_check CHECK (service IN ('PAYOUT', 'DMT', 'AEPS', 'BBPS'));
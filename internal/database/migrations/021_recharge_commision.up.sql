@@ -0,0 +1,76 @@
+ALTER TABLE commisions
+DROP CONSTRAINT IF EXISTS commisions_service_check;
+
+ALTER TABLE commisions
+ADD CONSTRAINT commisions_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'DMT',
+        'AEPS',
+        'BBPS',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE'
+    )
+);
+
+ALTER TABLE commision_slabs
+ADD COLUMN IF NOT EXISTS operator_code INTEGER;
+
+ALTER TABLE commision_slabs
+ADD CONSTRAINT commision_slabs_operator_code_check CHECK (
+    operator_code IS NULL
+    OR service IN ('MOBILE_RECHARGE', 'DTH_RECHARGE')
+);
+
+ALTER TABLE mobile_recharge
+ADD COLUMN IF NOT EXISTS distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE mobile_recharge
+ADD COLUMN IF NOT EXISTS master_distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE dth_recharge
+ADD COLUMN IF NOT EXISTS distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE dth_recharge
+ADD COLUMN IF NOT EXISTS master_distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0;
+
+-- The recharge commission that used to be hardcoded: a flat ₹1 to the
+-- retailer on recharges above ₹99, for every operator.
+INSERT INTO
+    commision_slabs (
+        service,
+        min_amount,
+        commision_type,
+        flat_commision,
+        admin_commision,
+        master_distributor_commision,
+        distributor_commision,
+        retailer_commision,
+        effective_from
+    )
+SELECT
+    s.service,
+    99.01,
+    'FLAT',
+    1,
+    0,
+    0,
+    0,
+    1,
+    '-infinity'
+FROM
+    (
+        VALUES
+            ('MOBILE_RECHARGE'),
+            ('DTH_RECHARGE')
+    ) AS s (service)
+WHERE
+    NOT EXISTS (
+        SELECT
+            1
+        FROM
+            commision_slabs c
+        WHERE
+            c.service = s.service
+            AND c.user_id IS NULL
+    );
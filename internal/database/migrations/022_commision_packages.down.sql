@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_commision_package_assignments_package_id;
+
+DROP TABLE IF EXISTS commision_package_assignments;
+
+DROP INDEX IF EXISTS idx_commision_slabs_package_id;
+
+DELETE FROM commision_slabs
+WHERE
+    package_id IS NOT NULL;
+
+ALTER TABLE commision_slabs
+DROP CONSTRAINT IF EXISTS commision_slabs_owner_check;
+
+ALTER TABLE commision_slabs
+DROP COLUMN IF EXISTS package_id;
+
+DROP TABLE IF EXISTS commision_packages;
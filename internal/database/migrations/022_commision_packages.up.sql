@@ -0,0 +1,33 @@
+CREATE TABLE
+    IF NOT EXISTS commision_packages (
+        package_id BIGSERIAL PRIMARY KEY,
+        package_name TEXT NOT NULL,
+        description TEXT NOT NULL DEFAULT '',
+        created_by TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        UNIQUE (created_by, package_name)
+    );
+
+ALTER TABLE commision_slabs
+ADD COLUMN IF NOT EXISTS package_id BIGINT REFERENCES commision_packages (package_id) ON DELETE CASCADE;
+
+ALTER TABLE commision_slabs
+ADD CONSTRAINT commision_slabs_owner_check CHECK (
+    user_id IS NULL
+    OR package_id IS NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_commision_slabs_package_id ON commision_slabs (package_id)
+WHERE
+    package_id IS NOT NULL;
+
+CREATE TABLE
+    IF NOT EXISTS commision_package_assignments (
+        user_id TEXT PRIMARY KEY,
+        package_id BIGINT NOT NULL REFERENCES commision_packages (package_id),
+        assigned_by TEXT NOT NULL,
+        assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_commision_package_assignments_package_id ON commision_package_assignments (package_id);
@@ -0,0 +1,9 @@
+ALTER TABLE commisions
+DROP CONSTRAINT IF EXISTS commisions_shares_check;
+
+ALTER TABLE commisions
+ALTER COLUMN retailer_commision TYPE NUMERIC(20, 2),
+ALTER COLUMN distributor_commision TYPE NUMERIC(20, 2),
+ALTER COLUMN master_distributor_commision TYPE NUMERIC(20, 2),
+ALTER COLUMN admin_commision TYPE NUMERIC(20, 2),
+ALTER COLUMN total_commision TYPE NUMERIC(20, 2);
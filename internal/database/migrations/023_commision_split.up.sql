@@ -0,0 +1,18 @@
+-- Shares are fractions of total_commision and need the precision of a Rate.
+ALTER TABLE commisions
+ALTER COLUMN total_commision TYPE NUMERIC(20, 4),
+ALTER COLUMN admin_commision TYPE NUMERIC(20, 4),
+ALTER COLUMN master_distributor_commision TYPE NUMERIC(20, 4),
+ALTER COLUMN distributor_commision TYPE NUMERIC(20, 4),
+ALTER COLUMN retailer_commision TYPE NUMERIC(20, 4);
+
+-- NOT VALID so existing rows do not block the migration; they are listed
+-- by the commission audit and have to be fixed by hand.
+ALTER TABLE commisions
+ADD CONSTRAINT commisions_shares_check CHECK (
+    admin_commision >= 0
+    AND master_distributor_commision >= 0
+    AND distributor_commision >= 0
+    AND retailer_commision >= 0
+    AND admin_commision + master_distributor_commision + distributor_commision + retailer_commision = 1
+) NOT VALID;
@@ -0,0 +1,71 @@
+INSERT INTO
+    system_accounts (account_code, account_name)
+VALUES
+    ('COMMISION_PAYABLE', 'Commission accrued, awaiting release')
+ON CONFLICT (account_code) DO NOTHING;
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE'
+    )
+);
+
+CREATE TABLE
+    IF NOT EXISTS commision_settlement_settings (
+        setting_id INT PRIMARY KEY DEFAULT 1 CHECK (setting_id = 1),
+        settlement_mode TEXT NOT NULL DEFAULT 'INSTANT' CHECK (settlement_mode IN ('INSTANT', 'DAILY', 'MONTHLY')),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    commision_settlement_settings (setting_id)
+VALUES
+    (1)
+ON CONFLICT (setting_id) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS pending_commisions (
+        pending_commision_id BIGSERIAL PRIMARY KEY,
+        service TEXT NOT NULL,
+        transaction_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        commision NUMERIC(20, 2) NOT NULL CHECK (commision > 0),
+        tds NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        net_commision NUMERIC(20, 2) NOT NULL,
+        reversed_commision NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        commision_status TEXT NOT NULL CHECK (
+            commision_status IN ('PENDING', 'DUE', 'RELEASED', 'REVERSED', 'CANCELLED')
+        ),
+        release_journal_id BIGINT REFERENCES ledger_journals (journal_id),
+        settled_at TIMESTAMPTZ,
+        released_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_pending_commision UNIQUE (service, transaction_id, user_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_pending_commisions_due ON pending_commisions (settled_at)
+WHERE
+    commision_status = 'DUE';
+
+CREATE INDEX IF NOT EXISTS idx_pending_commisions_user_id ON pending_commisions (user_id, created_at);
@@ -0,0 +1,29 @@
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE'
+    )
+) NOT VALID;
+
+DROP TABLE IF EXISTS dmt_beneficiaries;
+
+DROP TABLE IF EXISTS dmt_transactions;
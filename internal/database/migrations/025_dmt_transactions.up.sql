@@ -0,0 +1,83 @@
+CREATE TABLE
+    IF NOT EXISTS dmt_transactions (
+        dmt_transaction_id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        partner_request_id UUID NOT NULL,
+        operator_transaction_id TEXT NOT NULL,
+        order_id TEXT NOT NULL,
+        retailer_id TEXT NOT NULL,
+        mobile_number TEXT NOT NULL,
+        beneficiary_id TEXT NOT NULL,
+        beneficiary_name TEXT NOT NULL,
+        bank_name TEXT NOT NULL,
+        account_number TEXT NOT NULL,
+        ifsc_code TEXT NOT NULL,
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        transfer_type TEXT NOT NULL CHECK (transfer_type IN ('IMPS', 'NEFT')),
+        admin_commision NUMERIC(20, 2) NOT NULL,
+        master_distributor_commision NUMERIC(20, 2) NOT NULL,
+        distributor_commision NUMERIC(20, 2) NOT NULL,
+        retailer_commision NUMERIC(20, 2) NOT NULL,
+        provider TEXT NOT NULL DEFAULT 'RECHARGEKIT',
+        transaction_status TEXT NOT NULL CHECK (
+            transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+        ),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_dmt_partner_request_id UNIQUE (partner_request_id),
+        FOREIGN KEY (retailer_id) REFERENCES retailers (retailer_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_dmt_transactions_retailer_id ON dmt_transactions (retailer_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_dmt_transactions_pending ON dmt_transactions (created_at)
+WHERE
+    transaction_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_dmt_transactions_initiated ON dmt_transactions (created_at)
+WHERE
+    transaction_status = 'INITIATED';
+
+-- Beneficiaries the provider has verified with a penny drop whose account
+-- holder name matched. Transfers are only sent to these, with the details
+-- the bank returned.
+CREATE TABLE
+    IF NOT EXISTS dmt_beneficiaries (
+        mobile_number TEXT NOT NULL,
+        beneficiary_id TEXT NOT NULL,
+        retailer_id TEXT NOT NULL,
+        beneficiary_name TEXT NOT NULL,
+        bank_name TEXT NOT NULL,
+        account_number TEXT NOT NULL,
+        ifsc_code TEXT NOT NULL,
+        verified_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        PRIMARY KEY (mobile_number, beneficiary_id),
+        FOREIGN KEY (retailer_id) REFERENCES retailers (retailer_id) ON DELETE CASCADE
+    );
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND'
+    )
+);
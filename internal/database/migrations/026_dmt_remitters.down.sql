@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_dmt_transactions_mobile_number;
+
+DROP TABLE IF EXISTS dmt_remitters;
+
+DROP TABLE IF EXISTS dmt_remitter_limits;
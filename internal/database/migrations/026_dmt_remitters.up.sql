@@ -0,0 +1,26 @@
+CREATE TABLE
+    IF NOT EXISTS dmt_remitter_limits (
+        kyc_level TEXT PRIMARY KEY CHECK (kyc_level IN ('MINIMUM', 'FULL')),
+        per_transaction_limit NUMERIC(20, 2) NOT NULL CHECK (per_transaction_limit > 0),
+        monthly_limit NUMERIC(20, 2) NOT NULL CHECK (monthly_limit >= per_transaction_limit),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    dmt_remitter_limits (kyc_level, per_transaction_limit, monthly_limit)
+VALUES
+    ('MINIMUM', 5000, 25000),
+    ('FULL', 25000, 200000)
+ON CONFLICT (kyc_level) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS dmt_remitters (
+        mobile_number TEXT PRIMARY KEY,
+        remitter_name TEXT NOT NULL DEFAULT '',
+        kyc_level TEXT NOT NULL DEFAULT 'MINIMUM' REFERENCES dmt_remitter_limits (kyc_level),
+        retailer_id TEXT REFERENCES retailers (retailer_id) ON DELETE SET NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_dmt_transactions_mobile_number ON dmt_transactions (mobile_number, created_at);
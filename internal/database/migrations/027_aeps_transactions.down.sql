@@ -0,0 +1,66 @@
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND'
+    )
+) NOT VALID;
+
+DELETE FROM provider_routes
+WHERE
+    service = 'AEPS';
+
+ALTER TABLE provider_routes
+DROP CONSTRAINT IF EXISTS provider_routes_service_check;
+
+ALTER TABLE provider_routes
+ADD CONSTRAINT provider_routes_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'ELECTRICITY_BILL',
+        'DMT'
+    )
+);
+
+DELETE FROM commision_slabs
+WHERE
+    service = 'AEPS';
+
+ALTER TABLE commision_slabs
+DROP CONSTRAINT IF EXISTS commision_slabs_service_check;
+
+ALTER TABLE commision_slabs
+ADD CONSTRAINT commision_slabs_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'DMT',
+        'BBPS',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE'
+    )
+);
+
+DROP TABLE IF EXISTS aeps_transactions;
@@ -0,0 +1,100 @@
+CREATE TABLE
+    IF NOT EXISTS aeps_transactions (
+        aeps_transaction_id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        partner_request_id UUID NOT NULL,
+        operator_transaction_id TEXT NOT NULL DEFAULT '',
+        order_id TEXT NOT NULL DEFAULT '',
+        retailer_id TEXT NOT NULL,
+        transaction_type TEXT NOT NULL CHECK (
+            transaction_type IN ('CASH_WITHDRAWAL', 'BALANCE_ENQUIRY', 'MINI_STATEMENT', 'AADHAAR_PAY')
+        ),
+        customer_mobile_number TEXT NOT NULL,
+        aadhaar_last_digits TEXT NOT NULL,
+        bank_iin TEXT NOT NULL,
+        bank_name TEXT NOT NULL DEFAULT '',
+        amount NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (amount >= 0),
+        account_balance NUMERIC(20, 2),
+        master_distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        distributor_commision NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        retailer_commision NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        provider TEXT NOT NULL DEFAULT '',
+        message TEXT NOT NULL DEFAULT '',
+        transaction_status TEXT NOT NULL CHECK (
+            transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+        ),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_aeps_partner_request_id UNIQUE (partner_request_id),
+        FOREIGN KEY (retailer_id) REFERENCES retailers (retailer_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_aeps_transactions_retailer_id ON aeps_transactions (retailer_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_aeps_transactions_pending ON aeps_transactions (created_at)
+WHERE
+    transaction_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_aeps_transactions_initiated ON aeps_transactions (created_at)
+WHERE
+    transaction_status = 'INITIATED';
+
+ALTER TABLE commision_slabs
+DROP CONSTRAINT IF EXISTS commision_slabs_service_check;
+
+ALTER TABLE commision_slabs
+ADD CONSTRAINT commision_slabs_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'DMT',
+        'AEPS',
+        'BBPS',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE'
+    )
+);
+
+ALTER TABLE provider_routes
+DROP CONSTRAINT IF EXISTS provider_routes_service_check;
+
+ALTER TABLE provider_routes
+ADD CONSTRAINT provider_routes_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'ELECTRICITY_BILL',
+        'DMT',
+        'AEPS'
+    )
+);
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND',
+        'AEPS',
+        'AEPS_REFUND'
+    )
+);
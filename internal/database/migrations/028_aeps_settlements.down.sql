@@ -0,0 +1,52 @@
+DROP INDEX IF EXISTS idx_wallet_transactions_wallet_type;
+
+DROP TABLE IF EXISTS aeps_settlements;
+
+DROP TABLE IF EXISTS retailer_settlement_accounts;
+
+DROP TABLE IF EXISTS aeps_settlement_settings;
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND',
+        'AEPS',
+        'AEPS_REFUND'
+    )
+) NOT VALID;
+
+ALTER TABLE ledger_entries
+DROP CONSTRAINT IF EXISTS ledger_entries_account_type_check;
+
+ALTER TABLE ledger_entries
+ADD CONSTRAINT ledger_entries_account_type_check CHECK (account_type IN ('USER', 'SYSTEM')) NOT VALID;
+
+ALTER TABLE reconciliation_breaks
+DROP COLUMN IF EXISTS wallet_type;
+
+ALTER TABLE wallet_transactions
+DROP COLUMN IF EXISTS wallet_type;
+
+ALTER TABLE retailers
+DROP COLUMN IF EXISTS retailer_settlement_wallet_balance;
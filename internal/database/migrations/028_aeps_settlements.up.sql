@@ -0,0 +1,119 @@
+ALTER TABLE retailers
+ADD COLUMN IF NOT EXISTS retailer_settlement_wallet_balance NUMERIC(20, 2) NOT NULL DEFAULT 0.0 CHECK (retailer_settlement_wallet_balance >= 0.0);
+
+ALTER TABLE wallet_transactions
+ADD COLUMN IF NOT EXISTS wallet_type TEXT NOT NULL DEFAULT 'MAIN' CHECK (wallet_type IN ('MAIN', 'SETTLEMENT'));
+
+ALTER TABLE reconciliation_breaks
+ADD COLUMN IF NOT EXISTS wallet_type TEXT NOT NULL DEFAULT 'MAIN' CHECK (wallet_type IN ('MAIN', 'SETTLEMENT'));
+
+ALTER TABLE ledger_entries
+DROP CONSTRAINT IF EXISTS ledger_entries_account_type_check;
+
+ALTER TABLE ledger_entries
+ADD CONSTRAINT ledger_entries_account_type_check CHECK (account_type IN ('USER', 'SYSTEM', 'SETTLEMENT'));
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND',
+        'AEPS',
+        'AEPS_REFUND',
+        'AEPS_SETTLEMENT',
+        'AEPS_SETTLEMENT_REFUND'
+    )
+);
+
+CREATE TABLE
+    IF NOT EXISTS aeps_settlement_settings (
+        setting_id INT PRIMARY KEY DEFAULT 1 CHECK (setting_id = 1),
+        wallet_transfer_charge NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (wallet_transfer_charge >= 0),
+        bank_settlement_charge NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (bank_settlement_charge >= 0),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    aeps_settlement_settings (setting_id)
+VALUES
+    (1)
+ON CONFLICT (setting_id) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS retailer_settlement_accounts (
+        settlement_account_id BIGSERIAL PRIMARY KEY,
+        retailer_id TEXT NOT NULL REFERENCES retailers (retailer_id) ON DELETE CASCADE,
+        account_holder_name TEXT NOT NULL,
+        bank_name TEXT NOT NULL,
+        account_number TEXT NOT NULL,
+        ifsc_code TEXT NOT NULL,
+        is_verified BOOLEAN NOT NULL DEFAULT FALSE,
+        verified_by TEXT,
+        verified_at TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_retailer_settlement_account UNIQUE (retailer_id, account_number, ifsc_code)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS aeps_settlements (
+        aeps_settlement_id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        partner_request_id UUID NOT NULL,
+        operator_transaction_id TEXT NOT NULL DEFAULT '',
+        order_id TEXT NOT NULL DEFAULT '',
+        retailer_id TEXT NOT NULL,
+        settlement_type TEXT NOT NULL CHECK (settlement_type IN ('WALLET', 'BANK')),
+        settlement_account_id BIGINT REFERENCES retailer_settlement_accounts (settlement_account_id) ON DELETE SET NULL,
+        account_holder_name TEXT NOT NULL DEFAULT '',
+        bank_name TEXT NOT NULL DEFAULT '',
+        account_number TEXT NOT NULL DEFAULT '',
+        ifsc_code TEXT NOT NULL DEFAULT '',
+        transfer_type TEXT CHECK (transfer_type IN ('IMPS', 'NEFT')),
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        charge NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (charge >= 0),
+        provider TEXT NOT NULL DEFAULT '',
+        remarks TEXT NOT NULL DEFAULT '',
+        approved_by TEXT,
+        approved_at TIMESTAMPTZ,
+        transaction_status TEXT NOT NULL CHECK (
+            transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+        ),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_aeps_settlement_partner_request_id UNIQUE (partner_request_id),
+        FOREIGN KEY (retailer_id) REFERENCES retailers (retailer_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_retailer_settlement_accounts_retailer_id ON retailer_settlement_accounts (retailer_id);
+
+CREATE INDEX IF NOT EXISTS idx_aeps_settlements_retailer_id ON aeps_settlements (retailer_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_aeps_settlements_pending ON aeps_settlements (created_at)
+WHERE
+    transaction_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_aeps_settlements_initiated ON aeps_settlements (created_at)
+WHERE
+    transaction_status = 'INITIATED';
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_wallet_type ON wallet_transactions (user_id, wallet_type, wallet_transaction_id);
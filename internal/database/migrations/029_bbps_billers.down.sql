@@ -0,0 +1,180 @@
+-- Past postpaid recharges and electricity bill payments go back to their
+-- own service, taking along the status and references of anything that
+-- happened to them since.
+UPDATE mobile_recharge_postpaid t
+SET
+    recharge_status = b.transaction_status
+FROM
+    bbps_legacy_transactions l
+    JOIN bbps_transactions b ON b.bbps_transaction_id = l.bbps_transaction_id
+WHERE
+    l.service = 'POSTPAID_MOBILE_RECHARGE'
+    AND l.transaction_id = t.postpaid_recharge_transaction_id::TEXT;
+
+UPDATE electricity_bill_payments t
+SET
+    transaction_status = b.transaction_status
+FROM
+    bbps_legacy_transactions l
+    JOIN bbps_transactions b ON b.bbps_transaction_id = l.bbps_transaction_id
+WHERE
+    l.service = 'ELECTRICITY_BILL'
+    AND l.transaction_id = t.electricity_bill_transaction_id::TEXT;
+
+UPDATE ledger_journals j
+SET
+    reference_id = l.transaction_id,
+    journal_reason = CASE
+        WHEN j.journal_reason = 'BBPS' THEN l.service
+        ELSE l.service || '_REFUND'
+    END
+FROM
+    bbps_legacy_transactions l
+WHERE
+    j.reference_id = l.bbps_transaction_id::TEXT
+    AND j.journal_reason IN ('BBPS', 'BBPS_REFUND');
+
+UPDATE wallet_transactions w
+SET
+    reference_id = l.transaction_id,
+    transaction_reason = CASE
+        WHEN w.transaction_reason = 'BBPS' THEN l.service
+        ELSE l.service || '_REFUND'
+    END
+FROM
+    bbps_legacy_transactions l
+WHERE
+    w.reference_id = l.bbps_transaction_id::TEXT
+    AND w.transaction_reason IN ('BBPS', 'BBPS_REFUND');
+
+UPDATE wallet_reservations r
+SET
+    service = l.service,
+    reference_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    r.service = 'BBPS'
+    AND r.reference_id = l.bbps_transaction_id::TEXT;
+
+UPDATE pending_commisions c
+SET
+    service = l.service,
+    transaction_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = 'BBPS'
+    AND c.transaction_id = l.bbps_transaction_id::TEXT;
+
+UPDATE tds_commision c
+SET
+    service = l.service,
+    transaction_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = 'BBPS'
+    AND c.transaction_id = l.bbps_transaction_id::TEXT;
+
+UPDATE transaction_refunds f
+SET
+    service = l.service,
+    transaction_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    f.service = 'BBPS'
+    AND f.transaction_id = l.bbps_transaction_id::TEXT;
+
+UPDATE transaction_status_history h
+SET
+    service = l.service,
+    transaction_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    h.service = 'BBPS'
+    AND h.transaction_id = l.bbps_transaction_id::TEXT;
+
+UPDATE provider_callbacks c
+SET
+    service = l.service,
+    transaction_id = l.transaction_id
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = 'BBPS'
+    AND c.transaction_id = l.bbps_transaction_id::TEXT;
+
+DROP TABLE IF EXISTS bbps_legacy_transactions;
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND',
+        'AEPS',
+        'AEPS_REFUND',
+        'AEPS_SETTLEMENT',
+        'AEPS_SETTLEMENT_REFUND'
+    )
+) NOT VALID;
+
+ALTER TABLE provider_routes
+DROP CONSTRAINT IF EXISTS provider_routes_service_check;
+
+-- Routes of electricity operators go back to electricity bills and every
+-- other BBPS route to postpaid recharges.
+UPDATE provider_routes
+SET
+    service = CASE
+        WHEN operator_code IN (
+            SELECT operator_code FROM electricity_operators
+        ) THEN 'ELECTRICITY_BILL'
+        ELSE 'POSTPAID_MOBILE_RECHARGE'
+    END,
+    updated_at = NOW ()
+WHERE
+    service = 'BBPS';
+
+ALTER TABLE provider_routes
+ADD CONSTRAINT provider_routes_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'ELECTRICITY_BILL',
+        'DMT',
+        'AEPS'
+    )
+);
+
+DROP TABLE IF EXISTS bbps_transactions;
+
+DROP TABLE IF EXISTS bbps_biller_params;
+
+DROP TABLE IF EXISTS bbps_billers;
+
+DROP TABLE IF EXISTS bbps_categories;
@@ -0,0 +1,432 @@
+-- Postpaid recharges and electricity bills move to bbps_transactions,
+-- along with their past transactions. The old tables are kept as history,
+-- but nothing settles them any more, so they must not hold transactions
+-- still waiting on the provider.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM mobile_recharge_postpaid WHERE recharge_status IN ('INITIATED', 'PENDING')
+        UNION ALL
+        SELECT 1 FROM electricity_bill_payments WHERE transaction_status IN ('INITIATED', 'PENDING')
+    ) THEN
+        RAISE EXCEPTION 'settle pending postpaid recharges and electricity bill payments before migrating';
+    END IF;
+END
+$$;
+
+CREATE TABLE
+    IF NOT EXISTS bbps_categories (
+        category_code TEXT PRIMARY KEY,
+        category_name TEXT NOT NULL,
+        is_active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+INSERT INTO
+    bbps_categories (category_code, category_name)
+VALUES
+    ('MOBILE_POSTPAID', 'Mobile Postpaid'),
+    ('ELECTRICITY', 'Electricity'),
+    ('GAS', 'Piped Gas'),
+    ('WATER', 'Water'),
+    ('BROADBAND', 'Broadband'),
+    ('LANDLINE', 'Landline'),
+    ('LPG', 'LPG Cylinder'),
+    ('INSURANCE', 'Insurance'),
+    ('LOAN_EMI', 'Loan Repayment'),
+    ('FASTAG', 'FASTag')
+ON CONFLICT (category_code) DO NOTHING;
+
+CREATE TABLE
+    IF NOT EXISTS bbps_billers (
+        biller_id TEXT PRIMARY KEY,
+        biller_name TEXT NOT NULL,
+        category_code TEXT NOT NULL REFERENCES bbps_categories (category_code),
+        operator_code INTEGER NOT NULL,
+        fetch_required BOOLEAN NOT NULL DEFAULT FALSE,
+        is_active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+    );
+
+CREATE TABLE
+    IF NOT EXISTS bbps_biller_params (
+        param_id BIGSERIAL PRIMARY KEY,
+        biller_id TEXT NOT NULL REFERENCES bbps_billers (biller_id) ON DELETE CASCADE,
+        param_name TEXT NOT NULL,
+        display_name TEXT NOT NULL,
+        is_optional BOOLEAN NOT NULL DEFAULT FALSE,
+        min_length INTEGER NOT NULL DEFAULT 0 CHECK (min_length >= 0),
+        max_length INTEGER NOT NULL DEFAULT 0 CHECK (max_length >= 0),
+        regex TEXT NOT NULL DEFAULT '',
+        position INTEGER NOT NULL,
+        CONSTRAINT unique_bbps_biller_param UNIQUE (biller_id, param_name)
+    );
+
+CREATE TABLE
+    IF NOT EXISTS bbps_transactions (
+        bbps_transaction_id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+        partner_request_id UUID NOT NULL,
+        operator_transaction_id TEXT NOT NULL DEFAULT '',
+        order_id TEXT NOT NULL DEFAULT '',
+        retailer_id TEXT NOT NULL,
+        biller_id TEXT NOT NULL REFERENCES bbps_billers (biller_id),
+        biller_name TEXT NOT NULL,
+        category_code TEXT NOT NULL,
+        operator_code INTEGER NOT NULL,
+        customer_params JSONB NOT NULL DEFAULT '{}',
+        customer_email TEXT NOT NULL DEFAULT '',
+        amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+        commision NUMERIC(20, 2) NOT NULL DEFAULT 0,
+        provider TEXT NOT NULL DEFAULT '',
+        transaction_status TEXT NOT NULL CHECK (
+            transaction_status IN ('INITIATED', 'PENDING', 'SUCCESS', 'FAILED', 'REFUND')
+        ),
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+        CONSTRAINT unique_bbps_partner_request_id UNIQUE (partner_request_id),
+        FOREIGN KEY (retailer_id) REFERENCES retailers (retailer_id) ON DELETE CASCADE
+    );
+
+CREATE INDEX IF NOT EXISTS idx_bbps_billers_category_code ON bbps_billers (category_code)
+WHERE
+    is_active;
+
+CREATE INDEX IF NOT EXISTS idx_bbps_transactions_retailer_id ON bbps_transactions (retailer_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_bbps_transactions_category_code ON bbps_transactions (category_code, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_bbps_transactions_pending ON bbps_transactions (created_at)
+WHERE
+    transaction_status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_bbps_transactions_initiated ON bbps_transactions (created_at)
+WHERE
+    transaction_status = 'INITIATED';
+
+-- Electricity operators, and any operator past bill payments were made
+-- to, become billers paid with the consumer number they were paid with
+-- before.
+INSERT INTO
+    bbps_billers (biller_id, biller_name, category_code, operator_code)
+SELECT
+    'ELECTRICITY_' || operator_code,
+    MIN(operator_name),
+    'ELECTRICITY',
+    operator_code
+FROM
+    (
+        SELECT operator_code, operator_name FROM electricity_operators
+        UNION ALL
+        SELECT operator_code, operator_name FROM electricity_bill_payments
+    ) o
+GROUP BY
+    operator_code
+ON CONFLICT (biller_id) DO NOTHING;
+
+INSERT INTO
+    bbps_biller_params (biller_id, param_name, display_name, position)
+SELECT
+    biller_id,
+    'customer_id',
+    'Consumer Number',
+    1
+FROM
+    bbps_billers
+WHERE
+    category_code = 'ELECTRICITY'
+ON CONFLICT (biller_id, param_name) DO NOTHING;
+
+-- Postpaid recharges were paid against the mobile recharge operators with
+-- the mobile number and, optionally, the circle code.
+INSERT INTO
+    bbps_billers (biller_id, biller_name, category_code, operator_code)
+SELECT
+    'MOBILE_POSTPAID_' || operator_code,
+    MIN(operator_name),
+    'MOBILE_POSTPAID',
+    operator_code
+FROM
+    (
+        SELECT operator_code, operator_name FROM mobile_recharge_operators
+        UNION ALL
+        SELECT operator_code, operator_name FROM mobile_recharge_postpaid
+    ) o
+GROUP BY
+    operator_code
+ON CONFLICT (biller_id) DO NOTHING;
+
+INSERT INTO
+    bbps_biller_params (
+        biller_id,
+        param_name,
+        display_name,
+        is_optional,
+        min_length,
+        max_length,
+        regex,
+        position
+    )
+SELECT
+    b.biller_id,
+    p.param_name,
+    p.display_name,
+    p.is_optional,
+    p.min_length,
+    p.max_length,
+    p.regex,
+    p.position
+FROM
+    bbps_billers b
+    CROSS JOIN (
+        VALUES
+            ('mobile_number', 'Mobile Number', FALSE, 10, 10, '^[0-9]{10}$', 1),
+            ('circle', 'Circle Code', TRUE, 0, 0, '^[0-9]+$', 2)
+    ) AS p (param_name, display_name, is_optional, min_length, max_length, regex, position)
+WHERE
+    b.category_code = 'MOBILE_POSTPAID'
+ON CONFLICT (biller_id, param_name) DO NOTHING;
+
+ALTER TABLE provider_routes
+DROP CONSTRAINT IF EXISTS provider_routes_service_check;
+
+UPDATE provider_routes
+SET
+    service = 'BBPS',
+    updated_at = NOW ()
+WHERE
+    service IN ('POSTPAID_MOBILE_RECHARGE', 'ELECTRICITY_BILL');
+
+ALTER TABLE provider_routes
+ADD CONSTRAINT provider_routes_service_check CHECK (
+    service IN (
+        'PAYOUT',
+        'MOBILE_RECHARGE',
+        'DTH_RECHARGE',
+        'BBPS',
+        'DMT',
+        'AEPS'
+    )
+);
+
+ALTER TABLE wallet_transactions
+DROP CONSTRAINT IF EXISTS wallet_transactions_transaction_reason_check;
+
+ALTER TABLE wallet_transactions
+ADD CONSTRAINT wallet_transactions_transaction_reason_check CHECK (
+    transaction_reason IN (
+        'FUND_TRANSFER',
+        'FUND_REQUEST',
+        'MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE',
+        'POSTPAID_MOBILE_RECHARGE_REFUND',
+        'ELECTRICITY_BILL',
+        'ELECTRICITY_BILL_REFUND',
+        'MOBILE_RECHARGE_REFUND',
+        'DTH_RECHARGE_REFUND',
+        'PAYOUT_REFUND',
+        'DTH_RECHARGE',
+        'TOPUP',
+        'REVERT',
+        'PAYOUT',
+        'BENEFICIARY_VERIFICATION',
+        'ADJUSTMENT',
+        'COMMISION_RELEASE',
+        'DMT',
+        'DMT_REFUND',
+        'AEPS',
+        'AEPS_REFUND',
+        'AEPS_SETTLEMENT',
+        'AEPS_SETTLEMENT_REFUND',
+        'BBPS',
+        'BBPS_REFUND'
+    )
+);
+
+-- Past postpaid recharges and electricity bill payments are copied to
+-- bbps_transactions, and bbps_legacy_transactions maps each old id to its
+-- new one. Their ledger journals, wallet statement lines, commissions,
+-- refunds and status history move with them to the BBPS service, so they
+-- are listed and refunded like any other bill payment.
+CREATE TABLE
+    IF NOT EXISTS bbps_legacy_transactions (
+        service TEXT NOT NULL,
+        transaction_id TEXT NOT NULL,
+        bbps_transaction_id UUID NOT NULL UNIQUE,
+        PRIMARY KEY (service, transaction_id)
+    );
+
+INSERT INTO
+    bbps_legacy_transactions (service, transaction_id, bbps_transaction_id)
+SELECT
+    'POSTPAID_MOBILE_RECHARGE',
+    postpaid_recharge_transaction_id::TEXT,
+    gen_random_uuid ()
+FROM
+    mobile_recharge_postpaid
+UNION ALL
+SELECT
+    'ELECTRICITY_BILL',
+    electricity_bill_transaction_id::TEXT,
+    gen_random_uuid ()
+FROM
+    electricity_bill_payments
+ON CONFLICT (service, transaction_id) DO NOTHING;
+
+INSERT INTO
+    bbps_transactions (
+        bbps_transaction_id,
+        partner_request_id,
+        operator_transaction_id,
+        order_id,
+        retailer_id,
+        biller_id,
+        biller_name,
+        category_code,
+        operator_code,
+        customer_params,
+        customer_email,
+        amount,
+        commision,
+        provider,
+        transaction_status,
+        created_at,
+        updated_at
+    )
+SELECT
+    l.bbps_transaction_id,
+    t.partner_request_id::UUID,
+    COALESCE(t.operator_transaction_id, ''),
+    COALESCE(t.order_id, ''),
+    t.retailer_id,
+    'MOBILE_POSTPAID_' || t.operator_code,
+    t.operator_name,
+    'MOBILE_POSTPAID',
+    t.operator_code,
+    jsonb_build_object('mobile_number', t.mobile_number, 'circle', t.circle_code::TEXT),
+    '',
+    t.amount,
+    t.commision,
+    t.provider,
+    t.recharge_status,
+    t.created_at,
+    t.created_at
+FROM
+    mobile_recharge_postpaid t
+    JOIN bbps_legacy_transactions l ON l.service = 'POSTPAID_MOBILE_RECHARGE'
+    AND l.transaction_id = t.postpaid_recharge_transaction_id::TEXT
+UNION ALL
+SELECT
+    l.bbps_transaction_id,
+    t.partner_request_id::UUID,
+    COALESCE(t.operator_transaction_id, ''),
+    COALESCE(t.order_id, ''),
+    t.retailer_id,
+    'ELECTRICITY_' || t.operator_code,
+    t.operator_name,
+    'ELECTRICITY',
+    t.operator_code,
+    jsonb_build_object('customer_id', t.customer_id),
+    t.customer_email,
+    t.amount,
+    t.commision,
+    t.provider,
+    t.transaction_status,
+    t.created_at,
+    t.created_at
+FROM
+    electricity_bill_payments t
+    JOIN bbps_legacy_transactions l ON l.service = 'ELECTRICITY_BILL'
+    AND l.transaction_id = t.electricity_bill_transaction_id::TEXT
+ON CONFLICT (bbps_transaction_id) DO NOTHING;
+
+UPDATE ledger_journals j
+SET
+    reference_id = l.bbps_transaction_id::TEXT,
+    journal_reason = CASE
+        WHEN j.journal_reason = l.service THEN 'BBPS'
+        ELSE 'BBPS_REFUND'
+    END
+FROM
+    bbps_legacy_transactions l
+WHERE
+    j.reference_id = l.transaction_id
+    AND j.journal_reason IN (l.service, l.service || '_REFUND');
+
+UPDATE wallet_transactions w
+SET
+    reference_id = l.bbps_transaction_id::TEXT,
+    transaction_reason = CASE
+        WHEN w.transaction_reason = l.service THEN 'BBPS'
+        ELSE 'BBPS_REFUND'
+    END
+FROM
+    bbps_legacy_transactions l
+WHERE
+    w.reference_id = l.transaction_id
+    AND w.transaction_reason IN (l.service, l.service || '_REFUND');
+
+UPDATE wallet_reservations r
+SET
+    service = 'BBPS',
+    reference_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    r.service = l.service
+    AND r.reference_id = l.transaction_id;
+
+UPDATE pending_commisions c
+SET
+    service = 'BBPS',
+    transaction_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = l.service
+    AND c.transaction_id = l.transaction_id;
+
+UPDATE tds_commision c
+SET
+    service = 'BBPS',
+    transaction_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = l.service
+    AND c.transaction_id = l.transaction_id;
+
+UPDATE transaction_refunds f
+SET
+    service = 'BBPS',
+    transaction_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    f.service = l.service
+    AND f.transaction_id = l.transaction_id;
+
+UPDATE transaction_status_history h
+SET
+    service = 'BBPS',
+    transaction_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    h.service = l.service
+    AND h.transaction_id = l.transaction_id;
+
+UPDATE provider_callbacks c
+SET
+    service = 'BBPS',
+    transaction_id = l.bbps_transaction_id::TEXT
+FROM
+    bbps_legacy_transactions l
+WHERE
+    c.service = l.service
+    AND c.transaction_id = l.transaction_id;
+
+DELETE FROM status_checks s USING bbps_legacy_transactions l
+WHERE
+    s.service = l.service
+    AND s.transaction_id = l.transaction_id;
@@ -2,10 +2,13 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 func (db *Database) GetAllMobileRechargeOperatorsQuery(
@@ -66,189 +69,64 @@ func (db *Database) GetAllMobileRechargeCirclesQuery(
 	return circles, res.Err()
 }
 
-func (db *Database) CreateMobileRechargeSuccessOrPendingQuery(
+// ReserveMobileRechargeQuery records a pending recharge and reserves its
+// cost from the retailer wallet. The retailer's commission is a discount,
+// so only the amount minus the commission, plus the TDS on it, is reserved;
+// the commission pool funds the rest on settlement.
+func (db *Database) ReserveMobileRechargeQuery(
 	ctx context.Context,
 	req models.CreateMobileRechargeRequestModel,
-) error {
-	if req.Amount <= 99 {
-		return db.mobileRechargeWithoutCommision(ctx, req)
-	}
-	return db.mobileRechargeWithCommision(ctx, req)
-}
-
-func (db *Database) CreateMobileRechargeFailedQuery(
-	ctx context.Context,
-	req models.CreateMobileRechargeRequestModel,
-) error {
-	insertToMobileRechargeTable := `
-		INSERT INTO mobile_recharge (
-    		retailer_id,
-    		partner_request_id,
-    		mobile_number,
-    		operator_name,
-    		circle_name,
-    		operator_code,
-    		circle_code,
-    		amount,
-    		commision,
-    		recharge_type,
-			status
-		) VALUES (
-    		@retailer_id,
-    		@partner_request_id,
-    		@mobile_number,
-    		@operator_name,
-    		@circle_name,
-    		@operator_code,
-    		@circle_code,
-    		@amount,
-    		@commision,
-    		@recharge_type,
-			@status
-		)
-		RETURNING mobile_recharge_transaction_id AS transaction_id;
-	`
-	if _, err := db.pool.Exec(ctx, insertToMobileRechargeTable, pgx.NamedArgs{
-		"retailer_id":        req.RetailerID,
-		"partner_request_id": req.PartnerRequestID,
-		"mobile_number":      fmt.Sprintf("%d", req.MobileNumber),
-		"operator_name":      req.OperatorName,
-		"circle_name":        req.CircleName,
-		"operator_code":      req.OperatorCode,
-		"circle_code":        req.CircleCode,
-		"amount":             req.Amount,
-		"commision":          0,
-		"recharge_type":      1,
-		"status":             req.Status,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) mobileRechargeWithoutCommision(
-	ctx context.Context,
-	req models.CreateMobileRechargeRequestModel,
-) error {
+) (string, error) {
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer tx.Rollback(ctx)
 
-	getRetailerBeforeBalance := `
-		SELECT retailer_wallet_balance AS before_balance 
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var beforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerBeforeBalance, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&beforeBalance); err != nil {
-		return err
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerID); err != nil {
+		return "", err
 	}
 
-	deductAmountFromRetailerQuery := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance as after_balance;
-	`
-	var afterBalance float64
-	if err := tx.QueryRow(ctx, deductAmountFromRetailerQuery, pgx.NamedArgs{
-		"amount":      req.Amount,
-		"retailer_id": req.RetailerID,
-	}).Scan(&afterBalance); err != nil {
-		return err
+	quote, err := quoteTransaction(ctx, tx, "MOBILE_RECHARGE", req.RetailerID, req.OperatorCode, req.Amount)
+	if err != nil {
+		return "", err
 	}
+	split := &quote.Commision
+	commision := split.RetailerCommision
 
-	insertToMobileRechargeTable := `
-		INSERT INTO mobile_recharge (
-    		retailer_id,
-    		partner_request_id,
-    		mobile_number,
-    		operator_name,
-    		circle_name,
-    		operator_code,
-    		circle_code,
-    		amount,
-    		commision,
-    		recharge_type,
-			status
-		) VALUES (
-    		@retailer_id,
-    		@partner_request_id,
-    		@mobile_number,
-    		@operator_name,
-    		@circle_name,
-    		@operator_code,
-    		@circle_code,
-    		@amount,
-    		@commision,
-    		@recharge_type,
-			@status
-		)
-		RETURNING mobile_recharge_transaction_id AS transaction_id;
-	`
-	var transactionId string
-	if err := tx.QueryRow(ctx, insertToMobileRechargeTable, pgx.NamedArgs{
-		"retailer_id":        req.RetailerID,
-		"partner_request_id": req.PartnerRequestID,
-		"mobile_number":      fmt.Sprintf("%d", req.MobileNumber),
-		"operator_name":      req.OperatorName,
-		"circle_name":        req.CircleName,
-		"operator_code":      req.OperatorCode,
-		"circle_code":        req.CircleCode,
-		"amount":             req.Amount,
-		"commision":          0,
-		"recharge_type":      1,
-		"status":             req.Status,
-	}).Scan(&transactionId); err != nil {
-		return err
+	req.Status = txstate.Initiated
+	transactionID, err := insertMobileRecharge(ctx, tx, req, split)
+	if err != nil {
+		return "", err
 	}
-
-	insertToWalletTransactionsTable := `
-		INSERT INTO wallet_transactions (
-    		user_id,
-    		reference_id,
-    		debit_amount,
-    		before_balance,
-    		after_balance,
-    		transaction_reason,
-    		remarks
-		) VALUES (
-    		@user_id,
-    		@reference_id,
-    		@debit_amount,
-    		@before_balance,
-    		@after_balance,
-    		@transaction_reason,
-    		@remarks
-		);
-	`
-	if _, err := tx.Exec(ctx, insertToWalletTransactionsTable, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       transactionId,
-		"debit_amount":       req.Amount,
-		"before_balance":     beforeBalance,
-		"after_balance":      afterBalance,
-		"transaction_reason": "MOBILE_RECHARGE",
-		"remarks":            fmt.Sprintf("Mobile Recharge to: %d", req.MobileNumber),
-	}); err != nil {
-		return err
+	if err := txstate.Created(ctx, tx, "MOBILE_RECHARGE", transactionID, req.Status, txstate.Retailer(req.RetailerID)); err != nil {
+		return "", err
+	}
+	if err := accruePendingCommisions(ctx, tx, transactionID, quote); err != nil {
+		return "", err
 	}
 
-	// CRITICAL: Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return err
+	remarks := fmt.Sprintf("Mobile Recharge to: %d", req.MobileNumber)
+	if commision > 0 {
+		remarks = fmt.Sprintf("Mobile Recharge to: %d (Commission: ₹%s)", req.MobileNumber, commision)
+	}
+	if err := reserveWallet(ctx, tx, "MOBILE_RECHARGE", transactionID, req.RetailerID, quote.Debit, remarks); err != nil {
+		return "", err
 	}
 
-	return nil
+	return transactionID, tx.Commit(ctx)
 }
 
-func (db *Database) mobileRechargeWithCommision(
+// SettleMobileRechargeQuery applies the provider's answer to a pending
+// recharge: SUCCESS pays the provider from the reservation and the
+// hierarchy's commission from the commission pool, FAILED returns the
+// reservation to the retailer.
+func (db *Database) SettleMobileRechargeQuery(
 	ctx context.Context,
-	req models.CreateMobileRechargeRequestModel,
+	transactionID string,
+	status string,
+	actor txstate.Actor,
+	reason string,
 ) error {
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
@@ -256,74 +134,63 @@ func (db *Database) mobileRechargeWithCommision(
 	}
 	defer tx.Rollback(ctx)
 
-	getAdminBeforeBalanceQuery := `
-		SELECT ad.admin_wallet_balance, ad.admin_id
-		FROM retailers AS r
-		LEFT JOIN distributors AS d
-    		ON r.distributor_id = d.distributor_id
-		LEFT JOIN master_distributors AS md
-    		ON d.master_distributor_id = md.master_distributor_id
-		LEFT JOIN admins AS ad
-    		ON md.admin_id = ad.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-	var adminBeforeBalance float64
-	var adminID string
-	if err := tx.QueryRow(ctx, getAdminBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&adminBeforeBalance, &adminID); err != nil {
+	recharge, err := lockMobileRecharge(ctx, tx, transactionID)
+	if err != nil {
 		return err
 	}
-
-	// Deduct commission from admin wallet
-	deductAdminAmountAndGetAfterBalanceQuery := `
-		UPDATE admins AS ad
-		SET admin_wallet_balance = admin_wallet_balance - @commision
-		FROM retailers AS r
-		LEFT JOIN distributors AS d
-    		ON r.distributor_id = d.distributor_id
-		LEFT JOIN master_distributors AS md
-    		ON d.master_distributor_id = md.master_distributor_id
-		WHERE r.retailer_id = @retailer_id
-    		AND md.admin_id = ad.admin_id
-		RETURNING ad.admin_wallet_balance AS admin_after_balance;
-	`
-	var adminAfterBalance float64
-	if err := tx.QueryRow(ctx, deductAdminAmountAndGetAfterBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-		"commision":   1,
-	}).Scan(&adminAfterBalance); err != nil {
-		return err
+	if status == recharge.status {
+		return nil
 	}
-
-	getRetailerBeforeBalanceQuery := `
-		SELECT retailer_wallet_balance AS retailer_before_balance
-		FROM retailers
-		WHERE retailer_id = @retailer_id;
-	`
-	var retailerBeforeBalance float64
-	if err := tx.QueryRow(ctx, getRetailerBeforeBalanceQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerBeforeBalance); err != nil {
+	if err := txstate.Check(recharge.status, status, actor); err != nil {
 		return err
 	}
 
-	// Deduct (amount - commission) from retailer
-	// Retailer pays less because they get commission benefit
-	deductAmountFromRetailerQuery := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance AS retailer_after_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, deductAmountFromRetailerQuery, pgx.NamedArgs{
-		"amount":      req.Amount - 1, // Amount minus commission
-		"retailer_id": req.RetailerID,
-	}).Scan(&retailerAfterBalance); err != nil {
+	switch status {
+	case "SUCCESS":
+		r, err := lockReservation(ctx, tx, "MOBILE_RECHARGE", transactionID)
+		if err != nil {
+			return err
+		}
+		// Recharges created before reservations were paid in full at
+		// creation.
+		if r != nil {
+			journal := ledger.NewJournal(transactionID, "MOBILE_RECHARGE", fmt.Sprintf("Mobile Recharge to: %s", recharge.mobileNumber)).
+				Credit(ledger.ProviderFloat, recharge.amount, "")
+			if err := fundCommision(ctx, tx, journal, r, recharge.retailerID, &recharge.commision); err != nil {
+				return err
+			}
+			if err := settleReservation(ctx, tx, r, journal); err != nil {
+				return err
+			}
+		}
+	case "FAILED":
+		if err := refundFailed(ctx, tx, &serviceTransaction{"MOBILE_RECHARGE", transactionID, recharge.retailerID, recharge.amount, recharge.status}); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid recharge status")
+	}
+
+	if err := txstate.Transition(ctx, tx, txstate.Change{
+		Service:       "MOBILE_RECHARGE",
+		TransactionID: transactionID,
+		From:          recharge.status,
+		To:            status,
+		Actor:         actor,
+		Reason:        reason,
+	}); err != nil {
 		return err
 	}
+	return tx.Commit(ctx)
+}
 
+func insertMobileRecharge(
+	ctx context.Context,
+	tx pgx.Tx,
+	req models.CreateMobileRechargeRequestModel,
+	split *models.CommisionSplitModel,
+) (string, error) {
 	insertToMobileRechargeTableQuery := `
 		INSERT INTO mobile_recharge (
     		retailer_id,
@@ -335,6 +202,8 @@ func (db *Database) mobileRechargeWithCommision(
     		circle_code,
     		amount,
     		commision,
+    		distributor_commision,
+    		master_distributor_commision,
     		recharge_type,
 			status
 		) VALUES (
@@ -347,6 +216,8 @@ func (db *Database) mobileRechargeWithCommision(
     		@circle_code,
     		@amount,
     		@commision,
+    		@dis_commision,
+    		@md_commision,
     		@recharge_type,
 			@status
 		)
@@ -362,83 +233,51 @@ func (db *Database) mobileRechargeWithCommision(
 		"operator_code":      req.OperatorCode,
 		"circle_code":        req.CircleCode,
 		"amount":             req.Amount,
-		"commision":          1,
+		"commision":          split.RetailerCommision,
+		"dis_commision":      split.DistributorCommision,
+		"md_commision":       split.MasterDistributorCommision,
 		"recharge_type":      1,
 		"status":             req.Status,
 	}).Scan(&transactionID); err != nil {
-		return err
+		return "", err
 	}
+	return transactionID, nil
+}
 
-	// Record admin's commission deduction
-	insertToAdminWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-    		user_id,
-    		reference_id,
-    		debit_amount,
-    		before_balance,
-    		after_balance,
-    		transaction_reason,
-    		remarks
-		) VALUES (
-    		@user_id,
-    		@reference_id,
-    		@debit_amount,
-    		@before_balance,
-    		@after_balance,
-    		@transaction_reason,
-    		@remarks
-		);
-	`
-	if _, err := tx.Exec(ctx, insertToAdminWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            adminID,
-		"reference_id":       transactionID,
-		"debit_amount":       1,
-		"before_balance":     adminBeforeBalance,
-		"after_balance":      adminAfterBalance,
-		"transaction_reason": "MOBILE_RECHARGE",
-		"remarks":            fmt.Sprintf("Commission for Retailer: %s", req.RetailerID),
-	}); err != nil {
-		return err
-	}
+type lockedMobileRecharge struct {
+	transactionID string
+	retailerID    string
+	mobileNumber  string
+	amount        models.Money
+	commision     models.CommisionSplitModel
+	status        string
+}
 
-	// Record retailer's transaction (debit for recharge amount minus commission received)
-	insertToRetailerWalletTransactionsQuery := `
-		INSERT INTO wallet_transactions (
-    		user_id,
-    		reference_id,
-    		debit_amount,
-    		before_balance,
-    		after_balance,
-    		transaction_reason,
-    		remarks
-		) VALUES (
-    		@user_id,
-    		@reference_id,
-    		@debit_amount,
-    		@before_balance,
-    		@after_balance,
-    		@transaction_reason,
-    		@remarks
-		);
+func lockMobileRecharge(ctx context.Context, tx pgx.Tx, transactionID string) (*lockedMobileRecharge, error) {
+	query := `
+		SELECT retailer_id, mobile_number, amount, commision, distributor_commision, master_distributor_commision, status
+		FROM mobile_recharge
+		WHERE mobile_recharge_transaction_id = @transaction_id::BIGINT
+		FOR UPDATE;
 	`
-	if _, err := tx.Exec(ctx, insertToRetailerWalletTransactionsQuery, pgx.NamedArgs{
-		"user_id":            req.RetailerID,
-		"reference_id":       transactionID,
-		"debit_amount":       req.Amount - 1, // Net amount paid by retailer
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "MOBILE_RECHARGE",
-		"remarks":            fmt.Sprintf("Mobile Recharge to: %d (Commission: ₹1)", req.MobileNumber),
-	}); err != nil {
-		return err
-	}
-
-	// CRITICAL: Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return err
+	m := lockedMobileRecharge{transactionID: transactionID}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(
+		&m.retailerID,
+		&m.mobileNumber,
+		&m.amount,
+		&m.commision.RetailerCommision,
+		&m.commision.DistributorCommision,
+		&m.commision.MasterDistributorCommision,
+		&m.status,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid transaction id")
+		}
+		return nil, err
 	}
-
-	return nil
+	return &m, nil
 }
 
 func (db *Database) GetAllMobileRechargesQuery(
@@ -509,18 +348,6 @@ func (db *Database) GetAllMobileRechargesQuery(
 			return nil, err
 		}
 		fmt.Sscanf(mobileNumber, "%d", &recharge.MobileNumber)
-		if recharge.Status == "PENDING" {
-			newStatus, err := db.RechargeStatusCheck(recharge.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateRechargeStatus(ctx, newStatus, recharge.MobileRechargeTransactionID); err != nil {
-					return nil, err
-				}
-				recharge.Status = newStatus
-			}
-		}
 		history = append(history, recharge)
 	}
 	return history, res.Err()
@@ -598,192 +425,7 @@ func (db *Database) GetMobileRechargesByRetailerIDQuery(
 		}
 		// Convert string to int64
 		fmt.Sscanf(mobileNumber, "%d", &recharge.MobileNumber)
-		if recharge.Status == "PENDING" {
-			newStatus, err := db.RechargeStatusCheck(recharge.PartnerRequestID)
-			if err != nil {
-				return nil, err
-			}
-			if newStatus != "PENDING" {
-				if err := db.UpdateRechargeStatus(ctx, newStatus, recharge.MobileRechargeTransactionID); err != nil {
-					return nil, err
-				}
-				recharge.Status = newStatus
-			}
-		}
 		history = append(history, recharge)
 	}
 	return history, res.Err()
 }
-
-func (db *Database) UpdateRechargeStatus(
-	ctx context.Context,
-	status string,
-	transactionID int,
-) error {
-	query := `
-		UPDATE mobile_recharge 
-		SET status = @status
-		WHERE mobile_recharge_transaction_id=@transaction_id;
-	`
-	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
-		"status":         status,
-		"transaction_id": transactionID,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) MobileRechargeRefundQuery(
-	ctx context.Context,
-	transactionId string,
-) error {
-	tx, err := db.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	getDetailsQuery := `
-		SELECT retailer_id, amount, commision
-		FROM mobile_recharge
-		WHERE mobile_recharge_transaction_id = @transaction_id::BIGINT;
-	`
-	var (
-		retailerId string
-		amount     float64
-		commision  float64
-	)
-	if err := tx.QueryRow(ctx, getDetailsQuery, pgx.NamedArgs{
-		"transaction_id": transactionId,
-	}).Scan(
-		&retailerId,
-		&amount,
-		&commision,
-	); err != nil {
-		return err
-	}
-
-	getUserDetails := `
-		SELECT r.retailer_wallet_balance, a.admin_id, a.admin_wallet_balance
-		FROM retailers r
-		JOIN distributors d
-			ON d.distributor_id = r.distributor_id
-		JOIN master_distributors m
-			ON m.master_distributor_id = d.master_distributor_id
-		JOIN admins a
-			ON a.admin_id = m.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-	var (
-		retailerBeforeBalance float64
-		adminId               string
-		adminBeforeBalance    float64
-	)
-	if err := tx.QueryRow(ctx, getUserDetails, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(
-		&retailerBeforeBalance,
-		&adminId,
-		&adminBeforeBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToWalletTransactions := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		);
-	`
-
-	if commision > 0 {
-		updateAdminWallet := `
-			UPDATE admins
-			SET admin_wallet_balance = admin_wallet_balance + @commision
-			WHERE admin_id = @admin_id
-			RETURNING admin_wallet_balance;
-		`
-		var adminAfterBalance float64
-		if err := tx.QueryRow(ctx, updateAdminWallet, pgx.NamedArgs{
-			"admin_id":  adminId,
-			"commision": commision,
-		}).Scan(
-			&adminAfterBalance,
-		); err != nil {
-			return err
-		}
-
-		if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-			"user_id":            adminId,
-			"reference_id":       transactionId,
-			"credit_amount":      commision,
-			"debit_amount":       0,
-			"before_balance":     adminBeforeBalance,
-			"after_balance":      adminAfterBalance,
-			"transaction_reason": "MOBILE_RECHARGE_REFUND",
-			"remarks":            fmt.Sprintf("Refund from %s", retailerId),
-		}); err != nil {
-			return err
-		}
-	}
-
-	updateRetailerWallet := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance + @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailerWallet, pgx.NamedArgs{
-		"retailer_id": retailerId,
-		"amount":      amount,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            retailerId,
-		"reference_id":       transactionId,
-		"credit_amount":      amount,
-		"debit_amount":       0,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "MOBILE_RECHARGE_REFUND",
-		"remarks":            fmt.Sprintf("Refund of transaction %s", transactionId),
-	}); err != nil {
-		return err
-	}
-
-	fmt.Println(transactionId)
-
-	query := `
-		UPDATE mobile_recharge
-		SET status = 'REFUND'
-		WHERE mobile_recharge_transaction_id = @transaction_id;
-	`
-
-	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
-		"transaction_id": transactionId,
-	}); err != nil {
-		return err
-	}
-
-	return tx.Commit(ctx)
-}
@@ -2,182 +2,53 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
-func (db *Database) GetPayoutCommisionQuery(
-	ctx context.Context,
-	retailerId string,
-	amount float64,
-) (*models.GetPayoutCommisionModel, error) {
-
-	var (
-		adminId             string
-		masterDistributorId string
-		distributorId       string
-	)
-
-	hierarchyQuery := `
-		SELECT a.admin_id, md.master_distributor_id, d.distributor_id
-		FROM retailers r
-		JOIN distributors d ON d.distributor_id = r.distributor_id
-		JOIN master_distributors md ON md.master_distributor_id = d.master_distributor_id
-		JOIN admins a ON a.admin_id = md.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-
-	if err := db.pool.QueryRow(ctx, hierarchyQuery, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(&adminId, &masterDistributorId, &distributorId); err != nil {
-		return nil, err
-	}
-
-	getCommission := func(userId string) (*models.GetPayoutCommisionModel, error) {
-		query := `
-			SELECT 
-				total_commision,
-				admin_commision,
-				master_distributor_commision,
-				distributor_commision,
-				retailer_commision
-			FROM commisions
-			WHERE user_id=@user_id AND service='PAYOUT'
-			LIMIT 1;
-		`
-
-		var c models.GetPayoutCommisionModel
-		err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
-			"user_id": userId,
-		}).Scan(
-			&c.TotalCommision,
-			&c.AdminCommision,
-			&c.MasterDistributorCommision,
-			&c.DistributorCommision,
-			&c.RetailerCommision,
-		)
-
-		if err == pgx.ErrNoRows {
-			return nil, nil
-		}
-		if err != nil {
-			return nil, err
-		}
-		return &c, nil
+// commisionPoolEntry books the part of the total commission that is not
+// assigned to any wallet (when the configured shares do not add up to one,
+// or the TDS rate changed since the reservation) against the commission
+// pool, which keeps the journal balanced.
+func commisionPoolEntry(j *ledger.Journal, rest models.Money) {
+	if rest > 0 {
+		j.Credit(ledger.CommisionPool, rest, "Unallocated commission")
+	} else {
+		j.Debit(ledger.CommisionPool, -rest, "Commission over-allocation")
 	}
-
-	var commission *models.GetPayoutCommisionModel
-
-	ids := []string{
-		retailerId,
-		distributorId,
-		masterDistributorId,
-	}
-
-	for _, id := range ids {
-		c, err := getCommission(id)
-		if err != nil {
-			return nil, err
-		}
-		if c != nil {
-			commission = c
-			break
-		}
-	}
-
-	// Default commission if nothing found
-	if commission == nil {
-		commission = &models.GetPayoutCommisionModel{
-			TotalCommision:             1.2,
-			RetailerCommision:          0.5,
-			DistributorCommision:       0.2,
-			MasterDistributorCommision: 0.05,
-			AdminCommision:             0.25,
-		}
-	}
-
-	// Final calculation (percentage → amount)
-	totalAmount := (commission.TotalCommision / 100) * amount
-
-	return &models.GetPayoutCommisionModel{
-		TotalCommision:             totalAmount,
-		RetailerCommision:          totalAmount * commission.RetailerCommision,
-		DistributorCommision:       totalAmount * commission.DistributorCommision,
-		MasterDistributorCommision: totalAmount * commission.MasterDistributorCommision,
-		AdminCommision:             totalAmount * commission.AdminCommision,
-	}, nil
 }
 
-func (db *Database) VerifyRetailerForTransactionQuery(
-	ctx context.Context,
-	retailerId string,
-	minWallerBalance float64,
-) error {
-	var (
-		retailerWalletBalance float64
-		retailerKYCStatus     bool
-		retailerBlockStatus   bool
-	)
-	query := `
-		SELECT retailer_wallet_balance, retailer_kyc_status,
-		is_retailer_blocked
-		FROM retailers
-		WHERE retailer_id=@retailer_id;
-	`
-	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(
-		&retailerWalletBalance,
-		&retailerKYCStatus,
-		&retailerBlockStatus,
-	); err != nil {
-		return err
-	}
-
-	if retailerWalletBalance < minWallerBalance {
-		return fmt.Errorf("insufficient wallet balance")
-	}
-
-	if retailerKYCStatus == false {
-		return fmt.Errorf("retailer kyc is pending")
-	}
-
-	if retailerBlockStatus == true {
-		return fmt.Errorf("retailer is blocked")
-	}
-	return nil
-}
-
-func (db *Database) CreatePayoutSuccessOrPendingQuery(
+// ReservePayoutQuery records a pending payout and reserves the amount plus
+// the charge net of the retailer's own commission, and the TDS on that
+// commission, from the retailer wallet. It returns the payout transaction
+// id.
+func (db *Database) ReservePayoutQuery(
 	ctx context.Context,
 	req models.CreatePayoutRequestModel,
-	commision models.GetPayoutCommisionModel,
-) error {
+) (string, error) {
 
 	tx, err := db.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer tx.Rollback(ctx)
 
-	var userDetails struct {
-		adminId               string
-		adminBeforeBalance    float64
-		adminAfterBalance     float64
-		mdId                  string
-		mdBeforeBalance       float64
-		mdAfterBalance        float64
-		disId                 string
-		disBeforeBalance      float64
-		disAfterBalance       float64
-		retailerBeforeBalance float64
-		retailerAfterBalance  float64
-		transactionId         string
+	// 1️⃣ Lock the retailer
+	if err := lockRetailerForTransaction(ctx, tx, req.RetailerId); err != nil {
+		return "", err
+	}
+	quote, err := quoteTransaction(ctx, tx, "PAYOUT", req.RetailerId, 0, req.Amount)
+	if err != nil {
+		return "", err
 	}
+	commision := quote.Commision
 
-	// 1️⃣ Insert payout transaction
+	// 2️⃣ Insert payout transaction
 	insertToPayoutTransactionQuery := `
 		INSERT INTO payout_transactions (
 			partner_request_id,
@@ -198,8 +69,8 @@ func (db *Database) CreatePayoutSuccessOrPendingQuery(
 			payout_transaction_status
 		) VALUES (
 			@partner_request_id,
-			@operator_transaction_id,
-			@order_id,
+			'',
+			'',
 			@retailer_id,
 			@mobile_number,
 			@bank_name,
@@ -226,280 +97,209 @@ func (db *Database) CreatePayoutSuccessOrPendingQuery(
 		transferType = "NEFT"
 	}
 
+	var transactionId string
 	if err := tx.QueryRow(ctx, insertToPayoutTransactionQuery, pgx.NamedArgs{
-		"partner_request_id":      req.PartnerRequestId,
-		"operator_transaction_id": req.OperatorTransactionId,
-		"order_id":                req.OrderId,
-		"retailer_id":             req.RetailerId,
-		"mobile_number":           req.MobileNumber,
-		"bank_name":               req.BankName,
-		"beneficiary_name":        req.BeneficiaryName,
-		"account_number":          req.AccountNumber,
-		"ifsc_code":               req.IFSCCode,
-		"amount":                  req.Amount,
-		"transfer_type":           transferType,
-		"admin_commision":         commision.AdminCommision,
-		"md_commision":            commision.MasterDistributorCommision,
-		"dis_commision":           commision.DistributorCommision,
-		"retailer_commision":      commision.RetailerCommision,
-		"status":                  req.TransactionStatus,
-	}).Scan(&userDetails.transactionId); err != nil {
-		return err
-	}
+		"partner_request_id": req.PartnerRequestId,
+		"retailer_id":        req.RetailerId,
+		"mobile_number":      req.MobileNumber,
+		"bank_name":          req.BankName,
+		"beneficiary_name":   req.BeneficiaryName,
+		"account_number":     req.AccountNumber,
+		"ifsc_code":          req.IFSCCode,
+		"amount":             req.Amount,
+		"transfer_type":      transferType,
+		"admin_commision":    commision.AdminCommision,
+		"md_commision":       commision.MasterDistributorCommision,
+		"dis_commision":      commision.DistributorCommision,
+		"retailer_commision": commision.RetailerCommision,
+		"status":             txstate.Initiated,
+	}).Scan(&transactionId); err != nil {
+		return "", err
+	}
+	if err := txstate.Created(ctx, tx, "PAYOUT", transactionId, txstate.Initiated, txstate.Retailer(req.RetailerId)); err != nil {
+		return "", err
+	}
+	if err := accruePendingCommisions(ctx, tx, transactionId, quote); err != nil {
+		return "", err
+	}
+
+	// 3️⃣ Reserve the debit
+	if err := reserveWallet(ctx, tx, "PAYOUT", transactionId, req.RetailerId, quote.Debit, "Payout amount debited"); err != nil {
+		return "", err
+	}
+
+	// 4️⃣ Commit
+	return transactionId, tx.Commit(ctx)
+}
 
-	// 2️⃣ Fetch balances & hierarchy
-	getUserDetailsQuery := `
-		SELECT 
-			r.retailer_wallet_balance,
-			d.distributor_id,
-			d.distributor_wallet_balance,
-			m.master_distributor_id,
-			m.master_distributor_wallet_balance,
-			a.admin_id,
-			a.admin_wallet_balance
-		FROM retailers r
-		JOIN distributors d ON d.distributor_id = r.distributor_id
-		JOIN master_distributors m ON m.master_distributor_id = d.master_distributor_id
-		JOIN admins a ON a.admin_id = m.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
+// SettlePayoutQuery applies the provider's answer to a pending payout. On
+// SUCCESS the reservation pays the provider and the hierarchy commissions,
+// on FAILED it is returned to the retailer and on PENDING only the provider
+// references are stored.
+func (db *Database) SettlePayoutQuery(
+	ctx context.Context,
+	transactionId string,
+	status string,
+	orderId string,
+	operatorTransactionId string,
+	actor txstate.Actor,
+	reason string,
+) error {
 
-	if err := tx.QueryRow(ctx, getUserDetailsQuery, pgx.NamedArgs{
-		"retailer_id": req.RetailerId,
-	}).Scan(
-		&userDetails.retailerBeforeBalance,
-		&userDetails.disId,
-		&userDetails.disBeforeBalance,
-		&userDetails.mdId,
-		&userDetails.mdBeforeBalance,
-		&userDetails.adminId,
-		&userDetails.adminBeforeBalance,
-	); err != nil {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	// 3️⃣ Update admin wallet
-	updateAdminWalletBalanceQuery := `
-		UPDATE admins
-		SET admin_wallet_balance = admin_wallet_balance + @commision
-		WHERE admin_id = @admin_id
-		RETURNING admin_wallet_balance;
-	`
-
-	if err := tx.QueryRow(ctx, updateAdminWalletBalanceQuery, pgx.NamedArgs{
-		"admin_id":  userDetails.adminId,
-		"commision": commision.AdminCommision,
-	}).Scan(&userDetails.adminAfterBalance); err != nil {
+	payout, err := lockPayoutTransaction(ctx, tx, transactionId)
+	if err != nil {
 		return err
 	}
-
-	// 4️⃣ Update master distributor wallet
-	updateMasterDistributorWalletBalanceQuery := `
-		UPDATE master_distributors
-		SET master_distributor_wallet_balance =
-			master_distributor_wallet_balance + @commision
-		WHERE master_distributor_id = @md_id
-		RETURNING master_distributor_wallet_balance;
-	`
-
-	if err := tx.QueryRow(ctx, updateMasterDistributorWalletBalanceQuery, pgx.NamedArgs{
-		"md_id":     userDetails.mdId,
-		"commision": commision.MasterDistributorCommision,
-	}).Scan(&userDetails.mdAfterBalance); err != nil {
-		return err
+	if status == payout.status && status != txstate.Pending {
+		return nil
 	}
-
-	// 5️⃣ Update distributor wallet
-	updateDistributorWalletBalanceQuery := `
-		UPDATE distributors
-		SET distributor_wallet_balance =
-			distributor_wallet_balance + @commision
-		WHERE distributor_id = @dis_id
-		RETURNING distributor_wallet_balance;
-	`
-
-	if err := tx.QueryRow(ctx, updateDistributorWalletBalanceQuery, pgx.NamedArgs{
-		"dis_id":    userDetails.disId,
-		"commision": commision.DistributorCommision,
-	}).Scan(&userDetails.disAfterBalance); err != nil {
-		return err
+	if status != payout.status {
+		if err := txstate.Check(payout.status, status, actor); err != nil {
+			return err
+		}
 	}
 
-	// 6️⃣ Update retailer wallet
-	updateRetailerWalletBalanceQuery := `
-		UPDATE retailers
-		SET retailer_wallet_balance = retailer_wallet_balance - @after_balance
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
+	switch status {
+	case "SUCCESS":
+		r, err := lockReservation(ctx, tx, "PAYOUT", transactionId)
+		if err != nil {
+			return err
+		}
+		// Payouts created before reservations were debited and paid out
+		// in full at creation.
+		if r != nil {
+			remarks := fmt.Sprintf("Payout commission credited from %s", payout.retailerId)
+			journal := ledger.NewJournal(transactionId, "PAYOUT", remarks).
+				Credit(ledger.ProviderFloat, payout.amount, "Payout amount sent to provider")
+			for _, c := range []struct {
+				userID    string
+				commision models.Money
+			}{
+				{payout.adminId, payout.adminCommision},
+				{payout.mdId, payout.mdCommision},
+				{payout.disId, payout.disCommision},
+			} {
+				if err := creditCommision(ctx, tx, journal, "PAYOUT", transactionId, c.userID, c.commision, remarks); err != nil {
+					return err
+				}
+			}
+			if err := withholdCommisionTDS(ctx, tx, journal, "PAYOUT", transactionId, payout.retailerId, payout.retailerCommision); err != nil {
+				return err
+			}
+			_, credits := journal.Totals()
+			commisionPoolEntry(journal, r.Amount-credits)
 
-	if err := tx.QueryRow(ctx, updateRetailerWalletBalanceQuery, pgx.NamedArgs{
-		"retailer_id":   req.RetailerId,
-		"after_balance": req.Amount + (commision.TotalCommision - commision.RetailerCommision),
-	}).Scan(&userDetails.retailerAfterBalance); err != nil {
-		return err
+			if err := settleReservation(ctx, tx, r, journal); err != nil {
+				return err
+			}
+		}
+	case "FAILED":
+		if err := refundFailed(ctx, tx, &serviceTransaction{"PAYOUT", transactionId, payout.retailerId, payout.amount, payout.status}); err != nil {
+			return err
+		}
+	case "PENDING":
+	default:
+		return fmt.Errorf("invalid payout status")
 	}
 
-	// 7️⃣ Wallet transactions
-
-	insertToWalletTransactions := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		);
+	updatePayoutQuery := `
+		UPDATE payout_transactions
+		SET order_id = COALESCE(NULLIF(@order_id, ''), order_id),
+			operator_transaction_id = COALESCE(NULLIF(@operator_transaction_id, ''), operator_transaction_id),
+			updated_at = NOW()
+		WHERE payout_transaction_id = @transaction_id;
 	`
-
-	// Admin
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            userDetails.adminId,
-		"reference_id":       userDetails.transactionId,
-		"credit_amount":      commision.AdminCommision,
-		"debit_amount":       0,
-		"before_balance":     userDetails.adminBeforeBalance,
-		"after_balance":      userDetails.adminAfterBalance,
-		"transaction_reason": "PAYOUT",
-		"remarks":            fmt.Sprintf("Payout commission credited from %s", req.RetailerId),
+	if _, err := tx.Exec(ctx, updatePayoutQuery, pgx.NamedArgs{
+		"transaction_id":          transactionId,
+		"order_id":                orderId,
+		"operator_transaction_id": operatorTransactionId,
 	}); err != nil {
 		return err
 	}
 
-	// Master Distributor
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            userDetails.mdId,
-		"reference_id":       userDetails.transactionId,
-		"credit_amount":      commision.MasterDistributorCommision,
-		"debit_amount":       0,
-		"before_balance":     userDetails.mdBeforeBalance,
-		"after_balance":      userDetails.mdAfterBalance,
-		"transaction_reason": "PAYOUT",
-		"remarks":            fmt.Sprintf("Payout commission credited from %s", req.RetailerId),
-	}); err != nil {
-		return err
-	}
-
-	// Distributor
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            userDetails.disId,
-		"reference_id":       userDetails.transactionId,
-		"credit_amount":      commision.DistributorCommision,
-		"debit_amount":       0,
-		"before_balance":     userDetails.disBeforeBalance,
-		"after_balance":      userDetails.disAfterBalance,
-		"transaction_reason": "PAYOUT",
-		"remarks":            fmt.Sprintf("Payout commission credited from %s", req.RetailerId),
-	}); err != nil {
-		return err
-	}
-
-	// Retailer (DEBIT)
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            req.RetailerId,
-		"reference_id":       userDetails.transactionId,
-		"debit_amount":       req.Amount + (commision.TotalCommision - commision.RetailerCommision),
-		"credit_amount":      0,
-		"before_balance":     userDetails.retailerBeforeBalance,
-		"after_balance":      userDetails.retailerAfterBalance,
-		"transaction_reason": "PAYOUT",
-		"remarks":            "Payout amount debited",
-	}); err != nil {
-		return err
+	if status != payout.status {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       "PAYOUT",
+			TransactionID: transactionId,
+			From:          payout.status,
+			To:            status,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return err
+		}
 	}
-
-	// 8️⃣ Commit
 	return tx.Commit(ctx)
 }
 
-func (db *Database) CreatePayoutFailureQuery(
-	ctx context.Context,
-	req models.CreatePayoutRequestModel,
-	commision models.GetPayoutCommisionModel,
-) error {
+type lockedPayout struct {
+	transactionId     string
+	retailerId        string
+	amount            models.Money
+	adminCommision    models.Money
+	mdCommision       models.Money
+	disCommision      models.Money
+	retailerCommision models.Money
+	status            string
+	adminId           string
+	mdId              string
+	disId             string
+}
 
-	insertToPayoutTransactionQuery := `
-		INSERT INTO payout_transactions (
-			partner_request_id,
-			operator_transaction_id,
-			order_id,
-			retailer_id,
-			mobile_number,
-			bank_name,
-			beneficiary_name,
-			account_number,
-			ifsc_code,
-			amount,
-			transfer_type,
-			admin_commision,
-			master_distributor_commision,
-			distributor_commision,
-			retailer_commision,
-			payout_transaction_status
-		) VALUES (
-			@partner_request_id,
-			@operator_transaction_id,
-			@order_id,
-			@retailer_id,
-			@mobile_number,
-			@bank_name,
-			@beneficiary_name,
-			@account_number,
-			@ifsc_code,
-			@amount,
-			@transfer_type,
-			@admin_commision,
-			@md_commision,
-			@dis_commision,
-			@retailer_commision,
-			@status
-		)
-		RETURNING payout_transaction_id;
+// lockPayoutTransaction locks a payout row and loads the retailer's
+// hierarchy, which is credited or debited the payout commissions.
+func lockPayoutTransaction(ctx context.Context, tx pgx.Tx, transactionId string) (*lockedPayout, error) {
+	query := `
+		SELECT
+			p.retailer_id,
+			p.amount,
+			p.admin_commision,
+			p.master_distributor_commision,
+			p.distributor_commision,
+			p.retailer_commision,
+			p.payout_transaction_status,
+			a.admin_id,
+			m.master_distributor_id,
+			d.distributor_id
+		FROM payout_transactions p
+		JOIN retailers r
+			ON r.retailer_id = p.retailer_id
+		JOIN distributors d
+			ON d.distributor_id = r.distributor_id
+		JOIN master_distributors m
+			ON m.master_distributor_id = d.master_distributor_id
+		JOIN admins a
+			ON a.admin_id = m.admin_id
+		WHERE p.payout_transaction_id = @payout_transaction_id
+		FOR UPDATE OF p;
 	`
-
-	var transferType string
-
-	if req.TransferType == 5 {
-		transferType = "IMPS"
-	}
-
-	if req.TransferType == 6 {
-		transferType = "NEFT"
-	}
-
-	if _, err := db.pool.Exec(ctx, insertToPayoutTransactionQuery, pgx.NamedArgs{
-		"partner_request_id":      req.PartnerRequestId,
-		"operator_transaction_id": req.OperatorTransactionId,
-		"order_id":                req.OrderId,
-		"retailer_id":             req.RetailerId,
-		"mobile_number":           req.MobileNumber,
-		"bank_name":               req.BankName,
-		"beneficiary_name":        req.BeneficiaryName,
-		"account_number":          req.AccountNumber,
-		"ifsc_code":               req.IFSCCode,
-		"amount":                  req.Amount,
-		"transfer_type":           transferType,
-		"admin_commision":         commision.AdminCommision,
-		"md_commision":            commision.MasterDistributorCommision,
-		"dis_commision":           commision.DistributorCommision,
-		"retailer_commision":      commision.RetailerCommision,
-		"status":                  req.TransactionStatus,
-	}); err != nil {
-		return err
+	p := lockedPayout{transactionId: transactionId}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"payout_transaction_id": transactionId,
+	}).Scan(
+		&p.retailerId,
+		&p.amount,
+		&p.adminCommision,
+		&p.mdCommision,
+		&p.disCommision,
+		&p.retailerCommision,
+		&p.status,
+		&p.adminId,
+		&p.mdId,
+		&p.disId,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid payout transaction id")
+		}
+		return nil, err
 	}
-	return nil
+	return &p, nil
 }
 
 func (db *Database) GetAllPayoutTransactionsQuery(
@@ -581,18 +381,6 @@ LIMIT @limit OFFSET @offset;
 		); err != nil {
 			return nil, err
 		}
-		if transaction.TransactionStatus == "PENDING" {
-			status, err := db.PayoutStatusCheck(transaction.PartnerRequestId)
-			if err != nil {
-				return nil, err
-			}
-			if status != "PENDING" {
-				if err := db.UpdatePayoutStatus(ctx, status, transaction.PayoutTransactionId); err != nil {
-					return nil, err
-				}
-				transaction.TransactionStatus = status
-			}
-		}
 		transactions = append(transactions, transaction)
 	}
 	return transactions, res.Err()
@@ -673,263 +461,7 @@ LIMIT @limit OFFSET @offset;
 		); err != nil {
 			return nil, err
 		}
-		if transaction.TransactionStatus == "PENDING" {
-			status, err := db.PayoutStatusCheck(transaction.PartnerRequestId)
-			if err != nil {
-				return nil, err
-			}
-			if status != "PENDING" {
-				if err := db.UpdatePayoutStatus(ctx, status, transaction.PayoutTransactionId); err != nil {
-					return nil, err
-				}
-				transaction.TransactionStatus = status
-			}
-		}
 		transactions = append(transactions, transaction)
 	}
 	return transactions, res.Err()
 }
-
-func (db *Database) PayoutRefundQuery(
-	ctx context.Context,
-	transactionId string,
-) error {
-
-	tx, err := db.pool.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(ctx)
-
-	getPayoutDetails := `
-		SELECT retailer_id, admin_commision, master_distributor_commision, distributor_commision, amount
-		FROM payout_transactions
-		WHERE payout_transaction_id = @payout_transaction_id 
-		AND payout_transaction_status NOT IN ('REFUND');
-	`
-	var (
-		retailerId                 string
-		adminCommision             float64
-		masterDistributorCommision float64
-		distributorCommision       float64
-		amount                     float64
-	)
-	if err := tx.QueryRow(ctx, getPayoutDetails, pgx.NamedArgs{
-		"payout_transaction_id": transactionId,
-	}).Scan(
-		&retailerId,
-		&adminCommision,
-		&masterDistributorCommision,
-		&distributorCommision,
-		&amount,
-	); err != nil {
-		return err
-	}
-
-	getUserDetailsQuery := `
-		SELECT a.admin_id, a.admin_wallet_balance,
-		m.master_distributor_id, m.master_distributor_wallet_balance,
-		d.distributor_id, d.distributor_wallet_balance,
-		r.retailer_wallet_balance
-		FROM retailers r
-		JOIN distributors d
-			ON d.distributor_id = r.distributor_id
-		JOIN master_distributors m
-			ON m.master_distributor_id = d.master_distributor_id
-		JOIN admins a
-			ON a.admin_id = m.admin_id
-		WHERE r.retailer_id = @retailer_id;
-	`
-	var (
-		adminId                        string
-		adminBeforeBalance             float64
-		masterDistributorId            string
-		masterDistributorBeforeBalance float64
-		distributorId                  string
-		distributorBeforeBalance       float64
-		retailerBeforeBalance          float64
-	)
-	if err := tx.QueryRow(ctx, getUserDetailsQuery, pgx.NamedArgs{
-		"retailer_id": retailerId,
-	}).Scan(
-		&adminId,
-		&adminBeforeBalance,
-		&masterDistributorId,
-		&masterDistributorBeforeBalance,
-		&distributorId,
-		&distributorBeforeBalance,
-		&retailerBeforeBalance,
-	); err != nil {
-		return err
-	}
-
-	updateAdminWallet := `
-		UPDATE admins
-		SET admin_wallet_balance = admin_wallet_balance - @commision
-		WHERE admin_id = @admin_id
-		RETURNING admin_wallet_balance;
-	`
-	var adminAfterBalance float64
-	if err := tx.QueryRow(ctx, updateAdminWallet, pgx.NamedArgs{
-		"admin_id":  adminId,
-		"commision": adminCommision,
-	}).Scan(
-		&adminAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	updateMasterDistributorWallet := `
-		UPDATE master_distributors 
-		SET master_distributor_wallet_balance = master_distributor_wallet_balance - @commision
-		WHERE master_distributor_id = @master_distributor_id
-		RETURNING master_distributor_wallet_balance;
-	`
-	var masterDistributorAfterBalance float64
-	if err := tx.QueryRow(ctx, updateMasterDistributorWallet, pgx.NamedArgs{
-		"master_distributor_id": masterDistributorId,
-		"commision":             masterDistributorCommision,
-	}).Scan(
-		&masterDistributorAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	updateDistributorWallet := `
-		UPDATE distributors
-		SET distributor_wallet_balance = distributor_wallet_balance - @commision
-		WHERE distributor_id = @distributor_id
-		RETURNING distributor_wallet_balance;
-	`
-	var distributorAfterBalance float64
-	if err := tx.QueryRow(ctx, updateDistributorWallet, pgx.NamedArgs{
-		"distributor_id": distributorId,
-		"commision":      distributorCommision,
-	}).Scan(
-		&distributorAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	updateRetailerWallet := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance + @amount
-		WHERE retailer_id = @retailer_id
-		RETURNING retailer_wallet_balance;
-	`
-	var retailerAfterBalance float64
-	if err := tx.QueryRow(ctx, updateRetailerWallet, pgx.NamedArgs{
-		"retailer_id": retailerId,
-		"amount":      amount + masterDistributorCommision + distributorCommision + adminCommision,
-	}).Scan(
-		&retailerAfterBalance,
-	); err != nil {
-		return err
-	}
-
-	insertToWalletTransactions := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		)
-	`
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            adminId,
-		"reference_id":       transactionId,
-		"credit_amount":      0,
-		"debit_amount":       adminCommision,
-		"before_balance":     adminBeforeBalance,
-		"after_balance":      adminAfterBalance,
-		"transaction_reason": "PAYOUT_REFUND",
-		"remarks":            fmt.Sprintf("Commision Sent Back To: %s", retailerId),
-	}); err != nil {
-		return err
-	}
-
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            masterDistributorId,
-		"reference_id":       transactionId,
-		"credit_amount":      0,
-		"debit_amount":       masterDistributorCommision,
-		"before_balance":     masterDistributorBeforeBalance,
-		"after_balance":      masterDistributorAfterBalance,
-		"transaction_reason": "PAYOUT_REFUND",
-		"remarks":            fmt.Sprintf("Commision Sent Back To: %s", retailerId),
-	}); err != nil {
-		return err
-	}
-
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            distributorId,
-		"reference_id":       transactionId,
-		"credit_amount":      0,
-		"debit_amount":       distributorCommision,
-		"before_balance":     distributorBeforeBalance,
-		"after_balance":      distributorAfterBalance,
-		"transaction_reason": "PAYOUT_REFUND",
-		"remarks":            fmt.Sprintf("Commision Sent Back To: %s", retailerId),
-	}); err != nil {
-		return err
-	}
-
-	if _, err := tx.Exec(ctx, insertToWalletTransactions, pgx.NamedArgs{
-		"user_id":            retailerId,
-		"reference_id":       transactionId,
-		"credit_amount":      amount + adminCommision + masterDistributorCommision + distributorCommision,
-		"debit_amount":       0,
-		"before_balance":     retailerBeforeBalance,
-		"after_balance":      retailerAfterBalance,
-		"transaction_reason": "PAYOUT_REFUND",
-		"remarks":            fmt.Sprintf("Got Refund Of: %s", transactionId),
-	}); err != nil {
-		return err
-	}
-
-	fmt.Println(transactionId)
-
-	updatePayoutTable := `
-		UPDATE payout_transactions 
-		SET payout_transaction_status = 'REFUND'
-		WHERE payout_transaction_id = @transaction_id;
-	`
-	if _, err := tx.Exec(ctx, updatePayoutTable, pgx.NamedArgs{
-		"transaction_id": transactionId,
-	}); err != nil {
-		return err
-	}
-	return tx.Commit(ctx)
-}
-
-func (db *Database) UpdatePayoutStatus(
-	ctx context.Context,
-	status string,
-	payoutTransactionId string,
-) error {
-	query := `
-		UPDATE payout_transactions
-		SET payout_transaction_status = @status
-		WHERE payout_transaction_id = @transaction_id;
-	`
-	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
-		"transaction_id": payoutTransactionId,
-		"status":         status,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
@@ -3,7 +3,7 @@ package database
 import (
 	"context"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
@@ -33,19 +33,20 @@ func (db *Database) GetBeneficiaries(mobileNumber string) (*[]models.Beneficiary
 	return &beneficiaries, nil
 }
 
-func (db *Database) VerifyBenificary(ctx context.Context, amount float64, retailerId string) error {
-	deductAmountQuery := `
-		UPDATE retailers 
-		SET retailer_wallet_balance = retailer_wallet_balance - @amount
-		WHERE retailer_id = @retailer_id;
-	`
-	if _, err := db.pool.Exec(ctx, deductAmountQuery, pgx.NamedArgs{
-		"retailer_id": retailerId,
-		"amount":      amount,
-	}); err != nil {
+func (db *Database) VerifyBenificary(ctx context.Context, amount models.Money, retailerId string) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	journal := ledger.NewJournal(retailerId, "BENEFICIARY_VERIFICATION", "Beneficiary verification charge").
+		Debit(ledger.User(retailerId), amount, "").
+		Credit(ledger.ProviderFloat, amount, "")
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (db *Database) DeleteBeneficiary(beneficiaryId string) error {
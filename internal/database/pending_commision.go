@@ -0,0 +1,459 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// The commission a transaction pays the retailer's hierarchy is recorded in
+// pending_commisions when the transaction is reserved, and paid only once
+// it succeeds. In INSTANT mode the settlement credits the wallets straight
+// away. In DAILY and MONTHLY mode it credits the commission payable account
+// instead, and ReleaseDueCommisionsQuery moves it to the wallets once the
+// day or month the transaction succeeded in is over. A failed transaction
+// cancels its commission, and a refund claws it back from wherever it is.
+
+// commisionReleaseReason is the journal reason of batch releases.
+const commisionReleaseReason = "COMMISION_RELEASE"
+
+func commisionSettlementMode(ctx context.Context, q querier) (string, error) {
+	var mode string
+	err := q.QueryRow(ctx, `
+		SELECT settlement_mode
+		FROM commision_settlement_settings
+		WHERE setting_id = 1;
+	`).Scan(&mode)
+	return mode, err
+}
+
+// accruePendingCommisions records the commission a quoted transaction will
+// pay the hierarchy if it succeeds. The retailer's own commission is a
+// discount on its debit and is not recorded, except on AEPS, where it is
+// credited like the others.
+func accruePendingCommisions(
+	ctx context.Context,
+	tx pgx.Tx,
+	transactionID string,
+	quote *models.TransactionQuoteModel,
+) error {
+	query := `
+		INSERT INTO pending_commisions (
+			service,
+			transaction_id,
+			user_id,
+			commision,
+			tds,
+			net_commision,
+			commision_status
+		) VALUES (
+			@service,
+			@transaction_id,
+			@user_id,
+			@commision,
+			@tds,
+			@net_commision,
+			'PENDING'
+		);
+	`
+	for _, m := range quote.Members {
+		if m.Role == "retailer" && quote.Service != "AEPS" {
+			continue
+		}
+		if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+			"service":        quote.Service,
+			"transaction_id": transactionID,
+			"user_id":        m.UserID,
+			"commision":      m.Commision,
+			"tds":            m.TDS,
+			"net_commision":  m.NetCommision,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settlePendingCommision books a commission of a successful transaction,
+// net of TDS, to the user's wallet or, when commission is released in
+// batches, to the commission payable account. Transactions reserved before
+// commissions were accrued get their row here.
+func settlePendingCommision(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	service, transactionID, userID string,
+	commision, tds models.Money,
+	remarks string,
+) error {
+	mode, err := commisionSettlementMode(ctx, tx)
+	if err != nil {
+		return err
+	}
+	status, account := "RELEASED", ledger.User(userID)
+	if mode != "INSTANT" {
+		status, account = "DUE", ledger.CommisionPayable
+	}
+	j.Credit(account, commision-tds, remarks)
+
+	query := `
+		INSERT INTO pending_commisions (
+			service,
+			transaction_id,
+			user_id,
+			commision,
+			tds,
+			net_commision,
+			commision_status,
+			settled_at,
+			released_at
+		) VALUES (
+			@service,
+			@transaction_id,
+			@user_id,
+			@commision,
+			@tds,
+			@net_commision,
+			@status,
+			NOW(),
+			CASE WHEN @status = 'RELEASED' THEN NOW() END
+		)
+		ON CONFLICT (service, transaction_id, user_id) DO UPDATE
+		SET commision = EXCLUDED.commision,
+			tds = EXCLUDED.tds,
+			net_commision = EXCLUDED.net_commision,
+			commision_status = EXCLUDED.commision_status,
+			settled_at = EXCLUDED.settled_at,
+			released_at = EXCLUDED.released_at,
+			updated_at = NOW();
+	`
+	_, err = tx.Exec(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+		"user_id":        userID,
+		"commision":      commision,
+		"tds":            tds,
+		"net_commision":  commision - tds,
+		"status":         status,
+	})
+	return err
+}
+
+// cancelPendingCommisions drops the commission of a transaction that did
+// not go through.
+func cancelPendingCommisions(ctx context.Context, tx pgx.Tx, service, transactionID string) error {
+	query := `
+		UPDATE pending_commisions
+		SET commision_status = 'CANCELLED',
+			updated_at = NOW()
+		WHERE service = @service
+		AND transaction_id = @transaction_id
+		AND commision_status = 'PENDING';
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+	})
+	return err
+}
+
+// reverseSettledCommisions claws back the share part/whole of the
+// commission a refunded transaction paid, or everything not yet clawed back
+// on the last refund: from the wallet when it was released, as a clawback
+// the wallet's holds cannot block, and from the commission payable account
+// when it is still due. It returns the amount clawed back and the wallets
+// it covers, whose postings the refund must leave alone.
+func reverseSettledCommisions(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	service, transactionID string,
+	part, whole models.Money,
+	final bool,
+) (models.Money, map[ledger.Account]bool, error) {
+	query := `
+		SELECT
+			pending_commision_id,
+			user_id,
+			net_commision,
+			reversed_commision,
+			commision_status
+		FROM pending_commisions
+		WHERE service = @service
+		AND transaction_id = @transaction_id
+		AND commision_status IN ('DUE', 'RELEASED', 'REVERSED')
+		ORDER BY pending_commision_id
+		FOR UPDATE;
+	`
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	type settled struct {
+		id       int64
+		userID   string
+		net      models.Money
+		reversed models.Money
+		status   string
+	}
+	var all []settled
+	for rows.Next() {
+		var s settled
+		if err := rows.Scan(&s.id, &s.userID, &s.net, &s.reversed, &s.status); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		all = append(all, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	update := `
+		UPDATE pending_commisions
+		SET reversed_commision = @reversed,
+			commision_status = CASE WHEN @reversed = net_commision THEN 'REVERSED' ELSE commision_status END,
+			updated_at = NOW()
+		WHERE pending_commision_id = @id;
+	`
+	var returned models.Money
+	covered := make(map[ledger.Account]bool)
+	for _, s := range all {
+		covered[ledger.User(s.userID)] = true
+
+		share := s.net - s.reversed
+		if !final {
+			share = min(s.net.Prorate(part, whole), share)
+		}
+		if share <= 0 {
+			continue
+		}
+		remarks := fmt.Sprintf("Refund clawback of commission to %s", s.userID)
+		if s.status == "DUE" {
+			j.Debit(ledger.CommisionPayable, share, remarks)
+		} else {
+			j.Clawback(ledger.User(s.userID), share, remarks)
+		}
+		if _, err := tx.Exec(ctx, update, pgx.NamedArgs{
+			"id":       s.id,
+			"reversed": s.reversed + share,
+		}); err != nil {
+			return 0, nil, err
+		}
+		returned += share
+	}
+	return returned, covered, nil
+}
+
+// ReleaseDueCommisionsQuery credits the commission that is due to the
+// wallets, one journal per user. In DAILY mode that is the commission of
+// transactions that succeeded before today, in MONTHLY mode before this
+// month; in INSTANT mode whatever was left due when the mode was changed.
+func (db *Database) ReleaseDueCommisionsQuery(ctx context.Context) (*models.CommisionReleaseModel, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		release   models.CommisionReleaseModel
+		reference string
+	)
+	if err := tx.QueryRow(ctx, `
+		WITH s AS (
+			SELECT CASE settlement_mode
+				WHEN 'DAILY' THEN DATE_TRUNC('day', NOW() AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata'
+				WHEN 'MONTHLY' THEN DATE_TRUNC('month', NOW() AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata'
+				ELSE NOW()
+			END AS cutoff
+			FROM commision_settlement_settings
+			WHERE setting_id = 1
+		)
+		SELECT cutoff, TO_CHAR(cutoff AT TIME ZONE 'Asia/Kolkata', 'YYYY-MM-DD')
+		FROM s;
+	`).Scan(&release.Cutoff, &reference); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE pending_commisions
+		SET commision_status = 'RELEASED',
+			released_at = NOW(),
+			updated_at = NOW()
+		WHERE commision_status = 'DUE'
+		AND settled_at < @cutoff
+		RETURNING pending_commision_id, user_id, net_commision - reversed_commision;
+	`, pgx.NamedArgs{
+		"cutoff": release.Cutoff,
+	})
+	if err != nil {
+		return nil, err
+	}
+	type due struct {
+		ids    []int64
+		amount models.Money
+	}
+	byUser := make(map[string]*due)
+	for rows.Next() {
+		var (
+			id     int64
+			userID string
+			amount models.Money
+		)
+		if err := rows.Scan(&id, &userID, &amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d, ok := byUser[userID]
+		if !ok {
+			d = &due{}
+			byUser[userID] = d
+		}
+		d.ids = append(d.ids, id)
+		d.amount += amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(byUser))
+	for userID := range byUser {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+
+	remarks := fmt.Sprintf("Commission released for transactions before %s", reference)
+	for _, userID := range userIDs {
+		d := byUser[userID]
+		journal := ledger.NewJournal(reference, commisionReleaseReason, remarks).
+			Debit(ledger.CommisionPayable, d.amount, "").
+			Credit(ledger.User(userID), d.amount, "")
+		journalID, err := ledger.Post(ctx, tx, journal)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE pending_commisions
+			SET release_journal_id = @journal_id
+			WHERE pending_commision_id = ANY (@ids);
+		`, pgx.NamedArgs{
+			"journal_id": journalID,
+			"ids":        d.ids,
+		}); err != nil {
+			return nil, err
+		}
+		release.Users++
+		release.Commisions += len(d.ids)
+		release.Amount += d.amount
+	}
+	return &release, tx.Commit(ctx)
+}
+
+func (db *Database) GetCommisionSettlementSettingsQuery(ctx context.Context) (*models.CommisionSettlementSettingsModel, error) {
+	query := `
+		SELECT settlement_mode, updated_at
+		FROM commision_settlement_settings
+		WHERE setting_id = 1;
+	`
+	var s models.CommisionSettlementSettingsModel
+	if err := db.pool.QueryRow(ctx, query).Scan(
+		&s.SettlementMode,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *Database) UpdateCommisionSettlementSettingsQuery(
+	ctx context.Context,
+	req models.UpdateCommisionSettlementSettingsRequestModel,
+) error {
+	query := `
+		UPDATE commision_settlement_settings
+		SET settlement_mode = @settlement_mode,
+			updated_at = NOW()
+		WHERE setting_id = 1;
+	`
+	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"settlement_mode": req.SettlementMode,
+	}); err != nil {
+		return fmt.Errorf("failed to update commision settlement settings")
+	}
+	return nil
+}
+
+// GetPendingCommisionsQuery lists commission records, newest first. An
+// empty userID or status matches all.
+func (db *Database) GetPendingCommisionsQuery(
+	ctx context.Context,
+	userID, status string,
+	limit, offset int,
+) ([]models.PendingCommisionModel, error) {
+	query := `
+		SELECT
+			pending_commision_id,
+			service,
+			transaction_id,
+			user_id,
+			commision,
+			tds,
+			net_commision,
+			reversed_commision,
+			commision_status,
+			release_journal_id,
+			settled_at,
+			released_at,
+			created_at
+		FROM pending_commisions
+		WHERE (@user_id = '' OR user_id = @user_id)
+		AND (@status = '' OR commision_status = @status)
+		ORDER BY created_at DESC, pending_commision_id DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+		"status":  status,
+		"limit":   limit,
+		"offset":  offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commisions := []models.PendingCommisionModel{}
+	for rows.Next() {
+		var c models.PendingCommisionModel
+		if err := rows.Scan(
+			&c.PendingCommisionID,
+			&c.Service,
+			&c.TransactionID,
+			&c.UserID,
+			&c.Commision,
+			&c.TDS,
+			&c.NetCommision,
+			&c.ReversedCommision,
+			&c.Status,
+			&c.ReleaseJournalID,
+			&c.SettledAt,
+			&c.ReleasedAt,
+			&c.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		commisions = append(commisions, c)
+	}
+	return commisions, rows.Err()
+}
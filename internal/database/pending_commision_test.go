@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func payoutRequest(retailerID string, amount models.Money) models.CreatePayoutRequestModel {
+	return models.CreatePayoutRequestModel{
+		RetailerId:      retailerID,
+		MobileNumber:    "9876543210",
+		IFSCCode:        "HDFC0000001",
+		BankName:        "HDFC Bank",
+		AccountNumber:   "50100000000001",
+		BeneficiaryName: "Test",
+		Amount:          amount,
+		TransferType:    5,
+	}
+}
+
+func commisionStatuses(t *testing.T, conn *pgx.Conn, transactionID string) map[string]string {
+	t.Helper()
+	rows, err := conn.Query(context.Background(), `
+		SELECT user_id, commision_status
+		FROM pending_commisions
+		WHERE service = 'PAYOUT' AND transaction_id = $1;
+	`, transactionID)
+	if err != nil {
+		t.Fatalf("pending_commisions: %v", err)
+	}
+	statuses := make(map[string]string)
+	for rows.Next() {
+		var userID, status string
+		if err := rows.Scan(&userID, &status); err != nil {
+			t.Fatalf("pending_commisions: %v", err)
+		}
+		statuses[userID] = status
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("pending_commisions: %v", err)
+	}
+	return statuses
+}
+
+func setCommisionSettlementMode(t *testing.T, db *Database, mode string) {
+	t.Helper()
+	if err := db.UpdateCommisionSettlementSettingsQuery(context.Background(), models.UpdateCommisionSettlementSettingsRequestModel{
+		SettlementMode: mode,
+	}); err != nil {
+		t.Fatalf("UpdateCommisionSettlementSettingsQuery: %v", err)
+	}
+}
+
+// A payout of 1000 under the default slab pays the distributor 2.40 (2.35
+// net of TDS), the master distributor 0.60 (0.59) and the admin 3.00.
+var payoutNetCommisions = []models.Money{235 * models.Paisa, 59 * models.Paisa, models.Rupees(3)}
+
+func TestCommisionIsPaidOnlyOnSuccess(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(5000))
+	users := []string{h.DistributorID, h.MasterDistributorID, h.AdminID}
+
+	failed, err := db.ReservePayoutQuery(ctx, payoutRequest(h.RetailerID, models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("ReservePayoutQuery: %v", err)
+	}
+	succeeded, err := db.ReservePayoutQuery(ctx, payoutRequest(h.RetailerID, models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("ReservePayoutQuery: %v", err)
+	}
+	for _, userID := range users {
+		if got := commisionStatuses(t, conn, succeeded)[userID]; got != "PENDING" {
+			t.Errorf("commission of %s before settlement = %q, want PENDING", userID, got)
+		}
+		if got := dbtest.Balance(t, conn, userID); got != 0 {
+			t.Errorf("%s balance before settlement = %s, want 0.00", userID, got)
+		}
+	}
+
+	if err := db.SettlePayoutQuery(ctx, failed, "FAILED", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettlePayoutQuery: %v", err)
+	}
+	if err := db.SettlePayoutQuery(ctx, succeeded, "SUCCESS", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettlePayoutQuery: %v", err)
+	}
+	for i, userID := range users {
+		if got := commisionStatuses(t, conn, failed)[userID]; got != "CANCELLED" {
+			t.Errorf("commission of %s on the failed payout = %q, want CANCELLED", userID, got)
+		}
+		if got := commisionStatuses(t, conn, succeeded)[userID]; got != "RELEASED" {
+			t.Errorf("commission of %s on the successful payout = %q, want RELEASED", userID, got)
+		}
+		if got := dbtest.Balance(t, conn, userID); got != payoutNetCommisions[i] {
+			t.Errorf("%s balance = %s, want %s", userID, got, payoutNetCommisions[i])
+		}
+	}
+
+	// A refund claws the released commission back, holds or not.
+	if _, err := db.PlaceWalletHoldQuery(ctx, models.CreateWalletHoldRequestModel{
+		UserID:   h.DistributorID,
+		Amount:   payoutNetCommisions[0],
+		HoldType: "DISPUTE",
+		Remarks:  "test",
+	}, h.AdminID); err != nil {
+		t.Fatalf("PlaceWalletHoldQuery: %v", err)
+	}
+	if _, err := db.RefundTransactionQuery(ctx, "PAYOUT", succeeded, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+	for _, userID := range users {
+		if got := dbtest.Balance(t, conn, userID); got != 0 {
+			t.Errorf("%s balance after the refund = %s, want 0.00", userID, got)
+		}
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(5000) {
+		t.Errorf("retailer balance after the refund = %s, want 5000.00", got)
+	}
+}
+
+func TestDailyCommisionIsReleasedAfterTheDay(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(5000))
+	setCommisionSettlementMode(t, db, "DAILY")
+
+	transactionID, err := db.ReservePayoutQuery(ctx, payoutRequest(h.RetailerID, models.Rupees(1000)))
+	if err != nil {
+		t.Fatalf("ReservePayoutQuery: %v", err)
+	}
+	if err := db.SettlePayoutQuery(ctx, transactionID, "SUCCESS", "", "", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettlePayoutQuery: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.DistributorID); got != 0 {
+		t.Errorf("distributor balance before the release = %s, want 0.00", got)
+	}
+	if got, want := dbtest.SystemBalance(t, conn, "COMMISION_PAYABLE"), models.Rupees(5)+94*models.Paisa; got != want {
+		t.Errorf("commission payable = %s, want %s", got, want)
+	}
+
+	// Nothing from today is released yet.
+	release, err := db.ReleaseDueCommisionsQuery(ctx)
+	if err != nil {
+		t.Fatalf("ReleaseDueCommisionsQuery: %v", err)
+	}
+	if release.Commisions != 0 {
+		t.Errorf("released %d commissions from today, want 0", release.Commisions)
+	}
+
+	if _, err := conn.Exec(ctx, "UPDATE pending_commisions SET settled_at = settled_at - INTERVAL '1 day'"); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+	release, err = db.ReleaseDueCommisionsQuery(ctx)
+	if err != nil {
+		t.Fatalf("ReleaseDueCommisionsQuery: %v", err)
+	}
+	if release.Users != 3 || release.Commisions != 3 || release.Amount != models.Rupees(5)+94*models.Paisa {
+		t.Errorf("release = %+v, want 3 users, 3 commissions and 5.94", release)
+	}
+	for i, userID := range []string{h.DistributorID, h.MasterDistributorID, h.AdminID} {
+		if got := dbtest.Balance(t, conn, userID); got != payoutNetCommisions[i] {
+			t.Errorf("%s balance = %s, want %s", userID, got, payoutNetCommisions[i])
+		}
+		if got := commisionStatuses(t, conn, transactionID)[userID]; got != "RELEASED" {
+			t.Errorf("commission of %s = %q, want RELEASED", userID, got)
+		}
+	}
+	if got := dbtest.SystemBalance(t, conn, "COMMISION_PAYABLE"); got != 0 {
+		t.Errorf("commission payable after the release = %s, want 0.00", got)
+	}
+}
+
+func TestMonthlyCommisionIsReleasedAfterTheMonth(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(5000))
+	setCommisionSettlementMode(t, db, "MONTHLY")
+
+	var ids [2]string
+	for i := range ids {
+		id, err := db.ReservePayoutQuery(ctx, payoutRequest(h.RetailerID, models.Rupees(1000)))
+		if err != nil {
+			t.Fatalf("ReservePayoutQuery: %v", err)
+		}
+		if err := db.SettlePayoutQuery(ctx, id, "SUCCESS", "", "", txstate.Provider("TEST"), ""); err != nil {
+			t.Fatalf("SettlePayoutQuery: %v", err)
+		}
+		ids[i] = id
+	}
+	// The first payout succeeded in the last minute of last month, the
+	// second in the first minute of this one.
+	for i, offset := range []string{"-1 minute", "1 minute"} {
+		if _, err := conn.Exec(ctx, `
+			UPDATE pending_commisions
+			SET settled_at = DATE_TRUNC('month', NOW() AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata' + $1::INTERVAL
+			WHERE transaction_id = $2;
+		`, offset, ids[i]); err != nil {
+			t.Fatalf("backdate: %v", err)
+		}
+	}
+
+	release, err := db.ReleaseDueCommisionsQuery(ctx)
+	if err != nil {
+		t.Fatalf("ReleaseDueCommisionsQuery: %v", err)
+	}
+	if release.Commisions != 3 {
+		t.Errorf("released %d commissions, want the 3 of last month", release.Commisions)
+	}
+	for _, userID := range []string{h.DistributorID, h.MasterDistributorID, h.AdminID} {
+		if got := commisionStatuses(t, conn, ids[0])[userID]; got != "RELEASED" {
+			t.Errorf("last month's commission of %s = %q, want RELEASED", userID, got)
+		}
+		if got := commisionStatuses(t, conn, ids[1])[userID]; got != "DUE" {
+			t.Errorf("this month's commission of %s = %q, want DUE", userID, got)
+		}
+	}
+	if got := dbtest.Balance(t, conn, h.DistributorID); got != payoutNetCommisions[0] {
+		t.Errorf("distributor balance = %s, want %s", got, payoutNetCommisions[0])
+	}
+}
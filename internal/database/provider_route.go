@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func (db *Database) CreateProviderRouteQuery(
+	ctx context.Context,
+	req models.CreateProviderRouteRequestModel,
+) error {
+	query := `
+		INSERT INTO provider_routes(
+			service,
+			provider,
+			operator_code,
+			min_amount,
+			max_amount,
+			min_balance,
+			priority
+		) VALUES (
+			@service,
+			@provider,
+			@operator_code,
+			@min_amount,
+			@max_amount,
+			@min_balance,
+			@priority
+		);
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"service":       req.Service,
+		"provider":      req.Provider,
+		"operator_code": req.OperatorCode,
+		"min_amount":    req.MinAmount,
+		"max_amount":    req.MaxAmount,
+		"min_balance":   req.MinBalance,
+		"priority":      req.Priority,
+	})
+	return err
+}
+
+func (db *Database) UpdateProviderRouteQuery(
+	ctx context.Context,
+	req models.UpdateProviderRouteRequestModel,
+) error {
+	query := `
+		UPDATE provider_routes
+		SET provider = @provider,
+		operator_code = @operator_code,
+		min_amount = @min_amount,
+		max_amount = @max_amount,
+		min_balance = @min_balance,
+		priority = @priority,
+		is_active = @is_active,
+		updated_at = NOW()
+		WHERE route_id = @route_id;
+	`
+	res, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"provider":      req.Provider,
+		"operator_code": req.OperatorCode,
+		"min_amount":    req.MinAmount,
+		"max_amount":    req.MaxAmount,
+		"min_balance":   req.MinBalance,
+		"priority":      req.Priority,
+		"is_active":     req.IsActive,
+		"route_id":      req.RouteID,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (db *Database) DeleteProviderRouteQuery(ctx context.Context, routeId int) error {
+	query := `
+		DELETE FROM provider_routes
+		WHERE route_id = @route_id;
+	`
+	res, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"route_id": routeId,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (db *Database) GetAllProviderRoutesQuery(ctx context.Context) ([]models.ProviderRouteModel, error) {
+	query := `
+		SELECT
+			route_id,
+			service,
+			provider,
+			operator_code,
+			min_amount,
+			max_amount,
+			min_balance,
+			priority,
+			is_active,
+			created_at,
+			updated_at
+		FROM provider_routes
+		ORDER BY service, priority, route_id;
+	`
+	return db.queryProviderRoutes(ctx, query, nil)
+}
+
+// GetMatchingProviderRoutesQuery returns the active rules matching a
+// transaction in the order they are tried: by priority, with rules for the
+// transaction's operator ahead of rules for every operator.
+func (db *Database) GetMatchingProviderRoutesQuery(
+	ctx context.Context,
+	service string,
+	operatorCode int,
+	amount models.Money,
+) ([]models.ProviderRouteModel, error) {
+	query := `
+		SELECT
+			route_id,
+			service,
+			provider,
+			operator_code,
+			min_amount,
+			max_amount,
+			min_balance,
+			priority,
+			is_active,
+			created_at,
+			updated_at
+		FROM provider_routes
+		WHERE service = @service
+		AND is_active
+		AND (operator_code IS NULL OR operator_code = @operator_code)
+		AND min_amount <= @amount
+		AND (max_amount = 0 OR max_amount >= @amount)
+		ORDER BY priority, operator_code IS NULL, route_id;
+	`
+	return db.queryProviderRoutes(ctx, query, pgx.NamedArgs{
+		"service":       service,
+		"operator_code": operatorCode,
+		"amount":        amount,
+	})
+}
+
+func (db *Database) queryProviderRoutes(
+	ctx context.Context,
+	query string,
+	args pgx.NamedArgs,
+) ([]models.ProviderRouteModel, error) {
+	rows, err := db.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []models.ProviderRouteModel
+	for rows.Next() {
+		var r models.ProviderRouteModel
+		if err := rows.Scan(
+			&r.RouteID,
+			&r.Service,
+			&r.Provider,
+			&r.OperatorCode,
+			&r.MinAmount,
+			&r.MaxAmount,
+			&r.MinBalance,
+			&r.Priority,
+			&r.IsActive,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+var providerRouteServiceChecks = []*regexp.Regexp{
+	regexp.MustCompile(`(?s)CREATE TABLE\s+IF NOT EXISTS provider_routes \(.*?service TEXT NOT NULL CHECK \(\s*service IN \(([^)]*)\)`),
+	regexp.MustCompile(`ADD CONSTRAINT provider_routes_service_check CHECK \(\s*service IN \(([^)]*)\)`),
+}
+
+// TestProviderRouteServicesMatchMigrations checks that every service the
+// admin API accepts for a provider route is allowed by the provider_routes
+// CHECK the migrations leave in place, and no other.
+func TestProviderRouteServicesMatchMigrations(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	sort.Strings(files)
+
+	var allowed []string
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		for _, check := range providerRouteServiceChecks {
+			for _, m := range check.FindAllStringSubmatch(string(sql), -1) {
+				allowed = allowed[:0]
+				for _, s := range strings.Split(m[1], ",") {
+					allowed = append(allowed, strings.Trim(strings.TrimSpace(s), "'"))
+				}
+			}
+		}
+	}
+	if allowed == nil {
+		t.Fatal("no provider_routes service CHECK found in the migrations")
+	}
+
+	field, _ := reflect.TypeOf(models.CreateProviderRouteRequestModel{}).FieldByName("Service")
+	var accepted []string
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+			accepted = strings.Fields(values)
+		}
+	}
+
+	slices.Sort(allowed)
+	slices.Sort(accepted)
+	if !slices.Equal(allowed, accepted) {
+		t.Fatalf("provider_routes allows %v, the API accepts %v", allowed, accepted)
+	}
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+// Payouts, money transfers, AEPS, AEPS bank settlements, recharges and bill
+// payments each live in their own table but go
+// through the same provider. These helpers let status checks and provider
+// callbacks address any of them by service name.
+
+// GetProviderTransactionByPartnerRequestIDQuery finds the transaction sent to
+// the provider with the given partner_request_id.
+func (db *Database) GetProviderTransactionByPartnerRequestIDQuery(
+	ctx context.Context,
+	partnerRequestID string,
+) (*models.ProviderTransactionModel, error) {
+	query := `
+		SELECT 'PAYOUT', payout_transaction_id::TEXT, partner_request_id::TEXT, payout_transaction_status, provider
+		FROM payout_transactions
+		WHERE partner_request_id::TEXT = @partner_request_id
+		UNION ALL
+		SELECT 'MOBILE_RECHARGE', mobile_recharge_transaction_id::TEXT, partner_request_id, status, provider
+		FROM mobile_recharge
+		WHERE partner_request_id = @partner_request_id
+		UNION ALL
+		SELECT 'DTH_RECHARGE', dth_transaction_id::TEXT, partner_request_id, status, provider
+		FROM dth_recharge
+		WHERE partner_request_id = @partner_request_id
+		UNION ALL
+		SELECT 'BBPS', bbps_transaction_id::TEXT, partner_request_id::TEXT, transaction_status, provider
+		FROM bbps_transactions
+		WHERE partner_request_id::TEXT = @partner_request_id
+		UNION ALL
+		SELECT 'DMT', dmt_transaction_id::TEXT, partner_request_id::TEXT, transaction_status, provider
+		FROM dmt_transactions
+		WHERE partner_request_id::TEXT = @partner_request_id
+		UNION ALL
+		SELECT 'AEPS', aeps_transaction_id::TEXT, partner_request_id::TEXT, transaction_status, provider
+		FROM aeps_transactions
+		WHERE partner_request_id::TEXT = @partner_request_id
+		UNION ALL
+		SELECT 'AEPS_SETTLEMENT', aeps_settlement_id::TEXT, partner_request_id::TEXT, transaction_status, provider
+		FROM aeps_settlements
+		WHERE partner_request_id::TEXT = @partner_request_id
+		AND settlement_type = 'BANK'
+		LIMIT 1;
+	`
+	var t models.ProviderTransactionModel
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"partner_request_id": partnerRequestID,
+	}).Scan(
+		&t.Service,
+		&t.TransactionID,
+		&t.PartnerRequestID,
+		&t.Status,
+		&t.Provider,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("no transaction found for partner request id %s", partnerRequestID)
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// AssignProviderQuery records which provider a transaction is sent to. It is
+// called before each attempt, so status checks ask the provider that last
+// received the transaction. The first attempt moves the transaction from
+// INITIATED to PENDING; later attempts find it already PENDING.
+func (db *Database) AssignProviderQuery(
+	ctx context.Context,
+	service, transactionID string,
+	provider string,
+) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, err := txstate.Lock(ctx, tx, service, transactionID)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case txstate.Initiated:
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       service,
+			TransactionID: transactionID,
+			From:          status,
+			To:            txstate.Pending,
+			Actor:         txstate.System,
+			Reason:        fmt.Sprintf("sent to %s", provider),
+		}); err != nil {
+			return err
+		}
+	case txstate.Pending:
+	default:
+		return fmt.Errorf("%s transaction %s is already %s", service, transactionID, status)
+	}
+
+	var query string
+	switch service {
+	case "PAYOUT":
+		query = `
+			UPDATE payout_transactions
+			SET provider = @provider
+			WHERE payout_transaction_id = @transaction_id::UUID;
+		`
+	case "MOBILE_RECHARGE":
+		query = `
+			UPDATE mobile_recharge
+			SET provider = @provider
+			WHERE mobile_recharge_transaction_id = @transaction_id::BIGINT;
+		`
+	case "DTH_RECHARGE":
+		query = `
+			UPDATE dth_recharge
+			SET provider = @provider
+			WHERE dth_transaction_id = @transaction_id::BIGINT;
+		`
+	case "BBPS":
+		query = `
+			UPDATE bbps_transactions
+			SET provider = @provider
+			WHERE bbps_transaction_id = @transaction_id::UUID;
+		`
+	case "DMT":
+		query = `
+			UPDATE dmt_transactions
+			SET provider = @provider
+			WHERE dmt_transaction_id = @transaction_id::UUID;
+		`
+	case "AEPS":
+		query = `
+			UPDATE aeps_transactions
+			SET provider = @provider
+			WHERE aeps_transaction_id = @transaction_id::UUID;
+		`
+	case "AEPS_SETTLEMENT":
+		query = `
+			UPDATE aeps_settlements
+			SET provider = @provider
+			WHERE aeps_settlement_id = @transaction_id::UUID;
+		`
+	default:
+		return fmt.Errorf("unknown service %s", service)
+	}
+
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"provider":       provider,
+		"transaction_id": transactionID,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SettleProviderTransactionQuery applies a final or pending provider status
+// through the service's settlement, which refunds the retailer on FAILED.
+func (db *Database) SettleProviderTransactionQuery(
+	ctx context.Context,
+	service, transactionID string,
+	status string,
+	orderId string,
+	operatorTransactionId string,
+	actor txstate.Actor,
+	reason string,
+) error {
+	switch service {
+	case "PAYOUT":
+		return db.SettlePayoutQuery(ctx, transactionID, status, orderId, operatorTransactionId, actor, reason)
+	case "MOBILE_RECHARGE":
+		return db.SettleMobileRechargeQuery(ctx, transactionID, status, actor, reason)
+	case "DTH_RECHARGE":
+		return db.SettleDTHRechargeQuery(ctx, transactionID, status, actor, reason)
+	case "BBPS":
+		return db.SettleBBPSTransactionQuery(ctx, transactionID, status, orderId, operatorTransactionId, actor, reason)
+	case "DMT":
+		return db.SettleDMTTransactionQuery(ctx, transactionID, status, orderId, operatorTransactionId, actor, reason)
+	case "AEPS":
+		return db.SettleAEPSTransactionQuery(ctx, transactionID, status, orderId, operatorTransactionId, actor, reason)
+	case "AEPS_SETTLEMENT":
+		return db.SettleAEPSSettlementQuery(ctx, transactionID, status, orderId, operatorTransactionId, actor, reason)
+	default:
+		return fmt.Errorf("unknown service %s", service)
+	}
+}
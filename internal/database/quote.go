@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// quoteTransaction works out what a transaction costs a retailer and the
+// commission each member of its hierarchy gets. The reserve queries debit
+// exactly its Debit, so a quote matches the real transaction as long as
+// the commission and TDS settings do not change in between.
+//
+// On payouts and money transfers the whole commission is charged to the
+// retailer, who gets its own share back. On recharges and bill payments the
+// commission is funded by the commission pool and the retailer pays the
+// amount less its share; bill payments only pay the retailer's share. AEPS
+// cash withdrawals credit the amount to the settlement wallet and the
+// retailer's share, funded by the commission pool as on recharges, to the
+// main wallet, so their Debit is negative. The TDS on the retailer's share
+// is always taken with the debit.
+func quoteTransaction(
+	ctx context.Context,
+	q querier,
+	service, retailerID string,
+	operatorCode int,
+	amount models.Money,
+) (*models.TransactionQuoteModel, error) {
+	switch service {
+	case "PAYOUT", "DMT", "AEPS", "BBPS", "MOBILE_RECHARGE", "DTH_RECHARGE":
+	default:
+		return nil, fmt.Errorf("invalid service")
+	}
+	charged := service == "PAYOUT" || service == "DMT"
+	if charged || service == "AEPS" || service == "BBPS" {
+		operatorCode = 0
+	}
+
+	split, err := resolveCommision(ctx, q, service, retailerID, operatorCode, amount)
+	if err != nil {
+		return nil, err
+	}
+	if split == nil {
+		split = &models.CommisionSplitModel{}
+	}
+	if service == "BBPS" {
+		split.DistributorCommision = 0
+		split.MasterDistributorCommision = 0
+	}
+	h, err := getRetailerHierarchy(ctx, q, retailerID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.TransactionQuoteModel{
+		Service:      service,
+		OperatorCode: operatorCode,
+		Amount:       amount,
+		Commision:    *split,
+		Debit:        amount - split.RetailerCommision,
+	}
+	if service == "AEPS" {
+		quote.Debit = -split.RetailerCommision
+	}
+	members := []models.QuoteMemberModel{
+		{UserID: retailerID, Role: "retailer", Commision: split.RetailerCommision},
+		{UserID: h.distributorID, Role: "distributor", Commision: split.DistributorCommision},
+		{UserID: h.masterDistributorID, Role: "master_distributor", Commision: split.MasterDistributorCommision},
+	}
+	if charged {
+		quote.Charges = split.TotalCommision
+		quote.Debit += split.TotalCommision
+		members = append(members, models.QuoteMemberModel{
+			UserID: h.adminID, Role: "admin", Commision: split.AdminCommision,
+		})
+	}
+
+	for _, m := range members {
+		if m.Commision <= 0 {
+			continue
+		}
+		d, err := commisionTDS(ctx, q, m.UserID, m.Commision)
+		if err != nil {
+			return nil, err
+		}
+		m.TDS = d.tds
+		m.NetCommision = m.Commision - d.tds
+		quote.Members = append(quote.Members, m)
+	}
+	if len(quote.Members) > 0 && quote.Members[0].Role == "retailer" {
+		quote.RetailerTDS = quote.Members[0].TDS
+		quote.Debit += quote.RetailerTDS
+	}
+	return quote, nil
+}
+
+// GetTransactionQuoteQuery quotes a transaction against the retailer's
+// current available balance.
+func (db *Database) GetTransactionQuoteQuery(
+	ctx context.Context,
+	service, retailerID string,
+	operatorCode int,
+	amount models.Money,
+) (*models.TransactionQuoteModel, error) {
+	quote, err := quoteTransaction(ctx, db.pool, service, retailerID, operatorCode, amount)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := db.getWalletBalance(ctx, "retailer", retailerID)
+	if err != nil {
+		return nil, err
+	}
+	quote.WalletBalance = balance.AvailableBalance
+	quote.BalanceAfter = balance.AvailableBalance - quote.Debit
+	return quote, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestQuoteMatchesTheReservation(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(2000))
+
+	// The default payout slab charges 1.2%, of which the retailer gets
+	// half back; the retailer's TDS at 2% is taken with the debit.
+	payout, err := db.GetTransactionQuoteQuery(ctx, "PAYOUT", h.RetailerID, 0, models.Rupees(1000))
+	if err != nil {
+		t.Fatalf("GetTransactionQuoteQuery: %v", err)
+	}
+	if payout.Charges != models.Rupees(12) {
+		t.Errorf("payout charges = %s, want 12.00", payout.Charges)
+	}
+	if payout.RetailerTDS != 12*models.Paisa {
+		t.Errorf("payout retailer TDS = %s, want 0.12", payout.RetailerTDS)
+	}
+	if want := models.Rupees(1006) + 12*models.Paisa; payout.Debit != want {
+		t.Errorf("payout debit = %s, want %s", payout.Debit, want)
+	}
+	if want := models.Rupees(2000) - payout.Debit; payout.BalanceAfter != want {
+		t.Errorf("payout balance after = %s, want %s", payout.BalanceAfter, want)
+	}
+	wantMembers := []models.QuoteMemberModel{
+		{UserID: h.RetailerID, Role: "retailer", Commision: models.Rupees(6), TDS: 12 * models.Paisa, NetCommision: 588 * models.Paisa},
+		{UserID: h.DistributorID, Role: "distributor", Commision: 240 * models.Paisa, TDS: 5 * models.Paisa, NetCommision: 235 * models.Paisa},
+		{UserID: h.MasterDistributorID, Role: "master_distributor", Commision: 60 * models.Paisa, TDS: models.Paisa, NetCommision: 59 * models.Paisa},
+		{UserID: h.AdminID, Role: "admin", Commision: models.Rupees(3), NetCommision: models.Rupees(3)},
+	}
+	if len(payout.Members) != len(wantMembers) {
+		t.Fatalf("payout members = %+v, want %+v", payout.Members, wantMembers)
+	}
+	for i, m := range payout.Members {
+		if m != wantMembers[i] {
+			t.Errorf("payout member %d = %+v, want %+v", i, m, wantMembers[i])
+		}
+	}
+
+	before := dbtest.Balance(t, conn, h.RetailerID)
+	if _, err := db.ReservePayoutQuery(ctx, models.CreatePayoutRequestModel{
+		RetailerId:      h.RetailerID,
+		MobileNumber:    "9876543210",
+		IFSCCode:        "HDFC0000001",
+		BankName:        "HDFC Bank",
+		AccountNumber:   "50100000000001",
+		BeneficiaryName: "Test",
+		Amount:          models.Rupees(1000),
+		TransferType:    5,
+	}); err != nil {
+		t.Fatalf("ReservePayoutQuery: %v", err)
+	}
+	if got := before - dbtest.Balance(t, conn, h.RetailerID); got != payout.Debit {
+		t.Errorf("payout reserved %s, quoted %s", got, payout.Debit)
+	}
+
+	// A recharge above ₹99 earns the retailer ₹1, taken off the debit.
+	recharge, err := db.GetTransactionQuoteQuery(ctx, "MOBILE_RECHARGE", h.RetailerID, 1, models.Rupees(100))
+	if err != nil {
+		t.Fatalf("GetTransactionQuoteQuery: %v", err)
+	}
+	if want := models.Rupees(99) + 2*models.Paisa; recharge.Debit != want {
+		t.Errorf("recharge debit = %s, want %s", recharge.Debit, want)
+	}
+	before = dbtest.Balance(t, conn, h.RetailerID)
+	if _, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(100))); err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if got := before - dbtest.Balance(t, conn, h.RetailerID); got != recharge.Debit {
+		t.Errorf("recharge reserved %s, quoted %s", got, recharge.Debit)
+	}
+
+	// Nothing is configured for bill payments.
+	bill, err := db.GetTransactionQuoteQuery(ctx, "BBPS", h.RetailerID, 0, models.Rupees(500))
+	if err != nil {
+		t.Fatalf("GetTransactionQuoteQuery: %v", err)
+	}
+	if bill.Debit != models.Rupees(500) || len(bill.Members) != 0 {
+		t.Errorf("bill quote = %+v, want a debit of 500.00 and no commission", bill)
+	}
+
+	if _, err := db.GetTransactionQuoteQuery(ctx, "LOAN", h.RetailerID, 0, models.Rupees(500)); err == nil {
+		t.Error("quoting an unknown service succeeded")
+	}
+}
@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// RunReconciliationQuery checks every wallet, including the retailers' AEPS
+// settlement wallets, against its wallet_transactions statement and stores
+// the breaks it finds under a new reconciliation run. A wallet is
+// consistent when:
+//   - each row's before_balance equals the previous row's after_balance
+//     (zero for the first row),
+//   - each row moves the balance by exactly its credit or debit amount, and
+//   - the wallet balance equals the sum of all credits minus all debits.
+//
+// The checks run in a single repeatable read transaction so balances and
+// statements are compared as of the same snapshot.
+func (db *Database) RunReconciliationQuery(
+	ctx context.Context,
+	triggeredBy string,
+) (*models.ReconciliationRunModel, error) {
+	insertRunQuery := `
+		INSERT INTO reconciliation_runs (
+			triggered_by,
+			run_status
+		) VALUES (
+			@triggered_by,
+			'RUNNING'
+		)
+		RETURNING run_id, started_at;
+	`
+	run := models.ReconciliationRunModel{
+		TriggeredBy: triggeredBy,
+		RunStatus:   "RUNNING",
+	}
+	if err := db.pool.QueryRow(ctx, insertRunQuery, pgx.NamedArgs{
+		"triggered_by": triggeredBy,
+	}).Scan(&run.RunID, &run.StartedAt); err != nil {
+		return nil, fmt.Errorf("failed to start reconciliation run")
+	}
+
+	if err := db.reconcile(ctx, &run); err != nil {
+		failRunQuery := `
+			UPDATE reconciliation_runs
+			SET run_status = 'FAILED',
+				error_message = @error_message,
+				finished_at = NOW()
+			WHERE run_id = @run_id;
+		`
+		// The run is marked failed even if the caller's context is gone.
+		db.pool.Exec(context.WithoutCancel(ctx), failRunQuery, pgx.NamedArgs{
+			"run_id":        run.RunID,
+			"error_message": err.Error(),
+		})
+		return nil, fmt.Errorf("reconciliation run %d failed", run.RunID)
+	}
+
+	return &run, nil
+}
+
+func (db *Database) reconcile(ctx context.Context, run *models.ReconciliationRunModel) error {
+	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	countQuery := `
+		SELECT
+			(SELECT COUNT(*) FROM admins)
+			+ (SELECT COUNT(*) FROM master_distributors)
+			+ (SELECT COUNT(*) FROM distributors)
+			+ (SELECT COUNT(*) FROM retailers) * 2,
+			(SELECT COUNT(*) FROM wallet_transactions);
+	`
+	if err := tx.QueryRow(ctx, countQuery).Scan(
+		&run.WalletsChecked,
+		&run.TransactionsChecked,
+	); err != nil {
+		return err
+	}
+
+	insertBreaksQuery := `
+		WITH wallets AS (
+			SELECT admin_id AS user_id, 'MAIN' AS wallet_type, admin_wallet_balance AS balance FROM admins
+			UNION ALL
+			SELECT master_distributor_id, 'MAIN', master_distributor_wallet_balance FROM master_distributors
+			UNION ALL
+			SELECT distributor_id, 'MAIN', distributor_wallet_balance FROM distributors
+			UNION ALL
+			SELECT retailer_id, 'MAIN', retailer_wallet_balance FROM retailers
+			UNION ALL
+			SELECT retailer_id, 'SETTLEMENT', retailer_settlement_wallet_balance FROM retailers
+		),
+		statement AS (
+			SELECT
+				wallet_transaction_id,
+				user_id,
+				wallet_type,
+				reference_id,
+				COALESCE(credit_amount, 0) - COALESCE(debit_amount, 0) AS net_amount,
+				before_balance,
+				after_balance,
+				LAG(after_balance, 1, 0) OVER (
+					PARTITION BY user_id, wallet_type
+					ORDER BY wallet_transaction_id
+				) AS previous_after_balance
+			FROM wallet_transactions
+		),
+		totals AS (
+			SELECT
+				user_id,
+				wallet_type,
+				SUM(net_amount) AS net_amount,
+				(ARRAY_AGG(reference_id ORDER BY wallet_transaction_id DESC))[1] AS last_reference_id
+			FROM statement
+			GROUP BY user_id, wallet_type
+		)
+		INSERT INTO reconciliation_breaks (
+			run_id,
+			user_id,
+			wallet_type,
+			break_type,
+			reference_id,
+			wallet_transaction_id,
+			expected_amount,
+			actual_amount
+		)
+		SELECT
+			@run_id, user_id, wallet_type, 'CHAIN_BREAK', reference_id, wallet_transaction_id,
+			previous_after_balance, before_balance
+		FROM statement
+		WHERE before_balance <> previous_after_balance
+		UNION ALL
+		SELECT
+			@run_id, user_id, wallet_type, 'AMOUNT_MISMATCH', reference_id, wallet_transaction_id,
+			before_balance + net_amount, after_balance
+		FROM statement
+		WHERE after_balance <> before_balance + net_amount
+		UNION ALL
+		SELECT
+			@run_id, w.user_id, w.wallet_type, 'BALANCE_MISMATCH', t.last_reference_id, NULL,
+			COALESCE(t.net_amount, 0), w.balance
+		FROM wallets w
+		LEFT JOIN totals t
+			ON t.user_id = w.user_id
+			AND t.wallet_type = w.wallet_type
+		WHERE w.balance <> COALESCE(t.net_amount, 0);
+	`
+	tag, err := tx.Exec(ctx, insertBreaksQuery, pgx.NamedArgs{
+		"run_id": run.RunID,
+	})
+	if err != nil {
+		return err
+	}
+	run.BreaksFound = int(tag.RowsAffected())
+
+	completeRunQuery := `
+		UPDATE reconciliation_runs
+		SET run_status = 'COMPLETED',
+			wallets_checked = @wallets_checked,
+			transactions_checked = @transactions_checked,
+			breaks_found = @breaks_found,
+			finished_at = NOW()
+		WHERE run_id = @run_id
+		RETURNING run_status, finished_at;
+	`
+	if err := tx.QueryRow(ctx, completeRunQuery, pgx.NamedArgs{
+		"run_id":               run.RunID,
+		"wallets_checked":      run.WalletsChecked,
+		"transactions_checked": run.TransactionsChecked,
+		"breaks_found":         run.BreaksFound,
+	}).Scan(&run.RunStatus, &run.FinishedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (db *Database) GetReconciliationRunsQuery(
+	ctx context.Context,
+	limit, offset int,
+) ([]models.ReconciliationRunModel, error) {
+	query := `
+		SELECT
+			run_id,
+			triggered_by,
+			run_status,
+			wallets_checked,
+			transactions_checked,
+			breaks_found,
+			error_message,
+			started_at,
+			finished_at
+		FROM reconciliation_runs
+		ORDER BY run_id DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reconciliation runs")
+	}
+	defer rows.Close()
+
+	var runs []models.ReconciliationRunModel
+	for rows.Next() {
+		var run models.ReconciliationRunModel
+		if err := rows.Scan(
+			&run.RunID,
+			&run.TriggeredBy,
+			&run.RunStatus,
+			&run.WalletsChecked,
+			&run.TransactionsChecked,
+			&run.BreaksFound,
+			&run.ErrorMessage,
+			&run.StartedAt,
+			&run.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+func (db *Database) GetReconciliationBreaksQuery(
+	ctx context.Context,
+	runID int64,
+	limit, offset int,
+) ([]models.ReconciliationBreakModel, error) {
+	query := `
+		SELECT
+			break_id,
+			run_id,
+			user_id,
+			wallet_type,
+			break_type,
+			reference_id,
+			wallet_transaction_id,
+			expected_amount,
+			actual_amount,
+			created_at
+		FROM reconciliation_breaks
+		WHERE run_id = @run_id
+		ORDER BY user_id, wallet_type, wallet_transaction_id NULLS LAST
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"run_id": runID,
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reconciliation breaks")
+	}
+	defer rows.Close()
+
+	var breaks []models.ReconciliationBreakModel
+	for rows.Next() {
+		var b models.ReconciliationBreakModel
+		if err := rows.Scan(
+			&b.BreakID,
+			&b.RunID,
+			&b.UserID,
+			&b.WalletType,
+			&b.BreakType,
+			&b.ReferenceID,
+			&b.WalletTransactionID,
+			&b.ExpectedAmount,
+			&b.ActualAmount,
+			&b.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		breaks = append(breaks, b)
+	}
+
+	return breaks, rows.Err()
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestReconciliationFindsNoBreaksInPostedWallets(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(1000))
+	dbtest.Fund(t, conn, h.DistributorID, models.Rupees(500))
+
+	run, err := db.RunReconciliationQuery(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("RunReconciliationQuery: %v", err)
+	}
+	if run.RunStatus != "COMPLETED" || run.BreaksFound != 0 {
+		t.Errorf("run = %s with %d breaks, want COMPLETED with none", run.RunStatus, run.BreaksFound)
+	}
+	if run.WalletsChecked != 4 || run.TransactionsChecked != 2 {
+		t.Errorf("checked %d wallets and %d transactions, want 4 and 2", run.WalletsChecked, run.TransactionsChecked)
+	}
+}
+
+func TestReconciliationReportsBreaks(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(1000))
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(500))
+
+	// The second statement row no longer follows the first, and the wallet
+	// holds five rupees its statement does not explain.
+	if _, err := conn.Exec(ctx, `
+		UPDATE wallet_transactions
+		SET before_balance = 999
+		WHERE wallet_transaction_id = (SELECT MAX(wallet_transaction_id) FROM wallet_transactions);
+	`); err != nil {
+		t.Fatalf("break the statement: %v", err)
+	}
+	if _, err := conn.Exec(ctx, `
+		UPDATE retailers SET retailer_wallet_balance = retailer_wallet_balance + 5;
+	`); err != nil {
+		t.Fatalf("break the balance: %v", err)
+	}
+
+	run, err := db.RunReconciliationQuery(ctx, "test")
+	if err != nil {
+		t.Fatalf("RunReconciliationQuery: %v", err)
+	}
+	breaks, err := db.GetReconciliationBreaksQuery(ctx, run.RunID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetReconciliationBreaksQuery: %v", err)
+	}
+
+	want := map[string][2]models.Money{
+		"CHAIN_BREAK":      {models.Rupees(1000), models.Rupees(999)},
+		"AMOUNT_MISMATCH":  {models.Rupees(1499), models.Rupees(1500)},
+		"BALANCE_MISMATCH": {models.Rupees(1500), models.Rupees(1505)},
+	}
+	if run.BreaksFound != len(want) || len(breaks) != len(want) {
+		t.Fatalf("found %d breaks (%d stored), want %d", run.BreaksFound, len(breaks), len(want))
+	}
+	for _, b := range breaks {
+		amounts, ok := want[b.BreakType]
+		if !ok {
+			t.Errorf("unexpected %s break", b.BreakType)
+			continue
+		}
+		if b.UserID != h.RetailerID {
+			t.Errorf("%s break on %s, want %s", b.BreakType, b.UserID, h.RetailerID)
+		}
+		if b.ReferenceID == nil || *b.ReferenceID != h.RetailerID {
+			t.Errorf("%s break has reference %v, want %s", b.BreakType, b.ReferenceID, h.RetailerID)
+		}
+		if b.ExpectedAmount != amounts[0] || b.ActualAmount != amounts[1] {
+			t.Errorf("%s break expected %s, actual %s; want %s and %s",
+				b.BreakType, b.ExpectedAmount, b.ActualAmount, amounts[0], amounts[1])
+		}
+	}
+}
@@ -0,0 +1,531 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+// Refunds of payouts, recharges and bill payments do not know how each
+// service paid its provider and commissions. They read back what the ledger
+// recorded under the transaction's reference and post the opposite, so
+// every commission credited to the hierarchy, and any tax deducted from it,
+// is clawed back along with the provider payment. A partial refund reverses
+// the same share of every posting. Wallets give back what they were paid as
+// clawbacks: a member of the hierarchy who has already spent or held its
+// commission owes the shortfall, and the retailer is refunded regardless.
+// Transactions from before the ledger have no postings, so theirs are
+// rebuilt from the commissions on their row.
+// A reversed AEPS transaction runs the other way: the amount comes back out
+// of the settlement wallet and the commission returns to the commission
+// pool. Bank settlements of AEPS money go back to the settlement wallet
+// instead (see refundSentAEPSSettlement).
+
+// serviceTransaction is the part of a payout, recharge or bill payment that
+// a refund needs.
+type serviceTransaction struct {
+	service       string
+	transactionID string
+	retailerID    string
+	amount        models.Money
+	status        string
+}
+
+// lockServiceTransaction locks a transaction row of any service.
+func lockServiceTransaction(ctx context.Context, tx pgx.Tx, service, transactionID string) (*serviceTransaction, error) {
+	var query string
+	switch service {
+	case "PAYOUT":
+		query = `
+			SELECT retailer_id, amount, payout_transaction_status
+			FROM payout_transactions
+			WHERE payout_transaction_id = @transaction_id::UUID
+			FOR UPDATE;
+		`
+	case "MOBILE_RECHARGE":
+		query = `
+			SELECT retailer_id, amount, status
+			FROM mobile_recharge
+			WHERE mobile_recharge_transaction_id = @transaction_id::BIGINT
+			FOR UPDATE;
+		`
+	case "DTH_RECHARGE":
+		query = `
+			SELECT retailer_id, amount, status
+			FROM dth_recharge
+			WHERE dth_transaction_id = @transaction_id::BIGINT
+			FOR UPDATE;
+		`
+	case "BBPS":
+		query = `
+			SELECT retailer_id, amount, transaction_status
+			FROM bbps_transactions
+			WHERE bbps_transaction_id = @transaction_id::UUID
+			FOR UPDATE;
+		`
+	case "DMT":
+		query = `
+			SELECT retailer_id, amount, transaction_status
+			FROM dmt_transactions
+			WHERE dmt_transaction_id = @transaction_id::UUID
+			FOR UPDATE;
+		`
+	case "AEPS":
+		query = `
+			SELECT retailer_id, amount, transaction_status
+			FROM aeps_transactions
+			WHERE aeps_transaction_id = @transaction_id::UUID
+			FOR UPDATE;
+		`
+	default:
+		return nil, fmt.Errorf("unknown service %s", service)
+	}
+
+	t := serviceTransaction{service: service, transactionID: transactionID}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(
+		&t.retailerID,
+		&t.amount,
+		&t.status,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("invalid transaction id")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// refundFailed returns all of a transaction the provider declined to the
+// retailer.
+func refundFailed(ctx context.Context, tx pgx.Tx, t *serviceTransaction) error {
+	_, err := reverseTransaction(ctx, tx, t, 0, t.amount, fmt.Sprintf("Refund of transaction %s", t.transactionID))
+	return err
+}
+
+// RefundTransactionQuery refunds amount of a transaction to the retailer, or
+// whatever has not been refunded yet when amount is zero. The refund that
+// brings the total refunded up to the transaction amount moves the
+// transaction to REFUND; earlier partial refunds leave its status alone.
+func (db *Database) RefundTransactionQuery(
+	ctx context.Context,
+	service, transactionID string,
+	amount models.Money,
+	actor txstate.Actor,
+	reason string,
+) (*models.TransactionRefundModel, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if service == "AEPS_SETTLEMENT" {
+		refund, err := refundSentAEPSSettlement(ctx, tx, transactionID, amount, actor, reason)
+		if err != nil {
+			return nil, err
+		}
+		return refund, tx.Commit(ctx)
+	}
+
+	t, err := lockServiceTransaction(ctx, tx, service, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := txstate.Check(t.status, txstate.Refund, actor); err != nil {
+		return nil, err
+	}
+
+	refunded, err := refundedAmount(ctx, tx, service, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := t.amount - refunded
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("refund amount must be between 0.01 and %s", remaining)
+	}
+
+	journalID, err := reverseTransaction(ctx, tx, t, refunded, amount, fmt.Sprintf("Refund of transaction %s", transactionID))
+	if err != nil {
+		return nil, err
+	}
+
+	refund, err := insertRefund(ctx, tx, t, amount, journalID, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount == remaining {
+		if err := txstate.Transition(ctx, tx, txstate.Change{
+			Service:       service,
+			TransactionID: transactionID,
+			From:          t.status,
+			To:            txstate.Refund,
+			Actor:         actor,
+			Reason:        reason,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return refund, tx.Commit(ctx)
+}
+
+// reverseTransaction gives amount of a transaction back to the retailer,
+// refunded being what earlier refunds already gave back, and returns the
+// journal id. A transaction still holding its reservation has paid nobody
+// yet, so the reservation is released, which only a full refund can do.
+// Otherwise the refund reverses the transaction's postings: a partial
+// refund their share of them, and the last refund whatever is left, so
+// rounding in earlier partial refunds never leaves a paisa behind.
+func reverseTransaction(
+	ctx context.Context,
+	tx pgx.Tx,
+	t *serviceTransaction,
+	refunded models.Money,
+	amount models.Money,
+	remarks string,
+) (int64, error) {
+	r, err := lockReservation(ctx, tx, t.service, t.transactionID)
+	if err != nil {
+		return 0, err
+	}
+	if r != nil {
+		if refunded != 0 || amount != t.amount {
+			return 0, fmt.Errorf("a pending transaction can only be refunded in full")
+		}
+		return releaseReservation(ctx, tx, r, remarks)
+	}
+
+	final := refunded+amount == t.amount
+	postings, err := netPostings(ctx, tx, t.transactionID, []string{t.service})
+	if err != nil {
+		return 0, err
+	}
+	if len(postings) == 0 {
+		if postings, err = legacyPostings(ctx, tx, t); err != nil {
+			return 0, err
+		}
+	}
+	if final {
+		refunds, err := netPostings(ctx, tx, t.transactionID, []string{t.service + "_REFUND"})
+		if err != nil {
+			return 0, err
+		}
+		postings = mergePostings(postings, refunds)
+	}
+
+	// Every account gives back its share of what it received; the retailer
+	// takes up the rounding so the journal balances. TDS is reversed per
+	// deduction and commission per member of the hierarchy, so their
+	// records follow the ledger.
+	journal := ledger.NewJournal(t.transactionID, t.service+"_REFUND", remarks)
+	returned, err := reverseCommisionTDS(ctx, tx, journal, t.service, t.transactionID, amount, t.amount, final)
+	if err != nil {
+		return 0, err
+	}
+	clawedBack, covered, err := reverseSettledCommisions(ctx, tx, journal, t.service, t.transactionID, amount, t.amount, final)
+	if err != nil {
+		return 0, err
+	}
+	returned += clawedBack
+	for _, p := range postings {
+		if p.account == ledger.User(t.retailerID) || p.account == ledger.TDSPayable || p.account == ledger.CommisionPayable || covered[p.account] {
+			continue
+		}
+		share := p.net
+		if !final {
+			share = p.net.Prorate(amount, t.amount)
+		}
+		reverseEntry(journal, p.account, share, fmt.Sprintf("Refund clawback from %s", p.account.ID))
+		returned += share
+	}
+	reverseEntry(journal, ledger.User(t.retailerID), -returned, "")
+
+	return ledger.Post(ctx, tx, journal)
+}
+
+// legacyPostings rebuilds the postings of a transaction made before the
+// ledger from the commissions recorded on its row, so it is refunded like
+// any other: the provider gives back the amount and every commission goes
+// back to whoever funded it. The retailer's side is left out, as reversing
+// the rest credits it with what it paid. Payouts charged the hierarchy's
+// commission to the retailer; recharges and bill payments had the admin
+// fund it.
+func legacyPostings(ctx context.Context, tx pgx.Tx, t *serviceTransaction) ([]netPosting, error) {
+	var query string
+	charged := false
+	switch t.service {
+	case "PAYOUT":
+		charged = true
+		query = `
+			SELECT admin_commision, master_distributor_commision, distributor_commision, 0
+			FROM payout_transactions
+			WHERE payout_transaction_id = @transaction_id::UUID;
+		`
+	case "MOBILE_RECHARGE":
+		query = `
+			SELECT 0, master_distributor_commision, distributor_commision, commision
+			FROM mobile_recharge
+			WHERE mobile_recharge_transaction_id = @transaction_id::BIGINT;
+		`
+	case "DTH_RECHARGE":
+		query = `
+			SELECT 0, master_distributor_commision, distributor_commision, commision
+			FROM dth_recharge
+			WHERE dth_transaction_id = @transaction_id::BIGINT;
+		`
+	case "BBPS":
+		query = `
+			SELECT 0, 0, 0, commision
+			FROM bbps_transactions
+			WHERE bbps_transaction_id = @transaction_id::UUID;
+		`
+	default:
+		return nil, fmt.Errorf("transaction %s has no ledger postings to reverse", t.transactionID)
+	}
+
+	var admin, masterDistributor, distributor, retailer models.Money
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": t.transactionID,
+	}).Scan(
+		&admin,
+		&masterDistributor,
+		&distributor,
+		&retailer,
+	); err != nil {
+		return nil, err
+	}
+	h, err := getRetailerHierarchy(ctx, tx, t.retailerID)
+	if err != nil {
+		return nil, err
+	}
+	if !charged {
+		admin = -(masterDistributor + distributor + retailer)
+	}
+
+	postings := []netPosting{{account: ledger.ProviderFloat, net: t.amount}}
+	for _, p := range []netPosting{
+		{ledger.User(h.adminID), admin},
+		{ledger.User(h.masterDistributorID), masterDistributor},
+		{ledger.User(h.distributorID), distributor},
+	} {
+		if p.net != 0 {
+			postings = append(postings, p)
+		}
+	}
+	return postings, nil
+}
+
+// reverseEntry books the opposite of a net posting. Wallets give back what
+// they received as a clawback, so a refund never depends on whether they
+// still hold it.
+func reverseEntry(j *ledger.Journal, account ledger.Account, net models.Money, remarks string) {
+	if net > 0 && account.Type != ledger.SystemAccount {
+		j.Clawback(account, net, remarks)
+	} else if net > 0 {
+		j.Debit(account, net, remarks)
+	} else {
+		j.Credit(account, -net, remarks)
+	}
+}
+
+type netPosting struct {
+	account ledger.Account
+	net     models.Money
+}
+
+// netPostings returns, per account, the credits minus the debits of the
+// transaction's journals with the given reasons. Service transaction ids
+// are not unique across services, so the reasons also pick the service.
+func netPostings(ctx context.Context, tx pgx.Tx, referenceID string, reasons []string) ([]netPosting, error) {
+	query := `
+		SELECT
+			e.account_type,
+			e.account_id,
+			SUM(e.credit_amount - e.debit_amount)
+		FROM ledger_entries e
+		JOIN ledger_journals j
+			ON j.journal_id = e.journal_id
+		WHERE j.reference_id = @reference_id
+		AND j.journal_reason = ANY (@reasons)
+		GROUP BY e.account_type, e.account_id
+		HAVING SUM(e.credit_amount - e.debit_amount) <> 0
+		ORDER BY e.account_type, e.account_id;
+	`
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{
+		"reference_id": referenceID,
+		"reasons":      reasons,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postings []netPosting
+	for rows.Next() {
+		var (
+			p           netPosting
+			accountType string
+		)
+		if err := rows.Scan(&accountType, &p.account.ID, &p.net); err != nil {
+			return nil, err
+		}
+		p.account.Type = ledger.AccountType(accountType)
+		postings = append(postings, p)
+	}
+	return postings, rows.Err()
+}
+
+// mergePostings adds up the postings of both lists per account, dropping
+// accounts that net to zero.
+func mergePostings(a, b []netPosting) []netPosting {
+	var merged []netPosting
+	index := make(map[ledger.Account]int)
+	for _, p := range append(append([]netPosting(nil), a...), b...) {
+		if i, ok := index[p.account]; ok {
+			merged[i].net += p.net
+			continue
+		}
+		index[p.account] = len(merged)
+		merged = append(merged, p)
+	}
+
+	postings := merged[:0]
+	for _, p := range merged {
+		if p.net != 0 {
+			postings = append(postings, p)
+		}
+	}
+	return postings
+}
+
+func refundedAmount(ctx context.Context, tx pgx.Tx, service, transactionID string) (models.Money, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transaction_refunds
+		WHERE service = @service
+		AND transaction_id = @transaction_id;
+	`
+	var refunded models.Money
+	err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+	}).Scan(&refunded)
+	return refunded, err
+}
+
+func insertRefund(
+	ctx context.Context,
+	tx pgx.Tx,
+	t *serviceTransaction,
+	amount models.Money,
+	journalID int64,
+	actor txstate.Actor,
+	reason string,
+) (*models.TransactionRefundModel, error) {
+	query := `
+		INSERT INTO transaction_refunds (
+			service,
+			transaction_id,
+			amount,
+			journal_id,
+			actor_type,
+			actor_id,
+			reason
+		) VALUES (
+			@service,
+			@transaction_id,
+			@amount,
+			@journal_id,
+			@actor_type,
+			NULLIF(@actor_id, ''),
+			@reason
+		)
+		RETURNING refund_id, actor_id, created_at;
+	`
+	refund := models.TransactionRefundModel{
+		Service:       t.service,
+		TransactionID: t.transactionID,
+		Amount:        amount,
+		JournalID:     journalID,
+		ActorType:     actor.Type,
+		Reason:        reason,
+	}
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"service":        t.service,
+		"transaction_id": t.transactionID,
+		"amount":         amount,
+		"journal_id":     journalID,
+		"actor_type":     actor.Type,
+		"actor_id":       actor.ID,
+		"reason":         reason,
+	}).Scan(
+		&refund.RefundID,
+		&refund.ActorID,
+		&refund.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetTransactionRefundsQuery returns the refunds of a transaction, oldest
+// first.
+func (db *Database) GetTransactionRefundsQuery(
+	ctx context.Context,
+	service, transactionID string,
+) ([]models.TransactionRefundModel, error) {
+	query := `
+		SELECT
+			refund_id,
+			service,
+			transaction_id,
+			amount,
+			journal_id,
+			actor_type,
+			actor_id,
+			reason,
+			created_at
+		FROM transaction_refunds
+		WHERE service = @service
+		AND transaction_id = @transaction_id
+		ORDER BY refund_id;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []models.TransactionRefundModel
+	for rows.Next() {
+		var r models.TransactionRefundModel
+		if err := rows.Scan(
+			&r.RefundID,
+			&r.Service,
+			&r.TransactionID,
+			&r.Amount,
+			&r.JournalID,
+			&r.ActorType,
+			&r.ActorID,
+			&r.Reason,
+			&r.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, r)
+	}
+	return refunds, rows.Err()
+}
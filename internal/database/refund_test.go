@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func TestMergePostings(t *testing.T) {
+	original := []netPosting{
+		{ledger.ProviderFloat, models.Rupees(100)},
+		{ledger.User("D000001"), models.Rupees(2)},
+		{ledger.User("A000001"), models.Rupees(-3)},
+	}
+	refunds := []netPosting{
+		{ledger.ProviderFloat, models.Rupees(-40)},
+		{ledger.User("D000001"), models.Rupees(-2)},
+		{ledger.User("R000001"), models.Rupees(41)},
+	}
+
+	got := mergePostings(original, refunds)
+	want := []netPosting{
+		{ledger.ProviderFloat, models.Rupees(60)},
+		{ledger.User("A000001"), models.Rupees(-3)},
+		{ledger.User("R000001"), models.Rupees(41)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mergePostings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("posting %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func postJournal(t *testing.T, conn *pgx.Conn, journal *ledger.Journal) {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+func TestRefundClawsBackHeldAndSpentMoney(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.AdminID, models.Rupees(100))
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100))
+
+	transactionID, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(60)))
+	if err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if err := db.SettleMobileRechargeQuery(ctx, transactionID, "SUCCESS", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleMobileRechargeQuery: %v", err)
+	}
+
+	// The distributor earned 5 on the recharge, spent 3 of it and has the
+	// other 2 on hold.
+	postJournal(t, conn, ledger.NewJournal(transactionID, "MOBILE_RECHARGE", "test commission").
+		Debit(ledger.User(h.RetailerID), models.Rupees(5), "").
+		Credit(ledger.User(h.DistributorID), models.Rupees(5), ""))
+	postJournal(t, conn, ledger.NewJournal(h.DistributorID, "ADJUSTMENT", "test spend").
+		Debit(ledger.User(h.DistributorID), models.Rupees(3), "").
+		Credit(ledger.Funding, models.Rupees(3), ""))
+	if _, err := db.PlaceWalletHoldQuery(ctx, models.CreateWalletHoldRequestModel{
+		UserID:   h.DistributorID,
+		Amount:   models.Rupees(2),
+		HoldType: "DISPUTE",
+		Remarks:  "test",
+	}, h.AdminID); err != nil {
+		t.Fatalf("PlaceWalletHoldQuery: %v", err)
+	}
+
+	if _, err := db.RefundTransactionQuery(ctx, "MOBILE_RECHARGE", transactionID, 0, txstate.Provider("TEST"), "reversed"); err != nil {
+		t.Fatalf("RefundTransactionQuery: %v", err)
+	}
+
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(100) {
+		t.Errorf("retailer balance = %s, want 100.00", got)
+	}
+	if got := dbtest.Balance(t, conn, h.DistributorID); got != 0 {
+		t.Errorf("distributor balance = %s, want 0.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "RECEIVABLE"); got != models.Rupees(-3) {
+		t.Errorf("receivable = %s, want -3.00", got)
+	}
+	var owed models.Money
+	if err := conn.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM ledger_receivables
+		WHERE account_id = $1;
+	`, h.DistributorID).Scan(&owed); err != nil {
+		t.Fatalf("receivables: %v", err)
+	}
+	if owed != models.Rupees(3) {
+		t.Errorf("distributor owes %s, want 3.00", owed)
+	}
+	var status string
+	if err := conn.QueryRow(ctx, "SELECT status FROM mobile_recharge WHERE mobile_recharge_transaction_id::TEXT = $1", transactionID).Scan(&status); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if status != txstate.Refund {
+		t.Errorf("status = %s, want %s", status, txstate.Refund)
+	}
+}
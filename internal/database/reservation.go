@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Provider transactions follow a reserve-then-settle flow. Before the
+// provider is called the retailer is debited into the suspense account under
+// a row lock, so concurrent requests cannot spend the same balance. Once the
+// provider answers the reservation is either settled (suspense pays the
+// provider and commissions) or released back to the wallet.
+
+type reservation struct {
+	ReservationID int64
+	UserID        string
+	Service       string
+	ReferenceID   string
+	Amount        models.Money
+}
+
+// lockRetailerForTransaction locks the retailer row and checks that the
+// retailer may transact. The wallet balance itself is checked when the
+// reservation is posted. Retailers come first in the ledger's lock order,
+// so journals posted later in the same transaction lock their other wallets
+// after this one.
+func lockRetailerForTransaction(ctx context.Context, tx pgx.Tx, retailerID string) error {
+	var (
+		retailerKYCStatus   bool
+		retailerBlockStatus bool
+	)
+	query := `
+		SELECT retailer_kyc_status, is_retailer_blocked
+		FROM retailers
+		WHERE retailer_id = @retailer_id
+		FOR UPDATE;
+	`
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"retailer_id": retailerID,
+	}).Scan(
+		&retailerKYCStatus,
+		&retailerBlockStatus,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Rejectf("invalid retailer id or retailer not found")
+		}
+		return err
+	}
+
+	if !retailerKYCStatus {
+		return models.Rejectf("retailer kyc is pending")
+	}
+
+	if retailerBlockStatus {
+		return models.Rejectf("retailer is blocked")
+	}
+	return nil
+}
+
+// reserveWallet debits amount from the user's wallet into suspense for the
+// given service transaction. The journal uses the service as its reason so
+// the debit shows on the statement like a regular service debit.
+func reserveWallet(
+	ctx context.Context,
+	tx pgx.Tx,
+	service, referenceID, userID string,
+	amount models.Money,
+	remarks string,
+) error {
+	if amount <= 0 {
+		return models.Rejectf("invalid amount")
+	}
+
+	query := `
+		INSERT INTO wallet_reservations (
+			user_id,
+			service,
+			reference_id,
+			amount,
+			reservation_status
+		) VALUES (
+			@user_id,
+			@service,
+			@reference_id,
+			@amount,
+			'RESERVED'
+		);
+	`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"user_id":      userID,
+		"service":      service,
+		"reference_id": referenceID,
+		"amount":       amount,
+	}); err != nil {
+		return err
+	}
+
+	journal := ledger.NewJournal(referenceID, service, remarks).
+		Debit(ledger.User(userID), amount, "").
+		Credit(ledger.Suspense, amount, "")
+	_, err := ledger.Post(ctx, tx, journal)
+	return err
+}
+
+// lockReservation returns the open reservation of a service transaction,
+// or nil when there is none: either it was already settled or released, or
+// the transaction predates reservations and was debited in full when it was
+// created.
+func lockReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	service, referenceID string,
+) (*reservation, error) {
+	query := `
+		SELECT reservation_id, user_id, service, reference_id, amount
+		FROM wallet_reservations
+		WHERE service = @service
+		AND reference_id = @reference_id
+		AND reservation_status = 'RESERVED'
+		FOR UPDATE;
+	`
+	var r reservation
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"service":      service,
+		"reference_id": referenceID,
+	}).Scan(
+		&r.ReservationID,
+		&r.UserID,
+		&r.Service,
+		&r.ReferenceID,
+		&r.Amount,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// settleReservation pays the reserved amount out of suspense. The journal
+// carries the credits (provider, commissions); the suspense debit is added
+// here and posting fails if the two do not match.
+func settleReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	r *reservation,
+	journal *ledger.Journal,
+) error {
+	journal.Debit(ledger.Suspense, r.Amount, "")
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		return err
+	}
+	return closeReservation(ctx, tx, r, "SETTLED")
+}
+
+// releaseReservation returns the reserved amount to the wallet it was taken
+// from, booked under the service's refund reason, cancels the commission
+// the transaction would have paid and returns the journal id.
+func releaseReservation(
+	ctx context.Context,
+	tx pgx.Tx,
+	r *reservation,
+	remarks string,
+) (int64, error) {
+	journal := ledger.NewJournal(r.ReferenceID, r.Service+"_REFUND", remarks).
+		Debit(ledger.Suspense, r.Amount, "").
+		Credit(ledger.User(r.UserID), r.Amount, "")
+	journalID, err := ledger.Post(ctx, tx, journal)
+	if err != nil {
+		return 0, err
+	}
+	if err := cancelPendingCommisions(ctx, tx, r.Service, r.ReferenceID); err != nil {
+		return 0, err
+	}
+	return journalID, closeReservation(ctx, tx, r, "RELEASED")
+}
+
+func closeReservation(ctx context.Context, tx pgx.Tx, r *reservation, status string) error {
+	query := `
+		UPDATE wallet_reservations
+		SET reservation_status = @status,
+			updated_at = NOW()
+		WHERE reservation_id = @reservation_id;
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"status":         status,
+		"reservation_id": r.ReservationID,
+	})
+	return err
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func mobileRechargeRequest(retailerID string, amount models.Money) models.CreateMobileRechargeRequestModel {
+	return models.CreateMobileRechargeRequestModel{
+		RetailerID:       retailerID,
+		MobileNumber:     9876543210,
+		OperatorCode:     1,
+		OperatorName:     "Test Operator",
+		Amount:           amount,
+		CircleCode:       1,
+		CircleName:       "Test Circle",
+		PartnerRequestID: "test",
+	}
+}
+
+func reservationStatus(t *testing.T, conn *pgx.Conn, service, referenceID string) string {
+	t.Helper()
+	query := `
+		SELECT reservation_status
+		FROM wallet_reservations
+		WHERE service = $1 AND reference_id = $2;
+	`
+	var status string
+	if err := conn.QueryRow(context.Background(), query, service, referenceID).Scan(&status); err != nil {
+		t.Fatalf("reservation of %s %s: %v", service, referenceID, err)
+	}
+	return status
+}
+
+func TestReservationHoldsFundsUntilSettled(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100))
+
+	transactionID, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(60)))
+	if err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(40) {
+		t.Errorf("retailer balance after reserving = %s, want 40.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "SUSPENSE"); got != models.Rupees(60) {
+		t.Errorf("suspense after reserving = %s, want 60.00", got)
+	}
+
+	// The reserved money cannot be spent twice.
+	if _, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(60))); err == nil {
+		t.Error("reserving more than the remaining balance succeeded")
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(40) {
+		t.Errorf("retailer balance after the refused reservation = %s, want 40.00", got)
+	}
+
+	if err := db.SettleMobileRechargeQuery(ctx, transactionID, "SUCCESS", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleMobileRechargeQuery: %v", err)
+	}
+	if got := reservationStatus(t, conn, "MOBILE_RECHARGE", transactionID); got != "SETTLED" {
+		t.Errorf("reservation = %s, want SETTLED", got)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(40) {
+		t.Errorf("retailer balance after success = %s, want 40.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "SUSPENSE"); got != 0 {
+		t.Errorf("suspense after success = %s, want 0.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "PROVIDER_FLOAT"); got != models.Rupees(60) {
+		t.Errorf("provider float after success = %s, want 60.00", got)
+	}
+}
+
+func TestReservationIsReleasedOnFailure(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100))
+
+	transactionID, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(60)))
+	if err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if err := db.SettleMobileRechargeQuery(ctx, transactionID, "FAILED", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleMobileRechargeQuery: %v", err)
+	}
+
+	if got := reservationStatus(t, conn, "MOBILE_RECHARGE", transactionID); got != "RELEASED" {
+		t.Errorf("reservation = %s, want RELEASED", got)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(100) {
+		t.Errorf("retailer balance after failure = %s, want 100.00", got)
+	}
+	if got := dbtest.SystemBalance(t, conn, "SUSPENSE"); got != 0 {
+		t.Errorf("suspense after failure = %s, want 0.00", got)
+	}
+	if err := db.SettleMobileRechargeQuery(ctx, transactionID, "SUCCESS", txstate.Provider("TEST"), ""); err == nil {
+		t.Error("settling a failed recharge as successful succeeded")
+	}
+
+	// Repeating the final status changes nothing.
+	if err := db.SettleMobileRechargeQuery(ctx, transactionID, "FAILED", txstate.Provider("TEST"), ""); err != nil {
+		t.Errorf("repeating FAILED: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(100) {
+		t.Errorf("retailer balance after repeating FAILED = %s, want 100.00", got)
+	}
+}
@@ -433,6 +433,12 @@ func (db *Database) UpdateRetailerDetailsQuery(
 	req models.UpdateRetailerDetailsRequestModel,
 ) error {
 
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE retailers
 		SET
@@ -455,12 +461,11 @@ func (db *Database) UpdateRetailerDetailsQuery(
 
 			retailer_gst_number = COALESCE(@gst, retailer_gst_number),
 			retailer_documents_url = COALESCE(@documents_url, retailer_documents_url),
-			retailer_wallet_balance = COALESCE(@wallet_balance , retailer_wallet_balance),
 			updated_at = NOW()
 		WHERE retailer_id = @retailer_id;
 	`
 
-	tag, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+	tag, err := tx.Exec(ctx, query, pgx.NamedArgs{
 		"retailer_id":   req.RetailerID,
 		"name":          req.RetailerName,
 		"phone":         req.RetailerPhone,
@@ -477,7 +482,6 @@ func (db *Database) UpdateRetailerDetailsQuery(
 		"business_type": req.BusinessType,
 		"gst":           req.GSTNumber,
 		"documents_url": req.DocumentsURL,
-		"wallet_balance": req.WalletBalance,
 	})
 
 	if err != nil {
@@ -487,7 +491,13 @@ func (db *Database) UpdateRetailerDetailsQuery(
 		return fmt.Errorf("invalid retailer id or retailer not found")
 	}
 
-	return nil
+	if req.WalletBalance != nil {
+		if err := setWalletBalance(ctx, tx, req.RetailerID, *req.WalletBalance); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (db *Database) UpdateRetailerPasswordQuery(
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
@@ -20,64 +21,7 @@ func (db *Database) CreateRevertQuery(
 	defer tx.Rollback(ctx)
 
 	/* -------------------------------------------------------
-	   1. Resolve tables
-	------------------------------------------------------- */
-
-	fromTable, err := db.getUserTable(req.FromID)
-	if err != nil {
-		return err
-	}
-
-	onTable, err := db.getUserTable(req.OnID)
-	if err != nil {
-		return err
-	}
-
-	/* -------------------------------------------------------
-	   2. Lock & get balances
-	------------------------------------------------------- */
-
-	var fromBefore, onBefore float64
-
-	getBalance := func(table, id string) (float64, error) {
-		query := fmt.Sprintf(`
-			SELECT %s_wallet_balance
-			FROM %ss
-			WHERE %s_id = @id
-			FOR UPDATE
-		`, table, table, table)
-
-		var bal float64
-		err := tx.QueryRow(ctx, query, pgx.NamedArgs{
-			"id": id,
-		}).Scan(&bal)
-
-		return bal, err
-	}
-
-	fromBefore, err = getBalance(fromTable, req.FromID)
-	if err != nil {
-		return err
-	}
-
-	onBefore, err = getBalance(onTable, req.OnID)
-	if err != nil {
-		return err
-	}
-
-	/* -------------------------------------------------------
-	   3. Balance validation
-	------------------------------------------------------- */
-
-	if onBefore < req.Amount {
-		return fmt.Errorf("insufficient wallet balance")
-	}
-
-	fromAfter := fromBefore + req.Amount
-	onAfter := onBefore - req.Amount
-
-	/* -------------------------------------------------------
-	   4. Create revert transaction (PENDING)
+	   1. Create revert transaction (PENDING)
 	------------------------------------------------------- */
 
 	var revertID int64
@@ -112,92 +56,18 @@ func (db *Database) CreateRevertQuery(
 	refID := fmt.Sprintf("%d", revertID)
 
 	/* -------------------------------------------------------
-	   5. Update wallets
+	   2. Post journal (fails if the wallet cannot cover it)
 	------------------------------------------------------- */
 
-	updateWallet := func(table, id string, balance float64) error {
-		query := fmt.Sprintf(`
-			UPDATE %ss
-			SET %s_wallet_balance = @bal,
-			    updated_at = NOW()
-			WHERE %s_id = @id
-		`, table, table, table)
-
-		_, err := tx.Exec(ctx, query, pgx.NamedArgs{
-			"id":  id,
-			"bal": balance,
-		})
-		return err
-	}
-
-	if err := updateWallet(fromTable, req.FromID, fromAfter); err != nil {
-		return err
-	}
-
-	if err := updateWallet(onTable, req.OnID, onAfter); err != nil {
+	journal := ledger.NewJournal(refID, "REVERT", req.Remarks).
+		Credit(ledger.User(req.FromID), req.Amount, fmt.Sprintf("Revert received from %s", req.OnID)).
+		Debit(ledger.User(req.OnID), req.Amount, fmt.Sprintf("Revert sent to %s", req.FromID))
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
 		return err
 	}
 
 	/* -------------------------------------------------------
-	   6. Wallet transactions
-	------------------------------------------------------- */
-
-	insertTxn := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@uid,
-			@ref,
-			@credit,
-			@debit,
-			@before,
-			@after,
-			'REVERT',
-			@remarks
-		);
-	`
-
-	// Credit → FROM user
-	_, err = tx.Exec(ctx, insertTxn, pgx.NamedArgs{
-		"uid":    req.FromID,
-		"ref":    refID,
-		"credit": req.Amount,
-		"debit":  nil,
-		"before": fromBefore,
-		"after":  fromAfter,
-		"remarks": fmt.Sprintf(
-			"Revert received from %s", req.OnID,
-		),
-	})
-	if err != nil {
-		return err
-	}
-
-	// Debit → ON user
-	_, err = tx.Exec(ctx, insertTxn, pgx.NamedArgs{
-		"uid":    req.OnID,
-		"ref":    refID,
-		"credit": nil,
-		"debit":  req.Amount,
-		"before": onBefore,
-		"after":  onAfter,
-		"remarks": fmt.Sprintf(
-			"Revert sent to %s", req.FromID,
-		),
-	})
-	if err != nil {
-		return err
-	}
-
-	/* -------------------------------------------------------
-	   7. Mark revert SUCCESS
+	   3. Mark revert SUCCESS
 	------------------------------------------------------- */
 
 	_, err = tx.Exec(ctx, `
@@ -212,7 +82,7 @@ func (db *Database) CreateRevertQuery(
 	}
 
 	/* -------------------------------------------------------
-	   8. Commit
+	   4. Commit
 	------------------------------------------------------- */
 
 	return tx.Commit(ctx)
@@ -328,7 +198,6 @@ func (db *Database) GetRevertTransactionsByFromIDQuery(
 	return results, rows.Err()
 }
 
-
 func (db *Database) GetRevertTransactionsByOnIDQuery(
 	ctx context.Context,
 	req models.GetRevertTransactionFilterRequestModel,
@@ -433,4 +302,3 @@ func (db *Database) GetRevertTransactionsByOnIDQuery(
 
 	return results, rows.Err()
 }
-
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// GetDueStatusChecksQuery returns PENDING provider transactions whose next
+// status check is due, oldest first. Transactions younger than minAge are
+// skipped so the request that created them can record the provider's first
+// answer itself.
+func (db *Database) GetDueStatusChecksQuery(
+	ctx context.Context,
+	minAge time.Duration,
+	limit int,
+) ([]models.PendingTransactionModel, error) {
+	query := `
+		WITH pending AS (
+			SELECT 'PAYOUT' AS service, payout_transaction_id::TEXT AS transaction_id, partner_request_id::TEXT AS partner_request_id, provider, created_at
+			FROM payout_transactions
+			WHERE payout_transaction_status = 'PENDING'
+			UNION ALL
+			SELECT 'MOBILE_RECHARGE', mobile_recharge_transaction_id::TEXT, partner_request_id, provider, created_at
+			FROM mobile_recharge
+			WHERE status = 'PENDING'
+			UNION ALL
+			SELECT 'DTH_RECHARGE', dth_transaction_id::TEXT, partner_request_id, provider, created_at
+			FROM dth_recharge
+			WHERE status = 'PENDING'
+			UNION ALL
+			SELECT 'BBPS', bbps_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM bbps_transactions
+			WHERE transaction_status = 'PENDING'
+			UNION ALL
+			SELECT 'DMT', dmt_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM dmt_transactions
+			WHERE transaction_status = 'PENDING'
+			UNION ALL
+			SELECT 'AEPS', aeps_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM aeps_transactions
+			WHERE transaction_status = 'PENDING'
+			UNION ALL
+			SELECT 'AEPS_SETTLEMENT', aeps_settlement_id::TEXT, partner_request_id::TEXT, provider, approved_at
+			FROM aeps_settlements
+			WHERE transaction_status = 'PENDING'
+		)
+		SELECT
+			p.service,
+			p.transaction_id,
+			p.partner_request_id,
+			p.provider,
+			COALESCE(s.attempts, 0)
+		FROM pending p
+		LEFT JOIN status_checks s
+			ON s.service = p.service
+			AND s.transaction_id = p.transaction_id
+		WHERE p.created_at < NOW() - make_interval(secs => @min_age_seconds)
+		AND (s.next_check_at IS NULL OR s.next_check_at <= NOW())
+		ORDER BY p.created_at
+		LIMIT @limit;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"min_age_seconds": minAge.Seconds(),
+		"limit":           limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []models.PendingTransactionModel
+	for rows.Next() {
+		var p models.PendingTransactionModel
+		if err := rows.Scan(
+			&p.Service,
+			&p.TransactionID,
+			&p.PartnerRequestID,
+			&p.Provider,
+			&p.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	return pending, rows.Err()
+}
+
+// GetStaleInitiatedTransactionsQuery returns INITIATED provider transactions
+// older than minAge, oldest first. These were reserved but never sent to a
+// provider, as when the server stopped in between.
+func (db *Database) GetStaleInitiatedTransactionsQuery(
+	ctx context.Context,
+	minAge time.Duration,
+	limit int,
+) ([]models.PendingTransactionModel, error) {
+	query := `
+		SELECT service, transaction_id, partner_request_id, provider
+		FROM (
+			SELECT 'PAYOUT' AS service, payout_transaction_id::TEXT AS transaction_id, partner_request_id::TEXT AS partner_request_id, provider, created_at
+			FROM payout_transactions
+			WHERE payout_transaction_status = 'INITIATED'
+			UNION ALL
+			SELECT 'MOBILE_RECHARGE', mobile_recharge_transaction_id::TEXT, partner_request_id, provider, created_at
+			FROM mobile_recharge
+			WHERE status = 'INITIATED'
+			UNION ALL
+			SELECT 'DTH_RECHARGE', dth_transaction_id::TEXT, partner_request_id, provider, created_at
+			FROM dth_recharge
+			WHERE status = 'INITIATED'
+			UNION ALL
+			SELECT 'BBPS', bbps_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM bbps_transactions
+			WHERE transaction_status = 'INITIATED'
+			UNION ALL
+			SELECT 'DMT', dmt_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM dmt_transactions
+			WHERE transaction_status = 'INITIATED'
+			UNION ALL
+			SELECT 'AEPS', aeps_transaction_id::TEXT, partner_request_id::TEXT, provider, created_at
+			FROM aeps_transactions
+			WHERE transaction_status = 'INITIATED'
+			UNION ALL
+			-- Bank settlements wait for an admin while INITIATED; only
+			-- approved ones that were never sent are stale.
+			SELECT 'AEPS_SETTLEMENT', aeps_settlement_id::TEXT, partner_request_id::TEXT, provider, approved_at
+			FROM aeps_settlements
+			WHERE transaction_status = 'INITIATED'
+			AND approved_at IS NOT NULL
+		) initiated
+		WHERE created_at < NOW() - make_interval(secs => @min_age_seconds)
+		ORDER BY created_at
+		LIMIT @limit;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"min_age_seconds": minAge.Seconds(),
+		"limit":           limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []models.PendingTransactionModel
+	for rows.Next() {
+		var p models.PendingTransactionModel
+		if err := rows.Scan(
+			&p.Service,
+			&p.TransactionID,
+			&p.PartnerRequestID,
+			&p.Provider,
+		); err != nil {
+			return nil, err
+		}
+		stale = append(stale, p)
+	}
+
+	return stale, rows.Err()
+}
+
+// RecordStatusCheckQuery stores the outcome of a status check and when the
+// transaction should be checked next.
+func (db *Database) RecordStatusCheckQuery(
+	ctx context.Context,
+	service, transactionID string,
+	status string,
+	checkErr error,
+	nextCheckIn time.Duration,
+) error {
+	var lastError *string
+	if checkErr != nil {
+		msg := checkErr.Error()
+		lastError = &msg
+	}
+
+	query := `
+		INSERT INTO status_checks (
+			service,
+			transaction_id,
+			attempts,
+			last_status,
+			last_error,
+			last_checked_at,
+			next_check_at
+		) VALUES (
+			@service,
+			@transaction_id,
+			1,
+			NULLIF(@last_status, ''),
+			@last_error,
+			NOW(),
+			NOW() + make_interval(secs => @next_check_seconds)
+		)
+		ON CONFLICT (service, transaction_id) DO UPDATE
+		SET attempts = status_checks.attempts + 1,
+			last_status = EXCLUDED.last_status,
+			last_error = EXCLUDED.last_error,
+			last_checked_at = EXCLUDED.last_checked_at,
+			next_check_at = EXCLUDED.next_check_at;
+	`
+	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"service":            service,
+		"transaction_id":     transactionID,
+		"last_status":        status,
+		"last_error":         lastError,
+		"next_check_seconds": nextCheckIn.Seconds(),
+	})
+	return err
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func TestDueStatusChecks(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100))
+
+	old, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(10)))
+	if err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if _, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(10))); err != nil {
+		t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+	}
+	if _, err := conn.Exec(ctx, `
+		UPDATE mobile_recharge
+		SET created_at = NOW() - INTERVAL '10 minutes'
+		WHERE mobile_recharge_transaction_id::TEXT = $1;
+	`, old); err != nil {
+		t.Fatalf("age recharge: %v", err)
+	}
+
+	due := func() []models.PendingTransactionModel {
+		t.Helper()
+		pending, err := db.GetDueStatusChecksQuery(ctx, time.Minute, 10)
+		if err != nil {
+			t.Fatalf("GetDueStatusChecksQuery: %v", err)
+		}
+		return pending
+	}
+
+	// The fresh recharge is left to the request that created it.
+	pending := due()
+	if len(pending) != 1 || pending[0].TransactionID != old || pending[0].Service != "MOBILE_RECHARGE" {
+		t.Fatalf("due = %+v, want only MOBILE_RECHARGE %s", pending, old)
+	}
+	if pending[0].Attempts != 0 {
+		t.Errorf("attempts = %d, want 0", pending[0].Attempts)
+	}
+
+	// A check backs the transaction off until its next check is due.
+	if err := db.RecordStatusCheckQuery(ctx, "MOBILE_RECHARGE", old, "PENDING", nil, time.Hour); err != nil {
+		t.Fatalf("RecordStatusCheckQuery: %v", err)
+	}
+	if pending := due(); len(pending) != 0 {
+		t.Errorf("due right after a check = %+v, want none", pending)
+	}
+	if err := db.RecordStatusCheckQuery(ctx, "MOBILE_RECHARGE", old, "", errors.New("timeout"), 0); err != nil {
+		t.Fatalf("RecordStatusCheckQuery: %v", err)
+	}
+	if pending := due(); len(pending) != 1 || pending[0].Attempts != 2 {
+		t.Errorf("due after two checks = %+v, want the recharge with 2 attempts", pending)
+	}
+
+	// Settled transactions are no longer checked.
+	if err := db.SettleMobileRechargeQuery(ctx, old, "FAILED", txstate.Provider("TEST"), ""); err != nil {
+		t.Fatalf("SettleMobileRechargeQuery: %v", err)
+	}
+	if pending := due(); len(pending) != 0 {
+		t.Errorf("due after settling = %+v, want none", pending)
+	}
+}
@@ -0,0 +1,340 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Commission paid to master distributors, distributors and retailers is
+// subject to TDS. Every commission is booked through creditCommision or
+// withholdCommisionTDS, which deduct the tax at the configured rate (a
+// higher one when the user's PAN is not valid), send it to the TDS payable
+// account and record it in tds_commision for the quarterly TDS returns.
+// Admins run the platform and deduct the tax rather than suffer it.
+
+var panPattern = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]$`)
+
+type tdsDeduction struct {
+	userName string
+	pan      string
+	rate     models.Rate
+	tds      models.Money
+}
+
+// commisionTDS works out the TDS on a commission to a user. It is zero for
+// admins.
+func commisionTDS(ctx context.Context, q querier, userID string, commision models.Money) (*tdsDeduction, error) {
+	table, err := ledger.WalletTable(userID)
+	if err != nil {
+		return nil, err
+	}
+	if table == "admin" || commision <= 0 {
+		return &tdsDeduction{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT u.%s_name, u.%s_pan_number, s.tds_rate, s.no_pan_tds_rate
+		FROM %ss u
+		CROSS JOIN tds_settings s
+		WHERE u.%s_id = @user_id;
+	`, table, table, table, table)
+	var (
+		d         tdsDeduction
+		rate      models.Rate
+		noPANRate models.Rate
+	)
+	if err := q.QueryRow(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&d.userName, &d.pan, &rate, &noPANRate); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user %s not found", userID)
+		}
+		return nil, err
+	}
+
+	d.rate = noPANRate
+	if panPattern.MatchString(d.pan) {
+		d.rate = rate
+	}
+	d.tds = commision.Percent(d.rate)
+	return &d, nil
+}
+
+// creditCommision credits a commission to a user net of TDS, straight to
+// the wallet or to be released in the next batch (see
+// settlePendingCommision).
+func creditCommision(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	service, transactionID, userID string,
+	commision models.Money,
+	remarks string,
+) error {
+	if commision <= 0 {
+		return nil
+	}
+	d, err := commisionTDS(ctx, tx, userID, commision)
+	if err != nil {
+		return err
+	}
+	if err := settlePendingCommision(ctx, tx, j, service, transactionID, userID, commision, d.tds, remarks); err != nil {
+		return err
+	}
+	if d.tds == 0 {
+		return nil
+	}
+	j.Credit(ledger.TDSPayable, d.tds, fmt.Sprintf("TDS on commission to %s", userID))
+	return insertTDS(ctx, tx, service, transactionID, userID, commision, d, "DEDUCTED")
+}
+
+// withholdCommisionTDS books the TDS on a commission the user already got as
+// a discount on its debit. The caller's journal must fund the tax, which is
+// why reservations for discounted transactions include it.
+func withholdCommisionTDS(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	service, transactionID, userID string,
+	commision models.Money,
+) error {
+	d, err := commisionTDS(ctx, tx, userID, commision)
+	if err != nil {
+		return err
+	}
+	if d.tds == 0 {
+		return nil
+	}
+	j.Credit(ledger.TDSPayable, d.tds, fmt.Sprintf("TDS on commission to %s", userID))
+	return insertTDS(ctx, tx, service, transactionID, userID, commision, d, "DEDUCTED")
+}
+
+// reverseCommisionTDS takes back the TDS of a refunded transaction: the
+// share part/whole of what was deducted, or everything still deducted on
+// the last refund. It records the reversal per user, debits the TDS
+// payable account in the refund journal and returns the amount reversed.
+func reverseCommisionTDS(
+	ctx context.Context,
+	tx pgx.Tx,
+	j *ledger.Journal,
+	service, transactionID string,
+	part, whole models.Money,
+	final bool,
+) (models.Money, error) {
+	query := `
+		SELECT
+			user_id,
+			user_name,
+			pan_number,
+			MAX(tds_rate),
+			SUM(commision),
+			SUM(tds)
+		FROM tds_commision
+		WHERE service = @service
+		AND transaction_id = @transaction_id
+		AND (@final OR status = 'DEDUCTED')
+		GROUP BY user_id, user_name, pan_number
+		HAVING SUM(tds) <> 0;
+	`
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+		"final":          final,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	type deducted struct {
+		userID    string
+		commision models.Money
+		d         tdsDeduction
+	}
+	var all []deducted
+	for rows.Next() {
+		var r deducted
+		if err := rows.Scan(&r.userID, &r.d.userName, &r.d.pan, &r.d.rate, &r.commision, &r.d.tds); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var reversed models.Money
+	for _, r := range all {
+		if !final {
+			r.commision = r.commision.Prorate(part, whole)
+			r.d.tds = r.d.tds.Prorate(part, whole)
+		}
+		r.commision, r.d.tds = -r.commision, -r.d.tds
+		if err := insertTDS(ctx, tx, service, transactionID, r.userID, r.commision, &r.d, "REVERSED"); err != nil {
+			return 0, err
+		}
+		reversed -= r.d.tds
+	}
+	reverseEntry(j, ledger.TDSPayable, reversed, "TDS reversed on refund")
+	return reversed, nil
+}
+
+func insertTDS(
+	ctx context.Context,
+	tx pgx.Tx,
+	service, transactionID, userID string,
+	commision models.Money,
+	d *tdsDeduction,
+	status string,
+) error {
+	query := `
+		INSERT INTO tds_commision (
+			service,
+			transaction_id,
+			user_id,
+			user_name,
+			commision,
+			tds_rate,
+			tds,
+			paid_commision,
+			pan_number,
+			status
+		) VALUES (
+			@service,
+			@transaction_id,
+			@user_id,
+			@user_name,
+			@commision,
+			@tds_rate,
+			@tds,
+			@paid_commision,
+			@pan_number,
+			@status
+		);
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+		"user_id":        userID,
+		"user_name":      d.userName,
+		"commision":      commision,
+		"tds_rate":       d.rate,
+		"tds":            d.tds,
+		"paid_commision": commision - d.tds,
+		"pan_number":     d.pan,
+		"status":         status,
+	})
+	return err
+}
+
+func (db *Database) GetTDSSettingsQuery(ctx context.Context) (*models.TDSSettingsModel, error) {
+	query := `
+		SELECT tds_rate, no_pan_tds_rate, updated_at
+		FROM tds_settings
+		WHERE setting_id = 1;
+	`
+	var s models.TDSSettingsModel
+	if err := db.pool.QueryRow(ctx, query).Scan(
+		&s.TDSRate,
+		&s.NoPANTDSRate,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (db *Database) UpdateTDSSettingsQuery(
+	ctx context.Context,
+	req models.UpdateTDSSettingsRequestModel,
+) error {
+	query := `
+		UPDATE tds_settings
+		SET tds_rate = COALESCE(@tds_rate, tds_rate),
+			no_pan_tds_rate = COALESCE(@no_pan_tds_rate, no_pan_tds_rate),
+			updated_at = NOW()
+		WHERE setting_id = 1;
+	`
+	if _, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"tds_rate":        req.TDSRate,
+		"no_pan_tds_rate": req.NoPANTDSRate,
+	}); err != nil {
+		return fmt.Errorf("failed to update tds settings")
+	}
+	return nil
+}
+
+// GetTDSQuarterlySummaryQuery totals the TDS deducted per user, PAN and
+// quarter of a financial year (April to March, in Indian time), as needed
+// for Form 16A. A zero quarter returns all four, an empty userID all users.
+func (db *Database) GetTDSQuarterlySummaryQuery(
+	ctx context.Context,
+	financialYear, quarter int,
+	userID string,
+) ([]models.TDSQuarterlySummaryModel, error) {
+	query := `
+		WITH tds AS (
+			SELECT
+				*,
+				CASE
+					WHEN EXTRACT(MONTH FROM created_at AT TIME ZONE 'Asia/Kolkata') >= 4
+					THEN EXTRACT(YEAR FROM created_at AT TIME ZONE 'Asia/Kolkata')
+					ELSE EXTRACT(YEAR FROM created_at AT TIME ZONE 'Asia/Kolkata') - 1
+				END::INT AS financial_year,
+				(EXTRACT(MONTH FROM created_at AT TIME ZONE 'Asia/Kolkata')::INT + 8) % 12 / 3 + 1 AS quarter
+			FROM tds_commision
+			WHERE (@user_id = '' OR user_id = @user_id)
+		)
+		SELECT
+			user_id,
+			user_name,
+			pan_number,
+			financial_year,
+			quarter,
+			COUNT(*) FILTER (WHERE status = 'DEDUCTED'),
+			SUM(commision),
+			SUM(tds),
+			SUM(paid_commision)
+		FROM tds
+		WHERE financial_year = @financial_year
+		AND (@quarter = 0 OR quarter = @quarter)
+		GROUP BY user_id, user_name, pan_number, financial_year, quarter
+		ORDER BY user_id, quarter;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"financial_year": financialYear,
+		"quarter":        quarter,
+		"user_id":        userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []models.TDSQuarterlySummaryModel
+	for rows.Next() {
+		var s models.TDSQuarterlySummaryModel
+		if err := rows.Scan(
+			&s.UserID,
+			&s.UserName,
+			&s.PANNumber,
+			&s.FinancialYear,
+			&s.Quarter,
+			&s.Deductions,
+			&s.Commision,
+			&s.TDS,
+			&s.PaidCommision,
+		); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
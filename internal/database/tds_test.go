@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+func TestRechargeCommissionWithholdsTDS(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	noPAN := dbtest.AddRetailer(t, conn, h.DistributorID)
+	if _, err := conn.Exec(ctx, "UPDATE retailers SET retailer_pan_number = 'NOT A PAN' WHERE retailer_id = $1", noPAN); err != nil {
+		t.Fatalf("clear PAN: %v", err)
+	}
+
+	tests := []struct {
+		retailerID string
+		tds        models.Money
+	}{
+		{h.RetailerID, 2 * models.Paisa},
+		{noPAN, 20 * models.Paisa},
+	}
+	for _, tt := range tests {
+		dbtest.Fund(t, conn, tt.retailerID, models.Rupees(200))
+		transactionID, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(tt.retailerID, models.Rupees(100)))
+		if err != nil {
+			t.Fatalf("ReserveMobileRechargeQuery: %v", err)
+		}
+		// The retailer pays the amount less its commission of 1, plus the
+		// TDS on that commission.
+		if got, want := dbtest.Balance(t, conn, tt.retailerID), models.Rupees(101)-tt.tds; got != want {
+			t.Errorf("retailer %s balance = %s, want %s", tt.retailerID, got, want)
+		}
+		if err := db.SettleMobileRechargeQuery(ctx, transactionID, "SUCCESS", txstate.Provider("TEST"), ""); err != nil {
+			t.Fatalf("SettleMobileRechargeQuery: %v", err)
+		}
+
+		var tds models.Money
+		if err := conn.QueryRow(ctx, `
+			SELECT tds
+			FROM tds_commision
+			WHERE service = 'MOBILE_RECHARGE' AND transaction_id = $1 AND user_id = $2;
+		`, transactionID, tt.retailerID).Scan(&tds); err != nil {
+			t.Fatalf("tds_commision: %v", err)
+		}
+		if tds != tt.tds {
+			t.Errorf("retailer %s TDS = %s, want %s", tt.retailerID, tds, tt.tds)
+		}
+	}
+
+	if got, want := dbtest.SystemBalance(t, conn, "TDS_PAYABLE"), 22*models.Paisa; got != want {
+		t.Errorf("TDS payable = %s, want %s", got, want)
+	}
+	if got, want := dbtest.SystemBalance(t, conn, "COMMISION_POOL"), models.Rupees(-2); got != want {
+		t.Errorf("commission pool = %s, want %s", got, want)
+	}
+}
+
+func TestTDSQuarterlySummary(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+
+	rows := []struct {
+		at        time.Time
+		commision models.Money
+		tds       models.Money
+		status    string
+	}{
+		// The last evening of FY 2024-25.
+		{time.Date(2025, 3, 31, 23, 0, 0, 0, ist), models.Rupees(10), 20 * models.Paisa, "DEDUCTED"},
+		// Q1 of FY 2025-26, with a partial reversal.
+		{time.Date(2025, 4, 1, 0, 30, 0, 0, ist), models.Rupees(10), 20 * models.Paisa, "DEDUCTED"},
+		{time.Date(2025, 6, 30, 12, 0, 0, 0, ist), models.Rupees(-5), -10 * models.Paisa, "REVERSED"},
+		// Q4 of FY 2025-26: still 31 December in UTC.
+		{time.Date(2026, 1, 1, 1, 0, 0, 0, ist), models.Rupees(20), 40 * models.Paisa, "DEDUCTED"},
+	}
+	for _, r := range rows {
+		if _, err := conn.Exec(ctx, `
+			INSERT INTO tds_commision (
+				service, transaction_id, user_id, user_name, commision, tds_rate,
+				tds, paid_commision, pan_number, status, created_at
+			) VALUES ('MOBILE_RECHARGE', '1', $1, 'Retailer', $2, 2, $3, $4, 'ABCDE0001F', $5, $6);
+		`, h.RetailerID, r.commision, r.tds, r.commision-r.tds, r.status, r.at); err != nil {
+			t.Fatalf("insert tds_commision: %v", err)
+		}
+	}
+
+	summaries, err := db.GetTDSQuarterlySummaryQuery(ctx, 2025, 0, h.RetailerID)
+	if err != nil {
+		t.Fatalf("GetTDSQuarterlySummaryQuery: %v", err)
+	}
+	want := []models.TDSQuarterlySummaryModel{
+		{Quarter: 1, Deductions: 1, Commision: models.Rupees(5), TDS: 10 * models.Paisa, PaidCommision: 490 * models.Paisa},
+		{Quarter: 4, Deductions: 1, Commision: models.Rupees(20), TDS: 40 * models.Paisa, PaidCommision: 1960 * models.Paisa},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %d quarters, want %d: %+v", len(summaries), len(want), summaries)
+	}
+	for i, s := range summaries {
+		w := want[i]
+		if s.FinancialYear != 2025 || s.Quarter != w.Quarter || s.Deductions != w.Deductions ||
+			s.Commision != w.Commision || s.TDS != w.TDS || s.PaidCommision != w.PaidCommision {
+			t.Errorf("quarter %d = %+v, want %+v", i, s, w)
+		}
+	}
+
+	q1, err := db.GetTDSQuarterlySummaryQuery(ctx, 2025, 1, "")
+	if err != nil {
+		t.Fatalf("GetTDSQuarterlySummaryQuery: %v", err)
+	}
+	if len(q1) != 1 || q1[0].Quarter != 1 {
+		t.Errorf("Q1 summary = %+v, want just Q1", q1)
+	}
+}
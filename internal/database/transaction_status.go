@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// GetTransactionStatusHistoryQuery returns a transaction's status changes in
+// the order they happened.
+func (db *Database) GetTransactionStatusHistoryQuery(
+	ctx context.Context,
+	service, transactionID string,
+) ([]models.TransactionStatusHistoryModel, error) {
+	query := `
+		SELECT
+			history_id,
+			service,
+			transaction_id,
+			from_status,
+			to_status,
+			actor_type,
+			actor_id,
+			reason,
+			created_at
+		FROM transaction_status_history
+		WHERE service = @service
+		AND transaction_id = @transaction_id
+		ORDER BY history_id;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"service":        service,
+		"transaction_id": transactionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.TransactionStatusHistoryModel
+	for rows.Next() {
+		var h models.TransactionStatusHistoryModel
+		if err := rows.Scan(
+			&h.HistoryID,
+			&h.Service,
+			&h.TransactionID,
+			&h.FromStatus,
+			&h.ToStatus,
+			&h.ActorType,
+			&h.ActorID,
+			&h.Reason,
+			&h.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
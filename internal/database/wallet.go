@@ -2,55 +2,60 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
 	"github.com/levion-studio/paybazaar/internal/models"
 )
 
-func (db *Database) CreateWalletTransactionQuery(
+// setWalletBalance moves a wallet to the given balance by posting an
+// ADJUSTMENT journal against the external funding account, so that manual
+// balance edits are recorded in the ledger like any other movement.
+func setWalletBalance(
 	ctx context.Context,
-	req models.CreateWalletTransactionRequestModel,
+	tx pgx.Tx,
+	userID string,
+	balance models.Money,
 ) error {
+	table, err := ledger.WalletTable(userID)
+	if err != nil {
+		return err
+	}
 
-	query := `
-		INSERT INTO wallet_transactions (
-			user_id,
-			reference_id,
-			credit_amount,
-			debit_amount,
-			before_balance,
-			after_balance,
-			transaction_reason,
-			remarks
-		) VALUES (
-			@user_id,
-			@reference_id,
-			@credit_amount,
-			@debit_amount,
-			@before_balance,
-			@after_balance,
-			@transaction_reason,
-			@remarks
-		)
-	`
+	query := fmt.Sprintf(`
+		SELECT %s_wallet_balance
+		FROM %ss
+		WHERE %s_id = @id
+		FOR UPDATE;
+	`, table, table, table)
+	var current models.Money
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"id": userID,
+	}).Scan(&current); err != nil {
+		return err
+	}
 
-	_, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
-		"user_id":            req.UserID,
-		"reference_id":       req.ReferenceID,
-		"credit_amount":      req.CreditAmount,
-		"debit_amount":       req.DebitAmount,
-		"before_balance":     req.BeforeBalance,
-		"after_balance":      req.AfterBalance,
-		"transaction_reason": req.TransactionReason,
-		"remarks":            req.Remarks,
-	})
+	journal := ledger.NewJournal(userID, "ADJUSTMENT", "Wallet balance adjusted")
+	switch delta := balance - current; {
+	case delta > 0:
+		journal.Debit(ledger.Funding, delta, "").Credit(ledger.User(userID), delta, "")
+	case delta < 0:
+		journal.Debit(ledger.User(userID), -delta, "").Credit(ledger.Funding, -delta, "")
+	default:
+		return nil
+	}
 
+	_, err = ledger.Post(ctx, tx, journal)
 	return err
 }
 
+// getWalletTransactionsByUserID returns the statement of a user's MAIN
+// wallet or, for retailers, its AEPS SETTLEMENT wallet.
 func (db *Database) getWalletTransactionsByUserID(
 	ctx context.Context,
 	userID string,
+	walletType string,
 	limit, offset int,
 ) ([]models.GetWalletTransactionResponseModel, error) {
 
@@ -68,14 +73,16 @@ func (db *Database) getWalletTransactionsByUserID(
 			created_at
 		FROM wallet_transactions
 		WHERE user_id = @user_id
+		AND wallet_type = @wallet_type
 		ORDER BY created_at DESC
 		LIMIT @limit OFFSET @offset
 	`
 
 	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
-		"user_id": userID,
-		"limit":   limit,
-		"offset":  offset,
+		"user_id":     userID,
+		"wallet_type": walletType,
+		"limit":       limit,
+		"offset":      offset,
 	})
 	if err != nil {
 		return nil, err
@@ -111,7 +118,7 @@ func (db *Database) GetAdminWalletTransactionsQuery(
 	adminID string,
 	limit, offset int,
 ) ([]models.GetWalletTransactionResponseModel, error) {
-	return db.getWalletTransactionsByUserID(ctx, adminID, limit, offset)
+	return db.getWalletTransactionsByUserID(ctx, adminID, "MAIN", limit, offset)
 }
 
 func (db *Database) GetMasterDistributorWalletTransactionsQuery(
@@ -119,7 +126,7 @@ func (db *Database) GetMasterDistributorWalletTransactionsQuery(
 	masterDistributorID string,
 	limit, offset int,
 ) ([]models.GetWalletTransactionResponseModel, error) {
-	return db.getWalletTransactionsByUserID(ctx, masterDistributorID, limit, offset)
+	return db.getWalletTransactionsByUserID(ctx, masterDistributorID, "MAIN", limit, offset)
 }
 
 func (db *Database) GetDistributorWalletTransactionsQuery(
@@ -127,7 +134,7 @@ func (db *Database) GetDistributorWalletTransactionsQuery(
 	distributorID string,
 	limit, offset int,
 ) ([]models.GetWalletTransactionResponseModel, error) {
-	return db.getWalletTransactionsByUserID(ctx, distributorID, limit, offset)
+	return db.getWalletTransactionsByUserID(ctx, distributorID, "MAIN", limit, offset)
 }
 
 func (db *Database) GetRetailerWalletTransactionsQuery(
@@ -135,77 +142,97 @@ func (db *Database) GetRetailerWalletTransactionsQuery(
 	retailerID string,
 	limit, offset int,
 ) ([]models.GetWalletTransactionResponseModel, error) {
-	return db.getWalletTransactionsByUserID(ctx, retailerID, limit, offset)
+	return db.getWalletTransactionsByUserID(ctx, retailerID, "MAIN", limit, offset)
 }
 
-func (db *Database) GetAdminWalletBalanceQuery(
+func (db *Database) GetRetailerSettlementWalletTransactionsQuery(
 	ctx context.Context,
-	adminID string,
-) (float64, error) {
-	query := `
-		SELECT admin_wallet_balance
-		FROM admins
-		WHERE admin_id = @admin_id
-	`
+	retailerID string,
+	limit, offset int,
+) ([]models.GetWalletTransactionResponseModel, error) {
+	return db.getWalletTransactionsByUserID(ctx, retailerID, "SETTLEMENT", limit, offset)
+}
+
+// getWalletBalance reads a wallet's ledger balance together with the sum of
+// its active holds.
+func (db *Database) getWalletBalance(
+	ctx context.Context,
+	table string,
+	userID string,
+) (*models.GetWalletBalanceResponseModel, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			%s_wallet_balance,
+			(
+				SELECT COALESCE(SUM(amount), 0)
+				FROM wallet_holds
+				WHERE user_id = @id
+				AND hold_status = 'ACTIVE'
+			)
+		FROM %ss
+		WHERE %s_id = @id
+	`, table, table, table)
+
+	var res models.GetWalletBalanceResponseModel
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+		"id": userID,
+	}).Scan(
+		&res.LedgerBalance,
+		&res.HeldAmount,
+	); err != nil {
+		return nil, err
+	}
+	res.AvailableBalance = res.LedgerBalance - res.HeldAmount
 
-	var balance float64
-	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
-		"admin_id": adminID,
-	}).Scan(&balance)
+	return &res, nil
+}
 
-	return balance, err
+func (db *Database) GetAdminWalletBalanceQuery(
+	ctx context.Context,
+	adminID string,
+) (*models.GetWalletBalanceResponseModel, error) {
+	return db.getWalletBalance(ctx, "admin", adminID)
 }
 
 func (db *Database) GetMasterDistributorWalletBalanceQuery(
 	ctx context.Context,
 	masterDistributorID string,
-) (float64, error) {
-	query := `
-		SELECT master_distributor_wallet_balance
-		FROM master_distributors
-		WHERE master_distributor_id = @md_id
-	`
-
-	var balance float64
-	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
-		"md_id": masterDistributorID,
-	}).Scan(&balance)
-
-	return balance, err
+) (*models.GetWalletBalanceResponseModel, error) {
+	return db.getWalletBalance(ctx, "master_distributor", masterDistributorID)
 }
 
 func (db *Database) GetDistributorWalletBalanceQuery(
 	ctx context.Context,
 	distributorID string,
-) (float64, error) {
-	query := `
-		SELECT distributor_wallet_balance
-		FROM distributors
-		WHERE distributor_id = @distributor_id
-	`
-
-	var balance float64
-	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
-		"distributor_id": distributorID,
-	}).Scan(&balance)
-
-	return balance, err
+) (*models.GetWalletBalanceResponseModel, error) {
+	return db.getWalletBalance(ctx, "distributor", distributorID)
 }
 
 func (db *Database) GetRetailerWalletBalanceQuery(
 	ctx context.Context,
 	retailerID string,
-) (float64, error) {
+) (*models.GetWalletBalanceResponseModel, error) {
+	return db.getWalletBalance(ctx, "retailer", retailerID)
+}
+
+// GetRetailerSettlementWalletBalanceQuery reads a retailer's AEPS
+// settlement wallet, which holds are never placed on.
+func (db *Database) GetRetailerSettlementWalletBalanceQuery(
+	ctx context.Context,
+	retailerID string,
+) (*models.GetWalletBalanceResponseModel, error) {
 	query := `
-		SELECT retailer_wallet_balance
+		SELECT retailer_settlement_wallet_balance
 		FROM retailers
 		WHERE retailer_id = @retailer_id
 	`
-
-	var balance float64
-	err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
+	var res models.GetWalletBalanceResponseModel
+	if err := db.pool.QueryRow(ctx, query, pgx.NamedArgs{
 		"retailer_id": retailerID,
-	}).Scan(&balance)
+	}).Scan(&res.LedgerBalance); err != nil {
+		return nil, err
+	}
+	res.AvailableBalance = res.LedgerBalance
 
-	return balance, err
+	return &res, nil
 }
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Wallet holds block part of a wallet balance, e.g. while a transaction is
+// disputed or for a compliance lien. A hold does not move money: the ledger
+// balance is unchanged, but the ledger refuses any debit that would take the
+// wallet below the sum of its active holds.
+
+// PlaceWalletHoldQuery places a hold on a wallet. The wallet row is locked so
+// the hold cannot be placed on money that a concurrent debit is spending.
+func (db *Database) PlaceWalletHoldQuery(
+	ctx context.Context,
+	req models.CreateWalletHoldRequestModel,
+	placedBy string,
+) (int64, error) {
+	table, err := ledger.WalletTable(req.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := fmt.Sprintf(`
+		SELECT
+			%s_wallet_balance,
+			(
+				SELECT COALESCE(SUM(amount), 0)
+				FROM wallet_holds
+				WHERE user_id = @user_id
+				AND hold_status = 'ACTIVE'
+			)
+		FROM %ss
+		WHERE %s_id = @user_id
+		FOR UPDATE;
+	`, table, table, table)
+	var balance, held models.Money
+	if err := tx.QueryRow(ctx, lockQuery, pgx.NamedArgs{
+		"user_id": req.UserID,
+	}).Scan(&balance, &held); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("wallet not found for %s", req.UserID)
+		}
+		return 0, err
+	}
+
+	if balance-held < req.Amount {
+		return 0, fmt.Errorf("insufficient available balance")
+	}
+
+	insertQuery := `
+		INSERT INTO wallet_holds (
+			user_id,
+			amount,
+			hold_type,
+			remarks,
+			placed_by
+		) VALUES (
+			@user_id,
+			@amount,
+			@hold_type,
+			@remarks,
+			@placed_by
+		)
+		RETURNING hold_id;
+	`
+	var holdID int64
+	if err := tx.QueryRow(ctx, insertQuery, pgx.NamedArgs{
+		"user_id":   req.UserID,
+		"amount":    req.Amount,
+		"hold_type": req.HoldType,
+		"remarks":   req.Remarks,
+		"placed_by": placedBy,
+	}).Scan(&holdID); err != nil {
+		return 0, err
+	}
+
+	return holdID, tx.Commit(ctx)
+}
+
+func (db *Database) ReleaseWalletHoldQuery(
+	ctx context.Context,
+	holdID int64,
+	releasedBy string,
+) error {
+	query := `
+		UPDATE wallet_holds
+		SET hold_status = 'RELEASED',
+			released_by = @released_by,
+			released_at = NOW()
+		WHERE hold_id = @hold_id
+		AND hold_status = 'ACTIVE';
+	`
+	tag, err := db.pool.Exec(ctx, query, pgx.NamedArgs{
+		"hold_id":     holdID,
+		"released_by": releasedBy,
+	})
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("hold not found or already released")
+	}
+	return nil
+}
+
+func (db *Database) GetWalletHoldsQuery(
+	ctx context.Context,
+	userID string,
+	limit, offset int,
+) ([]models.WalletHoldModel, error) {
+	query := `
+		SELECT
+			hold_id,
+			user_id,
+			amount,
+			hold_type,
+			remarks,
+			hold_status,
+			placed_by,
+			released_by,
+			created_at,
+			released_at
+		FROM wallet_holds
+		WHERE user_id = @user_id
+		ORDER BY hold_id DESC
+		LIMIT @limit OFFSET @offset;
+	`
+	rows, err := db.pool.Query(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+		"limit":   limit,
+		"offset":  offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch wallet holds")
+	}
+	defer rows.Close()
+
+	var holds []models.WalletHoldModel
+	for rows.Next() {
+		var h models.WalletHoldModel
+		if err := rows.Scan(
+			&h.HoldID,
+			&h.UserID,
+			&h.Amount,
+			&h.HoldType,
+			&h.Remarks,
+			&h.HoldStatus,
+			&h.PlacedBy,
+			&h.ReleasedBy,
+			&h.CreatedAt,
+			&h.ReleasedAt,
+		); err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+
+	return holds, rows.Err()
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestWalletHoldBlocksDebits(t *testing.T) {
+	db, conn := newTestDatabase(t)
+	ctx := context.Background()
+	h := dbtest.Seed(t, conn)
+	dbtest.Fund(t, conn, h.RetailerID, models.Rupees(100))
+
+	holdID, err := db.PlaceWalletHoldQuery(ctx, models.CreateWalletHoldRequestModel{
+		UserID:   h.RetailerID,
+		Amount:   models.Rupees(70),
+		HoldType: "DISPUTE",
+		Remarks:  "test",
+	}, h.AdminID)
+	if err != nil {
+		t.Fatalf("PlaceWalletHoldQuery: %v", err)
+	}
+
+	balance, err := db.GetRetailerWalletBalanceQuery(ctx, h.RetailerID)
+	if err != nil {
+		t.Fatalf("GetRetailerWalletBalanceQuery: %v", err)
+	}
+	if balance.LedgerBalance != models.Rupees(100) ||
+		balance.HeldAmount != models.Rupees(70) ||
+		balance.AvailableBalance != models.Rupees(30) {
+		t.Errorf("balance = %+v, want 100.00 ledger, 70.00 held and 30.00 available", balance)
+	}
+
+	if _, err := db.PlaceWalletHoldQuery(ctx, models.CreateWalletHoldRequestModel{
+		UserID:   h.RetailerID,
+		Amount:   models.Rupees(40),
+		HoldType: "OTHER",
+		Remarks:  "test",
+	}, h.AdminID); err == nil {
+		t.Error("placing a hold above the available balance succeeded")
+	}
+
+	_, err = db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(50)))
+	if !errors.Is(err, ledger.ErrInsufficientBalance) {
+		t.Errorf("spending held money: err = %v, want %v", err, ledger.ErrInsufficientBalance)
+	}
+	if _, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(20))); err != nil {
+		t.Errorf("spending the available balance: %v", err)
+	}
+
+	if err := db.ReleaseWalletHoldQuery(ctx, holdID, h.AdminID); err != nil {
+		t.Fatalf("ReleaseWalletHoldQuery: %v", err)
+	}
+	if err := db.ReleaseWalletHoldQuery(ctx, holdID, h.AdminID); err == nil {
+		t.Error("releasing a hold twice succeeded")
+	}
+	if _, err := db.ReserveMobileRechargeQuery(ctx, mobileRechargeRequest(h.RetailerID, models.Rupees(50))); err != nil {
+		t.Errorf("spending released money: %v", err)
+	}
+	if got := dbtest.Balance(t, conn, h.RetailerID); got != models.Rupees(30) {
+		t.Errorf("retailer balance = %s, want 30.00", got)
+	}
+}
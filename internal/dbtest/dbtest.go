@@ -0,0 +1,242 @@
+// Package dbtest prepares PostgreSQL schemas for tests that need a
+// database. Set TEST_DATABASE_URL to run those tests; without it they are
+// skipped.
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Open creates a fresh schema in the TEST_DATABASE_URL database, applies
+// every up migration to it and returns a connection string whose
+// search_path is the schema, along with a connection to it. The schema is
+// dropped when the test ends.
+func Open(t *testing.T) (string, *pgx.Conn) {
+	t.Helper()
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	schema := fmt.Sprintf("paybazaar_test_%d", time.Now().UnixNano())
+	admin, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close(context.Background())
+	})
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	schemaURL := u.String()
+
+	conn, err := pgx.Connect(ctx, schemaURL)
+	if err != nil {
+		t.Fatalf("connect to schema: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(context.Background()) })
+	migrate(t, conn)
+
+	return schemaURL, conn
+}
+
+// migrate applies every up migration in order.
+func migrate(t *testing.T, conn *pgx.Conn) {
+	t.Helper()
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "database", "migrations")
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if len(bytes.TrimSpace(sql)) == 0 {
+			continue
+		}
+		if _, err := conn.Exec(context.Background(), string(sql)); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(file), err)
+		}
+	}
+}
+
+// Hierarchy is one user at every level, from the admin down to a
+// retailer.
+type Hierarchy struct {
+	AdminID             string
+	MasterDistributorID string
+	DistributorID       string
+	RetailerID          string
+}
+
+// Seed creates an admin, master distributor, distributor and retailer, all
+// KYC-verified and with empty wallets.
+func Seed(t *testing.T, conn *pgx.Conn) Hierarchy {
+	t.Helper()
+	query := `
+		WITH a AS (
+			INSERT INTO admins (admin_name, admin_email, admin_phone, admin_password)
+			VALUES ('Admin', 'admin@test.local', '9000000001', 'x')
+			RETURNING admin_id
+		), m AS (
+			INSERT INTO master_distributors (
+				admin_id, master_distributor_name, master_distributor_phone, master_distributor_email,
+				master_distributor_password, master_distributor_aadhar_number, master_distributor_pan_number,
+				master_distributor_date_of_birth, master_distributor_gender, master_distributor_city,
+				master_distributor_state, master_distributor_address, master_distributor_pincode,
+				master_distributor_business_name, master_distributor_business_type, master_distributor_kyc_status
+			)
+			SELECT admin_id, 'MD', '9000000002', 'md@test.local', 'x', '100000000002', 'ABCDE0002F',
+				'1990-01-01', 'OTHER', 'City', 'State', 'Address', '560001', 'MD Business', 'Shop', TRUE
+			FROM a
+			RETURNING admin_id, master_distributor_id
+		), d AS (
+			INSERT INTO distributors (
+				master_distributor_id, distributor_name, distributor_phone, distributor_email,
+				distributor_password, distributor_aadhar_number, distributor_pan_number,
+				distributor_date_of_birth, distributor_gender, distributor_city, distributor_state,
+				distributor_address, distributor_pincode, distributor_business_name,
+				distributor_business_type, distributor_kyc_status
+			)
+			SELECT master_distributor_id, 'Distributor', '9000000003', 'd@test.local', 'x', '100000000003', 'ABCDE0003F',
+				'1990-01-01', 'OTHER', 'City', 'State', 'Address', '560001', 'D Business', 'Shop', TRUE
+			FROM m
+			RETURNING distributor_id
+		)
+		SELECT m.admin_id, m.master_distributor_id, d.distributor_id
+		FROM m, d;
+	`
+	var h Hierarchy
+	if err := conn.QueryRow(context.Background(), query).Scan(
+		&h.AdminID,
+		&h.MasterDistributorID,
+		&h.DistributorID,
+	); err != nil {
+		t.Fatalf("seed hierarchy: %v", err)
+	}
+	h.RetailerID = AddRetailer(t, conn, h.DistributorID)
+	return h
+}
+
+// AddRetailer creates another KYC-verified retailer under the distributor
+// and returns its id.
+func AddRetailer(t *testing.T, conn *pgx.Conn, distributorID string) string {
+	t.Helper()
+	query := `
+		INSERT INTO retailers (
+			distributor_id, retailer_name, retailer_phone, retailer_email, retailer_password,
+			retailer_aadhar_number, retailer_pan_number, retailer_date_of_birth, retailer_gender,
+			retailer_city, retailer_state, retailer_address, retailer_pincode,
+			retailer_business_name, retailer_business_type, retailer_kyc_status
+		)
+		SELECT @distributor_id, 'Retailer', '91' || n, 'r' || n || '@test.local', 'x',
+			'2000' || n, 'ABCDE' || RIGHT(n, 4) || 'F', '1990-01-01', 'OTHER',
+			'City', 'State', 'Address', '560001', 'R Business', 'Shop', TRUE
+		FROM (SELECT LPAD((COUNT(*) + 1)::TEXT, 8, '0') AS n FROM retailers) s
+		RETURNING retailer_id;
+	`
+	var retailerID string
+	if err := conn.QueryRow(context.Background(), query, pgx.NamedArgs{
+		"distributor_id": distributorID,
+	}).Scan(&retailerID); err != nil {
+		t.Fatalf("add retailer: %v", err)
+	}
+	return retailerID
+}
+
+// Fund credits a user's wallet with amount from outside the platform.
+func Fund(t *testing.T, conn *pgx.Conn, userID string, amount models.Money) {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	journal := ledger.NewJournal(userID, "TOPUP", "test funding").
+		Debit(ledger.Funding, amount, "").
+		Credit(ledger.User(userID), amount, "")
+	if _, err := ledger.Post(ctx, tx, journal); err != nil {
+		t.Fatalf("fund %s: %v", userID, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+// Balance returns the balance of a user's wallet.
+func Balance(t *testing.T, conn *pgx.Conn, userID string) models.Money {
+	t.Helper()
+	table, err := ledger.WalletTable(userID)
+	if err != nil {
+		t.Fatalf("balance of %s: %v", userID, err)
+	}
+	query := fmt.Sprintf(
+		"SELECT %[1]s_wallet_balance FROM %[1]ss WHERE %[1]s_id = $1",
+		table,
+	)
+	var balance models.Money
+	if err := conn.QueryRow(context.Background(), query, userID).Scan(&balance); err != nil {
+		t.Fatalf("balance of %s: %v", userID, err)
+	}
+	return balance
+}
+
+// SettlementBalance returns the balance of a retailer's AEPS settlement
+// wallet.
+func SettlementBalance(t *testing.T, conn *pgx.Conn, retailerID string) models.Money {
+	t.Helper()
+	var balance models.Money
+	if err := conn.QueryRow(context.Background(), `
+		SELECT retailer_settlement_wallet_balance
+		FROM retailers
+		WHERE retailer_id = $1;
+	`, retailerID).Scan(&balance); err != nil {
+		t.Fatalf("settlement balance of %s: %v", retailerID, err)
+	}
+	return balance
+}
+
+// SystemBalance returns the balance of a system account, such as
+// PROVIDER_FLOAT.
+func SystemBalance(t *testing.T, conn *pgx.Conn, accountCode string) models.Money {
+	t.Helper()
+	query := `
+		SELECT balance
+		FROM system_account_balances
+		WHERE account_code = $1;
+	`
+	var balance models.Money
+	if err := conn.QueryRow(context.Background(), query, accountCode).Scan(&balance); err != nil {
+		t.Fatalf("balance of %s: %v", accountCode, err)
+	}
+	return balance
+}
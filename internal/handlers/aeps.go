@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type aepsHandler struct {
+	aepsRepository repositories.AEPSInterface
+}
+
+func NewAEPSHandler(aepsRepository repositories.AEPSInterface) *aepsHandler {
+	return &aepsHandler{
+		aepsRepository,
+	}
+}
+
+func (ah *aepsHandler) CashWithdrawalRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.CashWithdrawal(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps cash withdrawal request success",
+		Data:    map[string]any{"response": res},
+	})
+}
+
+func (ah *aepsHandler) AadhaarPayRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.AadhaarPay(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aadhaar pay request success",
+		Data:    map[string]any{"response": res},
+	})
+}
+
+func (ah *aepsHandler) BalanceEnquiryRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.BalanceEnquiry(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps balance enquiry success",
+		Data:    map[string]any{"response": res},
+	})
+}
+
+func (ah *aepsHandler) MiniStatementRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.MiniStatement(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps mini statement success",
+		Data:    map[string]any{"response": res},
+	})
+}
+
+func (ah *aepsHandler) GetAllAEPSTransactionsRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.GetAllAEPSTransactions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps transactions fetched successfully",
+		Data:    map[string]any{"transactions": res},
+	})
+}
+
+func (ah *aepsHandler) GetAEPSTransactionsByRetailerIDRequest(c echo.Context) error {
+	res, err := ah.aepsRepository.GetAEPSTransactionsByRetailerID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps transactions fetched successfully",
+		Data:    map[string]any{"transactions": res},
+	})
+}
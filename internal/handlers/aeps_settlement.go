@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type aepsSettlementHandler struct {
+	aepsSettlementRepository repositories.AEPSSettlementInterface
+}
+
+func NewAEPSSettlementHandler(aepsSettlementRepository repositories.AEPSSettlementInterface) *aepsSettlementHandler {
+	return &aepsSettlementHandler{
+		aepsSettlementRepository,
+	}
+}
+
+func (sh *aepsSettlementHandler) GetAEPSSettlementSettingsRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.GetAEPSSettlementSettings(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps settlement settings fetched successfully",
+		Data:    map[string]any{"settings": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) UpdateAEPSSettlementSettingsRequest(c echo.Context) error {
+	if err := sh.aepsSettlementRepository.UpdateAEPSSettlementSettings(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps settlement settings updated successfully",
+	})
+}
+
+func (sh *aepsSettlementHandler) CreateSettlementAccountRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.CreateSettlementAccount(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "settlement account added successfully",
+		Data:    map[string]any{"settlement_account_id": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) GetSettlementAccountsRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.GetSettlementAccounts(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "settlement accounts fetched successfully",
+		Data:    map[string]any{"accounts": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) VerifySettlementAccountRequest(c echo.Context) error {
+	if err := sh.aepsSettlementRepository.VerifySettlementAccount(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "settlement account verified successfully",
+	})
+}
+
+func (sh *aepsSettlementHandler) DeleteSettlementAccountRequest(c echo.Context) error {
+	if err := sh.aepsSettlementRepository.DeleteSettlementAccount(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "settlement account deleted successfully",
+	})
+}
+
+func (sh *aepsSettlementHandler) SettleToWalletRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.SettleToWallet(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps settlement to wallet successful",
+		Data:    map[string]any{"settlement": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) RequestBankSettlementRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.RequestBankSettlement(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps bank settlement requested successfully",
+		Data:    map[string]any{"settlement": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) ApproveBankSettlementRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.ApproveBankSettlement(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps bank settlement approved",
+		Data:    map[string]any{"settlement": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) RejectBankSettlementRequest(c echo.Context) error {
+	if err := sh.aepsSettlementRepository.RejectBankSettlement(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps bank settlement rejected",
+	})
+}
+
+func (sh *aepsSettlementHandler) GetAllAEPSSettlementsRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.GetAllAEPSSettlements(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps settlements fetched successfully",
+		Data:    map[string]any{"settlements": res},
+	})
+}
+
+func (sh *aepsSettlementHandler) GetAEPSSettlementsByRetailerIDRequest(c echo.Context) error {
+	res, err := sh.aepsSettlementRepository.GetAEPSSettlementsByRetailerID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "aeps settlements fetched successfully",
+		Data:    map[string]any{"settlements": res},
+	})
+}
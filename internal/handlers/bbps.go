@@ -18,130 +18,117 @@ func NewBBPSHandler(bbpsRepository repositories.BBPSInterface) *bbpsHandler {
 	}
 }
 
-func (bh *bbpsHandler) CreatePostpaidMobileRechargeRequest(c echo.Context) error {
-	if err := bh.bbpsRepository.CreatePostpaidMobileRecharge(c); err != nil {
-		return c.JSON(http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
-	}
-	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "postpaid mobile recharge successfull"},
-	)
-}
-
-func (bh *bbpsHandler) GetPostpaidMobileRechargeBalanceRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetPostpaidMobileRechargeBalance(c)
+func (bh *bbpsHandler) GetBBPSCategoriesRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.GetBBPSCategories(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "postpaid mobile recharge balance fetched successfully", Data: map[string]any{"response": res}},
+		models.ResponseModel{Status: "success", Message: "bbps categories fetched successfully", Data: map[string]any{"categories": res}},
 	)
 }
 
-func (bh *bbpsHandler) GetAllPostpaidMobileRechargeRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetAllPostpaidMobileRecharge(c)
+func (bh *bbpsHandler) GetBBPSBillersRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.GetBBPSBillers(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "postpaid mobile recharges fetched successfully", Data: map[string]any{"history": res}},
+		models.ResponseModel{Status: "success", Message: "bbps billers fetched successfully", Data: map[string]any{"billers": res}},
 	)
 }
 
-func (bh *bbpsHandler) GetPostpaidMobileRechargeByRetailerIDRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetPostpaidMobileRechargeByRetailerID(c)
+func (bh *bbpsHandler) GetBBPSBillerRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.GetBBPSBiller(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "postpaid mobile recharges fetched successfully", Data: map[string]any{"history": res}},
+		models.ResponseModel{Status: "success", Message: "bbps biller fetched successfully", Data: map[string]any{"biller": res}},
 	)
 }
 
-func (bh *bbpsHandler) CreateElectricityBillPaymentRequest(c echo.Context) error {
-	if err := bh.bbpsRepository.CreateElectricityBillPayment(c); err != nil {
+func (bh *bbpsHandler) CreateBBPSBillerRequest(c echo.Context) error {
+	if err := bh.bbpsRepository.CreateBBPSBiller(c); err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "electricity bill paid successfully"},
+		models.ResponseModel{Status: "success", Message: "bbps biller created successfully"},
 	)
 }
 
-func (bh *bbpsHandler) GetAllElectricityBillOperatorsRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetAllElectricityOperators(c)
-	if err != nil {
+func (bh *bbpsHandler) UpdateBBPSBillerRequest(c echo.Context) error {
+	if err := bh.bbpsRepository.UpdateBBPSBiller(c); err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "operators fetched successfully", Data: map[string]any{"operators": res}},
+		models.ResponseModel{Status: "success", Message: "bbps biller updated successfully"},
 	)
 }
 
-func (bh *bbpsHandler) GetElectricityBillBalanceRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetElectricityBillFetchBalance(c)
+func (bh *bbpsHandler) FetchBillRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.FetchBill(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "balance fetched successfully", Data: map[string]any{"response": res}},
+		models.ResponseModel{Status: "success", Message: "bill fetched successfully", Data: map[string]any{"response": res}},
 	)
 }
 
-func (bh *bbpsHandler) GetAllElectricityBillHistoryRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetAllElectricityBillPaymentTransactions(c)
+func (bh *bbpsHandler) PayBillRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.PayBill(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
+		return transactionFailed(c, err)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "transactions fetched successfully", Data: map[string]any{"transactions": res}},
+		models.ResponseModel{Status: "success", Message: "bill payment successfull", Data: map[string]any{"response": res}},
 	)
 }
 
-func (bh *bbpsHandler) GetElectricityBillHistoryByRetailerIDRequest(c echo.Context) error {
-	res, err := bh.bbpsRepository.GetElectricityBillPaymentTransactionsByRetailerID(c)
+func (bh *bbpsHandler) GetAllBBPSTransactionsRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.GetAllBBPSTransactions(c)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "transactions fetched successfully", Data: map[string]any{"transactions": res}},
+		models.ResponseModel{Status: "success", Message: "bbps transactions fetched successfully", Data: map[string]any{"transactions": res}},
 	)
 }
 
-func (bh *bbpsHandler) ElectricityBillPaymentRefundRequest(c echo.Context) error {
-	if err := bh.bbpsRepository.ElectricityBillPaymentRefund(c); err != nil {
+func (bh *bbpsHandler) GetBBPSTransactionsByRetailerIDRequest(c echo.Context) error {
+	res, err := bh.bbpsRepository.GetBBPSTransactionsByRetailerID(c)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "electricity bill refund successfull"},
+		models.ResponseModel{Status: "success", Message: "bbps transactions fetched successfully", Data: map[string]any{"transactions": res}},
 	)
 }
 
-func (bh *bbpsHandler) MobileRechargePostpaidRefundRequest(c echo.Context) error {
-	if err := bh.bbpsRepository.PostpaidMobileRechargeRefund(c); err != nil {
+func (bh *bbpsHandler) BBPSTransactionRefundRequest(c echo.Context) error {
+	if err := bh.bbpsRepository.BBPSTransactionRefund(c); err != nil {
 		return c.JSON(http.StatusBadRequest,
 			models.ResponseModel{Status: "failed", Message: err.Error()},
 		)
 	}
 	return c.JSON(http.StatusOK,
-		models.ResponseModel{Status: "success", Message: "postpaid mobile recharge refund successfull"},
+		models.ResponseModel{Status: "success", Message: "bbps transaction refunded successfully"},
 	)
 }
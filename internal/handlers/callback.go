@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type callbackHandler struct {
+	callbackRepository repositories.CallbackInterface
+}
+
+func NewCallbackHandler(callbackRepository repositories.CallbackInterface) *callbackHandler {
+	return &callbackHandler{
+		callbackRepository,
+	}
+}
+
+func (ch *callbackHandler) RechargeKitCallbackRequest(c echo.Context) error {
+	res, err := ch.callbackRepository.RechargeKitCallback(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "callback processed successfully",
+			Data:    map[string]any{"result": res},
+		},
+	)
+}
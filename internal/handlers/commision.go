@@ -117,6 +117,24 @@ func (ch *commisionHandler) DeleteCommisionRequest(c echo.Context) error {
 	})
 }
 
+func (ch *commisionHandler) GetCommisionAuditRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionAudit(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision audit fetched successfully",
+		Data: map[string]any{
+			"inconsistent_commisions": data,
+		},
+	})
+}
+
 func (ch *commisionHandler) GetAllTDSCommisionRequest(c echo.Context) error {
 	data, err := ch.commisionRepo.GetAllTDSCommision(c)
 	if err != nil {
@@ -152,3 +170,221 @@ func (ch *commisionHandler) GetTDSCommisionByUserIDRequest(c echo.Context) error
 		},
 	})
 }
+
+func (ch *commisionHandler) GetTDSSettingsRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetTDSSettings(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "tds settings fetched successfully",
+		Data: map[string]any{
+			"tds_settings": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) UpdateTDSSettingsRequest(c echo.Context) error {
+	if err := ch.commisionRepo.UpdateTDSSettings(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "tds settings updated successfully",
+	})
+}
+
+func (ch *commisionHandler) GetCommisionSettlementSettingsRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionSettlementSettings(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision settlement settings fetched successfully",
+		Data: map[string]any{
+			"settlement_settings": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) UpdateCommisionSettlementSettingsRequest(c echo.Context) error {
+	if err := ch.commisionRepo.UpdateCommisionSettlementSettings(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision settlement settings updated successfully",
+	})
+}
+
+func (ch *commisionHandler) GetPendingCommisionsRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetPendingCommisions(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "pending commisions fetched successfully",
+		Data: map[string]any{
+			"pending_commisions": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) ReleaseDueCommisionsRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.ReleaseDueCommisions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "due commisions released successfully",
+		Data: map[string]any{
+			"release": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetTDSQuarterlySummaryRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetTDSQuarterlySummary(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "tds summary fetched successfully",
+		Data: map[string]any{
+			"tds_summary": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetTransactionQuoteRequest(c echo.Context) error {
+	quote, err := ch.commisionRepo.GetTransactionQuote(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "quote fetched successfully",
+		Data: map[string]any{
+			"quote": quote,
+		},
+	})
+}
+
+func (ch *commisionHandler) CreateCommisionSlabRequest(c echo.Context) error {
+	slabID, err := ch.commisionRepo.CreateCommisionSlab(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision slab created successfully",
+		Data: map[string]any{
+			"slab_id": slabID,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetCommisionSlabByIDRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionSlabByID(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision slab fetched successfully",
+		Data: map[string]any{
+			"commision_slab": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetCommisionSlabsRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionSlabs(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision slabs fetched successfully",
+		Data: map[string]any{
+			"commision_slabs": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) UpdateCommisionSlabRequest(c echo.Context) error {
+	if err := ch.commisionRepo.UpdateCommisionSlab(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision slab updated successfully",
+	})
+}
+
+func (ch *commisionHandler) DeleteCommisionSlabRequest(c echo.Context) error {
+	if err := ch.commisionRepo.DeleteCommisionSlab(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision slab deleted successfully",
+	})
+}
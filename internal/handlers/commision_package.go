@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func (ch *commisionHandler) CreateCommisionPackageRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.CreateCommisionPackage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package created successfully",
+		Data: map[string]any{
+			"package_id": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetCommisionPackageByIDRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionPackageByID(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package fetched successfully",
+		Data: map[string]any{
+			"commision_package": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetCommisionPackagesRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetCommisionPackages(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision packages fetched successfully",
+		Data: map[string]any{
+			"commision_packages": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) UpdateCommisionPackageRequest(c echo.Context) error {
+	if err := ch.commisionRepo.UpdateCommisionPackage(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package updated successfully",
+	})
+}
+
+func (ch *commisionHandler) DeleteCommisionPackageRequest(c echo.Context) error {
+	if err := ch.commisionRepo.DeleteCommisionPackage(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package deleted successfully",
+	})
+}
+
+func (ch *commisionHandler) AssignCommisionPackageRequest(c echo.Context) error {
+	if err := ch.commisionRepo.AssignCommisionPackage(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package assigned successfully",
+	})
+}
+
+func (ch *commisionHandler) UnassignCommisionPackageRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.UnassignCommisionPackage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package unassigned successfully",
+		Data: map[string]any{
+			"unassigned_users": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) ReassignCommisionPackageRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.ReassignCommisionPackage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "commision package reassigned successfully",
+		Data: map[string]any{
+			"reassigned_users": data,
+		},
+	})
+}
+
+func (ch *commisionHandler) GetEffectiveCommisionRequest(c echo.Context) error {
+	data, err := ch.commisionRepo.GetEffectiveCommision(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "effective commision fetched successfully",
+		Data: map[string]any{
+			"effective_commision": data,
+		},
+	})
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// transactionFailed answers a money-moving request that failed. A rejection
+// is final and answered 400. Any other error may be transient, so it is
+// answered 503, which also frees the request's idempotency key for a retry.
+func transactionFailed(c echo.Context, err error) error {
+	status := http.StatusServiceUnavailable
+	if models.IsRejection(err) {
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status,
+		models.ResponseModel{Status: "failed", Message: err.Error()},
+	)
+}
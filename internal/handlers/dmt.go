@@ -93,6 +93,19 @@ func (dh *dmtHandler) GetDMTBeneficiariesRequest(c echo.Context) error {
 	})
 }
 
+func (dh *dmtHandler) VerifyDMTBeneficiaryRequest(c echo.Context) error {
+	res, err := dh.dmtRepository.VerifyDMTBeneficiary(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "beneficiary verified successfully",
+		Data:    map[string]any{"beneficiary": res},
+	})
+}
+
 func (dh *dmtHandler) GetDMTBankListRequest(c echo.Context) error {
 	res, err := dh.dmtRepository.GetDMTBankList(c)
 	if err != nil {
@@ -107,3 +120,102 @@ func (dh *dmtHandler) GetDMTBankListRequest(c echo.Context) error {
 		Data:    map[string]any{"response": res},
 	})
 }
+
+func (dh *dmtHandler) SendDMTTransferOTPRequest(c echo.Context) error {
+	res, err := dh.dmtRepository.SendDMTTransferOTP(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "dmt transfer otp sent successfully",
+		Data:    map[string]any{"response": res},
+	})
+}
+
+func (dh *dmtHandler) CreateDMTTransactionRequest(c echo.Context) error {
+	transactionID, err := dh.dmtRepository.CreateDMTTransaction(c)
+	if err != nil {
+		return transactionFailed(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "dmt transaction successfull",
+		Data:    map[string]any{"transaction_id": transactionID},
+	})
+}
+
+func (dh *dmtHandler) GetAllDMTTransactionsRequest(c echo.Context) error {
+	res, err := dh.dmtRepository.GetAllDMTTransactions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "dmt transactions fetched successfully",
+		Data:    map[string]any{"transactions": res},
+	})
+}
+
+func (dh *dmtHandler) GetDMTTransactionsByRetailerIDRequest(c echo.Context) error {
+	res, err := dh.dmtRepository.GetDMTTransactionsByRetailerID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "dmt transactions fetched successfully",
+		Data:    map[string]any{"transactions": res},
+	})
+}
+
+func (dh *dmtHandler) DMTRefundRequest(c echo.Context) error {
+	if err := dh.dmtRepository.DMTRefund(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "dmt transaction refund successfull",
+	})
+}
+
+func (dh *dmtHandler) GetDMTRemitterRequest(c echo.Context) error {
+	res, err := dh.dmtRepository.GetDMTRemitter(c)
+	if err != nil {
+		return c.JSON(http.StatusNotFound,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "remitter fetched successfully",
+		Data:    map[string]any{"remitter": res},
+	})
+}
+
+func (dh *dmtHandler) UpdateDMTRemitterRequest(c echo.Context) error {
+	if err := dh.dmtRepository.UpdateDMTRemitter(c); err != nil {
+		return c.JSON(http.StatusBadRequest,
+			models.ResponseModel{Status: "failed", Message: err.Error()},
+		)
+	}
+
+	return c.JSON(http.StatusOK, models.ResponseModel{
+		Status:  "success",
+		Message: "remitter updated successfully",
+	})
+}
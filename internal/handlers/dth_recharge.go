@@ -20,9 +20,7 @@ func NewDTHRechargeHandler(dthRechargeRepository repositories.DTHRechargeInterfa
 
 func (dh *dthRechargeHandler) CreateDTHRechargeRequest(c echo.Context) error {
 	if err := dh.dthRechargeRepository.CreateDTHRecharge(c); err != nil {
-		return c.JSON(http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
+		return transactionFailed(c, err)
 	}
 	return c.JSON(http.StatusOK,
 		models.ResponseModel{Status: "success", Message: "dth recharge successfull"},
@@ -18,10 +18,7 @@ func NewFundTransferHandler(repo repositories.FundTransferInterface) *fundTransf
 
 func (fh *fundTransferHandler) CreateFundTransfer(c echo.Context) error {
 	if err := fh.repo.CreateFundTransfer(c); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ResponseModel{
-			Status:  "failed",
-			Message: err.Error(),
-		})
+		return transactionFailed(c, err)
 	}
 
 	return c.JSON(http.StatusOK, models.ResponseModel{
@@ -20,9 +20,7 @@ func NewMobileRechargeHandler(mobileRechargeRepository repositories.MobileRechar
 
 func (mrh *mobileRechargeHandler) CreateMobileRechargeRequest(c echo.Context) error {
 	if err := mrh.mobileRechargeRepository.CreateMobileRecharge(c); err != nil {
-		return c.JSON(http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
+		return transactionFailed(c, err)
 	}
 	return c.JSON(http.StatusOK,
 		models.ResponseModel{Status: "success", Message: "mobile recharge successfull"},
@@ -21,9 +21,7 @@ func NewPayoutHandler(payoutRepository repositories.PayoutInterface) *payoutHand
 func (ph *payoutHandler) CreatePayoutRequest(c echo.Context) error {
 	err := ph.payoutRepository.CreatePayoutTransaction(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
+		return transactionFailed(c, err)
 	}
 	return c.JSON(http.StatusOK, models.ResponseModel{Message: "payout transaction successfull", Status: "success"})
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type providerRouteHandler struct {
+	providerRouteRepository repositories.ProviderRouteInterface
+}
+
+func NewProviderRouteHandler(providerRouteRepository repositories.ProviderRouteInterface) *providerRouteHandler {
+	return &providerRouteHandler{
+		providerRouteRepository,
+	}
+}
+
+func (prh *providerRouteHandler) CreateProviderRouteRequest(c echo.Context) error {
+	if err := prh.providerRouteRepository.CreateProviderRoute(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "provider route created successfully",
+		},
+	)
+}
+
+func (prh *providerRouteHandler) UpdateProviderRouteRequest(c echo.Context) error {
+	if err := prh.providerRouteRepository.UpdateProviderRoute(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "provider route updated successfully",
+		},
+	)
+}
+
+func (prh *providerRouteHandler) DeleteProviderRouteRequest(c echo.Context) error {
+	if err := prh.providerRouteRepository.DeleteProviderRoute(c); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "provider route deleted successfully",
+		},
+	)
+}
+
+func (prh *providerRouteHandler) GetAllProviderRoutesRequest(c echo.Context) error {
+	res, err := prh.providerRouteRepository.GetAllProviderRoutes(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "provider routes fetched successfully",
+			Data:    map[string]any{"routes": res},
+		},
+	)
+}
+
+func (prh *providerRouteHandler) GetProvidersRequest(c echo.Context) error {
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "providers fetched successfully",
+			Data:    map[string]any{"providers": prh.providerRouteRepository.GetProviders(c)},
+		},
+	)
+}
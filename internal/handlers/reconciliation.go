@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type reconciliationHandler struct {
+	reconciliationRepository repositories.ReconciliationInterface
+}
+
+func NewReconciliationHandler(reconciliationRepository repositories.ReconciliationInterface) *reconciliationHandler {
+	return &reconciliationHandler{
+		reconciliationRepository,
+	}
+}
+
+func (rh *reconciliationHandler) RunReconciliationRequest(c echo.Context) error {
+	res, err := rh.reconciliationRepository.RunReconciliation(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "reconciliation completed successfully",
+			Data:    map[string]any{"run": res},
+		},
+	)
+}
+
+func (rh *reconciliationHandler) GetReconciliationRunsRequest(c echo.Context) error {
+	res, err := rh.reconciliationRepository.GetReconciliationRuns(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "reconciliation runs fetched successfully",
+			Data:    map[string]any{"runs": res},
+		},
+	)
+}
+
+func (rh *reconciliationHandler) GetReconciliationBreaksRequest(c echo.Context) error {
+	res, err := rh.reconciliationRepository.GetReconciliationBreaks(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "reconciliation breaks fetched successfully",
+			Data:    map[string]any{"breaks": res},
+		},
+	)
+}
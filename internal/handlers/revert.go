@@ -20,10 +20,7 @@ func NewRevertHandler(revertRepository repositories.RevertInterface) *revertHand
 
 func (rh *revertHandler) CreateRevertRequest(c echo.Context) error {
 	if err := rh.revertRepository.CreateRevert(c); err != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			models.ResponseModel{Status: "failed", Message: err.Error()},
-		)
+		return transactionFailed(c, err)
 	}
 	return c.JSON(
 		http.StatusOK,
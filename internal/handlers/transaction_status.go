@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+type transactionStatusHandler struct {
+	transactionStatusRepository repositories.TransactionStatusInterface
+}
+
+func NewTransactionStatusHandler(transactionStatusRepository repositories.TransactionStatusInterface) *transactionStatusHandler {
+	return &transactionStatusHandler{
+		transactionStatusRepository,
+	}
+}
+
+func (tsh *transactionStatusHandler) GetTransactionStatusHistoryRequest(c echo.Context) error {
+	res, err := tsh.transactionStatusRepository.GetTransactionStatusHistory(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "transaction status history fetched successfully",
+			Data:    map[string]any{"history": res},
+		},
+	)
+}
+
+func (tsh *transactionStatusHandler) GetTransactionRefundsRequest(c echo.Context) error {
+	res, err := tsh.transactionStatusRepository.GetTransactionRefunds(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ResponseModel{
+			Status:  "failed",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "transaction refunds fetched successfully",
+			Data:    map[string]any{"refunds": res},
+		},
+	)
+}
@@ -20,32 +20,6 @@ func NewWalletTransactionHandler(
 	}
 }
 
-// ============================
-// CREATE WALLET TRANSACTION
-// ============================
-func (wh *walletTransactionHandler) CreateWalletTransactionRequest(
-	c echo.Context,
-) error {
-
-	if err := wh.walletRepository.CreateWalletTransaction(c); err != nil {
-		return c.JSON(
-			http.StatusBadRequest,
-			models.ResponseModel{
-				Status:  "failed",
-				Message: err.Error(),
-			},
-		)
-	}
-
-	return c.JSON(
-		http.StatusOK,
-		models.ResponseModel{
-			Status:  "success",
-			Message: "wallet transaction created successfully",
-		},
-	)
-}
-
 // ============================
 // GET WALLET TRANSACTIONS
 // ============================
@@ -174,7 +148,7 @@ func (wh *walletTransactionHandler) GetAdminWalletBalanceRequest(
 		models.ResponseModel{
 			Status:  "success",
 			Message: "wallet balance fetched successfully",
-			Data:    map[string]any{"wallet_balance": balance},
+			Data:    walletBalanceData(balance),
 		},
 	)
 }
@@ -199,7 +173,7 @@ func (wh *walletTransactionHandler) GetMasterDistributorWalletBalanceRequest(
 		models.ResponseModel{
 			Status:  "success",
 			Message: "wallet balance fetched successfully",
-			Data:    map[string]any{"wallet_balance": balance},
+			Data:    walletBalanceData(balance),
 		},
 	)
 }
@@ -224,7 +198,7 @@ func (wh *walletTransactionHandler) GetDistributorWalletBalanceRequest(
 		models.ResponseModel{
 			Status:  "success",
 			Message: "wallet balance fetched successfully",
-			Data:    map[string]any{"wallet_balance": balance},
+			Data:    walletBalanceData(balance),
 		},
 	)
 }
@@ -249,7 +223,145 @@ func (wh *walletTransactionHandler) GetRetailerWalletBalanceRequest(
 		models.ResponseModel{
 			Status:  "success",
 			Message: "wallet balance fetched successfully",
-			Data:    map[string]any{"wallet_balance": balance},
+			Data:    walletBalanceData(balance),
 		},
 	)
 }
+
+func (wh *walletTransactionHandler) GetRetailerSettlementWalletTransactionsRequest(
+	c echo.Context,
+) error {
+
+	res, err := wh.walletRepository.GetRetailerSettlementWalletTransactions(c)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			models.ResponseModel{
+				Status:  "failed",
+				Message: err.Error(),
+			},
+		)
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "settlement wallet transactions fetched successfully",
+			Data:    map[string]any{"transactions": res},
+		},
+	)
+}
+
+func (wh *walletTransactionHandler) GetRetailerSettlementWalletBalanceRequest(
+	c echo.Context,
+) error {
+
+	balance, err := wh.walletRepository.GetRetailerSettlementWalletBalance(c)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			models.ResponseModel{
+				Status:  "failed",
+				Message: err.Error(),
+			},
+		)
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "settlement wallet balance fetched successfully",
+			Data:    walletBalanceData(balance),
+		},
+	)
+}
+
+// ============================
+// WALLET HOLDS
+// ============================
+
+func (wh *walletTransactionHandler) PlaceWalletHoldRequest(
+	c echo.Context,
+) error {
+
+	holdID, err := wh.walletRepository.PlaceWalletHold(c)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			models.ResponseModel{
+				Status:  "failed",
+				Message: err.Error(),
+			},
+		)
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "wallet hold placed successfully",
+			Data:    map[string]any{"hold_id": holdID},
+		},
+	)
+}
+
+func (wh *walletTransactionHandler) ReleaseWalletHoldRequest(
+	c echo.Context,
+) error {
+
+	if err := wh.walletRepository.ReleaseWalletHold(c); err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			models.ResponseModel{
+				Status:  "failed",
+				Message: err.Error(),
+			},
+		)
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "wallet hold released successfully",
+		},
+	)
+}
+
+func (wh *walletTransactionHandler) GetWalletHoldsRequest(
+	c echo.Context,
+) error {
+
+	res, err := wh.walletRepository.GetWalletHolds(c)
+	if err != nil {
+		return c.JSON(
+			http.StatusBadRequest,
+			models.ResponseModel{
+				Status:  "failed",
+				Message: err.Error(),
+			},
+		)
+	}
+
+	return c.JSON(
+		http.StatusOK,
+		models.ResponseModel{
+			Status:  "success",
+			Message: "wallet holds fetched successfully",
+			Data:    map[string]any{"holds": res},
+		},
+	)
+}
+
+// walletBalanceData keeps wallet_balance (the ledger balance) for existing
+// clients next to the ledger/held/available split.
+func walletBalanceData(balance *models.GetWalletBalanceResponseModel) map[string]any {
+	return map[string]any{
+		"wallet_balance":    balance.LedgerBalance,
+		"ledger_balance":    balance.LedgerBalance,
+		"held_amount":       balance.HeldAmount,
+		"available_balance": balance.AvailableBalance,
+	}
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/database"
+)
+
+// CommisionRelease returns a job that credits the commission that has come
+// due to the wallets. It runs more often than the daily or monthly batch it
+// implements and releases nothing until the day or month is over.
+func CommisionRelease(db *database.Database) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+
+		release, err := db.ReleaseDueCommisionsQuery(ctx)
+		if err != nil {
+			return err
+		}
+		if release.Commisions > 0 {
+			log.Printf(
+				"released %d commissions worth %s to %d users",
+				release.Commisions, release.Amount, release.Users,
+			)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/database"
+)
+
+// IdempotencyCleanup returns a job that deletes expired idempotency keys.
+func IdempotencyCleanup(db *database.Database) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+
+		deleted, err := db.DeleteExpiredIdempotencyKeysQuery(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			log.Printf("deleted %d expired idempotency keys", deleted)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/database"
+)
+
+// Reconciliation returns a job that checks every wallet against its
+// transaction history and stores the result as a reconciliation run.
+func Reconciliation(db *database.Database) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+
+		run, err := db.RunReconciliationQuery(ctx, "SCHEDULER")
+		if err != nil {
+			return err
+		}
+		if run.BreaksFound > 0 {
+			log.Printf(
+				"reconciliation run %d found %d breaks across %d wallets",
+				run.RunID, run.BreaksFound, run.WalletsChecked,
+			)
+		}
+		return nil
+	}
+}
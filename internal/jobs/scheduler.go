@@ -0,0 +1,57 @@
+// Package jobs runs the periodic background work of the server, such as
+// wallet reconciliation and provider status checks.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type Scheduler struct {
+	wg sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every runs fn once per interval until ctx is cancelled. The first run
+// happens after one interval. A non-positive interval disables the job.
+// Errors are logged and do not stop the schedule.
+func (s *Scheduler) Every(
+	ctx context.Context,
+	name string,
+	interval time.Duration,
+	fn func(context.Context) error,
+) {
+	if interval <= 0 {
+		log.Printf("job %s is disabled", name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil {
+					log.Printf("job %s failed: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// Wait blocks until every job has stopped.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+const (
+	// statusCheckBatchSize caps how many transactions one run checks.
+	statusCheckBatchSize = 100
+	// statusCheckMinAge leaves fresh transactions to the request that
+	// created them.
+	statusCheckMinAge = time.Minute
+	// initiatedMaxAge is how long a transaction may stay INITIATED before
+	// it is taken as never sent and failed.
+	initiatedMaxAge = 5 * time.Minute
+	// The delay between checks of one transaction doubles from
+	// statusCheckBaseDelay up to statusCheckMaxDelay.
+	statusCheckBaseDelay = time.Minute
+	statusCheckMaxDelay  = time.Hour
+)
+
+// StatusCheck returns a job that asks each PENDING payout, recharge and
+// bill payment's provider for its status and settles the ones that have
+// finished. FAILED transactions are refunded to the retailer by the
+// settlement. Transactions that are still pending, or whose check failed,
+// are retried with exponential backoff. Transactions left INITIATED, which
+// never reached a provider, are failed.
+func StatusCheck(db *database.Database, registry *providers.Registry) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+
+		stale, err := db.GetStaleInitiatedTransactionsQuery(ctx, initiatedMaxAge, statusCheckBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, p := range stale {
+			if err := db.SettleProviderTransactionQuery(ctx, p.Service, p.TransactionID, "FAILED", "", "", txstate.System, "never sent to a provider"); err != nil {
+				log.Printf("failing stale %s %s failed: %v", p.Service, p.TransactionID, err)
+			}
+		}
+
+		pending, err := db.GetDueStatusChecksQuery(ctx, statusCheckMinAge, statusCheckBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range pending {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			status, err := checkStatus(ctx, registry, p)
+			if err == nil && status != "PENDING" {
+				err = db.SettleProviderTransactionQuery(ctx, p.Service, p.TransactionID, status, "", "", txstate.Provider(p.Provider), "status check")
+			}
+			if err != nil {
+				log.Printf("status check of %s %s failed: %v", p.Service, p.TransactionID, err)
+			}
+
+			if err := db.RecordStatusCheckQuery(ctx, p.Service, p.TransactionID, status, err, statusCheckBackoff(p.Attempts+1)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// checkStatus asks the provider a transaction was sent to for its status.
+func checkStatus(ctx context.Context, registry *providers.Registry, p models.PendingTransactionModel) (string, error) {
+	provider, err := registry.Get(p.Provider)
+	if err != nil {
+		return "", err
+	}
+	res, err := provider.StatusCheck(ctx, p.PartnerRequestID)
+	if err != nil {
+		return "", err
+	}
+	return res.Status, nil
+}
+
+// statusCheckBackoff is the delay before the given check attempt.
+func statusCheckBackoff(attempt int) time.Duration {
+	delay := statusCheckBaseDelay
+	for i := 1; i < attempt && delay < statusCheckMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, statusCheckMaxDelay)
+}
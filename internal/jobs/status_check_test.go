@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusCheckBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := statusCheckBackoff(tt.attempt); got != tt.want {
+			t.Errorf("statusCheckBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ledger
+
+import "errors"
+
+type AccountType string
+
+const (
+	UserAccount   AccountType = "USER"
+	SystemAccount AccountType = "SYSTEM"
+	// SettlementAccount is a retailer's AEPS settlement wallet, kept apart
+	// from its main wallet on the same retailers row.
+	SettlementAccount AccountType = "SETTLEMENT"
+)
+
+type Account struct {
+	Type AccountType
+	ID   string
+}
+
+// User is the wallet of an admin, master distributor, distributor or
+// retailer, identified by the usual prefixed user id.
+func User(userID string) Account {
+	return Account{Type: UserAccount, ID: userID}
+}
+
+// Settlement is the AEPS settlement wallet of a retailer. AEPS money lands
+// here and only reaches the main wallet or the retailer's bank through a
+// settlement.
+func Settlement(retailerID string) Account {
+	return Account{Type: SettlementAccount, ID: retailerID}
+}
+
+// System accounts stand for money held outside user wallets. They are
+// seeded by the ledger migration.
+var (
+	// ProviderFloat is money paid out to service providers (RechargeKit).
+	ProviderFloat = Account{Type: SystemAccount, ID: "PROVIDER_FLOAT"}
+	// CommisionPool collects commission that is not assigned to any wallet.
+	CommisionPool = Account{Type: SystemAccount, ID: "COMMISION_POOL"}
+	// CommisionPayable holds commission earned on settled transactions
+	// until it is released to wallets in the daily or monthly batch.
+	CommisionPayable = Account{Type: SystemAccount, ID: "COMMISION_PAYABLE"}
+	// TDSPayable is tax deducted from commissions, owed to the government.
+	TDSPayable = Account{Type: SystemAccount, ID: "TDS_PAYABLE"}
+	// Funding is the counterpart of money entering the platform from
+	// outside, such as admin wallet top-ups.
+	Funding = Account{Type: SystemAccount, ID: "FUNDING"}
+	// Suspense holds money reserved from wallets for transactions that are
+	// still waiting on the provider.
+	Suspense = Account{Type: SystemAccount, ID: "SUSPENSE"}
+	// Receivable is money users owe the platform after a clawback took
+	// more than their wallet held.
+	Receivable = Account{Type: SystemAccount, ID: "RECEIVABLE"}
+)
+
+// key orders accounts for locking. Wallets are locked from the bottom of
+// the hierarchy up: retailers, then distributors, master distributors and
+// admins. A service transaction that locks its retailer row before posting
+// therefore already holds the first lock in this order. A settlement wallet
+// sorts right after the main wallet of the same retailer, as both live on
+// the retailer's row. System accounts are not locked and sort last.
+func (a Account) key() string {
+	if a.Type == SystemAccount {
+		return "9:" + a.ID
+	}
+
+	rank := "8"
+	if len(a.ID) > 0 {
+		switch a.ID[0] {
+		case 'R':
+			rank = "0"
+		case 'D':
+			rank = "1"
+		case 'M':
+			rank = "2"
+		case 'A':
+			rank = "3"
+		}
+	}
+	if a.Type == SettlementAccount {
+		return rank + ":" + a.ID + ":" + string(SettlementAccount)
+	}
+	return rank + ":" + a.ID
+}
+
+// walletType is the wallet_transactions.wallet_type of a user or
+// settlement account's statement rows.
+func (a Account) walletType() string {
+	if a.Type == SettlementAccount {
+		return "SETTLEMENT"
+	}
+	return "MAIN"
+}
+
+// walletColumns returns the table, id column and balance column holding a
+// user or settlement account.
+func walletColumns(a Account) (table, idColumn, balanceColumn string, err error) {
+	prefix, err := WalletTable(a.ID)
+	if err != nil {
+		return "", "", "", err
+	}
+	if a.Type == SettlementAccount {
+		if prefix != "retailer" {
+			return "", "", "", errors.New("only retailers have a settlement wallet")
+		}
+		return "retailers", "retailer_id", "retailer_settlement_wallet_balance", nil
+	}
+	return prefix + "s", prefix + "_id", prefix + "_wallet_balance", nil
+}
+
+// WalletTable returns the table prefix for a user id, e.g. "retailer" for
+// R-prefixed ids. The table is <prefix>s and the balance column
+// <prefix>_wallet_balance.
+func WalletTable(userID string) (string, error) {
+	if len(userID) == 0 {
+		return "", errors.New("invalid user id")
+	}
+
+	switch userID[0] {
+	case 'A':
+		return "admin", nil
+	case 'M':
+		return "master_distributor", nil
+	case 'D':
+		return "distributor", nil
+	case 'R':
+		return "retailer", nil
+	default:
+		return "", errors.New("unknown user type")
+	}
+}
@@ -0,0 +1,112 @@
+// Package ledger is the single place where money moves between accounts.
+//
+// Every movement is described as a Journal: a set of debit and credit
+// entries against user wallets and system accounts that must balance
+// (total debits equal total credits) before it can be posted. All accounts
+// follow the wallet convention: a credit raises the balance and a debit
+// lowers it, so posting a balanced journal never changes the sum of all
+// account balances.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+var (
+	ErrUnbalancedJournal   = errors.New("journal debits and credits do not balance")
+	ErrEmptyJournal        = errors.New("journal has no entries")
+	ErrInsufficientBalance = models.Rejectf("insufficient wallet balance")
+)
+
+type Entry struct {
+	Account Account
+	Debit   models.Money
+	Credit  models.Money
+	Remarks string
+	// Clawback marks a debit taking back money the wallet was paid before
+	// (see Journal.Clawback).
+	Clawback bool
+}
+
+type Journal struct {
+	// ReferenceID is the service transaction this journal belongs to.
+	ReferenceID string
+	// Reason is recorded as the wallet_transactions.transaction_reason of
+	// every user entry.
+	Reason  string
+	Remarks string
+	Entries []Entry
+}
+
+func NewJournal(referenceID, reason, remarks string) *Journal {
+	return &Journal{
+		ReferenceID: referenceID,
+		Reason:      reason,
+		Remarks:     remarks,
+	}
+}
+
+// Debit lowers the balance of the account. Debits against user wallets fail
+// with ErrInsufficientBalance when the wallet's available balance (balance
+// minus active holds) cannot cover them. Zero amounts are ignored so callers
+// can pass optional commissions directly.
+func (j *Journal) Debit(account Account, amount models.Money, remarks string) *Journal {
+	if amount != 0 {
+		j.Entries = append(j.Entries, Entry{Account: account, Debit: amount, Remarks: remarks})
+	}
+	return j
+}
+
+// Clawback debits a wallet for money it was paid before, such as the
+// commission of a refunded transaction. It ignores holds and never fails
+// for lack of funds: what the wallet no longer holds is booked to
+// Receivable and recorded as owed by the user.
+func (j *Journal) Clawback(account Account, amount models.Money, remarks string) *Journal {
+	if amount != 0 {
+		j.Entries = append(j.Entries, Entry{Account: account, Debit: amount, Remarks: remarks, Clawback: true})
+	}
+	return j
+}
+
+// Credit raises the balance of the account.
+func (j *Journal) Credit(account Account, amount models.Money, remarks string) *Journal {
+	if amount != 0 {
+		j.Entries = append(j.Entries, Entry{Account: account, Credit: amount, Remarks: remarks})
+	}
+	return j
+}
+
+// Totals returns the sum of debits and credits in the journal.
+func (j *Journal) Totals() (debits, credits models.Money) {
+	for _, e := range j.Entries {
+		debits += e.Debit
+		credits += e.Credit
+	}
+	return debits, credits
+}
+
+// Validate checks that every entry is one-sided and positive and that the
+// journal balances.
+func (j *Journal) Validate() error {
+	if len(j.Entries) == 0 {
+		return ErrEmptyJournal
+	}
+	if j.ReferenceID == "" || j.Reason == "" {
+		return fmt.Errorf("journal reference and reason are required")
+	}
+	for _, e := range j.Entries {
+		if e.Account.ID == "" {
+			return fmt.Errorf("journal entry without an account")
+		}
+		if e.Debit < 0 || e.Credit < 0 || (e.Debit == 0) == (e.Credit == 0) {
+			return fmt.Errorf("invalid journal entry for %s", e.Account.ID)
+		}
+	}
+	if debits, credits := j.Totals(); debits != credits {
+		return fmt.Errorf("%w: debits %s, credits %s", ErrUnbalancedJournal, debits, credits)
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Post validates the journal and applies it inside tx: every wallet balance
+// is updated under a row lock, one ledger_entries row is written per entry
+// and user and settlement entries are also written to wallet_transactions,
+// which remains the per-wallet statement. It returns the new journal id.
+// The part of a clawback a wallet cannot cover is booked to Receivable and
+// recorded in ledger_receivables.
+//
+// Wallets are locked in a fixed order so concurrent journals touching the
+// same wallets cannot deadlock. System accounts are never locked: their
+// balance is the sum of their ledger entries (see the
+// system_account_balances view), so journals through SUSPENSE or
+// PROVIDER_FLOAT do not serialise on a shared row. Callers that lock a
+// retailer row before posting must not hold locks on any other wallet.
+func Post(ctx context.Context, tx pgx.Tx, j *Journal) (int64, error) {
+	if err := j.Validate(); err != nil {
+		return 0, err
+	}
+
+	insertJournalQuery := `
+		INSERT INTO ledger_journals (
+			reference_id,
+			journal_reason,
+			remarks
+		) VALUES (
+			@reference_id,
+			@journal_reason,
+			@remarks
+		)
+		RETURNING journal_id;
+	`
+	var journalID int64
+	if err := tx.QueryRow(ctx, insertJournalQuery, pgx.NamedArgs{
+		"reference_id":   j.ReferenceID,
+		"journal_reason": j.Reason,
+		"remarks":        j.Remarks,
+	}).Scan(&journalID); err != nil {
+		return 0, err
+	}
+
+	entries := make([]Entry, len(j.Entries))
+	copy(entries, j.Entries)
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Account.key() < entries[b].Account.key()
+	})
+
+	for _, e := range entries {
+		remarks := e.Remarks
+		if remarks == "" {
+			remarks = j.Remarks
+		}
+
+		if e.Account.Type == SystemAccount {
+			if err := checkSystemAccount(ctx, tx, e.Account); err != nil {
+				return 0, err
+			}
+			if err := insertLedgerEntry(ctx, tx, journalID, e, nil, nil, remarks); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		before, after, shortfall, err := applyEntry(ctx, tx, &e)
+		if err != nil {
+			return 0, err
+		}
+
+		if e.Debit > 0 || e.Credit > 0 {
+			if err := insertLedgerEntry(ctx, tx, journalID, e, &before, &after, remarks); err != nil {
+				return 0, err
+			}
+
+			if err := insertWalletTransaction(ctx, tx, journalID, j, e, before, after, remarks); err != nil {
+				return 0, err
+			}
+		}
+
+		if shortfall > 0 {
+			if err := bookReceivable(ctx, tx, journalID, e.Account, shortfall, remarks); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return journalID, nil
+}
+
+// checkSystemAccount makes sure the system account exists without locking
+// it.
+func checkSystemAccount(ctx context.Context, tx pgx.Tx, a Account) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM system_accounts
+			WHERE account_code = @account_code
+		);
+	`
+	var exists bool
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"account_code": a.ID,
+	}).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("unknown system account %s", a.ID)
+	}
+	return nil
+}
+
+// applyEntry updates a user or settlement wallet under a row lock and
+// returns its balance before and after the entry. A clawback takes no more
+// than the wallet holds: its debit is lowered to the balance and the rest
+// is returned as the shortfall.
+func applyEntry(ctx context.Context, tx pgx.Tx, e *Entry) (before, after, shortfall models.Money, err error) {
+	table, idColumn, balanceColumn, err := walletColumns(e.Account)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	lockQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE %s = @id
+		FOR UPDATE;
+	`, balanceColumn, table, idColumn)
+	if err := tx.QueryRow(ctx, lockQuery, pgx.NamedArgs{
+		"id": e.Account.ID,
+	}).Scan(&before); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, 0, models.Rejectf("wallet not found for %s", e.Account.ID)
+		}
+		return 0, 0, 0, err
+	}
+
+	after = before + e.Credit - e.Debit
+	if e.Clawback && after < 0 {
+		shortfall = -after
+		e.Debit -= shortfall
+		after = 0
+	}
+	if e.Debit > 0 && !e.Clawback {
+		// Holds only apply to main wallets.
+		var held models.Money
+		if e.Account.Type == UserAccount {
+			if held, err = heldAmount(ctx, tx, e.Account.ID); err != nil {
+				return 0, 0, 0, err
+			}
+		}
+		if after < held {
+			return 0, 0, 0, ErrInsufficientBalance
+		}
+	}
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE %s
+		SET %s = @balance,
+		    updated_at = NOW()
+		WHERE %s = @id;
+	`, table, balanceColumn, idColumn)
+	if _, err := tx.Exec(ctx, updateQuery, pgx.NamedArgs{
+		"balance": after,
+		"id":      e.Account.ID,
+	}); err != nil {
+		return 0, 0, 0, err
+	}
+	return before, after, shortfall, nil
+}
+
+// bookReceivable debits Receivable with the part of a clawback the wallet
+// could not cover and records it as owed by the wallet's user.
+func bookReceivable(ctx context.Context, tx pgx.Tx, journalID int64, a Account, shortfall models.Money, remarks string) error {
+	if err := checkSystemAccount(ctx, tx, Receivable); err != nil {
+		return err
+	}
+	e := Entry{Account: Receivable, Debit: shortfall}
+	if err := insertLedgerEntry(ctx, tx, journalID, e, nil, nil, fmt.Sprintf("Owed by %s: %s", a.ID, remarks)); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO ledger_receivables (
+			journal_id,
+			account_type,
+			account_id,
+			amount
+		) VALUES (
+			@journal_id,
+			@account_type,
+			@account_id,
+			@amount
+		);
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"journal_id":   journalID,
+		"account_type": a.Type,
+		"account_id":   a.ID,
+		"amount":       shortfall,
+	})
+	return err
+}
+
+// heldAmount returns the sum of the active holds on a wallet. Held money
+// stays in the wallet balance but cannot be debited until the hold is
+// released.
+func heldAmount(ctx context.Context, tx pgx.Tx, userID string) (models.Money, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM wallet_holds
+		WHERE user_id = @user_id
+		AND hold_status = 'ACTIVE';
+	`
+	var held models.Money
+	err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"user_id": userID,
+	}).Scan(&held)
+	return held, err
+}
+
+// insertLedgerEntry records one entry of a journal. before and after are
+// nil for system accounts, which keep no running balance.
+func insertLedgerEntry(
+	ctx context.Context,
+	tx pgx.Tx,
+	journalID int64,
+	e Entry,
+	before, after *models.Money,
+	remarks string,
+) error {
+	query := `
+		INSERT INTO ledger_entries (
+			journal_id,
+			account_type,
+			account_id,
+			debit_amount,
+			credit_amount,
+			before_balance,
+			after_balance,
+			remarks
+		) VALUES (
+			@journal_id,
+			@account_type,
+			@account_id,
+			@debit_amount,
+			@credit_amount,
+			@before_balance,
+			@after_balance,
+			@remarks
+		);
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"journal_id":     journalID,
+		"account_type":   e.Account.Type,
+		"account_id":     e.Account.ID,
+		"debit_amount":   e.Debit,
+		"credit_amount":  e.Credit,
+		"before_balance": before,
+		"after_balance":  after,
+		"remarks":        remarks,
+	})
+	return err
+}
+
+func insertWalletTransaction(
+	ctx context.Context,
+	tx pgx.Tx,
+	journalID int64,
+	j *Journal,
+	e Entry,
+	before, after models.Money,
+	remarks string,
+) error {
+	query := `
+		INSERT INTO wallet_transactions (
+			journal_id,
+			user_id,
+			wallet_type,
+			reference_id,
+			credit_amount,
+			debit_amount,
+			before_balance,
+			after_balance,
+			transaction_reason,
+			remarks
+		) VALUES (
+			@journal_id,
+			@user_id,
+			@wallet_type,
+			@reference_id,
+			@credit_amount,
+			@debit_amount,
+			@before_balance,
+			@after_balance,
+			@transaction_reason,
+			@remarks
+		);
+	`
+	var credit, debit *models.Money
+	if e.Credit > 0 {
+		credit = &e.Credit
+	} else {
+		debit = &e.Debit
+	}
+
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"journal_id":         journalID,
+		"user_id":            e.Account.ID,
+		"wallet_type":        e.Account.walletType(),
+		"reference_id":       j.ReferenceID,
+		"credit_amount":      credit,
+		"debit_amount":       debit,
+		"before_balance":     before,
+		"after_balance":      after,
+		"transaction_reason": j.Reason,
+		"remarks":            remarks,
+	})
+	return err
+}
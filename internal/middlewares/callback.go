@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/config"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+const CallbackTokenHeader = "X-Callback-Token"
+
+// RechargeKitCallbackMiddleware authenticates provider callbacks. The shared
+// secret is accepted in the X-Callback-Token header or the token query
+// parameter, since callback URLs are usually configured without headers.
+// When an IP allowlist is configured the caller must also be on it; the
+// caller's address comes from the router's IPExtractor, which only follows
+// X-Forwarded-For through trusted proxies.
+// Callbacks are refused while no secret is configured.
+func RechargeKitCallbackMiddleware(cfg *config.RechargeKitConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if cfg.CallbackSecret == "" {
+				return c.JSON(http.StatusServiceUnavailable, models.ResponseModel{
+					Status:  "failed",
+					Message: "callbacks are not configured",
+				})
+			}
+
+			token := c.Request().Header.Get(CallbackTokenHeader)
+			if token == "" {
+				token = c.QueryParam("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.CallbackSecret)) != 1 {
+				return c.JSON(http.StatusUnauthorized, models.ResponseModel{
+					Status:  "failed",
+					Message: "invalid callback token",
+				})
+			}
+
+			if len(cfg.CallbackAllowedIPs) > 0 && !slices.Contains(cfg.CallbackAllowedIPs, c.RealIP()) {
+				return c.JSON(http.StatusForbidden, models.ResponseModel{
+					Status:  "failed",
+					Message: "callback source is not allowed",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
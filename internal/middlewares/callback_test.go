@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/config"
+)
+
+func TestRechargeKitCallbackMiddleware(t *testing.T) {
+	cfg := &config.RechargeKitConfig{
+		CallbackSecret:     "secret",
+		CallbackAllowedIPs: []string{"203.0.113.7"},
+	}
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.POST("/callback", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, RechargeKitCallbackMiddleware(cfg))
+
+	tests := []struct {
+		name       string
+		target     string
+		token      string
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		{"header token from allowed ip", "/callback", "secret", "203.0.113.7:4000", "", http.StatusOK},
+		{"query token from allowed ip", "/callback?token=secret", "", "203.0.113.7:4000", "", http.StatusOK},
+		{"missing token", "/callback", "", "203.0.113.7:4000", "", http.StatusUnauthorized},
+		{"wrong token", "/callback", "guess", "203.0.113.7:4000", "", http.StatusUnauthorized},
+		{"other ip", "/callback", "secret", "198.51.100.1:4000", "", http.StatusForbidden},
+		{"spoofed forwarding header", "/callback", "secret", "198.51.100.1:4000", "203.0.113.7", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.token != "" {
+			req.Header.Set(CallbackTokenHeader, tt.token)
+		}
+		if tt.forwarded != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, tt.forwarded)
+			req.Header.Set(echo.HeaderXRealIP, tt.forwarded)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRechargeKitCallbackMiddlewareWithoutSecret(t *testing.T) {
+	e := echo.New()
+	e.POST("/callback", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, RechargeKitCallbackMiddleware(&config.RechargeKitConfig{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/callback?token=", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
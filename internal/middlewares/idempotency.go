@@ -0,0 +1,142 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyKeyTTL is how long a key is remembered after first use.
+	IdempotencyKeyTTL = 24 * time.Hour
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware makes a money-moving endpoint safe to retry. When the
+// request carries an Idempotency-Key header the first request with that key
+// runs normally and its response is stored; any later request with the same
+// key from the same user replays the stored response instead of executing
+// the transaction again. Requests without the header are not affected.
+//
+// Only a success or a rejection is stored: handlers behind this middleware
+// answer any failure that may be transient with a 5xx, and the key is then
+// released so the client's retry runs again.
+//
+// Keys are scoped to the user and route. Reusing a key with a different
+// request body, or while the first request is still running, is rejected.
+// Must be registered after AuthorizationMiddleware.
+func IdempotencyMiddleware(db *database.Database) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, models.ResponseModel{
+					Status:  "failed",
+					Message: "idempotency key is too long",
+				})
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, models.ResponseModel{
+					Status:  "failed",
+					Message: "failed to read request body",
+				})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(sum[:])
+			scope := idempotencyScope(c)
+
+			// The key outlives the request: a client that times out and
+			// retries must still find the outcome of the first attempt.
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(c.Request().Context()), 10*time.Second)
+			defer cancel()
+
+			existing, err := db.ClaimIdempotencyKeyQuery(storeCtx, scope, key, requestHash, IdempotencyKeyTTL)
+			if err != nil {
+				log.Printf("failed to claim idempotency key %s: %v", key, err)
+				return c.JSON(http.StatusServiceUnavailable, models.ResponseModel{
+					Status:  "failed",
+					Message: "failed to process idempotency key",
+				})
+			}
+			if existing != nil {
+				return replayIdempotentResponse(c, existing, requestHash)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				db.ReleaseIdempotencyKeyQuery(storeCtx, scope, key)
+				return err
+			}
+
+			status := c.Response().Status
+			if status < http.StatusOK || status >= http.StatusInternalServerError {
+				db.ReleaseIdempotencyKeyQuery(storeCtx, scope, key)
+				return nil
+			}
+			if err := db.CompleteIdempotencyKeyQuery(storeCtx, scope, key, status, recorder.body.Bytes()); err != nil {
+				log.Printf("failed to store response for idempotency key %s: %v", key, err)
+			}
+			return nil
+		}
+	}
+}
+
+func replayIdempotentResponse(c echo.Context, record *models.IdempotencyKeyModel, requestHash string) error {
+	if record.RequestHash != requestHash {
+		return c.JSON(http.StatusUnprocessableEntity, models.ResponseModel{
+			Status:  "failed",
+			Message: "idempotency key was already used for a different request",
+		})
+	}
+	if record.KeyStatus != "COMPLETED" || record.ResponseStatus == nil {
+		return c.JSON(http.StatusConflict, models.ResponseModel{
+			Status:  "failed",
+			Message: "a request with this idempotency key is still in progress",
+		})
+	}
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return c.Blob(*record.ResponseStatus, echo.MIMEApplicationJSONCharsetUTF8, record.ResponseBody)
+}
+
+// idempotencyScope keeps keys of different users and endpoints apart.
+func idempotencyScope(c echo.Context) string {
+	userID := ""
+	if claims, ok := c.Get("user").(*models.AccessTokenClaims); ok && claims != nil {
+		userID = claims.UserID
+		if userID == "" {
+			userID = claims.AdminID
+		}
+	}
+	return userID + ":" + c.Request().Method + " " + c.Path()
+}
+
+// responseRecorder copies the response body while it is written to the
+// client.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
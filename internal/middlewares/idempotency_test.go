@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// idempotencyServer serves POST /pay behind the middleware, answering with
+// the status set in *status and counting how many times the handler ran.
+func idempotencyServer(t *testing.T, status *int, calls *int) *echo.Echo {
+	t.Helper()
+	databaseURL, _ := dbtest.Open(t)
+	db, err := database.NewDatabaseConnection(database.Config{DatabaseURL: databaseURL})
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	e := echo.New()
+	setUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &models.AccessTokenClaims{UserID: "R000001"})
+			return next(c)
+		}
+	}
+	e.POST("/pay", func(c echo.Context) error {
+		*calls++
+		return c.JSON(*status, models.ResponseModel{Status: "done", Message: strings.Repeat("x", *calls)})
+	}, setUser, IdempotencyMiddleware(db))
+	return e
+}
+
+func postWithKey(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pay", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(IdempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysCompletedRequests(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	e := idempotencyServer(t, &status, &calls)
+
+	first := postWithKey(e, "key-1", `{"amount":100}`)
+	second := postWithKey(e, "key-1", `{"amount":100}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is not marked as replayed")
+	}
+
+	if rec := postWithKey(e, "key-1", `{"amount":200}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reusing the key for another body answered %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if rec := postWithKey(e, "key-2", `{"amount":100}`); rec.Code != http.StatusOK || calls != 2 {
+		t.Errorf("a new key answered %d after %d handler runs, want 200 after 2", rec.Code, calls)
+	}
+}
+
+func TestIdempotencyReplaysRejections(t *testing.T) {
+	status, calls := http.StatusBadRequest, 0
+	e := idempotencyServer(t, &status, &calls)
+
+	postWithKey(e, "key-1", `{}`)
+	status = http.StatusOK
+	if rec := postWithKey(e, "key-1", `{}`); rec.Code != http.StatusBadRequest || calls != 1 {
+		t.Errorf("retrying a rejection answered %d after %d handler runs, want 400 after 1", rec.Code, calls)
+	}
+}
+
+func TestIdempotencyRetriesTransientFailures(t *testing.T) {
+	status, calls := http.StatusServiceUnavailable, 0
+	e := idempotencyServer(t, &status, &calls)
+
+	postWithKey(e, "key-1", `{}`)
+	status = http.StatusOK
+	if rec := postWithKey(e, "key-1", `{}`); rec.Code != http.StatusOK || calls != 2 {
+		t.Errorf("retrying a transient failure answered %d after %d handler runs, want 200 after 2", rec.Code, calls)
+	}
+	if rec := postWithKey(e, "key-1", `{}`); rec.Code != http.StatusOK || calls != 2 {
+		t.Errorf("retrying the success answered %d after %d handler runs, want 200 after 2", rec.Code, calls)
+	}
+}
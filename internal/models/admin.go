@@ -25,8 +25,8 @@ type UpdateAdminPasswordRequestModel struct {
 }
 
 type UpdateAdminWalletRequestModel struct {
-	AdminID string  `json:"admin_id" validate:"required"`
-	Amount  float64 `json:"amount" validate:"required,min=1"`
+	AdminID string `json:"admin_id" validate:"required"`
+	Amount  Money  `json:"amount" validate:"required,min=1"`
 }
 
 type UpdateAdminBlockStatusRequestModel struct {
@@ -39,7 +39,7 @@ type GetCompleteAdminDetailsResponseModel struct {
 	AdminName          string    `json:"admin_name"`
 	AdminEmail         string    `json:"admin_email"`
 	AdminPhone         string    `json:"admin_phone"`
-	AdminWalletBalance float64   `json:"admin_wallet_balance"`
+	AdminWalletBalance Money     `json:"admin_wallet_balance"`
 	IsAdminBlocked     bool      `json:"is_admin_blocked"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
@@ -63,8 +63,8 @@ type AdminLoginRequestModel struct {
 }
 
 type RechargeKitWalletBalanceResponseModel struct {
-	Error           int     `json:"error"`
-	Message         string  `json:"msg"`
-	WalletAmount    float64 `json:"wallet_amount"`
-	DMRWalletAmount float64 `json:"dmr_wallet_amount"`
+	Error           int    `json:"error"`
+	Message         string `json:"msg"`
+	WalletAmount    Money  `json:"wallet_amount"`
+	DMRWalletAmount Money  `json:"dmr_wallet_amount"`
 }
@@ -0,0 +1,164 @@
+package models
+
+import "time"
+
+// AEPSTransactionRequestModel is an AEPS request captured at the retailer's
+// counter: the customer's Aadhaar number and bank, authenticated with the
+// biometric PidData. Amount is only used by cash withdrawals and Aadhaar
+// Pay.
+type AEPSTransactionRequestModel struct {
+	RetailerID           string `json:"retailer_id"`
+	TransactionType      string `json:"transaction_type"`
+	CustomerMobileNumber string `json:"customer_mobile_number" validate:"required"`
+	AadhaarNumber        string `json:"aadhaar_number" validate:"required,len=12,numeric"`
+	BankIIN              string `json:"bank_iin" validate:"required"`
+	BankName             string `json:"bank_name"`
+	Amount               Money  `json:"amount" validate:"min=0"`
+	Latitude             string `json:"lat" validate:"required"`
+	Longitude            string `json:"long" validate:"required"`
+	PidData              string `json:"pid_data" validate:"required"`
+	IsIris               int    `json:"is_iris"`
+	PartnerRequestID     string `json:"partner_request_id"`
+}
+
+type AEPSMiniStatementEntryModel struct {
+	Date      string `json:"date"`
+	Narration string `json:"narration"`
+	TxnType   string `json:"txn_type"`
+	Amount    Money  `json:"amount"`
+}
+
+// AEPSEnquiryResponseModel is the provider's answer to a balance enquiry
+// or mini statement. Status is SUCCESS or FAILED.
+type AEPSEnquiryResponseModel struct {
+	Status                string                        `json:"status"`
+	Message               string                        `json:"message"`
+	OrderID               string                        `json:"order_id"`
+	OperatorTransactionID string                        `json:"operator_transaction_id"`
+	AccountBalance        Money                         `json:"account_balance"`
+	MiniStatement         []AEPSMiniStatementEntryModel `json:"mini_statement,omitempty"`
+}
+
+type AEPSTransactionResponseModel struct {
+	AEPSTransactionID     string                        `json:"aeps_transaction_id"`
+	TransactionType       string                        `json:"transaction_type"`
+	TransactionStatus     string                        `json:"transaction_status"`
+	Message               string                        `json:"message"`
+	Amount                Money                         `json:"amount"`
+	OperatorTransactionID string                        `json:"operator_transaction_id"`
+	AccountBalance        *Money                        `json:"account_balance,omitempty"`
+	MiniStatement         []AEPSMiniStatementEntryModel `json:"mini_statement,omitempty"`
+}
+
+type GetAEPSTransactionsResponseModel struct {
+	AEPSTransactionID          string    `json:"aeps_transaction_id"`
+	PartnerRequestID           string    `json:"partner_request_id"`
+	OperatorTransactionID      string    `json:"operator_transaction_id"`
+	OrderID                    string    `json:"order_id"`
+	RetailerID                 string    `json:"retailer_id"`
+	RetailerName               string    `json:"retailer_name"`
+	RetailerBusinessName       string    `json:"retailer_business_name"`
+	TransactionType            string    `json:"transaction_type"`
+	CustomerMobileNumber       string    `json:"customer_mobile_number"`
+	AadhaarLastDigits          string    `json:"aadhaar_last_digits"`
+	BankIIN                    string    `json:"bank_iin"`
+	BankName                   string    `json:"bank_name"`
+	Amount                     Money     `json:"amount"`
+	AccountBalance             *Money    `json:"account_balance"`
+	MasterDistributorCommision Money     `json:"master_distributor_commision"`
+	DistributorCommision       Money     `json:"distributor_commision"`
+	RetailerCommision          Money     `json:"retailer_commision"`
+	Provider                   string    `json:"provider"`
+	Message                    string    `json:"message"`
+	BeforeBalance              *Money    `json:"before_balance"`
+	AfterBalance               *Money    `json:"after_balance"`
+	TransactionStatus          string    `json:"transaction_status"`
+	CreatedAt                  time.Time `json:"created_at"`
+	UpdatedAt                  time.Time `json:"updated_at"`
+}
+
+// AEPSSettlementSettingsModel holds the flat charges taken from the
+// settlement wallet on top of the amount settled to the main wallet or to
+// the bank. Charges are credited to the admin.
+type AEPSSettlementSettingsModel struct {
+	WalletTransferCharge Money     `json:"wallet_transfer_charge"`
+	BankSettlementCharge Money     `json:"bank_settlement_charge"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type UpdateAEPSSettlementSettingsRequestModel struct {
+	WalletTransferCharge Money `json:"wallet_transfer_charge" validate:"min=0"`
+	BankSettlementCharge Money `json:"bank_settlement_charge" validate:"min=0"`
+}
+
+// SettlementAccountModel is a bank account of a retailer that AEPS money
+// can be settled to once an admin has verified it.
+type SettlementAccountModel struct {
+	SettlementAccountID int64      `json:"settlement_account_id"`
+	RetailerID          string     `json:"retailer_id"`
+	AccountHolderName   string     `json:"account_holder_name"`
+	BankName            string     `json:"bank_name"`
+	AccountNumber       string     `json:"account_number"`
+	IFSCCode            string     `json:"ifsc_code"`
+	IsVerified          bool       `json:"is_verified"`
+	VerifiedBy          *string    `json:"verified_by"`
+	VerifiedAt          *time.Time `json:"verified_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type CreateSettlementAccountRequestModel struct {
+	RetailerID        string `json:"retailer_id"`
+	AccountHolderName string `json:"account_holder_name" validate:"required"`
+	BankName          string `json:"bank_name" validate:"required"`
+	AccountNumber     string `json:"account_number" validate:"required,numeric,min=6,max=20"`
+	IFSCCode          string `json:"ifsc_code" validate:"required,len=11"`
+}
+
+type AEPSWalletSettlementRequestModel struct {
+	RetailerID string `json:"retailer_id"`
+	Amount     Money  `json:"amount" validate:"required,gt=0"`
+}
+
+type AEPSBankSettlementRequestModel struct {
+	RetailerID          string `json:"retailer_id"`
+	SettlementAccountID int64  `json:"settlement_account_id" validate:"required"`
+	Amount              Money  `json:"amount" validate:"required,gt=0"`
+	TransferType        string `json:"transfer_type" validate:"required,oneof=IMPS NEFT"`
+	PartnerRequestID    string `json:"partner_request_id"`
+}
+
+type RejectAEPSSettlementRequestModel struct {
+	Remarks string `json:"remarks" validate:"required"`
+}
+
+// AEPSSettlementModel is a move of money out of a retailer's settlement
+// wallet. WALLET settlements go to the main wallet and succeed at once;
+// BANK settlements stay INITIATED until an admin approves them and are
+// then paid out to the verified account through the payout provider.
+type AEPSSettlementModel struct {
+	AEPSSettlementID      string     `json:"aeps_settlement_id"`
+	PartnerRequestID      string     `json:"partner_request_id"`
+	OperatorTransactionID string     `json:"operator_transaction_id"`
+	OrderID               string     `json:"order_id"`
+	RetailerID            string     `json:"retailer_id"`
+	RetailerName          string     `json:"retailer_name"`
+	RetailerBusinessName  string     `json:"retailer_business_name"`
+	RetailerPhone         string     `json:"retailer_phone"`
+	SettlementType        string     `json:"settlement_type"`
+	SettlementAccountID   *int64     `json:"settlement_account_id"`
+	AccountHolderName     string     `json:"account_holder_name"`
+	BankName              string     `json:"bank_name"`
+	AccountNumber         string     `json:"account_number"`
+	IFSCCode              string     `json:"ifsc_code"`
+	TransferType          *string    `json:"transfer_type"`
+	Amount                Money      `json:"amount"`
+	Charge                Money      `json:"charge"`
+	Provider              string     `json:"provider"`
+	Remarks               string     `json:"remarks"`
+	ApprovedBy            *string    `json:"approved_by"`
+	ApprovedAt            *time.Time `json:"approved_at"`
+	TransactionStatus     string     `json:"transaction_status"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
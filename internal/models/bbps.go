@@ -2,111 +2,120 @@ package models
 
 import "time"
 
-type GetPostpaidMobileRechargeBillFetchAPIRequestModel struct {
-	MobileNumber string `json:"mobile_no" validate:"required"`
-	OperatorCode int    `json:"operator_code" validate:"required"`
+type BBPSCategoryModel struct {
+	CategoryCode string `json:"category_code"`
+	CategoryName string `json:"category_name"`
 }
 
-type GetPostpaidMobileRechargeBillFetchAPIResponseModel struct {
-	Error      int    `json:"error"`
-	Message    string `json:"msg"`
-	Status     int    `json:"status"`
-	BillAmount any    `json:"billAmount"`
+// BBPSBillerParamModel is a customer parameter a biller identifies a bill
+// by, such as a consumer number or a policy number. MaxLength zero means no
+// limit and an empty Regex accepts any value.
+type BBPSBillerParamModel struct {
+	ParamName   string `json:"param_name" validate:"required"`
+	DisplayName string `json:"display_name" validate:"required"`
+	IsOptional  bool   `json:"is_optional"`
+	MinLength   int    `json:"min_length" validate:"min=0"`
+	MaxLength   int    `json:"max_length" validate:"min=0"`
+	Regex       string `json:"regex"`
 }
 
-type CreatePostpaidMobileRechargeAPIRequestModel struct {
-	RetailerID       string  `json:"retailer_id" validate:"required"`
-	MobileNumber     string  `json:"mobile_no" validate:"required"`
-	OperatorCode     int     `json:"operator_code" validate:"required"`
-	Amount           float64 `json:"amount" validate:"required"`
-	OperatorCircle   int     `json:"circle" validate:"required"`
-	PartnerRequestID string  `json:"partner_request_id,omitempty"`
-	OperatorName     string  `json:"operator_name" validate:"required"`
-	CircleName       string  `json:"circle_name" validate:"required"`
+// BBPSBillerModel is a biller of any category. OperatorCode is the code
+// providers know the biller by, and a biller with FetchRequired is only
+// paid after its bill has been fetched.
+type BBPSBillerModel struct {
+	BillerID      string                 `json:"biller_id"`
+	BillerName    string                 `json:"biller_name"`
+	CategoryCode  string                 `json:"category_code"`
+	OperatorCode  int                    `json:"operator_code"`
+	FetchRequired bool                   `json:"fetch_required"`
+	IsActive      bool                   `json:"is_active"`
+	Params        []BBPSBillerParamModel `json:"params"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
 }
 
-type GetPostpaidMobileRechargeAPIResponseModel struct {
-	Error                 int    `json:"error"`
-	Message               string `json:"msg"`
-	Status                int    `json:"status"`
-	OrderID               string `json:"orderid"`
-	OperatorTransactionID string `json:"optransid"`
+type CreateBBPSBillerRequestModel struct {
+	BillerID      string                 `json:"biller_id" validate:"required"`
+	BillerName    string                 `json:"biller_name" validate:"required"`
+	CategoryCode  string                 `json:"category_code" validate:"required"`
+	OperatorCode  int                    `json:"operator_code" validate:"required"`
+	FetchRequired bool                   `json:"fetch_required"`
+	Params        []BBPSBillerParamModel `json:"params" validate:"required,min=1,dive"`
 }
 
-type GetPostpaidMobileRechargeHistoryResponseModel struct {
-	PostpaidRechargeTransactionID int       `json:"postpaid_recharge_transaction_id"`
-	RetailerID                    string    `json:"retailer_id"`
-	RetailerName                  string    `json:"retailer_name"`
-	RetailerBusinessName          string    `json:"retailer_business_name"`
-	PartnerRequestID              string    `json:"partner_request_id"`
-	OperatorTransactionID         string    `json:"operator_transaction_id"`
-	OrderID                       string    `json:"order_id"`
-	MobileNumber                  string    `json:"mobile_number"`
-	OperatorCode                  string    `json:"operator_code"`
-	Amount                        float64   `json:"amount"`
-	BeforeBalance                 float64   `json:"before_balance"`
-	AfterBalance                  float64   `json:"after_balance"`
-	CircleCode                    string    `json:"circle_code"`
-	CircleName                    string    `json:"circle_name"`
-	OperatorName                  string    `json:"operator_name"`
-	RechargeType                  string    `json:"recharge_type"`
-	RechargeStatus                string    `json:"recharge_status"`
-	Commission                    float64   `json:"commission"`
-	CreatedAt                     time.Time `json:"created_at"`
+// UpdateBBPSBillerRequestModel changes the fields that are set. Params,
+// when given, replace all of the biller's parameters.
+type UpdateBBPSBillerRequestModel struct {
+	BillerID      string                 `json:"biller_id"`
+	BillerName    *string                `json:"biller_name" validate:"omitempty"`
+	OperatorCode  *int                   `json:"operator_code" validate:"omitempty"`
+	FetchRequired *bool                  `json:"fetch_required"`
+	IsActive      *bool                  `json:"is_active"`
+	Params        []BBPSBillerParamModel `json:"params" validate:"omitempty,min=1,dive"`
 }
 
-type GetElectricityBillFetchRequestModel struct {
-	CustomerID   string `json:"customer_id" validate:"required"`
-	OperatorCode int    `json:"operator_code" validate:"required"`
+type BBPSBillFetchRequestModel struct {
+	BillerID       string            `json:"biller_id" validate:"required"`
+	CustomerParams map[string]string `json:"customer_params" validate:"required"`
 }
 
-type GetElectricityBillFetchResponseModel struct {
+type BBPSBillFetchResponseModel struct {
 	Error      int    `json:"error"`
 	Message    string `json:"msg"`
 	Status     int    `json:"status"`
 	BillAmount any    `json:"billAmount"`
 }
 
-type CreateElectricityBillPaymentRequestModel struct {
-	RetailerID       string  `json:"retailer_id" validate:"required"`
-	CustomerID       string  `json:"customer_id" validate:"required"`
-	CustomerEmail    string  `json:"customer_email" validate:"required"`
-	OperatorCode     int     `json:"operator_code" validate:"required"`
-	OperatorName     string  `json:"operator_name" validate:"required"`
-	Amount           float64 `json:"amount" validate:"required"`
-	PartnerRequestID string  `json:"partner_request_id,omitempty"`
+type BBPSBillPaymentRequestModel struct {
+	RetailerID       string            `json:"retailer_id"`
+	BillerID         string            `json:"biller_id" validate:"required"`
+	CustomerParams   map[string]string `json:"customer_params" validate:"required"`
+	CustomerEmail    string            `json:"customer_email" validate:"omitempty,email"`
+	Amount           Money             `json:"amount" validate:"required"`
+	PartnerRequestID string            `json:"partner_request_id"`
 }
 
-type GetElectricityBillPaymentAPIResponseModel struct {
-	Error                 int    `json:"error"`
-	Status                int    `json:"status"`
-	Message               string `json:"msg"`
-	OrderID               string `json:"orderid"`
-	PartnerRequestID      string `json:"partnerreqid"`
-	OperatorTransactionID string `json:"optransid"`
+// BBPSProviderRequestModel is a bill fetch or payment as sent to a
+// provider. CustomerParams holds the values of the biller's parameters in
+// the biller's order; Amount, CustomerEmail and PartnerRequestID are only
+// set on payments.
+type BBPSProviderRequestModel struct {
+	CategoryCode     string   `json:"category_code"`
+	OperatorCode     int      `json:"operator_code"`
+	CustomerParams   []string `json:"customer_params"`
+	CustomerEmail    string   `json:"customer_email"`
+	Amount           Money    `json:"amount"`
+	PartnerRequestID string   `json:"partner_request_id"`
 }
 
-type GetElectricityOperatorResponseModel struct {
-	OperatorName string `json:"operator_name"`
-	OperatorCode int    `json:"operator_code"`
+type BBPSBillPaymentResponseModel struct {
+	BBPSTransactionID     string `json:"bbps_transaction_id"`
+	TransactionStatus     string `json:"transaction_status"`
+	Message               string `json:"message"`
+	Amount                Money  `json:"amount"`
+	OperatorTransactionID string `json:"operator_transaction_id"`
 }
 
-type GetElectricityBillHistoryResponseModel struct {
-	ElectricityBillTransactionID int       `json:"electricity_bill_transaction_id"`
-	OperatorTransactionID        *string   `json:"operator_transaction_id"`
-	OrderID                      *string   `json:"order_id"`
-	PartnerRequestID             string    `json:"partner_request_id"`
-	RetailerID                   string    `json:"retailer_id"`
-	RetailerName                 string    `json:"retailer_name"`
-	RetailerBusinessName         string    `json:"retailer_business_name"`
-	CustomerID                   string    `json:"customer_id"`
-	CustomerEmail                string    `json:"customer_email"`
-	OperatorName                 string    `json:"operator_name"`
-	OperatorID                   int       `json:"operator_id"`
-	Amount                       float64   `json:"amount"`
-	Commision                    float64   `json:"commision"`
-	BeforeBalance                float64   `json:"before_balance"`
-	AfterBalance                 float64   `json:"after_balance"`
-	TransactionStatus            string    `json:"transaction_status"`
-	CreatedAt                    time.Time `json:"created_at"`
+type GetBBPSTransactionsResponseModel struct {
+	BBPSTransactionID     string            `json:"bbps_transaction_id"`
+	PartnerRequestID      string            `json:"partner_request_id"`
+	OperatorTransactionID string            `json:"operator_transaction_id"`
+	OrderID               string            `json:"order_id"`
+	RetailerID            string            `json:"retailer_id"`
+	RetailerName          string            `json:"retailer_name"`
+	RetailerBusinessName  string            `json:"retailer_business_name"`
+	BillerID              string            `json:"biller_id"`
+	BillerName            string            `json:"biller_name"`
+	CategoryCode          string            `json:"category_code"`
+	OperatorCode          int               `json:"operator_code"`
+	CustomerParams        map[string]string `json:"customer_params"`
+	CustomerEmail         string            `json:"customer_email"`
+	Amount                Money             `json:"amount"`
+	Commision             Money             `json:"commision"`
+	BeforeBalance         *Money            `json:"before_balance"`
+	AfterBalance          *Money            `json:"after_balance"`
+	Provider              string            `json:"provider"`
+	TransactionStatus     string            `json:"transaction_status"`
+	CreatedAt             time.Time         `json:"created_at"`
+	UpdatedAt             time.Time         `json:"updated_at"`
 }
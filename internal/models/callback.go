@@ -0,0 +1,23 @@
+package models
+
+// RechargeKitCallbackModel is a status notification from RechargeKit. It
+// uses the same field names as the transaction responses and may arrive as
+// JSON, a form or a query string.
+type RechargeKitCallbackModel struct {
+	Status                int    `json:"status" form:"status" query:"status"`
+	Message               string `json:"msg" form:"msg" query:"msg"`
+	OrderId               string `json:"orderid" form:"orderid" query:"orderid"`
+	OperatorTransactionId string `json:"optransid" form:"optransid" query:"optransid"`
+	PartnerRequestId      string `json:"partnerreqid" form:"partnerreqid" query:"partnerreqid"`
+}
+
+type ProviderCallbackModel struct {
+	Provider         string
+	PartnerRequestID string
+	ProviderStatus   int
+	Payload          []byte
+	SourceIP         string
+	Service          *string
+	TransactionID    *string
+	CallbackResult   string
+}
@@ -3,47 +3,191 @@ package models
 import "time"
 
 type CreateCommisionRequestModel struct {
-	UserID                     string  `json:"user_id" validate:"required"`
-	Service                    string  `json:"service" validate:"required"`
-	TotalCommision             float64 `json:"total_commision" validate:"required"`
-	AdminCommision             float64 `json:"admin_commision" validate:"required"`
-	MasterDistributorCommision float64 `json:"master_distributor_commision" validate:"required"`
-	DistributorCommision       float64 `json:"distributor_commision" validate:"required"`
-	RetailerCommision          float64 `json:"retailer_commision" validate:"required"`
+	UserID                     string `json:"user_id" validate:"required"`
+	Service                    string `json:"service" validate:"required"`
+	TotalCommision             Rate   `json:"total_commision" validate:"required"`
+	AdminCommision             Rate   `json:"admin_commision" validate:"min=0"`
+	MasterDistributorCommision Rate   `json:"master_distributor_commision" validate:"min=0"`
+	DistributorCommision       Rate   `json:"distributor_commision" validate:"min=0"`
+	RetailerCommision          Rate   `json:"retailer_commision" validate:"min=0"`
 }
 
 type UpdateCommisionRequestModel struct {
-	CommisionID                int64    `json:"commision_id" validate:"required"`
-	TotalCommision             *float64 `json:"total_commision" validate:"omitempty"`
-	AdminCommision             *float64 `json:"admin_commision" validate:"omitempty"`
-	MasterDistributorCommision *float64 `json:"master_distributor_commision" validate:"omitempty"`
-	DistributorCommision       *float64 `json:"distributor_commision" validate:"omitempty"`
-	RetailerCommision          *float64 `json:"retailer_commision" validate:"omitempty"`
+	CommisionID                int64 `json:"commision_id" validate:"required"`
+	TotalCommision             *Rate `json:"total_commision" validate:"omitempty,gt=0"`
+	AdminCommision             *Rate `json:"admin_commision" validate:"omitempty,min=0"`
+	MasterDistributorCommision *Rate `json:"master_distributor_commision" validate:"omitempty,min=0"`
+	DistributorCommision       *Rate `json:"distributor_commision" validate:"omitempty,min=0"`
+	RetailerCommision          *Rate `json:"retailer_commision" validate:"omitempty,min=0"`
 }
 
 type GetCommisionResponseModel struct {
 	CommisionID                int64     `json:"commision_id"`
 	UserID                     string    `json:"user_id"`
 	Service                    string    `json:"service"`
-	TotalCommision             float64   `json:"total_commision"`
-	AdminCommision             float64   `json:"admin_commision"`
-	MasterDistributorCommision float64   `json:"master_distributor_commision"`
-	DistributorCommision       float64   `json:"distributor_commision"`
-	RetailerCommision          float64   `json:"retailer_commision"`
+	TotalCommision             Rate      `json:"total_commision"`
+	AdminCommision             Rate      `json:"admin_commision"`
+	MasterDistributorCommision Rate      `json:"master_distributor_commision"`
+	DistributorCommision       Rate      `json:"distributor_commision"`
+	RetailerCommision          Rate      `json:"retailer_commision"`
+	CreatedAt                  time.Time `json:"created_at"`
+	UpdatedAt                  time.Time `json:"updated_at"`
+}
+
+// CommisionAuditModel is a row of commisions that breaks the split rules,
+// with the row of its user's parent it was measured against.
+type CommisionAuditModel struct {
+	Commision       GetCommisionResponseModel  `json:"commision"`
+	ParentCommision *GetCommisionResponseModel `json:"parent_commision,omitempty"`
+	Problems        []string                   `json:"problems"`
+}
+
+// CommisionSplitModel is the commission on one transaction and the part
+// of it each member of the retailer's hierarchy gets.
+type CommisionSplitModel struct {
+	TotalCommision             Money `json:"total_commision"`
+	AdminCommision             Money `json:"admin_commision"`
+	MasterDistributorCommision Money `json:"master_distributor_commision"`
+	DistributorCommision       Money `json:"distributor_commision"`
+	RetailerCommision          Money `json:"retailer_commision"`
+}
+
+// CreateCommisionSlabRequestModel configures the commission on the
+// transactions of a service (of one operator for recharges, when
+// OperatorCode is set) whose amount falls between MinAmount and MaxAmount
+// (no upper bound when nil): a flat amount or a percentage of the
+// transaction, kept between MinCommision and MaxCommision, and split by the
+// four shares, which must add up to one. A slab without a user applies to
+// everyone who has no slab of their own; a slab with a PackageID belongs to
+// that commission package instead of a user. It takes effect at EffectiveFrom,
+// or immediately.
+type CreateCommisionSlabRequestModel struct {
+	UserID                     *string    `json:"user_id"`
+	PackageID                  *int64     `json:"package_id"`
+	Service                    string     `json:"service" validate:"required,oneof=PAYOUT DMT AEPS BBPS MOBILE_RECHARGE DTH_RECHARGE"`
+	OperatorCode               *int       `json:"operator_code"`
+	MinAmount                  Money      `json:"min_amount" validate:"min=0"`
+	MaxAmount                  *Money     `json:"max_amount" validate:"omitempty,min=0"`
+	CommisionType              string     `json:"commision_type" validate:"required,oneof=FLAT PERCENTAGE"`
+	FlatCommision              Money      `json:"flat_commision" validate:"min=0"`
+	PercentCommision           Rate       `json:"percent_commision" validate:"min=0,max=1000000"`
+	MinCommision               Money      `json:"min_commision" validate:"min=0"`
+	MaxCommision               *Money     `json:"max_commision" validate:"omitempty,min=0"`
+	AdminCommision             Rate       `json:"admin_commision" validate:"min=0"`
+	MasterDistributorCommision Rate       `json:"master_distributor_commision" validate:"min=0"`
+	DistributorCommision       Rate       `json:"distributor_commision" validate:"min=0"`
+	RetailerCommision          Rate       `json:"retailer_commision" validate:"min=0"`
+	EffectiveFrom              *time.Time `json:"effective_from"`
+}
+
+// UpdateCommisionSlabRequestModel replaces every setting of a slab.
+type UpdateCommisionSlabRequestModel struct {
+	SlabID int64 `json:"slab_id" validate:"required"`
+	CreateCommisionSlabRequestModel
+}
+
+type GetCommisionSlabResponseModel struct {
+	SlabID                     int64     `json:"slab_id"`
+	UserID                     *string   `json:"user_id"`
+	PackageID                  *int64    `json:"package_id"`
+	Service                    string    `json:"service"`
+	OperatorCode               *int      `json:"operator_code"`
+	MinAmount                  Money     `json:"min_amount"`
+	MaxAmount                  *Money    `json:"max_amount"`
+	CommisionType              string    `json:"commision_type"`
+	FlatCommision              Money     `json:"flat_commision"`
+	PercentCommision           Rate      `json:"percent_commision"`
+	MinCommision               Money     `json:"min_commision"`
+	MaxCommision               *Money    `json:"max_commision"`
+	AdminCommision             Rate      `json:"admin_commision"`
+	MasterDistributorCommision Rate      `json:"master_distributor_commision"`
+	DistributorCommision       Rate      `json:"distributor_commision"`
+	RetailerCommision          Rate      `json:"retailer_commision"`
+	EffectiveFrom              time.Time `json:"effective_from"`
 	CreatedAt                  time.Time `json:"created_at"`
 	UpdatedAt                  time.Time `json:"updated_at"`
 }
 
 type GetTDSCommisionResponseModel struct {
 	TDSCommisionID int64     `json:"tds_commision_id"`
+	Service        string    `json:"service"`
 	TransactionID  string    `json:"transaction_id"`
 	UserID         string    `json:"user_id"`
 	UserName       string    `json:"user_name"`
-	Commision      float64   `json:"commision"`
-	TDS            float64   `json:"tds"`
-	PaidCommision  float64   `json:"paid_commision"`
+	Commision      Money     `json:"commision"`
+	TDSRate        Rate      `json:"tds_rate"`
+	TDS            Money     `json:"tds"`
+	PaidCommision  Money     `json:"paid_commision"`
 	PANNumber      string    `json:"pan_number"`
 	Status         string    `json:"status"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+type TDSSettingsModel struct {
+	TDSRate      Rate      `json:"tds_rate"`
+	NoPANTDSRate Rate      `json:"no_pan_tds_rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type UpdateTDSSettingsRequestModel struct {
+	TDSRate      *Rate `json:"tds_rate" validate:"omitempty,min=0,max=1000000"`
+	NoPANTDSRate *Rate `json:"no_pan_tds_rate" validate:"omitempty,min=0,max=1000000"`
+}
+
+// TDSQuarterlySummaryModel is the TDS deducted from a user in a quarter of
+// a financial year, the figures a Form 16A certificate is made from.
+// Commision, TDS and PaidCommision are net of reversals.
+type TDSQuarterlySummaryModel struct {
+	UserID        string `json:"user_id"`
+	UserName      string `json:"user_name"`
+	PANNumber     string `json:"pan_number"`
+	FinancialYear int    `json:"financial_year"`
+	Quarter       int    `json:"quarter"`
+	Deductions    int64  `json:"deductions"`
+	Commision     Money  `json:"commision"`
+	TDS           Money  `json:"tds"`
+	PaidCommision Money  `json:"paid_commision"`
+}
+
+// CommisionSettlementSettingsModel says when the hierarchy's commission on
+// a successful transaction reaches their wallets: INSTANT on success, or
+// in a DAILY or MONTHLY batch for the transactions settled before the
+// current day or month (Indian time).
+type CommisionSettlementSettingsModel struct {
+	SettlementMode string    `json:"settlement_mode"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type UpdateCommisionSettlementSettingsRequestModel struct {
+	SettlementMode string `json:"settlement_mode" validate:"required,oneof=INSTANT DAILY MONTHLY"`
+}
+
+// PendingCommisionModel is the commission of one member of the hierarchy
+// on one transaction. It is PENDING while the transaction is, DUE once the
+// transaction succeeded and RELEASED once credited to the wallet; a failed
+// transaction CANCELLED it and a refund REVERSED it. ReversedCommision is
+// the part of NetCommision clawed back by partial refunds.
+type PendingCommisionModel struct {
+	PendingCommisionID int64      `json:"pending_commision_id"`
+	Service            string     `json:"service"`
+	TransactionID      string     `json:"transaction_id"`
+	UserID             string     `json:"user_id"`
+	Commision          Money      `json:"commision"`
+	TDS                Money      `json:"tds"`
+	NetCommision       Money      `json:"net_commision"`
+	ReversedCommision  Money      `json:"reversed_commision"`
+	Status             string     `json:"status"`
+	ReleaseJournalID   *int64     `json:"release_journal_id,omitempty"`
+	SettledAt          *time.Time `json:"settled_at,omitempty"`
+	ReleasedAt         *time.Time `json:"released_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// CommisionReleaseModel is the result of releasing the commission that was
+// due before Cutoff.
+type CommisionReleaseModel struct {
+	Cutoff     time.Time `json:"cutoff"`
+	Users      int       `json:"users"`
+	Commisions int       `json:"commisions"`
+	Amount     Money     `json:"amount"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// A commission package is a named set of commission slabs covering any
+// number of services. Assigning it to a user applies its slabs to the
+// user's transactions wherever the user has no override of their own.
+
+type CreateCommisionPackageRequestModel struct {
+	PackageName string `json:"package_name" validate:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateCommisionPackageRequestModel struct {
+	PackageID   int64   `json:"package_id" validate:"required"`
+	PackageName *string `json:"package_name" validate:"omitempty,min=1"`
+	Description *string `json:"description"`
+}
+
+// AssignCommisionPackageRequestModel assigns a package to users, replacing
+// whatever package they had.
+type AssignCommisionPackageRequestModel struct {
+	PackageID int64    `json:"package_id" validate:"required"`
+	UserIDs   []string `json:"user_ids" validate:"required,min=1,dive,required"`
+}
+
+type UnassignCommisionPackageRequestModel struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,required"`
+}
+
+// ReassignCommisionPackageRequestModel moves every user of one package to
+// another.
+type ReassignCommisionPackageRequestModel struct {
+	FromPackageID int64 `json:"from_package_id" validate:"required"`
+	ToPackageID   int64 `json:"to_package_id" validate:"required"`
+}
+
+type GetCommisionPackageResponseModel struct {
+	PackageID     int64                           `json:"package_id"`
+	PackageName   string                          `json:"package_name"`
+	Description   string                          `json:"description"`
+	CreatedBy     string                          `json:"created_by"`
+	AssignedUsers int64                           `json:"assigned_users"`
+	Slabs         []GetCommisionSlabResponseModel `json:"slabs,omitempty"`
+	CreatedAt     time.Time                       `json:"created_at"`
+	UpdatedAt     time.Time                       `json:"updated_at"`
+}
+
+// EffectiveCommisionLevelModel is what one member of a user's hierarchy
+// contributes to the user's commission: their own overrides and the
+// package assigned to them.
+type EffectiveCommisionLevelModel struct {
+	UserID     string                            `json:"user_id"`
+	Slabs      []GetCommisionSlabResponseModel   `json:"slabs"`
+	Commisions []GetCommisionResponseModel       `json:"commisions"`
+	Package    *GetCommisionPackageResponseModel `json:"package"`
+}
+
+// EffectiveCommisionModel explains the commission of a user's transactions.
+// For each service the first level with a rule covering the amount wins,
+// in the order listed; within a level the overrides come before the
+// package. DefaultSlabs apply when no level has a rule.
+type EffectiveCommisionModel struct {
+	UserID       string                          `json:"user_id"`
+	Levels       []EffectiveCommisionLevelModel  `json:"levels"`
+	DefaultSlabs []GetCommisionSlabResponseModel `json:"default_slabs"`
+}
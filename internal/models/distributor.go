@@ -38,7 +38,7 @@ type UpdateDistributorDetailsRequestModel struct {
 	BusinessType     *string    `json:"business_type"`
 	GSTNumber        *string    `json:"gst_number"`
 	DocumentsURL     *string    `json:"documents_url"`
-	WalletBalance    *float64    `json:"wallet_balance"`
+	WalletBalance    *Money     `json:"wallet_balance"`
 }
 
 type UpdateDistributorPasswordRequestModel struct {
@@ -101,7 +101,7 @@ type GetCompleteDistributorDetailsResponseModel struct {
 	GSTNumber           *string   `json:"gst_number"`
 	KYCStatus           bool      `json:"kyc_status"`
 	DocumentsURL        *string   `json:"documents_url"`
-	WalletBalance       float64   `json:"wallet_balance"`
+	WalletBalance       Money     `json:"wallet_balance"`
 	IsBlocked           bool      `json:"is_blocked"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type DMTWalletCheckRequestModel struct {
 	MobileNumber string `json:"mobile_no" validate:"required"`
 }
@@ -34,6 +36,7 @@ type DMTWalletVerificationRequestModel struct {
 	OTP              string `json:"otp" validate:"required"`
 	EKycID           string `json:"ekyc_id" validate:"required"`
 	StateResp        string `json:"stateresp" validate:"required"`
+	RemitterName     string `json:"remitter_name"`
 	PartnerRequestID string `json:"partner_request_id"`
 }
 
@@ -81,18 +84,135 @@ type DMTGetBeneficiaryResponseModel struct {
 	BeneficiaryList any    `json:"BeneficiaryList"`
 }
 
+type DMTVerifyBeneficiaryRequestModel struct {
+	RetailerID       string `json:"retailer_id"`
+	MobileNumber     string `json:"mobile_no" validate:"required"`
+	BeneficiaryID    string `json:"beneficiary_id" validate:"required"`
+	BeneficiaryName  string `json:"beneficiary_name" validate:"required"`
+	PartnerRequestID string `json:"partner_request_id"`
+}
+
+// DMTVerifyBeneficiaryResponseModel is the outcome of the provider's penny
+// drop to a beneficiary: the account it credited and the name the bank
+// holds for it.
+type DMTVerifyBeneficiaryResponseModel struct {
+	Error           int    `json:"error"`
+	Message         string `json:"msg"`
+	BeneficiaryName string `json:"beneName"`
+	BankName        string `json:"bankName"`
+	AccountNumber   string `json:"accountNo"`
+	IFSCCode        string `json:"ifsc"`
+}
+
+// DMTBeneficiaryModel is a beneficiary verified for transfers, with the
+// details the bank returned for it.
+type DMTBeneficiaryModel struct {
+	MobileNumber    string    `json:"mobile_number"`
+	BeneficiaryID   string    `json:"beneficiary_id"`
+	RetailerID      string    `json:"retailer_id"`
+	BeneficiaryName string    `json:"beneficiary_name"`
+	BankName        string    `json:"bank_name"`
+	AccountNumber   string    `json:"account_number"`
+	IFSCCode        string    `json:"ifsc_code"`
+	VerifiedAt      time.Time `json:"verified_at"`
+}
+
+type DMTTransferOTPRequestModel struct {
+	MobileNumber  string `json:"mobile_no" validate:"required"`
+	BeneficiaryID string `json:"beneficiary_id" validate:"required"`
+	Amount        Money  `json:"amount" validate:"required"`
+}
+
+type DMTTransferOTPResponseModel struct {
+	Error       int    `json:"error"`
+	Message     string `json:"msg"`
+	StateResp   string `json:"stateresp"`
+	Description string `json:"description"`
+}
+
 type DMTTransactionRequestModel struct {
-	RetailerID        string `json:"retailer_id"`
-	MobileNumber      string `json:"mobile_number"`
-	TransferType      string `json:"transfer_type"`
-	Amount            int    `json:"amount"`
-	BeneficiaryID     int    `json:"beneficiary_id"`
-	BeneficiaryName   string `json:"beneficiary_name"`
-	BankName          string `json:"bank_name"`
-	AccountNumber     string `json:"account_number"`
-	IFSCCode          string `json:"ifsc_code"`
-	Pincode           string `json:"pincode"`
-	Address           string `json:"address"`
-	PartnerRequestID  string `json:"partner_request_id"`
-	TransactionStatus string `json:"transaction_status"`
+	RetailerID       string `json:"retailer_id"`
+	MobileNumber     string `json:"mobile_no" validate:"required"`
+	TransferType     string `json:"transfer_type" validate:"required,oneof=IMPS NEFT"`
+	Amount           Money  `json:"amount" validate:"required"`
+	BeneficiaryID    string `json:"beneficiary_id" validate:"required"`
+	BeneficiaryName  string `json:"beneficiary_name"`
+	BankName         string `json:"bank_name"`
+	AccountNumber    string `json:"account_number"`
+	IFSCCode         string `json:"ifsc_code"`
+	OTP              string `json:"otp" validate:"required"`
+	StateResp        string `json:"stateresp" validate:"required"`
+	Pincode          string `json:"pincode"`
+	Address          string `json:"address"`
+	PartnerRequestID string `json:"partner_request_id"`
+}
+
+type GetAllDMTTransactionsResponseModel struct {
+	DMTTransactionID           string    `json:"dmt_transaction_id"`
+	OperatorTransactionID      string    `json:"operator_transaction_id"`
+	PartnerRequestID           string    `json:"partner_request_id"`
+	OrderID                    string    `json:"order_id"`
+	RetailerID                 string    `json:"retailer_id"`
+	RetailerName               string    `json:"retailer_name"`
+	RetailerBusinessName       string    `json:"retailer_business_name"`
+	MobileNumber               string    `json:"mobile_number"`
+	BeneficiaryID              string    `json:"beneficiary_id"`
+	BeneficiaryName            string    `json:"beneficiary_name"`
+	BankName                   string    `json:"bank_name"`
+	AccountNumber              string    `json:"account_number"`
+	IFSCCode                   string    `json:"ifsc_code"`
+	Amount                     Money     `json:"amount"`
+	TransferType               string    `json:"transfer_type"`
+	AdminCommision             Money     `json:"admin_commision"`
+	MasterDistributorCommision Money     `json:"master_distributor_commision"`
+	DistributorCommision       Money     `json:"distributor_commision"`
+	RetailerCommision          Money     `json:"retailer_commision"`
+	Provider                   string    `json:"provider"`
+	BeforeBalance              *Money    `json:"before_balance"`
+	AfterBalance               *Money    `json:"after_balance"`
+	TransactionStatus          string    `json:"transaction_status"`
+	CreatedAt                  time.Time `json:"created_at"`
+	UpdatedAt                  time.Time `json:"updated_at"`
+}
+
+type GetRetailerDMTTransactionsResponseModel struct {
+	DMTTransactionID      string    `json:"dmt_transaction_id"`
+	OperatorTransactionID string    `json:"operator_transaction_id"`
+	PartnerRequestID      string    `json:"partner_request_id"`
+	OrderID               string    `json:"order_id"`
+	RetailerID            string    `json:"retailer_id"`
+	MobileNumber          string    `json:"mobile_number"`
+	BeneficiaryID         string    `json:"beneficiary_id"`
+	BeneficiaryName       string    `json:"beneficiary_name"`
+	BankName              string    `json:"bank_name"`
+	AccountNumber         string    `json:"account_number"`
+	IFSCCode              string    `json:"ifsc_code"`
+	Amount                Money     `json:"amount"`
+	TransferType          string    `json:"transfer_type"`
+	RetailerCommision     Money     `json:"retailer_commision"`
+	BeforeBalance         *Money    `json:"before_balance"`
+	AfterBalance          *Money    `json:"after_balance"`
+	TransactionStatus     string    `json:"transaction_status"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// DMTRemitterModel is a remitter in the local registry, with the caps of
+// its KYC level and what it has transferred in the current calendar month.
+type DMTRemitterModel struct {
+	MobileNumber        string    `json:"mobile_number"`
+	RemitterName        string    `json:"remitter_name"`
+	KYCLevel            string    `json:"kyc_level"`
+	RetailerID          *string   `json:"retailer_id"`
+	PerTransactionLimit Money     `json:"per_transaction_limit"`
+	MonthlyLimit        Money     `json:"monthly_limit"`
+	MonthlyTransferred  Money     `json:"monthly_transferred"`
+	MonthlyRemaining    Money     `json:"monthly_remaining"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type UpdateDMTRemitterRequestModel struct {
+	RemitterName *string `json:"remitter_name"`
+	KYCLevel     *string `json:"kyc_level" validate:"omitempty,oneof=MINIMUM FULL"`
 }
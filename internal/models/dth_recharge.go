@@ -3,14 +3,14 @@ package models
 import "time"
 
 type CreateDTHRechargeRequestModel struct {
-	RetailerID       string  `json:"retailer_id" validate:"required"`
-	CustomerID       string  `json:"customer_id" validate:"required"`
-	OperatorName     string  `json:"operator_name" validate:"required"`
-	OperatorCode     int     `json:"operator_code" validate:"required"`
-	Amount           float64 `json:"amount" validate:"required"`
-	PartnerRequestID string  `json:"partner_request_id"`
-	Status           string  `json:"status"`
-	Commision        float64 `json:"commision"`
+	RetailerID       string `json:"retailer_id" validate:"required"`
+	CustomerID       string `json:"customer_id" validate:"required"`
+	OperatorName     string `json:"operator_name" validate:"required"`
+	OperatorCode     int    `json:"operator_code" validate:"required"`
+	Amount           Money  `json:"amount" validate:"required"`
+	PartnerRequestID string `json:"partner_request_id"`
+	Status           string `json:"status"`
+	Commision        Money  `json:"commision"`
 }
 
 type GetDTHRechargeHistoryResponseModel struct {
@@ -21,13 +21,13 @@ type GetDTHRechargeHistoryResponseModel struct {
 	CustomerID       string    `json:"customer_id"`
 	OperatorName     string    `json:"operator_name"`
 	OperatorCode     int       `json:"operator_code"`
-	Amount           float64   `json:"amount"`
+	Amount           Money     `json:"amount"`
 	PartnerRequestID string    `json:"partner_request_id"`
 	Status           string    `json:"status"`
-	BeforeBalance    float64   `json:"before_balance"`
-	AfterBalance     float64   `json:"after_balance"`
+	BeforeBalance    Money     `json:"before_balance"`
+	AfterBalance     Money     `json:"after_balance"`
 	CreatedAt        time.Time `json:"created_at"`
-	Commision        float64   `json:"commision"`
+	Commision        Money     `json:"commision"`
 }
 
 type GetDTHOperatorsResponseModel struct {
@@ -6,7 +6,7 @@ type FundRequestModel struct {
 	FundRequestID int64
 	RequesterID   string
 	RequestToID   string
-	Amount        float64
+	Amount        Money
 	BankName      *string
 	RequestDate   time.Time
 	UTRNumber     *string
@@ -21,7 +21,7 @@ type FundRequestModel struct {
 type CreateFundRequestModel struct {
 	RequesterID string    `json:"requester_id" validate:"required"`
 	RequestToID string    `json:"request_to_id" validate:"required"`
-	Amount      float64   `json:"amount" validate:"required,gt=0"`
+	Amount      Money     `json:"amount" validate:"required,gt=0"`
 	BankName    string    `json:"bank_name"`
 	RequestDate time.Time `json:"request_date" validate:"required"`
 	UTRNumber   string    `json:"utr_number"`
@@ -45,7 +45,7 @@ type GetFundRequestResponseModel struct {
 	RequesterID   string    `json:"requester_id"`
 	RequestToID   string    `json:"request_to_id"`
 	BusinessName  string    `json:"business_name"`
-	Amount        float64   `json:"amount"`
+	Amount        Money     `json:"amount"`
 	BankName      *string   `json:"bank_name"`
 	RequestDate   time.Time `json:"request_date"`
 	UTRNumber     *string   `json:"utr_number"`
@@ -3,10 +3,10 @@ package models
 import "time"
 
 type CreateFundTransferModel struct {
-	FromID  string  `json:"from_id" validate:"required"`
-	ToID    string  `json:"to_id" validate:"required"`
-	Amount  float64 `json:"amount" validate:"required"`
-	Remarks string  `json:"remarks" validate:"required"`
+	FromID  string `json:"from_id" validate:"required"`
+	ToID    string `json:"to_id" validate:"required"`
+	Amount  Money  `json:"amount" validate:"required"`
+	Remarks string `json:"remarks" validate:"required"`
 }
 
 type GetFundTransferResponseModel struct {
@@ -15,7 +15,7 @@ type GetFundTransferResponseModel struct {
 	FundTransferToID     string    `json:"fund_transfer_on_id"`
 	FundTransferFromName string    `json:"fund_transfer_from_name"`
 	FundTransferToName   string    `json:"fund_transfer_on_name"`
-	Amount               float64   `json:"amount"`
+	Amount               Money     `json:"amount"`
 	Remarks              string    `json:"remarks"`
 	CreatedAT            time.Time `json:"created_at"`
 }
@@ -0,0 +1,13 @@
+package models
+
+// IdempotencyKeyModel is a stored Idempotency-Key. While the first request
+// is running the key is IN_PROGRESS; once it finishes the response is kept
+// so retries can be answered with it.
+type IdempotencyKeyModel struct {
+	Scope          string
+	IdempotencyKey string
+	RequestHash    string
+	KeyStatus      string
+	ResponseStatus *int
+	ResponseBody   []byte
+}
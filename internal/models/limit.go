@@ -3,15 +3,15 @@ package models
 import "time"
 
 type CreateTransactionLimitRequestModel struct {
-	RetailerID  string  `json:"retailer_id" validate:"required"`
-	LimitAmount float64 `json:"limit_amount" validate:"required"`
-	Service     string  `json:"service" validate:"required"`
+	RetailerID  string `json:"retailer_id" validate:"required"`
+	LimitAmount Money  `json:"limit_amount" validate:"required"`
+	Service     string `json:"service" validate:"required"`
 }
 
 type UpdateTransactionLimitRequestModel struct {
-	LimitID     int     `json:"limit_id" validate:"required"`
-	LimitAmount float64 `json:"limit_amount"`
-	Service     string  `json:"service"`
+	LimitID     int    `json:"limit_id" validate:"required"`
+	LimitAmount Money  `json:"limit_amount"`
+	Service     string `json:"service"`
 }
 
 type GetLimitRequestModel struct {
@@ -22,7 +22,7 @@ type GetLimitRequestModel struct {
 type GetLimitResponseModel struct {
 	LimitID     int       `json:"limit_id"`
 	RetailerID  string    `json:"retailer_id"`
-	LimitAmount float64   `json:"limit_amount"`
+	LimitAmount Money     `json:"limit_amount"`
 	Service     string    `json:"service"`
 	CreatedAT   time.Time `json:"created_at"`
 	UpdatedAT   time.Time `json:"updated_at"`
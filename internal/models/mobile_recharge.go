@@ -3,17 +3,17 @@ package models
 import "time"
 
 type CreateMobileRechargeRequestModel struct {
-	RetailerID       string  `json:"retailer_id"`
-	MobileNumber     int64   `json:"mobile_number" validate:"required"`
-	OperatorCode     int     `json:"operator_code" validate:"required"`
-	OperatorName     string  `json:"operator_name" validate:"required"`
-	Amount           float64 `json:"amount" validate:"required"`
-	CircleCode       int     `json:"circle_code" validate:"required"`
-	CircleName       string  `json:"circle_name" validate:"required"`
-	RechargeType     string  `json:"recharge_type,omitempty"`
-	PartnerRequestID string  `json:"partner_request_id,omitempty"`
-	Commision        float64 `json:"commision"`
-	Status           string  `json:"status"`
+	RetailerID       string `json:"retailer_id"`
+	MobileNumber     int64  `json:"mobile_number" validate:"required"`
+	OperatorCode     int    `json:"operator_code" validate:"required"`
+	OperatorName     string `json:"operator_name" validate:"required"`
+	Amount           Money  `json:"amount" validate:"required"`
+	CircleCode       int    `json:"circle_code" validate:"required"`
+	CircleName       string `json:"circle_name" validate:"required"`
+	RechargeType     string `json:"recharge_type,omitempty"`
+	PartnerRequestID string `json:"partner_request_id,omitempty"`
+	Commision        Money  `json:"commision"`
+	Status           string `json:"status"`
 }
 
 type GetMobileRechargeHistoryResponseModel struct {
@@ -24,15 +24,15 @@ type GetMobileRechargeHistoryResponseModel struct {
 	MobileNumber                int64     `json:"mobile_number"`
 	OperatorCode                int       `json:"operator_code"`
 	OperatorName                string    `json:"operator_name"`
-	Amount                      float64   `json:"amount"`
+	Amount                      Money     `json:"amount"`
 	CircleCode                  int       `json:"circle_code"`
 	CircleName                  string    `json:"circle_name"`
 	RechargeType                string    `json:"recharge_type"`
 	PartnerRequestID            string    `json:"partner_request_id"`
 	CreatedAt                   time.Time `json:"created_at"`
-	Commision                   float64   `json:"commision"`
-	BeforeBalance               float64   `json:"before_balance"`
-	AfterBalance                float64   `json:"after_balance"`
+	Commision                   Money     `json:"commision"`
+	BeforeBalance               Money     `json:"before_balance"`
+	AfterBalance                Money     `json:"after_balance"`
 	Status                      string    `json:"status"`
 }
 
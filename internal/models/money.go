@@ -0,0 +1,357 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Money is an amount in paise. All wallet balances, transaction amounts and
+// commissions are carried as Money so that no arithmetic ever goes through
+// binary floating point. It is read and written as a NUMERIC(20, 2) rupee
+// value in the database and as a rupee number in JSON.
+type Money int64
+
+const (
+	Paisa Money = 1
+	Rupee Money = 100
+)
+
+// Rupees builds a Money from a whole number of rupees.
+func Rupees(r int64) Money {
+	return Money(r) * Rupee
+}
+
+// ParseMoney parses a rupee amount such as "1250", "1250.5" or "-3.25".
+// More than two decimal places is an error rather than being rounded.
+func ParseMoney(in string) (Money, error) {
+	s := strings.TrimSpace(in)
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount %q", in)
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", in)
+	}
+	if len(frac) > 2 {
+		if strings.TrimRight(frac[2:], "0") != "" {
+			return 0, fmt.Errorf("amount %q has more than two decimal places", in)
+		}
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	rupees, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", in)
+	}
+	paise, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || paise < 0 {
+		return 0, fmt.Errorf("invalid amount %q", in)
+	}
+	if rupees > (math.MaxInt64-paise)/100 {
+		return 0, fmt.Errorf("amount %q is out of range", in)
+	}
+
+	m := Money(rupees*100 + paise)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// String formats the amount in rupees with exactly two decimal places.
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// Float64 returns the amount in rupees. It is only meant for display and
+// validation bounds, never for further arithmetic.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// Abs returns the absolute value of m.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Percent returns pct percent of m, rounded half away from zero to the
+// nearest paisa. It is used for charges and commission totals.
+func (m Money) Percent(pct Rate) Money {
+	return Money(mulDivRound(int64(m), int64(pct), 100*rateScale))
+}
+
+// Share returns the fraction f of m (where 1 is the whole amount), rounded
+// down to the paisa. Rounding down guarantees that the parts of a split
+// never add up to more than the amount being split.
+func (m Money) Share(f Rate) Money {
+	return Money(mulDivFloor(int64(m), int64(f), rateScale))
+}
+
+// Prorate returns the part of m that part is of whole, rounded half away
+// from zero to the paisa. It is used to scale a transaction's postings to a
+// partial refund. A zero whole has no parts, so the result is zero.
+func (m Money) Prorate(part, whole Money) Money {
+	if whole == 0 {
+		return 0
+	}
+	return Money(mulDivRound(int64(m), int64(part), int64(whole)))
+}
+
+// Split distributes m across the given fractional shares. Every share is
+// rounded down to the paisa; when the shares add up to exactly one whole,
+// the final share absorbs the leftover paise so the parts sum to m.
+// Otherwise the unallocated remainder stays with the caller.
+func (m Money) Split(shares ...Rate) []Money {
+	parts := make([]Money, len(shares))
+	if len(shares) == 0 {
+		return parts
+	}
+
+	var (
+		allocated Money
+		total     Rate
+	)
+	for i, f := range shares {
+		parts[i] = m.Share(f)
+		allocated += parts[i]
+		total += f
+	}
+
+	if total == RateOne {
+		parts[len(parts)-1] += m - allocated
+	}
+	return parts
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a quoted decimal string.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return err
+		}
+		s = unquoted
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, ok := new(big.Float).SetString(s)
+		if !ok {
+			return fmt.Errorf("invalid amount %q", s)
+		}
+		s = f.Text('f', 10)
+	}
+
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// SkipUnderlyingTypePlan stops pgx from treating Money as a plain int64.
+func (Money) SkipUnderlyingTypePlan() {}
+
+func (m *Money) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return fmt.Errorf("cannot scan NULL into Money")
+	}
+	v, err := numericToScaled(n, 2)
+	if err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
+
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(m)), Exp: -2, Valid: true}, nil
+}
+
+// Rate is a decimal quantity with four places, used for commission
+// percentages and fractional shares. A value of 1.5 is stored as 15000.
+type Rate int64
+
+const (
+	rateScale = 10000
+
+	// RateOne is the whole when a Rate is used as a fraction.
+	RateOne Rate = rateScale
+)
+
+// ParseRate parses a decimal such as "1.2" or "0.05".
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	f, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	f.Mul(f, big.NewRat(rateScale, 1))
+	if !f.IsInt() {
+		return 0, fmt.Errorf("rate %q has more than four decimal places", s)
+	}
+	if !f.Num().IsInt64() {
+		return 0, fmt.Errorf("rate %q is out of range", s)
+	}
+	return Rate(f.Num().Int64()), nil
+}
+
+func (r Rate) String() string {
+	sign := ""
+	v := int64(r)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	s := fmt.Sprintf("%s%d.%04d", sign, v/rateScale, v%rateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Float64 returns the rate as a float. Only used for validation bounds.
+func (r Rate) Float64() float64 {
+	return float64(r) / rateScale
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	s := string(b)
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return err
+		}
+		s = unquoted
+	}
+	v, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// SkipUnderlyingTypePlan stops pgx from treating Rate as a plain int64.
+func (Rate) SkipUnderlyingTypePlan() {}
+
+func (r *Rate) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return fmt.Errorf("cannot scan NULL into Rate")
+	}
+	v, err := numericToScaled(n, 4)
+	if err != nil {
+		return err
+	}
+	*r = Rate(v)
+	return nil
+}
+
+func (r Rate) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(r)), Exp: -4, Valid: true}, nil
+}
+
+// numericToScaled converts a NUMERIC into an integer count of 10^-places
+// units, refusing values that would lose precision.
+func numericToScaled(n pgtype.Numeric, places int32) (int64, error) {
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return 0, fmt.Errorf("cannot scan non-finite numeric")
+	}
+
+	v := new(big.Int)
+	if n.Int != nil {
+		v.Set(n.Int)
+	}
+
+	shift := n.Exp + places
+	ten := big.NewInt(10)
+	if shift > 0 {
+		v.Mul(v, new(big.Int).Exp(ten, big.NewInt(int64(shift)), nil))
+	} else if shift < 0 {
+		div := new(big.Int).Exp(ten, big.NewInt(int64(-shift)), nil)
+		var rem big.Int
+		v.QuoRem(v, div, &rem)
+		if rem.Sign() != 0 {
+			return 0, fmt.Errorf("numeric has more than %d decimal places", places)
+		}
+	}
+
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("numeric is out of range")
+	}
+	return v.Int64(), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// mulDivRound returns a*b/d rounded half away from zero.
+func mulDivRound(a, b, d int64) int64 {
+	p := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	q, r := new(big.Int).QuoRem(p, big.NewInt(d), new(big.Int))
+	if new(big.Int).Abs(r).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(big.NewInt(d)) >= 0 {
+		if p.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// mulDivFloor returns a*b/d rounded towards negative infinity.
+func mulDivFloor(a, b, d int64) int64 {
+	p := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	return new(big.Int).Div(p, big.NewInt(d)).Int64()
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{"0", 0},
+		{"1250", 125000},
+		{"1250.5", 125050},
+		{"1250.05", 125005},
+		{"1250.500", 125050},
+		{".5", 50},
+		{"5.", 500},
+		{"-3.25", -325},
+		{"+3.25", 325},
+		{"  42.10 ", 4210},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMoneyErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", `invalid amount ""`},
+		{"-", `invalid amount "-"`},
+		{".", `invalid amount "."`},
+		{"12a", `invalid amount "12a"`},
+		{"1.2.3", `invalid amount "1.2.3"`},
+		{"--5", `invalid amount "--5"`},
+		{"-1.005", `amount "-1.005" has more than two decimal places`},
+		{"-92233720368547758.08", `amount "-92233720368547758.08" is out of range`},
+	}
+	for _, tt := range tests {
+		_, err := ParseMoney(tt.in)
+		if err == nil {
+			t.Errorf("ParseMoney(%q) returned no error", tt.in)
+			continue
+		}
+		if err.Error() != tt.wantErr {
+			t.Errorf("ParseMoney(%q) error = %q, want %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{125050, "1250.50"},
+		{-325, "-3.25"},
+		{-5, "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A Money `json:"a"`
+		B Money `json:"b"`
+		C Money `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 10.5, "b": "0.07", "c": 1.5e2}`), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if v.A != 1050 || v.B != 7 || v.C != 15000 {
+		t.Fatalf("Unmarshal = %d, %d, %d; want 1050, 7, 15000", v.A, v.B, v.C)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": 0.001}`), &v); err == nil {
+		t.Fatal("Unmarshal of 0.001 returned no error")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"a":10.50,"b":0.07,"c":150.00}`; string(b) != want {
+		t.Fatalf("Marshal = %s, want %s", b, want)
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		m    Money
+		pct  string
+		want Money
+	}{
+		{Rupees(1000), "1.2", 1200},
+		{Rupees(100), "0.05", 5},
+		// 0.3% of 1.50 is 0.45 paise, rounded down.
+		{150, "0.3", 0},
+		// 0.5% of 1.00 is exactly half a paisa, rounded up.
+		{100, "0.5", 1},
+		// Negative amounts round half away from zero as well.
+		{-100, "0.5", -1},
+		{-150, "0.3", 0},
+		{Rupees(999), "100", Rupees(999)},
+	}
+	for _, tt := range tests {
+		pct, err := ParseRate(tt.pct)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tt.pct, err)
+		}
+		if got := tt.m.Percent(pct); got != tt.want {
+			t.Errorf("Money(%d).Percent(%s) = %d, want %d", tt.m, tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyShare(t *testing.T) {
+	tests := []struct {
+		m    Money
+		f    string
+		want Money
+	}{
+		{Rupees(10), "0.5", 500},
+		// A third of one rupee is 33.33 paise, always rounded down.
+		{100, "0.3333", 33},
+		{101, "0.5", 50},
+		{-101, "0.5", -51},
+		{Rupees(10), "1", Rupees(10)},
+		{Rupees(10), "0", 0},
+	}
+	for _, tt := range tests {
+		f, err := ParseRate(tt.f)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tt.f, err)
+		}
+		if got := tt.m.Share(f); got != tt.want {
+			t.Errorf("Money(%d).Share(%s) = %d, want %d", tt.m, tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestMoneySplit(t *testing.T) {
+	rates := func(ss ...string) []Rate {
+		out := make([]Rate, len(ss))
+		for i, s := range ss {
+			r, err := ParseRate(s)
+			if err != nil {
+				t.Fatalf("ParseRate(%q): %v", s, err)
+			}
+			out[i] = r
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		m      Money
+		shares []Rate
+		want   []Money
+	}{
+		{"whole absorbs leftover", 100, rates("0.3333", "0.3333", "0.3334"), []Money{33, 33, 34}},
+		{"whole with odd paise", 101, rates("0.5", "0.5"), []Money{50, 51}},
+		{"partial keeps remainder", 101, rates("0.25", "0.25"), []Money{25, 25}},
+		{"no shares", 100, nil, []Money{}},
+	}
+	for _, tt := range tests {
+		got := tt.m.Split(tt.shares...)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Split returned %d parts, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		var sum Money
+		for i := range got {
+			sum += got[i]
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: part %d = %d, want %d", tt.name, i, got[i], tt.want[i])
+			}
+		}
+		if sum > tt.m {
+			t.Errorf("%s: parts sum to %d, more than %d", tt.name, sum, tt.m)
+		}
+	}
+}
+
+func TestMoneyProrate(t *testing.T) {
+	tests := []struct {
+		m, part, whole Money
+		want           Money
+	}{
+		{Rupees(10), Rupees(50), Rupees(100), Rupees(5)},
+		// 10.01 * 1/2 = 5.005, rounded half away from zero.
+		{1001, 1, 2, 501},
+		{-1001, 1, 2, -501},
+		// 1.00 * 1/3 = 33.33 paise.
+		{100, 1, 3, 33},
+		// 1.00 * 2/3 = 66.67 paise.
+		{100, 2, 3, 67},
+		{Rupees(10), Rupees(100), Rupees(100), Rupees(10)},
+		{Rupees(10), 0, Rupees(100), 0},
+		{Rupees(10), Rupees(5), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Prorate(tt.part, tt.whole); got != tt.want {
+			t.Errorf("Money(%d).Prorate(%d, %d) = %d, want %d", tt.m, tt.part, tt.whole, got, tt.want)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rate
+	}{
+		{"1.2", 12000},
+		{"0.05", 500},
+		{"100", 1000000},
+		{"-0.0001", -1},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if err != nil {
+			t.Errorf("ParseRate(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRate(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+		if back, err := ParseRate(got.String()); err != nil || back != got {
+			t.Errorf("Rate(%d) does not round-trip through %q", got, got.String())
+		}
+	}
+
+	if _, err := ParseRate("0.00001"); err == nil {
+		t.Error(`ParseRate("0.00001") returned no error`)
+	}
+}
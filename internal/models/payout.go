@@ -3,26 +3,18 @@ package models
 import "time"
 
 type CreatePayoutRequestModel struct {
-	RetailerId            string  `json:"retailer_id" validate:"required"`
-	MobileNumber          string  `json:"mobile_number" validate:"required"`
-	IFSCCode              string  `json:"ifsc_code" validate:"required"`
-	BankName              string  `json:"bank_name" validate:"required"`
-	AccountNumber         string  `json:"account_number" validate:"required"`
-	BeneficiaryName       string  `json:"beneficiary_name" validate:"required"`
-	Amount                float64 `json:"amount" validate:"required"`
-	TransferType          int     `json:"transfer_type" validate:"required"`
-	PartnerRequestId      string  `json:"partner_request_id"`
-	OrderId               string  `json:"order_id"`
-	OperatorTransactionId string  `json:"operator_transaction_id"`
-	TransactionStatus     string  `json:"transaction_status"`
-}
-
-type GetPayoutCommisionModel struct {
-	TotalCommision             float64
-	AdminCommision             float64
-	MasterDistributorCommision float64
-	DistributorCommision       float64
-	RetailerCommision          float64
+	RetailerId            string `json:"retailer_id" validate:"required"`
+	MobileNumber          string `json:"mobile_number" validate:"required"`
+	IFSCCode              string `json:"ifsc_code" validate:"required"`
+	BankName              string `json:"bank_name" validate:"required"`
+	AccountNumber         string `json:"account_number" validate:"required"`
+	BeneficiaryName       string `json:"beneficiary_name" validate:"required"`
+	Amount                Money  `json:"amount" validate:"required"`
+	TransferType          int    `json:"transfer_type" validate:"required"`
+	PartnerRequestId      string `json:"partner_request_id"`
+	OrderId               string `json:"order_id"`
+	OperatorTransactionId string `json:"operator_transaction_id"`
+	TransactionStatus     string `json:"transaction_status"`
 }
 
 type GetAllPayoutTransactionsResponseModel struct {
@@ -38,15 +30,15 @@ type GetAllPayoutTransactionsResponseModel struct {
 	BeneficiaryName            string    `json:"beneficiary_name"`
 	AccountNumber              string    `json:"account_number"`
 	IFSCCode                   string    `json:"ifsc_code"`
-	Amount                     float64   `json:"amount"`
+	Amount                     Money     `json:"amount"`
 	TransferType               string    `json:"transfer_type"`
 	TransactionStatus          string    `json:"transaction_status"`
-	AdminCommision             float64   `json:"admin_commision"`
-	MasterDistributorCommision float64   `json:"master_distributor_commision"`
-	DistributorCommision       float64   `json:"distributor_commision"`
-	RetailerCommision          float64   `json:"retailer_commision"`
-	BeforeBalance              *float64   `json:"before_balance"`
-	AfterBalance               *float64   `json:"after_balance"`
+	AdminCommision             Money     `json:"admin_commision"`
+	MasterDistributorCommision Money     `json:"master_distributor_commision"`
+	DistributorCommision       Money     `json:"distributor_commision"`
+	RetailerCommision          Money     `json:"retailer_commision"`
+	BeforeBalance              *Money    `json:"before_balance"`
+	AfterBalance               *Money    `json:"after_balance"`
 	CreatedAt                  time.Time `json:"created_at"`
 	UpdatedAt                  time.Time `json:"updated_at"`
 }
@@ -64,12 +56,12 @@ type GetRetailerPayoutTransactionsResponseModel struct {
 	BeneficiaryName       string    `json:"beneficiary_name"`
 	AccountNumber         string    `json:"account_number"`
 	IFSCCode              string    `json:"ifsc_code"`
-	Amount                float64   `json:"amount"`
+	Amount                Money     `json:"amount"`
 	TransferType          string    `json:"transfer_type"`
 	TransactionStatus     string    `json:"transaction_status"`
-	RetailerCommision     float64   `json:"retailer_commision"`
-	BeforeBalance         float64   `json:"before_balance"`
-	AfterBalance          float64   `json:"after_balance"`
+	RetailerCommision     Money     `json:"retailer_commision"`
+	BeforeBalance         Money     `json:"before_balance"`
+	AfterBalance          Money     `json:"after_balance"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ProviderRouteModel is a rule sending a service's transactions to a
+// provider. OperatorCode nil matches every operator and a MaxAmount of 0
+// means no upper bound. Among matching rules the lowest Priority is tried
+// first.
+type ProviderRouteModel struct {
+	RouteID      int       `json:"route_id"`
+	Service      string    `json:"service"`
+	Provider     string    `json:"provider"`
+	OperatorCode *int      `json:"operator_code"`
+	MinAmount    Money     `json:"min_amount"`
+	MaxAmount    Money     `json:"max_amount"`
+	MinBalance   Money     `json:"min_balance"`
+	Priority     int       `json:"priority"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateProviderRouteRequestModel struct {
+	Service      string `json:"service" validate:"required,oneof=PAYOUT MOBILE_RECHARGE DTH_RECHARGE BBPS DMT AEPS"`
+	Provider     string `json:"provider" validate:"required"`
+	OperatorCode *int   `json:"operator_code"`
+	MinAmount    Money  `json:"min_amount" validate:"gte=0"`
+	MaxAmount    Money  `json:"max_amount" validate:"gte=0"`
+	MinBalance   Money  `json:"min_balance" validate:"gte=0"`
+	Priority     int    `json:"priority"`
+}
+
+type UpdateProviderRouteRequestModel struct {
+	RouteID      int    `json:"route_id" validate:"required"`
+	Provider     string `json:"provider" validate:"required"`
+	OperatorCode *int   `json:"operator_code"`
+	MinAmount    Money  `json:"min_amount" validate:"gte=0"`
+	MaxAmount    Money  `json:"max_amount" validate:"gte=0"`
+	MinBalance   Money  `json:"min_balance" validate:"gte=0"`
+	Priority     int    `json:"priority"`
+	IsActive     bool   `json:"is_active"`
+}
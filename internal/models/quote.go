@@ -0,0 +1,30 @@
+package models
+
+// QuoteMemberModel is the commission one member of the retailer's
+// hierarchy gets on a transaction and the TDS deducted from it.
+type QuoteMemberModel struct {
+	UserID       string `json:"user_id"`
+	Role         string `json:"role"`
+	Commision    Money  `json:"commision"`
+	TDS          Money  `json:"tds"`
+	NetCommision Money  `json:"net_commision"`
+}
+
+// TransactionQuoteModel is what a transaction would cost a retailer at the
+// current commission and TDS settings. Debit is what is taken from the
+// wallet: the amount, plus Charges, less the retailer's own commission,
+// plus the TDS on it; it is negative when the transaction credits the
+// wallet. BalanceAfter is the available balance after the debit and is
+// negative when the wallet cannot cover it.
+type TransactionQuoteModel struct {
+	Service       string              `json:"service"`
+	OperatorCode  int                 `json:"operator_code,omitempty"`
+	Amount        Money               `json:"amount"`
+	Charges       Money               `json:"charges"`
+	Commision     CommisionSplitModel `json:"commision"`
+	Members       []QuoteMemberModel  `json:"members"`
+	RetailerTDS   Money               `json:"retailer_tds"`
+	Debit         Money               `json:"debit"`
+	WalletBalance Money               `json:"wallet_balance"`
+	BalanceAfter  Money               `json:"balance_after"`
+}
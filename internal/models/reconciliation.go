@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type ReconciliationRunModel struct {
+	RunID               int64      `json:"run_id"`
+	TriggeredBy         string     `json:"triggered_by"`
+	RunStatus           string     `json:"run_status"`
+	WalletsChecked      int        `json:"wallets_checked"`
+	TransactionsChecked int64      `json:"transactions_checked"`
+	BreaksFound         int        `json:"breaks_found"`
+	ErrorMessage        *string    `json:"error_message,omitempty"`
+	StartedAt           time.Time  `json:"started_at"`
+	FinishedAt          *time.Time `json:"finished_at,omitempty"`
+}
+
+type ReconciliationBreakModel struct {
+	BreakID             int64     `json:"break_id"`
+	RunID               int64     `json:"run_id"`
+	UserID              string    `json:"user_id"`
+	WalletType          string    `json:"wallet_type"`
+	BreakType           string    `json:"break_type"`
+	ReferenceID         *string   `json:"reference_id"`
+	WalletTransactionID *int64    `json:"wallet_transaction_id"`
+	ExpectedAmount      Money     `json:"expected_amount"`
+	ActualAmount        Money     `json:"actual_amount"`
+	CreatedAt           time.Time `json:"created_at"`
+}
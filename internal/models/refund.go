@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TransactionRefundModel is a full or partial refund of a payout, recharge
+// or bill payment. JournalID is the ledger journal that moved the money.
+type TransactionRefundModel struct {
+	RefundID      int64     `json:"refund_id"`
+	Service       string    `json:"service"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        Money     `json:"amount"`
+	JournalID     int64     `json:"journal_id"`
+	ActorType     string    `json:"actor_type"`
+	ActorID       *string   `json:"actor_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -1,7 +1,45 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+)
+
 type ResponseModel struct {
 	Message string `json:"message"`
 	Status  string `json:"status"`
 	Data    any    `json:"data,omitempty"`
 }
+
+// Rejection is an error that refuses a request for good: sent again as it
+// is, the request would be refused again. Any other error may be transient.
+type Rejection struct {
+	err error
+}
+
+// Reject marks err as a rejection.
+func Reject(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Rejection{err}
+}
+
+// Rejectf returns a rejection with a formatted message.
+func Rejectf(format string, args ...any) error {
+	return &Rejection{fmt.Errorf(format, args...)}
+}
+
+func (r *Rejection) Error() string {
+	return r.err.Error()
+}
+
+func (r *Rejection) Unwrap() error {
+	return r.err
+}
+
+// IsRejection reports whether err is, or wraps, a rejection.
+func IsRejection(err error) bool {
+	var r *Rejection
+	return errors.As(err, &r)
+}
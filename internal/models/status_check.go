@@ -0,0 +1,21 @@
+package models
+
+// PendingTransactionModel is a provider transaction still waiting for its
+// final status, as picked up by the status check worker.
+type PendingTransactionModel struct {
+	Service          string
+	TransactionID    string
+	PartnerRequestID string
+	Provider         string
+	Attempts         int
+}
+
+// ProviderTransactionModel identifies a payout, recharge or bill payment by
+// the partner_request_id it was sent to the provider with.
+type ProviderTransactionModel struct {
+	Service          string
+	TransactionID    string
+	PartnerRequestID string
+	Status           string
+	Provider         string
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TransactionStatusHistoryModel is one status change of a payout, recharge
+// or bill payment. FromStatus is nil for the status it was created with.
+type TransactionStatusHistoryModel struct {
+	HistoryID     int64     `json:"history_id"`
+	Service       string    `json:"service"`
+	TransactionID string    `json:"transaction_id"`
+	FromStatus    *string   `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	ActorType     string    `json:"actor_type"`
+	ActorID       *string   `json:"actor_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
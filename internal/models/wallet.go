@@ -7,10 +7,10 @@ type WalletTransactionModel struct {
 	UserID              string
 	ReferenceID         string
 
-	CreditAmount  *float64
-	DebitAmount   *float64
-	BeforeBalance float64
-	AfterBalance  float64
+	CreditAmount  *Money
+	DebitAmount   *Money
+	BeforeBalance Money
+	AfterBalance  Money
 
 	TransactionReason string
 	Remarks           string
@@ -18,31 +18,46 @@ type WalletTransactionModel struct {
 	CreatedAt time.Time
 }
 
-type CreateWalletTransactionRequestModel struct {
-	UserID      string `json:"user_id" validate:"required"`
-	ReferenceID string `json:"reference_id" validate:"required"`
-
-	CreditAmount *float64 `json:"credit_amount"`
-	DebitAmount  *float64 `json:"debit_amount"`
-
-	BeforeBalance float64 `json:"before_balance" validate:"required"`
-	AfterBalance  float64 `json:"after_balance" validate:"required"`
-
-	TransactionReason string `json:"transaction_reason" validate:"required"`
-	Remarks           string `json:"remarks" validate:"required"`
-}
-
 type GetWalletTransactionResponseModel struct {
 	WalletTransactionID string `json:"wallet_transaction_id"`
 	UserID              string `json:"user_id"`
 	ReferenceID         string `json:"reference_id"`
 
-	CreditAmount  *float64 `json:"credit_amount,omitempty"`
-	DebitAmount   *float64 `json:"debit_amount,omitempty"`
-	BeforeBalance float64  `json:"before_balance"`
-	AfterBalance  float64  `json:"after_balance"`
+	CreditAmount  *Money `json:"credit_amount,omitempty"`
+	DebitAmount   *Money `json:"debit_amount,omitempty"`
+	BeforeBalance Money  `json:"before_balance"`
+	AfterBalance  Money  `json:"after_balance"`
 
 	TransactionReason string    `json:"transaction_reason"`
 	Remarks           string    `json:"remarks"`
 	CreatedAt         time.Time `json:"created_at"`
 }
+
+// GetWalletBalanceResponseModel splits a wallet into its ledger balance and
+// the part of it that can be spent. Active holds stay in the ledger balance
+// but are not available.
+type GetWalletBalanceResponseModel struct {
+	LedgerBalance    Money `json:"ledger_balance"`
+	HeldAmount       Money `json:"held_amount"`
+	AvailableBalance Money `json:"available_balance"`
+}
+
+type CreateWalletHoldRequestModel struct {
+	UserID   string `json:"user_id" validate:"required"`
+	Amount   Money  `json:"amount" validate:"required,gt=0"`
+	HoldType string `json:"hold_type" validate:"required,oneof=DISPUTE COMPLIANCE OTHER"`
+	Remarks  string `json:"remarks" validate:"required"`
+}
+
+type WalletHoldModel struct {
+	HoldID     int64      `json:"hold_id"`
+	UserID     string     `json:"user_id"`
+	Amount     Money      `json:"amount"`
+	HoldType   string     `json:"hold_type"`
+	Remarks    string     `json:"remarks"`
+	HoldStatus string     `json:"hold_status"`
+	PlacedBy   string     `json:"placed_by"`
+	ReleasedBy *string    `json:"released_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
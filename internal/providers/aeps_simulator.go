@@ -0,0 +1,159 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// AEPSSimulatorName is the provider name of the AEPS simulator.
+const AEPSSimulatorName = "AEPS_SIMULATOR"
+
+// aepsSimulatorOpeningBalance is the bank balance every simulated customer
+// starts with.
+var aepsSimulatorOpeningBalance = models.Rupees(50000)
+
+// AEPSSimulator is an in-process AEPS provider for running the AEPS flows
+// offline. It keeps a bank account per Aadhaar number in memory: cash
+// withdrawals and Aadhaar Pay are declined without biometric data or when
+// they exceed the account balance, and succeed otherwise. Transactions are
+// remembered for status checks until the server stops. AEPS is routed to
+// it with a provider route naming AEPS_SIMULATOR.
+type AEPSSimulator struct {
+	mu           sync.Mutex
+	accounts     map[string]*aepsSimulatedAccount
+	transactions map[string]string
+	seq          int
+}
+
+type aepsSimulatedAccount struct {
+	balance   models.Money
+	statement []models.AEPSMiniStatementEntryModel
+}
+
+func NewAEPSSimulator() *AEPSSimulator {
+	return &AEPSSimulator{
+		accounts:     make(map[string]*aepsSimulatedAccount),
+		transactions: make(map[string]string),
+	}
+}
+
+func (s *AEPSSimulator) Name() string {
+	return AEPSSimulatorName
+}
+
+// Balance reports no float: AEPS brings money in rather than spending it.
+func (s *AEPSSimulator) Balance(ctx context.Context, category Category) (models.Money, error) {
+	if category != CategoryAEPS {
+		return 0, fmt.Errorf("%s does not serve %s", AEPSSimulatorName, category)
+	}
+	return 0, nil
+}
+
+func (s *AEPSSimulator) StatusCheck(ctx context.Context, partnerRequestID string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.transactions[partnerRequestID]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", partnerRequestID)
+	}
+	return &Result{
+		Provider: AEPSSimulatorName,
+		Status:   status,
+		Message:  "Transaction " + status,
+	}, nil
+}
+
+func (s *AEPSSimulator) AEPSCashWithdrawal(ctx context.Context, req models.AEPSTransactionRequestModel) (*Result, error) {
+	return s.debit(req, "Cash withdrawal")
+}
+
+func (s *AEPSSimulator) AEPSAadhaarPay(ctx context.Context, req models.AEPSTransactionRequestModel) (*Result, error) {
+	return s.debit(req, "Aadhaar Pay")
+}
+
+func (s *AEPSSimulator) AEPSBalanceEnquiry(ctx context.Context, req models.AEPSTransactionRequestModel) (*models.AEPSEnquiryResponseModel, error) {
+	return s.enquire(req, false)
+}
+
+func (s *AEPSSimulator) AEPSMiniStatement(ctx context.Context, req models.AEPSTransactionRequestModel) (*models.AEPSEnquiryResponseModel, error) {
+	return s.enquire(req, true)
+}
+
+// debit takes a withdrawal or payment from the customer's account.
+func (s *AEPSSimulator) debit(req models.AEPSTransactionRequestModel, narration string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID, rrn := s.next()
+	res := &Result{
+		Provider: AEPSSimulatorName,
+		Status:   "SUCCESS",
+		Message:  "Transaction successful",
+		OrderID:  orderID,
+	}
+	account := s.account(req.AadhaarNumber)
+	switch {
+	case req.PidData == "":
+		res.Status, res.Message = "FAILED", "Biometric data missing"
+	case req.Amount > account.balance:
+		res.Status, res.Message = "FAILED", "Insufficient funds in customer account"
+	default:
+		account.balance -= req.Amount
+		account.statement = append(account.statement, models.AEPSMiniStatementEntryModel{
+			Date:      time.Now().Format("02/01/2006"),
+			Narration: narration,
+			TxnType:   "DR",
+			Amount:    req.Amount,
+		})
+		res.OperatorTransactionID = rrn
+	}
+	s.transactions[req.PartnerRequestID] = res.Status
+	return res, nil
+}
+
+func (s *AEPSSimulator) enquire(req models.AEPSTransactionRequestModel, statement bool) (*models.AEPSEnquiryResponseModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID, rrn := s.next()
+	if req.PidData == "" {
+		return &models.AEPSEnquiryResponseModel{
+			Status:  "FAILED",
+			Message: "Biometric data missing",
+			OrderID: orderID,
+		}, nil
+	}
+	account := s.account(req.AadhaarNumber)
+	res := &models.AEPSEnquiryResponseModel{
+		Status:                "SUCCESS",
+		Message:               "Enquiry successful",
+		OrderID:               orderID,
+		OperatorTransactionID: rrn,
+		AccountBalance:        account.balance,
+	}
+	if statement {
+		// A mini statement shows the last ten transactions.
+		entries := account.statement[max(len(account.statement)-10, 0):]
+		res.MiniStatement = append([]models.AEPSMiniStatementEntryModel{}, entries...)
+	}
+	return res, nil
+}
+
+func (s *AEPSSimulator) account(aadhaarNumber string) *aepsSimulatedAccount {
+	a, ok := s.accounts[aadhaarNumber]
+	if !ok {
+		a = &aepsSimulatedAccount{balance: aepsSimulatorOpeningBalance}
+		s.accounts[aadhaarNumber] = a
+	}
+	return a
+}
+
+// next returns a new order id and bank reference number.
+func (s *AEPSSimulator) next() (string, string) {
+	s.seq++
+	return fmt.Sprintf("AEPSSIM%08d", s.seq), fmt.Sprintf("%012d", s.seq)
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestAEPSSimulatorWithdrawals(t *testing.T) {
+	ctx := context.Background()
+	s := NewAEPSSimulator()
+	req := models.AEPSTransactionRequestModel{
+		TransactionType:  "CASH_WITHDRAWAL",
+		AadhaarNumber:    "123456789012",
+		BankIIN:          "607094",
+		Amount:           models.Rupees(1000),
+		PidData:          "<PidData/>",
+		PartnerRequestID: "P1",
+	}
+
+	res, err := s.AEPSCashWithdrawal(ctx, req)
+	if err != nil {
+		t.Fatalf("AEPSCashWithdrawal: %v", err)
+	}
+	if res.Status != "SUCCESS" || res.OperatorTransactionID == "" {
+		t.Errorf("withdrawal = %+v, want SUCCESS with a bank reference", res)
+	}
+
+	noBiometrics := req
+	noBiometrics.PidData = ""
+	noBiometrics.PartnerRequestID = "P2"
+	if res, _ := s.AEPSCashWithdrawal(ctx, noBiometrics); res.Status != "FAILED" {
+		t.Errorf("withdrawal without biometrics = %s, want FAILED", res.Status)
+	}
+
+	tooMuch := req
+	tooMuch.Amount = models.Rupees(49001)
+	tooMuch.PartnerRequestID = "P3"
+	if res, _ := s.AEPSAadhaarPay(ctx, tooMuch); res.Status != "FAILED" {
+		t.Errorf("payment above the balance = %s, want FAILED", res.Status)
+	}
+
+	statement, err := s.AEPSMiniStatement(ctx, req)
+	if err != nil {
+		t.Fatalf("AEPSMiniStatement: %v", err)
+	}
+	if statement.AccountBalance != models.Rupees(49000) {
+		t.Errorf("balance = %s, want 49000.00", statement.AccountBalance)
+	}
+	if len(statement.MiniStatement) != 1 || statement.MiniStatement[0].Amount != models.Rupees(1000) {
+		t.Errorf("mini statement = %+v, want the one withdrawal", statement.MiniStatement)
+	}
+
+	for id, want := range map[string]string{"P1": "SUCCESS", "P3": "FAILED"} {
+		res, err := s.StatusCheck(ctx, id)
+		if err != nil {
+			t.Fatalf("StatusCheck(%s): %v", id, err)
+		}
+		if res.Status != want {
+			t.Errorf("StatusCheck(%s) = %s, want %s", id, res.Status, want)
+		}
+	}
+	if _, err := s.StatusCheck(ctx, "P9"); err == nil {
+		t.Error("StatusCheck of an unknown transaction succeeded")
+	}
+}
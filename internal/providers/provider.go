@@ -0,0 +1,104 @@
+// Package providers decouples the services from the aggregators that carry
+// them out. Each service category has its own provider interface, providers
+// are registered by name in a Registry, and a Router picks the providers for
+// a transaction from the routing rules stored in the database, failing over
+// to the next one when a provider declines, errors or runs low on float.
+package providers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// Category is a group of services that a provider offers together.
+type Category string
+
+const (
+	CategoryPrepaid Category = "PREPAID"
+	CategoryDTH     Category = "DTH"
+	CategoryBBPS    Category = "BBPS"
+	CategoryPayout  Category = "PAYOUT"
+	CategoryDMT     Category = "DMT"
+	CategoryAEPS    Category = "AEPS"
+)
+
+// ErrUnknownOutcome is wrapped by errors from money-moving requests that the
+// provider may have carried out anyway, such as a timeout after the request
+// was sent. The transaction must stay pending, with its funds reserved,
+// until a status check settles it.
+var ErrUnknownOutcome = errors.New("provider outcome unknown")
+
+// Result is a provider's answer to a money-moving request. Status is
+// SUCCESS, PENDING or FAILED, and Provider names the provider that gave it.
+type Result struct {
+	Provider              string
+	Status                string
+	Message               string
+	OrderID               string
+	OperatorTransactionID string
+}
+
+// Provider is what every aggregator implements, whatever categories it
+// serves. Operator codes passed to a provider are the ones stored in our
+// operator tables; a provider using other codes maps them itself.
+type Provider interface {
+	// Name identifies the provider in routing rules and on transactions.
+	Name() string
+	// Balance returns the float the provider holds for the category.
+	Balance(ctx context.Context, category Category) (models.Money, error)
+	// StatusCheck returns the current status of a transaction sent with
+	// the given partner request id.
+	StatusCheck(ctx context.Context, partnerRequestID string) (*Result, error)
+}
+
+type PrepaidProvider interface {
+	Provider
+	PrepaidRecharge(context.Context, models.CreateMobileRechargeRequestModel) (*Result, error)
+	PrepaidPlans(context.Context, models.GetMobileRechargePlansRequestModel) (*models.GetMobileRechargePlansResponseModel, error)
+}
+
+type DTHProvider interface {
+	Provider
+	DTHRecharge(context.Context, models.CreateDTHRechargeRequestModel) (*Result, error)
+}
+
+// BBPSProvider fetches and pays the bills of any BBPS biller, whatever its
+// category. Billers are told apart by their operator code.
+type BBPSProvider interface {
+	Provider
+	BillFetch(context.Context, models.BBPSProviderRequestModel) (*models.BBPSBillFetchResponseModel, error)
+	BillPayment(context.Context, models.BBPSProviderRequestModel) (*Result, error)
+}
+
+type PayoutProvider interface {
+	Provider
+	Payout(context.Context, models.CreatePayoutRequestModel) (*Result, error)
+}
+
+// DMTProvider keeps remitters and their beneficiaries on the provider's
+// side, so a remitter has to stay with the provider that registered them.
+type DMTProvider interface {
+	Provider
+	DMTWalletExists(context.Context, models.DMTWalletCheckRequestModel) (*models.DMTWalletCheckResponseModel, error)
+	CreateDMTWallet(context.Context, models.DMTCreateWalletRequestModel) (*models.DMTCreateWalletResponseModel, error)
+	VerifyDMTWallet(context.Context, models.DMTWalletVerificationRequestModel) (*models.DMTWalletVerificationResponseModel, error)
+	AddDMTBeneficiary(context.Context, models.DMTAddBeneficiaryRequestModel) (*models.DMTAddBeneficiaryResponseModel, error)
+	DMTBeneficiaries(context.Context, models.DMTGetBeneficiaryRequestModel) (*models.DMTGetBeneficiaryResponseModel, error)
+	VerifyDMTBeneficiary(context.Context, models.DMTVerifyBeneficiaryRequestModel) (*models.DMTVerifyBeneficiaryResponseModel, error)
+	DMTBankList(context.Context) (*models.DMTBankListResponseModel, error)
+	DMTTransferOTP(context.Context, models.DMTTransferOTPRequestModel) (*models.DMTTransferOTPResponseModel, error)
+	DMTTransfer(context.Context, models.DMTTransactionRequestModel) (*Result, error)
+}
+
+// AEPSProvider carries out Aadhaar-authenticated banking at the retailer's
+// counter. Cash withdrawals and Aadhaar Pay move the customer's money to
+// the platform; enquiries move nothing.
+type AEPSProvider interface {
+	Provider
+	AEPSCashWithdrawal(context.Context, models.AEPSTransactionRequestModel) (*Result, error)
+	AEPSAadhaarPay(context.Context, models.AEPSTransactionRequestModel) (*Result, error)
+	AEPSBalanceEnquiry(context.Context, models.AEPSTransactionRequestModel) (*models.AEPSEnquiryResponseModel, error)
+	AEPSMiniStatement(context.Context, models.AEPSTransactionRequestModel) (*models.AEPSEnquiryResponseModel, error)
+}
@@ -0,0 +1,305 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
+)
+
+// RechargeKitName is the provider name of RechargeKit.
+const RechargeKitName = "RECHARGEKIT"
+
+// RechargeKit serves every category through the RechargeKit client.
+type RechargeKit struct {
+	client *rechargekit.Client
+}
+
+func NewRechargeKit(client *rechargekit.Client) *RechargeKit {
+	return &RechargeKit{
+		client,
+	}
+}
+
+func (rk *RechargeKit) Name() string {
+	return RechargeKitName
+}
+
+// Balance reads the recharge wallet for recharges and bill payments, and the
+// primary wallets for payouts and DMT.
+func (rk *RechargeKit) Balance(ctx context.Context, category Category) (models.Money, error) {
+	switch category {
+	case CategoryPrepaid, CategoryDTH, CategoryBBPS:
+		res, err := rk.client.RechargeWalletBalance(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return res.WalletAmount, nil
+	case CategoryPayout:
+		res, err := rk.client.PrimaryWalletBalance(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return res.WalletAmount, nil
+	case CategoryDMT:
+		res, err := rk.client.PrimaryWalletBalance(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return res.DMRWalletAmount, nil
+	default:
+		return 0, fmt.Errorf("unknown category %s", category)
+	}
+}
+
+func (rk *RechargeKit) StatusCheck(ctx context.Context, partnerRequestID string) (*Result, error) {
+	res, err := rk.client.StatusCheck(ctx, partnerRequestID)
+	if err != nil {
+		return nil, err
+	}
+	status := res.Status.String()
+	if status == "" {
+		return nil, fmt.Errorf("invalid status code in response: %s", res.Message)
+	}
+	return &Result{
+		Status:  status,
+		Message: res.Message,
+	}, nil
+}
+
+func (rk *RechargeKit) PrepaidRecharge(ctx context.Context, req models.CreateMobileRechargeRequestModel) (*Result, error) {
+	return transactionResult(rk.client.PrepaidRecharge(ctx, rechargekit.PrepaidRechargeRequest{
+		MobileNumber:     req.MobileNumber,
+		OperatorCode:     req.OperatorCode,
+		Amount:           req.Amount,
+		PartnerRequestID: req.PartnerRequestID,
+		Circle:           req.CircleCode,
+		RechargeType:     1,
+	}))
+}
+
+func (rk *RechargeKit) PrepaidPlans(
+	ctx context.Context,
+	req models.GetMobileRechargePlansRequestModel,
+) (*models.GetMobileRechargePlansResponseModel, error) {
+	res, err := rk.client.PrepaidPlans(ctx, rechargekit.PrepaidPlansRequest{
+		OperatorCode: req.OperatorCode,
+		Circle:       req.Circle,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error == 1 {
+		return nil, fmt.Errorf("failed to fetch plan: %s", res.Message)
+	}
+	return res, nil
+}
+
+func (rk *RechargeKit) DTHRecharge(ctx context.Context, req models.CreateDTHRechargeRequestModel) (*Result, error) {
+	return transactionResult(rk.client.DTHRecharge(ctx, rechargekit.DTHRechargeRequest{
+		CustomerID:       req.CustomerID,
+		OperatorCode:     req.OperatorCode,
+		Amount:           req.Amount,
+		PartnerRequestID: req.PartnerRequestID,
+	}))
+}
+
+// BillFetch fetches postpaid mobile bills through RechargeKit's postpaid
+// API and the bills of every other category through its electricity bill
+// fetch, which serves all BBPS billers.
+func (rk *RechargeKit) BillFetch(
+	ctx context.Context,
+	req models.BBPSProviderRequestModel,
+) (*models.BBPSBillFetchResponseModel, error) {
+	if len(req.CustomerParams) == 0 {
+		return nil, fmt.Errorf("missing customer parameters")
+	}
+	if req.CategoryCode == "MOBILE_POSTPAID" {
+		return rk.client.PostpaidBillFetch(ctx, req.CustomerParams[0], req.OperatorCode)
+	}
+	return rk.client.ElectricityBillFetch(ctx, req.CustomerParams[0], req.OperatorCode)
+}
+
+// BillPayment pays postpaid mobile bills as postpaid recharges, taking the
+// mobile number and an optional circle code, and the bills of every other
+// category through RechargeKit's bill payment, which takes the customer
+// parameters as p1 to p3.
+func (rk *RechargeKit) BillPayment(ctx context.Context, req models.BBPSProviderRequestModel) (*Result, error) {
+	if len(req.CustomerParams) == 0 {
+		return nil, fmt.Errorf("missing customer parameters")
+	}
+	if req.CategoryCode == "MOBILE_POSTPAID" {
+		var circle int
+		if len(req.CustomerParams) > 1 && req.CustomerParams[1] != "" {
+			var err error
+			if circle, err = strconv.Atoi(req.CustomerParams[1]); err != nil {
+				return nil, fmt.Errorf("invalid circle code %s", req.CustomerParams[1])
+			}
+		}
+		return transactionResult(rk.client.PostpaidRecharge(ctx, rechargekit.PostpaidRechargeRequest{
+			MobileNumber:     req.CustomerParams[0],
+			PartnerRequestID: req.PartnerRequestID,
+			OperatorCode:     req.OperatorCode,
+			Circle:           circle,
+			Amount:           req.Amount,
+			RechargeType:     1,
+		}))
+	}
+	if len(req.CustomerParams) > 3 {
+		return nil, fmt.Errorf("%s takes at most 3 customer parameters", RechargeKitName)
+	}
+	params := make([]string, 3)
+	copy(params, req.CustomerParams)
+	return transactionResult(rk.client.BillPayment(ctx, rechargekit.BillPaymentRequest{
+		ConsumerID:       params[0],
+		Param2:           params[1],
+		Param3:           params[2],
+		PartnerRequestID: req.PartnerRequestID,
+		OperatorCode:     req.OperatorCode,
+		CustomerEmail:    req.CustomerEmail,
+		Amount:           req.Amount,
+	}))
+}
+
+func (rk *RechargeKit) Payout(ctx context.Context, req models.CreatePayoutRequestModel) (*Result, error) {
+	return transactionResult(rk.client.Payout(ctx, rechargekit.PayoutRequest{
+		MobileNumber:     req.MobileNumber,
+		AccountNumber:    req.AccountNumber,
+		IFSCCode:         req.IFSCCode,
+		BankName:         req.BankName,
+		BeneficiaryName:  req.BeneficiaryName,
+		Amount:           req.Amount,
+		TransferType:     req.TransferType,
+		PartnerRequestID: req.PartnerRequestId,
+	}))
+}
+
+func (rk *RechargeKit) DMTWalletExists(ctx context.Context, req models.DMTWalletCheckRequestModel) (*models.DMTWalletCheckResponseModel, error) {
+	return rk.client.DMTWalletExists(ctx, req.MobileNumber)
+}
+
+func (rk *RechargeKit) CreateDMTWallet(ctx context.Context, req models.DMTCreateWalletRequestModel) (*models.DMTCreateWalletResponseModel, error) {
+	return rk.client.CreateDMTWallet(ctx, rechargekit.CreateDMTWalletRequest{
+		MobileNumber:  req.MobileNumber,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		AadhaarNumber: req.AadharNumber,
+		PidData:       req.PidData,
+		IsIris:        req.IsIris,
+	})
+}
+
+func (rk *RechargeKit) VerifyDMTWallet(
+	ctx context.Context,
+	req models.DMTWalletVerificationRequestModel,
+) (*models.DMTWalletVerificationResponseModel, error) {
+	return rk.client.VerifyDMTWallet(ctx, rechargekit.VerifyDMTWalletRequest{
+		MobileNumber:     req.MobileNumber,
+		OTP:              req.OTP,
+		EKycID:           req.EKycID,
+		StateResp:        req.StateResp,
+		PartnerRequestID: req.PartnerRequestID,
+	})
+}
+
+func (rk *RechargeKit) AddDMTBeneficiary(
+	ctx context.Context,
+	req models.DMTAddBeneficiaryRequestModel,
+) (*models.DMTAddBeneficiaryResponseModel, error) {
+	return rk.client.AddDMTBeneficiary(ctx, rechargekit.AddDMTBeneficiaryRequest{
+		MobileNumber:     req.MobileNumber,
+		BeneficiaryName:  req.BeneficiaryName,
+		AccountNumber:    req.AccountNumber,
+		IFSCCode:         req.IFSCCode,
+		BankID:           req.BankID,
+		PartnerRequestID: req.PartnerRequestID,
+	})
+}
+
+func (rk *RechargeKit) DMTBeneficiaries(
+	ctx context.Context,
+	req models.DMTGetBeneficiaryRequestModel,
+) (*models.DMTGetBeneficiaryResponseModel, error) {
+	return rk.client.DMTBeneficiaries(ctx, req.MobileNumber)
+}
+
+func (rk *RechargeKit) VerifyDMTBeneficiary(
+	ctx context.Context,
+	req models.DMTVerifyBeneficiaryRequestModel,
+) (*models.DMTVerifyBeneficiaryResponseModel, error) {
+	return rk.client.VerifyDMTBeneficiary(ctx, rechargekit.VerifyDMTBeneficiaryRequest{
+		MobileNumber:     req.MobileNumber,
+		BeneficiaryID:    req.BeneficiaryID,
+		PartnerRequestID: req.PartnerRequestID,
+	})
+}
+
+func (rk *RechargeKit) DMTBankList(ctx context.Context) (*models.DMTBankListResponseModel, error) {
+	return rk.client.DMTBankList(ctx)
+}
+
+func (rk *RechargeKit) DMTTransferOTP(
+	ctx context.Context,
+	req models.DMTTransferOTPRequestModel,
+) (*models.DMTTransferOTPResponseModel, error) {
+	res, err := rk.client.DMTTransferOTP(ctx, rechargekit.DMTTransferOTPRequest{
+		MobileNumber:  req.MobileNumber,
+		BeneficiaryID: req.BeneficiaryID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error == 1 {
+		return nil, fmt.Errorf("failed to send transfer otp: %s", res.Message)
+	}
+	return res, nil
+}
+
+// DMTTransfer sends a transfer with RechargeKit's payout transfer type
+// codes: 5 for IMPS and 6 for NEFT.
+func (rk *RechargeKit) DMTTransfer(ctx context.Context, req models.DMTTransactionRequestModel) (*Result, error) {
+	transferType := 5
+	if req.TransferType == "NEFT" {
+		transferType = 6
+	}
+	return transactionResult(rk.client.DMTTransfer(ctx, rechargekit.DMTTransferRequest{
+		MobileNumber:     req.MobileNumber,
+		BeneficiaryID:    req.BeneficiaryID,
+		Amount:           req.Amount,
+		TransferType:     transferType,
+		OTP:              req.OTP,
+		StateResp:        req.StateResp,
+		Pincode:          req.Pincode,
+		Address:          req.Address,
+		PartnerRequestID: req.PartnerRequestID,
+	}))
+}
+
+// transactionResult converts RechargeKit's answer to a money-moving request.
+// A response without a documented status is a decline when RechargeKit
+// flags it as an error, and leaves the outcome unknown otherwise.
+func transactionResult(res *rechargekit.TransactionResponse, err error) (*Result, error) {
+	if err != nil {
+		if errors.Is(err, rechargekit.ErrUnknownOutcome) {
+			return nil, fmt.Errorf("%w: %w", ErrUnknownOutcome, err)
+		}
+		return nil, err
+	}
+	status := res.Status.String()
+	if status == "" {
+		if res.Error != 1 {
+			return nil, fmt.Errorf("%w: invalid status from recharge kit: %s", ErrUnknownOutcome, res.Message)
+		}
+		status = "FAILED"
+	}
+	return &Result{
+		Status:                status,
+		Message:               res.Message,
+		OrderID:               res.OrderID,
+		OperatorTransactionID: res.OperatorTransactionID,
+	}, nil
+}
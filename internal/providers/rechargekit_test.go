@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
+)
+
+func newTestRechargeKit(t *testing.T) (*RechargeKit, *rechargekit.FakeServer) {
+	t.Helper()
+	fake := rechargekit.NewFakeServer()
+	t.Cleanup(fake.Close)
+	return NewRechargeKit(fake.Client()), fake
+}
+
+func prepaidRequest(partnerRequestID string) models.CreateMobileRechargeRequestModel {
+	return models.CreateMobileRechargeRequestModel{
+		MobileNumber:     9876543210,
+		OperatorCode:     1,
+		Amount:           models.Rupees(199),
+		CircleCode:       5,
+		PartnerRequestID: partnerRequestID,
+	}
+}
+
+func TestRechargeKitTransactionResults(t *testing.T) {
+	tests := []struct {
+		status rechargekit.Status
+		want   string
+	}{
+		{rechargekit.StatusSuccess, "SUCCESS"},
+		{rechargekit.StatusPending, "PENDING"},
+		{rechargekit.StatusFailed, "FAILED"},
+	}
+	for _, tt := range tests {
+		rk, fake := newTestRechargeKit(t)
+		fake.SetResponseStatus(tt.status)
+
+		res, err := rk.PrepaidRecharge(context.Background(), prepaidRequest("req-"+tt.want))
+		if err != nil {
+			t.Fatalf("%s: PrepaidRecharge: %v", tt.want, err)
+		}
+		if res.Status != tt.want || res.OrderID == "" {
+			t.Fatalf("%s: PrepaidRecharge = %+v", tt.want, res)
+		}
+	}
+}
+
+func TestRechargeKitUndocumentedStatusIsUnknown(t *testing.T) {
+	rk, fake := newTestRechargeKit(t)
+	fake.SetResponseStatus(rechargekit.Status(0))
+
+	_, err := rk.PrepaidRecharge(context.Background(), prepaidRequest("req-unknown"))
+	if !errors.Is(err, ErrUnknownOutcome) {
+		t.Fatalf("PrepaidRecharge error = %v, want ErrUnknownOutcome", err)
+	}
+}
+
+func TestRechargeKitPendingSettledByStatusCheck(t *testing.T) {
+	rk, fake := newTestRechargeKit(t)
+	ctx := context.Background()
+	fake.SetResponseStatus(rechargekit.StatusPending)
+
+	res, err := rk.PrepaidRecharge(ctx, prepaidRequest("req-later"))
+	if err != nil {
+		t.Fatalf("PrepaidRecharge: %v", err)
+	}
+	if res.Status != "PENDING" {
+		t.Fatalf("PrepaidRecharge status = %s, want PENDING", res.Status)
+	}
+
+	fake.SetTransactionStatus("req-later", rechargekit.StatusFailed)
+	check, err := rk.StatusCheck(ctx, "req-later")
+	if err != nil {
+		t.Fatalf("StatusCheck: %v", err)
+	}
+	if check.Status != "FAILED" {
+		t.Fatalf("StatusCheck status = %s, want FAILED", check.Status)
+	}
+
+	if _, err := rk.StatusCheck(ctx, "never-sent"); err == nil {
+		t.Fatal("StatusCheck of an unknown transaction returned no error")
+	}
+}
+
+func TestRechargeKitBillPaymentByCategory(t *testing.T) {
+	rk, fake := newTestRechargeKit(t)
+	ctx := context.Background()
+
+	for _, req := range []models.BBPSProviderRequestModel{
+		{CategoryCode: "MOBILE_POSTPAID", OperatorCode: 2, CustomerParams: []string{"9876543210", "5"}, Amount: models.Rupees(499), PartnerRequestID: "req-postpaid"},
+		{CategoryCode: "GAS", OperatorCode: 40, CustomerParams: []string{"1234567", "", "BP01"}, Amount: models.Rupees(800), PartnerRequestID: "req-gas"},
+	} {
+		res, err := rk.BillPayment(ctx, req)
+		if err != nil {
+			t.Fatalf("%s: BillPayment: %v", req.CategoryCode, err)
+		}
+		if res.Status != "SUCCESS" {
+			t.Fatalf("%s: BillPayment status = %s, want SUCCESS", req.CategoryCode, res.Status)
+		}
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("fake received %d requests, want 2", len(requests))
+	}
+	if requests[0].Path != "/recharge/postpaid" || !strings.Contains(string(requests[0].Body), `"circle":5`) {
+		t.Errorf("postpaid bill sent as %s %s", requests[0].Path, requests[0].Body)
+	}
+	if requests[1].Path != "/recharge/billpayment" || !strings.Contains(string(requests[1].Body), `"p1":"1234567"`) || !strings.Contains(string(requests[1].Body), `"p3":"BP01"`) {
+		t.Errorf("gas bill sent as %s %s", requests[1].Path, requests[1].Body)
+	}
+
+	if _, err := rk.BillPayment(ctx, models.BBPSProviderRequestModel{
+		CategoryCode:   "INSURANCE",
+		CustomerParams: []string{"a", "b", "c", "d"},
+	}); err == nil {
+		t.Error("BillPayment with four customer parameters succeeded")
+	}
+}
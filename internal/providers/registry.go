@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Registry holds the providers the server can route to. The first provider
+// registered is the default, used for services without routing rules.
+type Registry struct {
+	providers map[string]Provider
+	fallback  string
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{
+		providers: make(map[string]Provider),
+	}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds a provider, replacing any provider of the same name.
+func (r *Registry) Register(p Provider) {
+	if r.fallback == "" {
+		r.fallback = p.Name()
+	}
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %s", name)
+	}
+	return p, nil
+}
+
+// Default returns the default provider's name.
+func (r *Registry) Default() string {
+	return r.fallback
+}
+
+// Names returns the registered provider names in order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,290 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+const (
+	// A provider that errors failureThreshold times in a row is skipped
+	// for failureCooldown before it is tried again.
+	failureThreshold = 3
+	failureCooldown  = time.Minute
+	// balanceTTL is how long a provider's balance is reused before it is
+	// read again.
+	balanceTTL = 30 * time.Second
+)
+
+// ErrNoProvider is returned when every provider routed for a transaction
+// was skipped.
+var ErrNoProvider = errors.New("no provider available for this service")
+
+// Route describes a transaction to route. OperatorCode and Amount are left
+// zero for requests that have none.
+type Route struct {
+	Service      string
+	OperatorCode int
+	Amount       models.Money
+}
+
+// Router picks providers for transactions from the provider_routes rules.
+// Services without a matching rule go to the registry's default provider.
+type Router struct {
+	db       *database.Database
+	registry *Registry
+
+	mu       sync.Mutex
+	failures map[string]*providerFailures
+	balances map[balanceKey]cachedBalance
+}
+
+type providerFailures struct {
+	count      int
+	retryAfter time.Time
+}
+
+type balanceKey struct {
+	provider string
+	category Category
+}
+
+type cachedBalance struct {
+	amount    models.Money
+	fetchedAt time.Time
+}
+
+type candidate struct {
+	provider   Provider
+	minBalance models.Money
+}
+
+func NewRouter(db *database.Database, registry *Registry) *Router {
+	return &Router{
+		db:       db,
+		registry: registry,
+		failures: make(map[string]*providerFailures),
+		balances: make(map[balanceKey]cachedBalance),
+	}
+}
+
+func (r *Router) Registry() *Registry {
+	return r.registry
+}
+
+// Send sends a money-moving request to the providers routed for it, in
+// order, until one accepts it. A provider is skipped while it is failing or
+// when its balance would drop below the rule's minimum balance, and a
+// FAILED result moves on to the next provider. An error from send is
+// returned without failing over, since the provider may have processed
+// the request; the last FAILED result is returned when no provider accepts.
+func Send[P Provider](ctx context.Context, r *Router, route Route, send func(P) (*Result, error)) (*Result, error) {
+	category, err := serviceCategory(route.Service)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := r.candidates(ctx, route)
+	if err != nil {
+		return nil, err
+	}
+
+	var declined *Result
+	for _, c := range candidates {
+		p, ok := c.provider.(P)
+		if !ok || !r.available(p.Name()) {
+			continue
+		}
+		if !r.hasBalance(ctx, p, category, route.Amount+c.minBalance) {
+			continue
+		}
+
+		res, err := send(p)
+		if err != nil {
+			r.recordFailure(p.Name())
+			return nil, err
+		}
+		r.recordSuccess(p.Name())
+		res.Provider = p.Name()
+		if res.Status != "FAILED" {
+			r.spend(p.Name(), category, route.Amount)
+			return res, nil
+		}
+		log.Printf("%s declined %s: %s", p.Name(), route.Service, res.Message)
+		declined = res
+	}
+
+	if declined != nil {
+		return declined, nil
+	}
+	return nil, ErrNoProvider
+}
+
+// Query sends a request that moves no money, failing over to the next
+// provider on any error. These errors are often caused by the request, so
+// unlike errors from Send they do not count against the provider.
+func Query[P Provider, T any](ctx context.Context, r *Router, route Route, query func(P) (T, error)) (T, error) {
+	var zero T
+	candidates, err := r.candidates(ctx, route)
+	if err != nil {
+		return zero, err
+	}
+
+	lastErr := ErrNoProvider
+	for _, c := range candidates {
+		p, ok := c.provider.(P)
+		if !ok || !r.available(p.Name()) {
+			continue
+		}
+		res, err := query(p)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return res, nil
+	}
+	return zero, lastErr
+}
+
+// First returns the first provider routed for a request that must not
+// fail over, such as DMT calls for a remitter kept at one provider.
+func First[P Provider](ctx context.Context, r *Router, route Route) (P, error) {
+	var zero P
+	candidates, err := r.candidates(ctx, route)
+	if err != nil {
+		return zero, err
+	}
+	for _, c := range candidates {
+		if p, ok := c.provider.(P); ok {
+			return p, nil
+		}
+	}
+	return zero, ErrNoProvider
+}
+
+// candidates returns the providers of the rules matching the route in
+// order, or the default provider when no rule matches.
+func (r *Router) candidates(ctx context.Context, route Route) ([]candidate, error) {
+	rules, err := r.db.GetMatchingProviderRoutesQuery(ctx, route.Service, route.OperatorCode, route.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var candidates []candidate
+	for _, rule := range rules {
+		if seen[rule.Provider] {
+			continue
+		}
+		p, err := r.registry.Get(rule.Provider)
+		if err != nil {
+			log.Printf("provider route %d: %v", rule.RouteID, err)
+			continue
+		}
+		seen[rule.Provider] = true
+		candidates = append(candidates, candidate{p, rule.MinBalance})
+	}
+
+	if fallback := r.registry.Default(); len(candidates) == 0 && fallback != "" {
+		p, err := r.registry.Get(fallback)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate{provider: p})
+	}
+	return candidates, nil
+}
+
+func (r *Router) available(provider string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.failures[provider]
+	return !ok || f.count < failureThreshold || time.Now().After(f.retryAfter)
+}
+
+func (r *Router) recordFailure(provider string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.failures[provider]
+	if !ok {
+		f = &providerFailures{}
+		r.failures[provider] = f
+	}
+	f.count++
+	if f.count >= failureThreshold {
+		f.retryAfter = time.Now().Add(failureCooldown)
+		log.Printf("provider %s failed %d times in a row, skipping it for %s", provider, f.count, failureCooldown)
+	}
+}
+
+func (r *Router) recordSuccess(provider string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, provider)
+}
+
+// hasBalance reports whether the provider holds at least the required
+// amount for the category. A balance that cannot be read counts as a
+// provider failure.
+func (r *Router) hasBalance(ctx context.Context, p Provider, category Category, required models.Money) bool {
+	key := balanceKey{p.Name(), category}
+
+	r.mu.Lock()
+	cached, ok := r.balances[key]
+	r.mu.Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) > balanceTTL {
+		balance, err := p.Balance(ctx, category)
+		if err != nil {
+			log.Printf("failed to read %s balance for %s: %v", p.Name(), category, err)
+			r.recordFailure(p.Name())
+			return false
+		}
+		cached = cachedBalance{balance, time.Now()}
+		r.mu.Lock()
+		r.balances[key] = cached
+		r.mu.Unlock()
+	}
+
+	if cached.amount < required {
+		log.Printf("%s balance for %s is too low: %s, need %s", p.Name(), category, cached.amount, required)
+		return false
+	}
+	return true
+}
+
+// spend lowers the cached balance by an accepted transaction until the
+// balance is read again.
+func (r *Router) spend(provider string, category Category, amount models.Money) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := balanceKey{provider, category}
+	if cached, ok := r.balances[key]; ok {
+		cached.amount -= amount
+		r.balances[key] = cached
+	}
+}
+
+func serviceCategory(service string) (Category, error) {
+	switch service {
+	case "MOBILE_RECHARGE":
+		return CategoryPrepaid, nil
+	case "DTH_RECHARGE":
+		return CategoryDTH, nil
+	case "BBPS":
+		return CategoryBBPS, nil
+	case "PAYOUT":
+		return CategoryPayout, nil
+	case "DMT":
+		return CategoryDMT, nil
+	case "AEPS":
+		return CategoryAEPS, nil
+	default:
+		return "", fmt.Errorf("unknown service %s", service)
+	}
+}
@@ -2,17 +2,14 @@ package repositories
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
 	"github.com/levion-studio/paybazaar/pkg"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
 )
 
 type AdminInterface interface {
@@ -31,14 +28,16 @@ type AdminInterface interface {
 }
 
 type adminRepository struct {
-	db       *database.Database
-	jwtUtils *pkg.JwtUtils
+	db          *database.Database
+	jwtUtils    *pkg.JwtUtils
+	rechargeKit *rechargekit.Client
 }
 
-func NewAdminRepository(db *database.Database, jwtUtils *pkg.JwtUtils) *adminRepository {
+func NewAdminRepository(db *database.Database, jwtUtils *pkg.JwtUtils, rechargeKit *rechargekit.Client) *adminRepository {
 	return &adminRepository{
-		db:       db,
-		jwtUtils: jwtUtils,
+		db:          db,
+		jwtUtils:    jwtUtils,
+		rechargeKit: rechargeKit,
 	}
 }
 
@@ -154,73 +153,13 @@ func (ar *adminRepository) AdminLogin(c echo.Context) (string, error) {
 }
 
 func (ar *adminRepository) GetRechargeKitWalletRechargeBalance(c echo.Context) (*models.RechargeKitWalletBalanceResponseModel, error) {
-	apiUrl := `https://v2a.rechargkit.biz/recharge/balanceCheck`
-
-	apiRequest, err := http.NewRequest(
-		http.MethodGet,
-		apiUrl,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var apiResponse models.RechargeKitWalletBalanceResponseModel
-
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return nil, err
-	}
-	return &apiResponse, nil
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*20)
+	defer cancel()
+	return ar.rechargeKit.RechargeWalletBalance(ctx)
 }
 
 func (ar *adminRepository) GetRechargeKitWalletPrimaryBalance(c echo.Context) (*models.RechargeKitWalletBalanceResponseModel, error) {
-	apiUrl := `https://v2bapi.rechargkit.biz/recharge/balanceCheck`
-
-	apiRequest, err := http.NewRequest(
-		http.MethodGet,
-		apiUrl,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var apiResponse models.RechargeKitWalletBalanceResponseModel
-
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return nil, err
-	}
-	return &apiResponse, nil
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*20)
+	defer cancel()
+	return ar.rechargeKit.PrimaryWalletBalance(ctx)
 }
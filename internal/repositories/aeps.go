@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+type AEPSInterface interface {
+	CashWithdrawal(echo.Context) (*models.AEPSTransactionResponseModel, error)
+	AadhaarPay(echo.Context) (*models.AEPSTransactionResponseModel, error)
+	BalanceEnquiry(echo.Context) (*models.AEPSTransactionResponseModel, error)
+	MiniStatement(echo.Context) (*models.AEPSTransactionResponseModel, error)
+	GetAllAEPSTransactions(echo.Context) ([]models.GetAEPSTransactionsResponseModel, error)
+	GetAEPSTransactionsByRetailerID(echo.Context) ([]models.GetAEPSTransactionsResponseModel, error)
+}
+
+type aepsRepository struct {
+	db        *database.Database
+	providers *providers.Router
+}
+
+func NewAEPSRepository(db *database.Database, providerRouter *providers.Router) *aepsRepository {
+	return &aepsRepository{
+		db,
+		providerRouter,
+	}
+}
+
+// bindRequest reads an AEPS request made by the retailer at its counter.
+func (ar *aepsRepository) bindRequest(c echo.Context, transactionType string) (models.AEPSTransactionRequestModel, error) {
+	var req models.AEPSTransactionRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return req, err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return req, fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+	req.TransactionType = transactionType
+	req.PartnerRequestID = uuid.NewString()
+	return req, nil
+}
+
+// provider returns the AEPS provider. The biometric data is captured for
+// one request, so AEPS calls never fail over.
+func (ar *aepsRepository) provider(ctx context.Context, amount models.Money) (providers.AEPSProvider, error) {
+	return providers.First[providers.AEPSProvider](ctx, ar.providers, providers.Route{Service: "AEPS", Amount: amount})
+}
+
+func (ar *aepsRepository) CashWithdrawal(c echo.Context) (*models.AEPSTransactionResponseModel, error) {
+	req, err := ar.bindRequest(c, "CASH_WITHDRAWAL")
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount < models.Rupees(100) {
+		return nil, models.Rejectf("invalid amount minimum amount is 100")
+	}
+	return ar.credit(c, req, func(ctx context.Context, p providers.AEPSProvider) (*providers.Result, error) {
+		return p.AEPSCashWithdrawal(ctx, req)
+	})
+}
+
+func (ar *aepsRepository) AadhaarPay(c echo.Context) (*models.AEPSTransactionResponseModel, error) {
+	req, err := ar.bindRequest(c, "AADHAAR_PAY")
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, models.Rejectf("invalid amount")
+	}
+	return ar.credit(c, req, func(ctx context.Context, p providers.AEPSProvider) (*providers.Result, error) {
+		return p.AEPSAadhaarPay(ctx, req)
+	})
+}
+
+// credit carries out a cash withdrawal or Aadhaar Pay within the retailer's
+// AEPS limit. The transaction is recorded before the provider is called,
+// and one whose outcome is unknown stays PENDING until a status check or
+// callback settles it.
+func (ar *aepsRepository) credit(
+	c echo.Context,
+	req models.AEPSTransactionRequestModel,
+	send func(context.Context, providers.AEPSProvider) (*providers.Result, error),
+) (*models.AEPSTransactionResponseModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+
+	limit, err := ar.db.GetLimitAmountByRetailerIDAndServiceQuery(ctx, req.RetailerID, "AEPS")
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = models.Rupees(10000)
+	}
+	if req.Amount > limit {
+		return nil, models.Rejectf("invalid amount cross the limit")
+	}
+
+	provider, err := ar.provider(ctx, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	transactionID, err := ar.db.CreateAEPSTransactionQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	res := &models.AEPSTransactionResponseModel{
+		AEPSTransactionID: transactionID,
+		TransactionType:   req.TransactionType,
+		Amount:            req.Amount,
+	}
+
+	if err := ar.db.AssignProviderQuery(ctx, "AEPS", transactionID, provider.Name()); err != nil {
+		ar.settle(ctx, transactionID, "FAILED", "", "", txstate.System, err.Error())
+		return nil, models.Reject(err)
+	}
+	result, err := send(ctx, provider)
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, ar.db, "AEPS", transactionID, err)
+		res.TransactionStatus = txstate.Pending
+		res.Message = "transaction is pending with the bank"
+		return res, nil
+	}
+	if err != nil {
+		ar.settle(ctx, transactionID, "FAILED", "", "", txstate.System, err.Error())
+		return nil, models.Reject(err)
+	}
+
+	ar.settle(ctx, transactionID, result.Status, result.OrderID, result.OperatorTransactionID, txstate.Provider(provider.Name()), result.Message)
+	if result.Status == txstate.Failed {
+		return nil, models.Rejectf("aeps transaction failed: %s", result.Message)
+	}
+	res.TransactionStatus = result.Status
+	res.Message = result.Message
+	res.OperatorTransactionID = result.OperatorTransactionID
+	return res, nil
+}
+
+// settle records the provider's answer for a cash withdrawal or Aadhaar
+// Pay. An answer that cannot be recorded leaves the transaction PENDING for
+// the status check.
+func (ar *aepsRepository) settle(
+	ctx context.Context,
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := ar.db.SettleAEPSTransactionQuery(settleCtx, transactionID, status, orderID, operatorTransactionID, actor, reason); err != nil {
+		log.Printf("failed to settle aeps transaction %s as %s: %v", transactionID, status, err)
+		awaitStatusCheck(ctx, ar.db, "AEPS", transactionID, err)
+	}
+}
+
+func (ar *aepsRepository) BalanceEnquiry(c echo.Context) (*models.AEPSTransactionResponseModel, error) {
+	req, err := ar.bindRequest(c, "BALANCE_ENQUIRY")
+	if err != nil {
+		return nil, err
+	}
+	req.Amount = 0
+	return ar.enquire(c, req, func(ctx context.Context, p providers.AEPSProvider) (*models.AEPSEnquiryResponseModel, error) {
+		return p.AEPSBalanceEnquiry(ctx, req)
+	})
+}
+
+func (ar *aepsRepository) MiniStatement(c echo.Context) (*models.AEPSTransactionResponseModel, error) {
+	req, err := ar.bindRequest(c, "MINI_STATEMENT")
+	if err != nil {
+		return nil, err
+	}
+	req.Amount = 0
+	return ar.enquire(c, req, func(ctx context.Context, p providers.AEPSProvider) (*models.AEPSEnquiryResponseModel, error) {
+		return p.AEPSMiniStatement(ctx, req)
+	})
+}
+
+// enquire sends a balance enquiry or mini statement and records the
+// provider's answer in the AEPS history.
+func (ar *aepsRepository) enquire(
+	c echo.Context,
+	req models.AEPSTransactionRequestModel,
+	send func(context.Context, providers.AEPSProvider) (*models.AEPSEnquiryResponseModel, error),
+) (*models.AEPSTransactionResponseModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+
+	provider, err := ar.provider(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	result, err := send(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != txstate.Success {
+		result.Status = txstate.Failed
+	}
+
+	recordCtx, cancelRecord := settlementContext(ctx)
+	defer cancelRecord()
+	transactionID, err := ar.db.RecordAEPSEnquiryQuery(recordCtx, req, provider.Name(), result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == txstate.Failed {
+		return nil, fmt.Errorf("aeps enquiry failed: %s", result.Message)
+	}
+	return &models.AEPSTransactionResponseModel{
+		AEPSTransactionID:     transactionID,
+		TransactionType:       req.TransactionType,
+		TransactionStatus:     result.Status,
+		Message:               result.Message,
+		OperatorTransactionID: result.OperatorTransactionID,
+		AccountBalance:        &result.AccountBalance,
+		MiniStatement:         result.MiniStatement,
+	}, nil
+}
+
+func (ar *aepsRepository) GetAllAEPSTransactions(c echo.Context) ([]models.GetAEPSTransactionsResponseModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return ar.db.GetAEPSTransactionsQuery(ctx, c.QueryParam("retailer_id"), limit, offset)
+}
+
+// GetAEPSTransactionsByRetailerID lists a retailer's AEPS transactions.
+// Retailers only see their own, whatever retailer_id they ask for.
+func (ar *aepsRepository) GetAEPSTransactionsByRetailerID(c echo.Context) ([]models.GetAEPSTransactionsResponseModel, error) {
+	retailerID, err := ownRetailerID(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return ar.db.GetAEPSTransactionsQuery(ctx, retailerID, limit, offset)
+}
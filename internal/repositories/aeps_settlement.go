@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+type AEPSSettlementInterface interface {
+	GetAEPSSettlementSettings(echo.Context) (*models.AEPSSettlementSettingsModel, error)
+	UpdateAEPSSettlementSettings(echo.Context) error
+	CreateSettlementAccount(echo.Context) (int64, error)
+	GetSettlementAccounts(echo.Context) ([]models.SettlementAccountModel, error)
+	VerifySettlementAccount(echo.Context) error
+	DeleteSettlementAccount(echo.Context) error
+	SettleToWallet(echo.Context) (*models.AEPSSettlementModel, error)
+	RequestBankSettlement(echo.Context) (*models.AEPSSettlementModel, error)
+	ApproveBankSettlement(echo.Context) (*models.AEPSSettlementModel, error)
+	RejectBankSettlement(echo.Context) error
+	GetAllAEPSSettlements(echo.Context) ([]models.AEPSSettlementModel, error)
+	GetAEPSSettlementsByRetailerID(echo.Context) ([]models.AEPSSettlementModel, error)
+}
+
+type aepsSettlementRepository struct {
+	db        *database.Database
+	providers *providers.Router
+}
+
+func NewAEPSSettlementRepository(db *database.Database, providerRouter *providers.Router) *aepsSettlementRepository {
+	return &aepsSettlementRepository{
+		db,
+		providerRouter,
+	}
+}
+
+func (sr *aepsSettlementRepository) GetAEPSSettlementSettings(c echo.Context) (*models.AEPSSettlementSettingsModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.GetAEPSSettlementSettingsQuery(ctx)
+}
+
+func (sr *aepsSettlementRepository) UpdateAEPSSettlementSettings(c echo.Context) error {
+	var req models.UpdateAEPSSettlementSettingsRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.UpdateAEPSSettlementSettingsQuery(ctx, req)
+}
+
+func (sr *aepsSettlementRepository) CreateSettlementAccount(c echo.Context) (int64, error) {
+	var req models.CreateSettlementAccountRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return 0, fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.CreateSettlementAccountQuery(ctx, req)
+}
+
+// GetSettlementAccounts lists a retailer's settlement accounts. Retailers
+// only see their own, whatever retailer_id they ask for.
+func (sr *aepsSettlementRepository) GetSettlementAccounts(c echo.Context) ([]models.SettlementAccountModel, error) {
+	retailerID, err := ownRetailerID(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.GetSettlementAccountsByRetailerIDQuery(ctx, retailerID)
+}
+
+func (sr *aepsSettlementRepository) VerifySettlementAccount(c echo.Context) error {
+	accountID, err := parseInt64Param(c, "settlement_account_id")
+	if err != nil {
+		return err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return fmt.Errorf("unauthorized")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.VerifySettlementAccountQuery(ctx, accountID, claims.AdminID)
+}
+
+func (sr *aepsSettlementRepository) DeleteSettlementAccount(c echo.Context) error {
+	accountID, err := parseInt64Param(c, "settlement_account_id")
+	if err != nil {
+		return err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return fmt.Errorf("unauthorized")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.DeleteSettlementAccountQuery(ctx, accountID, claims.UserID)
+}
+
+func (sr *aepsSettlementRepository) SettleToWallet(c echo.Context) (*models.AEPSSettlementModel, error) {
+	var req models.AEPSWalletSettlementRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return nil, err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	settlementID, err := sr.db.SettleAEPSToWalletQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return sr.recorded(ctx, settlementID), nil
+}
+
+// RequestBankSettlement reserves a settlement to a verified bank account.
+// Nothing is sent to the bank until an admin approves it.
+func (sr *aepsSettlementRepository) RequestBankSettlement(c echo.Context) (*models.AEPSSettlementModel, error) {
+	var req models.AEPSBankSettlementRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return nil, err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+	req.PartnerRequestID = uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	settlementID, err := sr.db.RequestAEPSBankSettlementQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return sr.recorded(ctx, settlementID), nil
+}
+
+// recorded reads back a settlement the request has just recorded. The
+// settlement stands even when it cannot be read, so the answer is then
+// only its id.
+func (sr *aepsSettlementRepository) recorded(ctx context.Context, settlementID string) *models.AEPSSettlementModel {
+	s, err := sr.db.GetAEPSSettlementByIDQuery(ctx, settlementID)
+	if err != nil {
+		log.Printf("failed to read aeps settlement %s: %v", settlementID, err)
+		return &models.AEPSSettlementModel{AEPSSettlementID: settlementID}
+	}
+	return s
+}
+
+// ApproveBankSettlement approves a bank settlement and pays it out to the
+// retailer's account through the payout providers.
+func (sr *aepsSettlementRepository) ApproveBankSettlement(c echo.Context) (*models.AEPSSettlementModel, error) {
+	settlementID := c.Param("settlement_id")
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+
+	s, err := sr.db.ApproveAEPSSettlementQuery(ctx, settlementID, claims.AdminID)
+	if err != nil {
+		return nil, err
+	}
+	req := models.CreatePayoutRequestModel{
+		RetailerId:       s.RetailerID,
+		MobileNumber:     s.RetailerPhone,
+		IFSCCode:         s.IFSCCode,
+		BankName:         s.BankName,
+		AccountNumber:    s.AccountNumber,
+		BeneficiaryName:  s.AccountHolderName,
+		Amount:           s.Amount,
+		TransferType:     5,
+		PartnerRequestId: s.PartnerRequestID,
+	}
+	if s.TransferType != nil && *s.TransferType == "NEFT" {
+		req.TransferType = 6
+	}
+
+	route := providers.Route{
+		Service: "PAYOUT",
+		Amount:  s.Amount,
+	}
+	res, err := providers.Send(ctx, sr.providers, route, func(p providers.PayoutProvider) (*providers.Result, error) {
+		if err := sr.db.AssignProviderQuery(ctx, "AEPS_SETTLEMENT", settlementID, p.Name()); err != nil {
+			return nil, err
+		}
+		return p.Payout(ctx, req)
+	})
+	switch {
+	case errors.Is(err, providers.ErrUnknownOutcome):
+		awaitStatusCheck(ctx, sr.db, "AEPS_SETTLEMENT", settlementID, err)
+	case err != nil:
+		sr.settle(ctx, settlementID, "FAILED", "", "", txstate.System, err.Error())
+		return nil, err
+	default:
+		sr.settle(ctx, settlementID, res.Status, res.OrderID, res.OperatorTransactionID, txstate.Provider(res.Provider), res.Message)
+	}
+	return sr.db.GetAEPSSettlementByIDQuery(ctx, settlementID)
+}
+
+// settle records the provider's answer for an approved bank settlement.
+// An answer that cannot be recorded leaves the settlement PENDING for the
+// status check.
+func (sr *aepsSettlementRepository) settle(
+	ctx context.Context,
+	settlementID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := sr.db.SettleAEPSSettlementQuery(settleCtx, settlementID, status, orderID, operatorTransactionID, actor, reason); err != nil {
+		log.Printf("failed to settle aeps settlement %s as %s: %v", settlementID, status, err)
+		awaitStatusCheck(ctx, sr.db, "AEPS_SETTLEMENT", settlementID, err)
+	}
+}
+
+func (sr *aepsSettlementRepository) RejectBankSettlement(c echo.Context) error {
+	var req models.RejectAEPSSettlementRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return fmt.Errorf("unauthorized")
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return sr.db.RejectAEPSSettlementQuery(ctx, c.Param("settlement_id"), claims.AdminID, req.Remarks)
+}
+
+// GetAllAEPSSettlements reads retailer_id and status from the query.
+func (sr *aepsSettlementRepository) GetAllAEPSSettlements(c echo.Context) ([]models.AEPSSettlementModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return sr.db.GetAEPSSettlementsQuery(ctx, c.QueryParam("retailer_id"), c.QueryParam("status"), limit, offset)
+}
+
+// GetAEPSSettlementsByRetailerID lists a retailer's settlements. Retailers
+// only see their own, whatever retailer_id they ask for.
+func (sr *aepsSettlementRepository) GetAEPSSettlementsByRetailerID(c echo.Context) ([]models.AEPSSettlementModel, error) {
+	retailerID, err := ownRetailerID(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return sr.db.GetAEPSSettlementsQuery(ctx, retailerID, c.QueryParam("status"), limit, offset)
+}
@@ -1,357 +1,308 @@
 package repositories
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"strconv"
+	"log"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 type BBPSInterface interface {
-	CreatePostpaidMobileRecharge(echo.Context) error
-	GetPostpaidMobileRechargeBalance(echo.Context) (*models.GetPostpaidMobileRechargeBillFetchAPIResponseModel, error)
-	GetAllPostpaidMobileRecharge(echo.Context) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error)
-	GetPostpaidMobileRechargeByRetailerID(echo.Context) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error)
-	CreateElectricityBillPayment(echo.Context) error
-	GetAllElectricityOperators(echo.Context) ([]models.GetElectricityOperatorResponseModel, error)
-	GetElectricityBillFetchBalance(c echo.Context) (*models.GetElectricityBillFetchResponseModel, error)
-	GetAllElectricityBillPaymentTransactions(echo.Context) ([]models.GetElectricityBillHistoryResponseModel, error)
-	GetElectricityBillPaymentTransactionsByRetailerID(c echo.Context) ([]models.GetElectricityBillHistoryResponseModel, error)
-	PostpaidMobileRechargeRefund(echo.Context) error
-	ElectricityBillPaymentRefund(echo.Context) error
+	GetBBPSCategories(echo.Context) ([]models.BBPSCategoryModel, error)
+	GetBBPSBillers(echo.Context) ([]models.BBPSBillerModel, error)
+	GetBBPSBiller(echo.Context) (*models.BBPSBillerModel, error)
+	CreateBBPSBiller(echo.Context) error
+	UpdateBBPSBiller(echo.Context) error
+	FetchBill(echo.Context) (*models.BBPSBillFetchResponseModel, error)
+	PayBill(echo.Context) (*models.BBPSBillPaymentResponseModel, error)
+	GetAllBBPSTransactions(echo.Context) ([]models.GetBBPSTransactionsResponseModel, error)
+	GetBBPSTransactionsByRetailerID(echo.Context) ([]models.GetBBPSTransactionsResponseModel, error)
+	BBPSTransactionRefund(echo.Context) error
 }
 
 type bbpsRepository struct {
-	db *database.Database
+	db        *database.Database
+	providers *providers.Router
 }
 
-func NewBBPSRepository(db *database.Database) *bbpsRepository {
+func NewBBPSRepository(db *database.Database, providerRouter *providers.Router) *bbpsRepository {
 	return &bbpsRepository{
 		db,
+		providerRouter,
 	}
 }
 
-func (bp *bbpsRepository) CreatePostpaidMobileRecharge(c echo.Context) error {
-	var req models.CreatePostpaidMobileRechargeAPIRequestModel
-	if err := bindAndValidate(c, &req); err != nil {
-		return err
-	}
+func (bp *bbpsRepository) GetBBPSCategories(c echo.Context) ([]models.BBPSCategoryModel, error) {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
 	defer cancel()
-	err := bp.db.VerifyRetailerForTransactionQuery(ctx, req.RetailerID, req.Amount)
-	if err != nil {
-		return err
-	}
-	req.PartnerRequestID = uuid.NewString()
+	return bp.db.GetBBPSCategoriesQuery(ctx)
+}
 
-	apiUrl := `https://v2a.rechargkit.biz/recharge/postpaid`
-	reqBody, err := json.Marshal(map[string]any{
-		"mobile_no":          req.MobileNumber,
-		"partner_request_id": req.PartnerRequestID,
-		"operator_code":      req.OperatorCode,
-		"circle":             req.OperatorCircle,
-		"amount":             req.Amount,
-		"recharge_type":      1,
-	})
-	if err != nil {
-		return err
+// GetBBPSBillers lists the billers of a category. Admins also see the
+// inactive ones.
+func (bp *bbpsRepository) GetBBPSBillers(c echo.Context) ([]models.BBPSBillerModel, error) {
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
 	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return bp.db.GetBBPSBillersQuery(ctx, c.Param("category_code"), claims.UserRole == "admin")
+}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
+func (bp *bbpsRepository) GetBBPSBiller(c echo.Context) (*models.BBPSBillerModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return bp.db.GetBBPSBillerByIDQuery(ctx, c.Param("biller_id"))
+}
+
+func (bp *bbpsRepository) CreateBBPSBiller(c echo.Context) error {
+	var req models.CreateBBPSBillerRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return err
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
+	if err := validateBillerParams(req.Params); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return bp.db.CreateBBPSBillerQuery(ctx, req)
+}
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
+func (bp *bbpsRepository) UpdateBBPSBiller(c echo.Context) error {
+	var req models.UpdateBBPSBillerRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return err
 	}
-
-	var res models.GetPostpaidMobileRechargeAPIResponseModel
-	if err := json.Unmarshal(respBytes, &res); err != nil {
+	req.BillerID = c.Param("biller_id")
+	if err := validateBillerParams(req.Params); err != nil {
 		return err
 	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return bp.db.UpdateBBPSBillerQuery(ctx, req)
+}
 
-	fmt.Println(string(respBytes))
-
-	var status string
-	if res.Status == 1 {
-		status = "SUCCESS"
-		if err := bp.db.CreatePostpaidMobileRechargeSuccessOrPendingQuery(ctx, req, res, status); err != nil {
-			return err
+// validateBillerParams checks the parameter definitions of a biller.
+func validateBillerParams(params []models.BBPSBillerParamModel) error {
+	for _, p := range params {
+		if p.MaxLength > 0 && p.MaxLength < p.MinLength {
+			return fmt.Errorf("invalid length limits for parameter %s", p.ParamName)
+		}
+		if _, err := regexp.Compile(p.Regex); err != nil {
+			return fmt.Errorf("invalid regex for parameter %s", p.ParamName)
 		}
-		return nil
 	}
+	return nil
+}
 
-	if res.Status == 2 {
-		status = "PENDING"
-		if err := bp.db.CreatePostpaidMobileRechargeSuccessOrPendingQuery(ctx, req, res, status); err != nil {
-			return err
+// billerRequest checks the customer parameters against the biller's and
+// returns the request to send to its provider.
+func billerRequest(biller *models.BBPSBillerModel, customerParams map[string]string) (models.BBPSProviderRequestModel, error) {
+	req := models.BBPSProviderRequestModel{
+		CategoryCode: biller.CategoryCode,
+		OperatorCode: biller.OperatorCode,
+	}
+	if !biller.IsActive {
+		return req, models.Rejectf("biller %s is not active", biller.BillerID)
+	}
+
+	known := make(map[string]bool, len(biller.Params))
+	for _, p := range biller.Params {
+		known[p.ParamName] = true
+		value := customerParams[p.ParamName]
+		if value == "" {
+			if !p.IsOptional {
+				return req, models.Rejectf("%s is required", p.DisplayName)
+			}
+			req.CustomerParams = append(req.CustomerParams, "")
+			continue
+		}
+		if len(value) < p.MinLength || (p.MaxLength > 0 && len(value) > p.MaxLength) {
+			return req, models.Rejectf("invalid length of %s", p.DisplayName)
 		}
-		return nil
+		if p.Regex != "" {
+			matched, err := regexp.MatchString(p.Regex, value)
+			if err != nil {
+				return req, err
+			}
+			if !matched {
+				return req, models.Rejectf("invalid %s", p.DisplayName)
+			}
+		}
+		req.CustomerParams = append(req.CustomerParams, value)
 	}
-
-	if res.Status == 3 {
-		status = "FAILED"
-		if err := bp.db.CreatePostpaidMobileRechargeFailureQuery(ctx, req, res); err != nil {
-			return err
+	for name := range customerParams {
+		if !known[name] {
+			return req, models.Rejectf("unknown parameter %s for biller %s", name, biller.BillerID)
 		}
-		return nil
 	}
-
-	return fmt.Errorf("invalid status from recharge kit")
+	return req, nil
 }
 
-func (bp *bbpsRepository) GetPostpaidMobileRechargeBalance(c echo.Context) (*models.GetPostpaidMobileRechargeBillFetchAPIResponseModel, error) {
-	var req models.GetPostpaidMobileRechargeBillFetchAPIRequestModel
+func (bp *bbpsRepository) FetchBill(c echo.Context) (*models.BBPSBillFetchResponseModel, error) {
+	var req models.BBPSBillFetchRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	apiUrl := fmt.Sprintf("https://v2a.rechargkit.biz/recharge/postPaidBillFetch?mobile_no=%s&operator_code=%d", req.MobileNumber, req.OperatorCode)
-
-	apiRequest, err := http.NewRequest(
-		http.MethodGet,
-		apiUrl,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
 
-	resp, err := client.Do(apiRequest)
+	biller, err := bp.db.GetBBPSBillerByIDQuery(ctx, req.BillerID)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	providerReq, err := billerRequest(biller, req.CustomerParams)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println(string(respBytes))
-
-	var res models.GetPostpaidMobileRechargeBillFetchAPIResponseModel
-	if err := json.Unmarshal(respBytes, &res); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return bp.fetch(ctx, providerReq)
 }
 
-func (bp *bbpsRepository) GetAllPostpaidMobileRecharge(c echo.Context) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error) {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	limit, offset := parsePagination(c)
-	return bp.db.GetAllPostpaidMobileRechargeQuery(ctx, limit, offset)
-}
-
-func (bp *bbpsRepository) GetPostpaidMobileRechargeByRetailerID(c echo.Context) ([]models.GetPostpaidMobileRechargeHistoryResponseModel, error) {
-	var retailerId = c.Param("retailer_id")
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	limit, offset := parsePagination(c)
-	return bp.db.GetPostpaidMobileRechargeByRetailerIDQuery(ctx, retailerId, limit, offset)
+func (bp *bbpsRepository) fetch(ctx context.Context, req models.BBPSProviderRequestModel) (*models.BBPSBillFetchResponseModel, error) {
+	route := providers.Route{
+		Service:      "BBPS",
+		OperatorCode: req.OperatorCode,
+	}
+	return providers.Query(ctx, bp.providers, route, func(p providers.BBPSProvider) (*models.BBPSBillFetchResponseModel, error) {
+		return p.BillFetch(ctx, req)
+	})
 }
 
-func (bp *bbpsRepository) CreateElectricityBillPayment(c echo.Context) error {
-	var req models.CreateElectricityBillPaymentRequestModel
+// PayBill pays a bill of any biller. Billers that require a fetch are only
+// paid when the provider finds a bill for the customer. The wallet is
+// debited before the provider is called, so two requests cannot both spend
+// the same balance.
+func (bp *bbpsRepository) PayBill(c echo.Context) (*models.BBPSBillPaymentResponseModel, error) {
+	var req models.BBPSBillPaymentRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
-		return err
-	}
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	err := bp.db.VerifyRetailerForTransactionQuery(ctx, req.RetailerID, req.Amount)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	req.PartnerRequestID = uuid.NewString()
-
-	apiUrl := `https://v2a.rechargkit.biz/recharge/billpayment`
-	reqBody, err := json.Marshal(map[string]any{
-		"p1":                 req.CustomerID,
-		"partner_request_id": req.PartnerRequestID,
-		"operator_code":      req.OperatorCode,
-		"customer_email":     req.CustomerEmail,
-		"amount":             req.Amount,
-	})
-	if err != nil {
-		return err
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
 	}
-
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return err
+	if req.Amount <= 0 {
+		return nil, models.Rejectf("invalid amount")
 	}
+	req.RetailerID = claims.UserID
+	req.PartnerRequestID = uuid.NewString()
 
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
 
-	resp, err := client.Do(apiRequest)
+	biller, err := bp.db.GetBBPSBillerByIDQuery(ctx, req.BillerID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	providerReq, err := billerRequest(biller, req.CustomerParams)
 	if err != nil {
-		return err
-	}
-
-	var res models.GetElectricityBillPaymentAPIResponseModel
-	if err := json.Unmarshal(respBytes, &res); err != nil {
-		return err
+		return nil, err
 	}
-
-	fmt.Println(string(respBytes))
-
-	var status string
-	if res.Status == 1 {
-		status = "SUCCESS"
-		if err := bp.db.CreateElectricityBillPaymentSuccessOrPendingQuery(ctx, req, res, status); err != nil {
-			return err
+	if biller.FetchRequired {
+		bill, err := bp.fetch(ctx, providerReq)
+		if err != nil {
+			return nil, err
 		}
-		return nil
-	}
-
-	if res.Status == 2 {
-		status = "PENDING"
-		if err := bp.db.CreateElectricityBillPaymentSuccessOrPendingQuery(ctx, req, res, status); err != nil {
-			return err
+		if bill.Error != 0 {
+			return nil, models.Rejectf("bill fetch failed: %s", bill.Message)
 		}
-		return nil
 	}
+	providerReq.CustomerEmail = req.CustomerEmail
+	providerReq.Amount = req.Amount
+	providerReq.PartnerRequestID = req.PartnerRequestID
 
-	if res.Status == 3 {
-		status = "FAILED"
-		if err := bp.db.CreateElectricityBillPaymentFailureQuery(ctx, req, res); err != nil {
-			return err
-		}
-		return nil
-	}
-
-	return fmt.Errorf("invalid status from recharge kit")
-}
-
-func (bp *bbpsRepository) GetAllElectricityOperators(c echo.Context) ([]models.GetElectricityOperatorResponseModel, error) {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	return bp.db.GetElectricityOperatorsQuery(ctx)
-}
-
-func (bp *bbpsRepository) GetElectricityBillFetchBalance(c echo.Context) (*models.GetElectricityBillFetchResponseModel, error) {
-	var req models.GetElectricityBillFetchRequestModel
-	if err := bindAndValidate(c, &req); err != nil {
-		return nil, err
-	}
-	apiUrl := fmt.Sprintf("https://v2a.rechargkit.biz/recharge/electricityBillFetch?consumer_id=%s&operator_code=%d", req.CustomerID, req.OperatorCode)
-
-	apiRequest, err := http.NewRequest(
-		http.MethodGet,
-		apiUrl,
-		nil,
-	)
+	transactionID, err := bp.db.ReserveBBPSTransactionQuery(ctx, req, biller)
 	if err != nil {
 		return nil, err
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return nil, err
+	res := &models.BBPSBillPaymentResponseModel{
+		BBPSTransactionID: transactionID,
+		Amount:            req.Amount,
 	}
-	defer resp.Body.Close()
 
-	respBytes, err := io.ReadAll(resp.Body)
+	route := providers.Route{
+		Service:      "BBPS",
+		OperatorCode: biller.OperatorCode,
+		Amount:       req.Amount,
+	}
+	result, err := providers.Send(ctx, bp.providers, route, func(p providers.BBPSProvider) (*providers.Result, error) {
+		if err := bp.db.AssignProviderQuery(ctx, route.Service, transactionID, p.Name()); err != nil {
+			return nil, err
+		}
+		return p.BillPayment(ctx, providerReq)
+	})
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, bp.db, route.Service, transactionID, err)
+		res.TransactionStatus = txstate.Pending
+		res.Message = "bill payment is pending with the biller"
+		return res, nil
+	}
 	if err != nil {
-		return nil, err
+		bp.settle(ctx, transactionID, "FAILED", "", "", txstate.System, err.Error())
+		return nil, models.Reject(err)
 	}
-	fmt.Println(string(respBytes))
-
-	var res models.GetElectricityBillFetchResponseModel
-	if err := json.Unmarshal(respBytes, &res); err != nil {
-		return nil, err
+	bp.settle(ctx, transactionID, result.Status, result.OrderID, result.OperatorTransactionID, txstate.Provider(result.Provider), result.Message)
+	if result.Status == txstate.Failed {
+		return nil, models.Rejectf("bill payment failed: %s", result.Message)
 	}
-	return &res, nil
+	res.TransactionStatus = result.Status
+	res.Message = result.Message
+	res.OperatorTransactionID = result.OperatorTransactionID
+	return res, nil
 }
 
-func (bp *bbpsRepository) GetAllElectricityBillPaymentTransactions(c echo.Context) ([]models.GetElectricityBillHistoryResponseModel, error) {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+// settle records the provider's answer for a reserved bill payment. An
+// answer that cannot be recorded leaves the payment PENDING for the status
+// check.
+func (bp *bbpsRepository) settle(
+	ctx context.Context,
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) {
+	settleCtx, cancel := settlementContext(ctx)
 	defer cancel()
-	limit, offset := parsePagination(c)
-	return bp.db.GetAllElectricityBillPaymentTransactionsQuery(ctx, offset, limit)
+	if err := bp.db.SettleBBPSTransactionQuery(settleCtx, transactionID, status, orderID, operatorTransactionID, actor, reason); err != nil {
+		log.Printf("failed to settle bbps transaction %s as %s: %v", transactionID, status, err)
+		awaitStatusCheck(ctx, bp.db, "BBPS", transactionID, err)
+	}
 }
 
-func (bp *bbpsRepository) GetElectricityBillPaymentTransactionsByRetailerID(c echo.Context) ([]models.GetElectricityBillHistoryResponseModel, error) {
-	var retailerId = c.Param("retailer_id")
+// GetAllBBPSTransactions reads retailer_id and category_code from the
+// query.
+func (bp *bbpsRepository) GetAllBBPSTransactions(c echo.Context) ([]models.GetBBPSTransactionsResponseModel, error) {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
 	defer cancel()
 	limit, offset := parsePagination(c)
-	return bp.db.GetElectricityBillPaymentTransactionsByRetailerIDQuery(ctx, retailerId, offset, limit)
+	return bp.db.GetBBPSTransactionsQuery(ctx, c.QueryParam("retailer_id"), c.QueryParam("category_code"), limit, offset)
 }
 
-func (bp *bbpsRepository) PostpaidMobileRechargeRefund(c echo.Context) error {
-	var transactionId = c.Param("transaction_id")
-
-	trId, err := strconv.ParseInt(transactionId, 10, 64)
+// GetBBPSTransactionsByRetailerID lists a retailer's bill payments.
+// Retailers only see their own, whatever retailer_id they ask for.
+func (bp *bbpsRepository) GetBBPSTransactionsByRetailerID(c echo.Context) ([]models.GetBBPSTransactionsResponseModel, error) {
+	retailerID, err := ownRetailerID(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
 	defer cancel()
-
-	return bp.db.RefundPostpaidMobileRechargeQuery(ctx, int(trId))
+	limit, offset := parsePagination(c)
+	return bp.db.GetBBPSTransactionsQuery(ctx, retailerID, c.QueryParam("category_code"), limit, offset)
 }
 
-func (bp *bbpsRepository) ElectricityBillPaymentRefund(c echo.Context) error {
-	var transactionId = c.Param("transaction_id")
-
-	trId, err := strconv.ParseInt(transactionId, 10, 64)
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-
-	return bp.db.RefundElectricityBillPaymentQuery(ctx, int(trId))
+func (bp *bbpsRepository) BBPSTransactionRefund(c echo.Context) error {
+	return refundTransaction(c, bp.db, "BBPS", c.Param("transaction_id"))
 }
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestBillerRequest(t *testing.T) {
+	biller := &models.BBPSBillerModel{
+		BillerID:     "INSURANCE_12",
+		CategoryCode: "INSURANCE",
+		OperatorCode: 12,
+		IsActive:     true,
+		Params: []models.BBPSBillerParamModel{
+			{ParamName: "policy_number", DisplayName: "Policy Number", MinLength: 8, MaxLength: 12, Regex: `^[0-9]+$`},
+			{ParamName: "dob", DisplayName: "Date of Birth", IsOptional: true},
+		},
+	}
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   []string
+		reject bool
+	}{
+		{"all parameters", map[string]string{"policy_number": "12345678", "dob": "01-01-1990"}, []string{"12345678", "01-01-1990"}, false},
+		{"optional left out", map[string]string{"policy_number": "12345678"}, []string{"12345678", ""}, false},
+		{"required left out", map[string]string{"dob": "01-01-1990"}, nil, true},
+		{"too short", map[string]string{"policy_number": "1234567"}, nil, true},
+		{"too long", map[string]string{"policy_number": "1234567890123"}, nil, true},
+		{"not matching", map[string]string{"policy_number": "12345ABC"}, nil, true},
+		{"unknown parameter", map[string]string{"policy_number": "12345678", "mobile": "9876543210"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := billerRequest(biller, tt.params)
+			if tt.reject {
+				if !models.IsRejection(err) {
+					t.Fatalf("billerRequest() err = %v, want a rejection", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("billerRequest(): %v", err)
+			}
+			if !reflect.DeepEqual(req.CustomerParams, tt.want) || req.OperatorCode != 12 || req.CategoryCode != "INSURANCE" {
+				t.Errorf("billerRequest() = %+v, want parameters %q", req, tt.want)
+			}
+		})
+	}
+
+	inactive := *biller
+	inactive.IsActive = false
+	if _, err := billerRequest(&inactive, map[string]string{"policy_number": "12345678"}); !models.IsRejection(err) {
+		t.Errorf("paying an inactive biller: err = %v, want a rejection", err)
+	}
+}
+
+func TestValidateBillerParams(t *testing.T) {
+	tests := []struct {
+		param models.BBPSBillerParamModel
+		ok    bool
+	}{
+		{models.BBPSBillerParamModel{ParamName: "customer_id", MinLength: 5, MaxLength: 10, Regex: `^\d+$`}, true},
+		{models.BBPSBillerParamModel{ParamName: "customer_id", MinLength: 5}, true},
+		{models.BBPSBillerParamModel{ParamName: "customer_id", MinLength: 10, MaxLength: 5}, false},
+		{models.BBPSBillerParamModel{ParamName: "customer_id", Regex: `^[0-9`}, false},
+	}
+	for _, tt := range tests {
+		if err := validateBillerParams([]models.BBPSBillerParamModel{tt.param}); (err == nil) != tt.ok {
+			t.Errorf("validateBillerParams(%+v) = %v, want ok %v", tt.param, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
+)
+
+type CallbackInterface interface {
+	RechargeKitCallback(echo.Context) (string, error)
+}
+
+type callbackRepository struct {
+	db *database.Database
+}
+
+func NewCallbackRepository(db *database.Database) *callbackRepository {
+	return &callbackRepository{
+		db,
+	}
+}
+
+// RechargeKitCallback applies a RechargeKit status notification to the
+// transaction it belongs to and returns what was done with it. Callbacks can
+// arrive more than once and in any order relative to the status check
+// worker, so only these transitions move money:
+//   - PENDING to SUCCESS or FAILED settles the transaction, refunding the
+//     retailer on FAILED,
+//   - SUCCESS to FAILED is a provider reversal and refunds the transaction.
+//
+// Any other callback is recorded and ignored.
+func (cr *callbackRepository) RechargeKitCallback(c echo.Context) (string, error) {
+	var req models.RechargeKitCallbackModel
+	if err := c.Bind(&req); err != nil {
+		return "", fmt.Errorf("invalid callback payload")
+	}
+	if req.PartnerRequestId == "" {
+		return "", fmt.Errorf("partner request id is required")
+	}
+
+	var status string
+	switch req.Status {
+	case 1:
+		status = "SUCCESS"
+	case 2:
+		status = "PENDING"
+	case 3:
+		status = "FAILED"
+	default:
+		return "", fmt.Errorf("invalid status from recharge kit")
+	}
+
+	// The callback is applied even if the provider hangs up early.
+	ctx, cancel := settlementContext(c.Request().Context())
+	defer cancel()
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	callback := models.ProviderCallbackModel{
+		Provider:         providers.RechargeKitName,
+		PartnerRequestID: req.PartnerRequestId,
+		ProviderStatus:   req.Status,
+		Payload:          payload,
+		SourceIP:         c.RealIP(),
+	}
+
+	result, err := cr.applyCallback(ctx, &callback, status, req)
+	if err != nil {
+		callback.CallbackResult = "ERROR: " + err.Error()
+	} else {
+		callback.CallbackResult = result
+	}
+	if err := cr.db.InsertProviderCallbackQuery(ctx, callback); err != nil {
+		log.Printf("failed to record callback for %s: %v", req.PartnerRequestId, err)
+	}
+	return result, err
+}
+
+func (cr *callbackRepository) applyCallback(
+	ctx context.Context,
+	callback *models.ProviderCallbackModel,
+	status string,
+	req models.RechargeKitCallbackModel,
+) (string, error) {
+	transaction, err := cr.db.GetProviderTransactionByPartnerRequestIDQuery(ctx, req.PartnerRequestId)
+	if err != nil {
+		return "", err
+	}
+	callback.Service = &transaction.Service
+	callback.TransactionID = &transaction.TransactionID
+
+	// A transaction that failed over to another provider is settled by
+	// that provider's answer only.
+	if transaction.Provider != providers.RechargeKitName {
+		return "IGNORED", nil
+	}
+
+	switch {
+	case transaction.Status == "PENDING":
+		if err := cr.db.SettleProviderTransactionQuery(
+			ctx,
+			transaction.Service,
+			transaction.TransactionID,
+			status,
+			req.OrderId,
+			req.OperatorTransactionId,
+			txstate.Provider(transaction.Provider),
+			"callback",
+		); err != nil {
+			return "", err
+		}
+		return status, nil
+	case transaction.Status == "SUCCESS" && status == "FAILED":
+		if _, err := cr.db.RefundTransactionQuery(ctx, transaction.Service, transaction.TransactionID, 0, txstate.Provider(transaction.Provider), "reversed by callback"); err != nil {
+			return "", err
+		}
+		return "REFUND", nil
+	default:
+		return "IGNORED", nil
+	}
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -16,8 +18,31 @@ type CommisionInterface interface {
 	GetCommisionByUserIDAndService(echo.Context) (*models.GetCommisionResponseModel, error)
 	UpdateCommisionDetails(echo.Context) error
 	DeleteCommision(echo.Context) error
+	GetCommisionAudit(echo.Context) ([]models.CommisionAuditModel, error)
 	GetAllTDSCommision(echo.Context) ([]models.GetTDSCommisionResponseModel, error)
 	GetTDSCommisionByUserID(echo.Context) ([]models.GetTDSCommisionResponseModel, error)
+	GetTDSSettings(echo.Context) (*models.TDSSettingsModel, error)
+	UpdateTDSSettings(echo.Context) error
+	GetTDSQuarterlySummary(echo.Context) ([]models.TDSQuarterlySummaryModel, error)
+	GetCommisionSettlementSettings(echo.Context) (*models.CommisionSettlementSettingsModel, error)
+	UpdateCommisionSettlementSettings(echo.Context) error
+	GetPendingCommisions(echo.Context) ([]models.PendingCommisionModel, error)
+	ReleaseDueCommisions(echo.Context) (*models.CommisionReleaseModel, error)
+	CreateCommisionSlab(echo.Context) (int64, error)
+	GetCommisionSlabByID(echo.Context) (*models.GetCommisionSlabResponseModel, error)
+	GetCommisionSlabs(echo.Context) ([]models.GetCommisionSlabResponseModel, error)
+	UpdateCommisionSlab(echo.Context) error
+	DeleteCommisionSlab(echo.Context) error
+	CreateCommisionPackage(echo.Context) (int64, error)
+	GetCommisionPackageByID(echo.Context) (*models.GetCommisionPackageResponseModel, error)
+	GetCommisionPackages(echo.Context) ([]models.GetCommisionPackageResponseModel, error)
+	UpdateCommisionPackage(echo.Context) error
+	DeleteCommisionPackage(echo.Context) error
+	AssignCommisionPackage(echo.Context) error
+	UnassignCommisionPackage(echo.Context) (int64, error)
+	ReassignCommisionPackage(echo.Context) (int64, error)
+	GetEffectiveCommision(echo.Context) (*models.EffectiveCommisionModel, error)
+	GetTransactionQuote(echo.Context) (*models.TransactionQuoteModel, error)
 }
 
 type commisionRepository struct {
@@ -118,6 +143,17 @@ func (cr *commisionRepository) DeleteCommision(
 	return cr.db.DeleteCommisionQuery(ctx, commisionID)
 }
 
+func (cr *commisionRepository) GetCommisionAudit(
+	c echo.Context,
+) ([]models.CommisionAuditModel, error) {
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetCommisionAuditQuery(ctx)
+}
+
 func (cr *commisionRepository) GetAllTDSCommision(c echo.Context) ([]models.GetTDSCommisionResponseModel, error) {
 	ctx, cancel := context.WithTimeout(
 		c.Request().Context(),
@@ -138,3 +174,295 @@ func (cr *commisionRepository) GetTDSCommisionByUserID(c echo.Context) ([]models
 	limit, offset := parsePagination(c)
 	return cr.db.GetTDSCommisionByUserIDQuery(ctx, userID, limit, offset)
 }
+
+func (cr *commisionRepository) GetTDSSettings(c echo.Context) (*models.TDSSettingsModel, error) {
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetTDSSettingsQuery(ctx)
+}
+
+func (cr *commisionRepository) UpdateTDSSettings(c echo.Context) error {
+	var req models.UpdateTDSSettingsRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.UpdateTDSSettingsQuery(ctx, req)
+}
+
+// istLocation is Indian Standard Time, which financial years follow.
+var istLocation = time.FixedZone("IST", 5*60*60+30*60)
+
+// GetTDSQuarterlySummary reads financial_year (the year it starts in,
+// defaulting to the current one), quarter (1-4, or all) and user_id from
+// the query. Users other than admins only see their own deductions.
+func (cr *commisionRepository) GetTDSQuarterlySummary(c echo.Context) ([]models.TDSQuarterlySummaryModel, error) {
+	now := time.Now().In(istLocation)
+	financialYear := now.Year()
+	if now.Month() < time.April {
+		financialYear--
+	}
+	if fy := c.QueryParam("financial_year"); fy != "" {
+		v, err := strconv.Atoi(fy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid financial year")
+		}
+		financialYear = v
+	}
+
+	var quarter int
+	if q := c.QueryParam("quarter"); q != "" {
+		v, err := strconv.Atoi(q)
+		if err != nil || v < 1 || v > 4 {
+			return nil, fmt.Errorf("invalid quarter")
+		}
+		quarter = v
+	}
+
+	userID := c.QueryParam("user_id")
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	if claims.UserRole != "admin" {
+		userID = claims.UserID
+	}
+
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetTDSQuarterlySummaryQuery(ctx, financialYear, quarter, userID)
+}
+
+func (cr *commisionRepository) GetCommisionSettlementSettings(c echo.Context) (*models.CommisionSettlementSettingsModel, error) {
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetCommisionSettlementSettingsQuery(ctx)
+}
+
+func (cr *commisionRepository) UpdateCommisionSettlementSettings(c echo.Context) error {
+	var req models.UpdateCommisionSettlementSettingsRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.UpdateCommisionSettlementSettingsQuery(ctx, req)
+}
+
+// GetPendingCommisions reads user_id and status from the query. Users
+// other than admins only see their own commission.
+func (cr *commisionRepository) GetPendingCommisions(c echo.Context) ([]models.PendingCommisionModel, error) {
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	userID := c.QueryParam("user_id")
+	if claims.UserRole != "admin" {
+		userID = claims.UserID
+	}
+
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return cr.db.GetPendingCommisionsQuery(ctx, userID, c.QueryParam("status"), limit, offset)
+}
+
+// ReleaseDueCommisions runs the commission release batch now instead of
+// waiting for the scheduler.
+func (cr *commisionRepository) ReleaseDueCommisions(c echo.Context) (*models.CommisionReleaseModel, error) {
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.ReleaseDueCommisionsQuery(ctx)
+}
+
+// GetTransactionQuote reads service, amount and, on recharges,
+// operator_code from the query. Retailers are quoted for themselves and
+// admins pass retailer_id.
+func (cr *commisionRepository) GetTransactionQuote(c echo.Context) (*models.TransactionQuoteModel, error) {
+	service := c.QueryParam("service")
+	if service == "" {
+		return nil, fmt.Errorf("service is required")
+	}
+	amount, err := models.ParseMoney(c.QueryParam("amount"))
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+
+	var operatorCode int
+	if o := c.QueryParam("operator_code"); o != "" {
+		if operatorCode, err = strconv.Atoi(o); err != nil || operatorCode < 0 {
+			return nil, fmt.Errorf("invalid operator code")
+		}
+	}
+
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	retailerID := claims.UserID
+	if claims.UserRole == "admin" {
+		retailerID = c.QueryParam("retailer_id")
+	}
+	if retailerID == "" {
+		return nil, fmt.Errorf("retailer id is required")
+	}
+
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetTransactionQuoteQuery(ctx, service, retailerID, operatorCode, amount)
+}
+
+// validateCommisionSlab checks what the validation tags cannot: the value
+// matches the commission type, the ranges are in order and the shares add
+// up to the whole commission.
+func validateCommisionSlab(req *models.CreateCommisionSlabRequestModel) error {
+	if req.UserID != nil && *req.UserID == "" {
+		req.UserID = nil
+	}
+	if req.UserID != nil && req.PackageID != nil {
+		return fmt.Errorf("a slab belongs to a user or a package, not both")
+	}
+	if req.OperatorCode != nil && req.Service != "MOBILE_RECHARGE" && req.Service != "DTH_RECHARGE" {
+		return fmt.Errorf("only recharge slabs can be set per operator")
+	}
+	switch req.CommisionType {
+	case "FLAT":
+		if req.PercentCommision != 0 {
+			return fmt.Errorf("a flat slab cannot have a percent commision")
+		}
+	case "PERCENTAGE":
+		if req.FlatCommision != 0 {
+			return fmt.Errorf("a percentage slab cannot have a flat commision")
+		}
+	}
+	if req.MaxAmount != nil && *req.MaxAmount < req.MinAmount {
+		return fmt.Errorf("max amount is below min amount")
+	}
+	if req.MaxCommision != nil && *req.MaxCommision < req.MinCommision {
+		return fmt.Errorf("max commision is below min commision")
+	}
+	shares := req.AdminCommision + req.MasterDistributorCommision + req.DistributorCommision + req.RetailerCommision
+	if shares != models.RateOne {
+		return fmt.Errorf("commision shares must add up to 1")
+	}
+	return nil
+}
+
+func (cr *commisionRepository) CreateCommisionSlab(c echo.Context) (int64, error) {
+	var req models.CreateCommisionSlabRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+	if err := validateCommisionSlab(&req); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	if err := cr.checkSlabPackage(ctx, c, req.PackageID, req.UserID); err != nil {
+		return 0, err
+	}
+	return cr.db.CreateCommisionSlabQuery(ctx, req)
+}
+
+func (cr *commisionRepository) GetCommisionSlabByID(c echo.Context) (*models.GetCommisionSlabResponseModel, error) {
+	slabID, err := parseInt64Param(c, "slab_id")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetCommisionSlabByIDQuery(ctx, slabID)
+}
+
+// GetCommisionSlabs lists slabs filtered by the service, user_id and
+// package_id query parameters; user_id=default lists the slabs that apply
+// to everyone.
+func (cr *commisionRepository) GetCommisionSlabs(c echo.Context) ([]models.GetCommisionSlabResponseModel, error) {
+	var packageID int64
+	if p := c.QueryParam("package_id"); p != "" {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package id")
+		}
+		packageID = v
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return cr.db.GetCommisionSlabsQuery(ctx, c.QueryParam("service"), c.QueryParam("user_id"), packageID, limit, offset)
+}
+
+func (cr *commisionRepository) UpdateCommisionSlab(c echo.Context) error {
+	var req models.UpdateCommisionSlabRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := validateCommisionSlab(&req.CreateCommisionSlabRequestModel); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	if err := cr.checkSlabOwner(ctx, c, req.SlabID); err != nil {
+		return err
+	}
+	if err := cr.checkSlabPackage(ctx, c, req.PackageID, req.UserID); err != nil {
+		return err
+	}
+	return cr.db.UpdateCommisionSlabQuery(ctx, req)
+}
+
+func (cr *commisionRepository) DeleteCommisionSlab(c echo.Context) error {
+	slabID, err := parseInt64Param(c, "slab_id")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	if err := cr.checkSlabOwner(ctx, c, slabID); err != nil {
+		return err
+	}
+	return cr.db.DeleteCommisionSlabQuery(ctx, slabID)
+}
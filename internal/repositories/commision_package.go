@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+// commisionOwner returns who is managing commissions: the master
+// distributor's id, or "" for an admin, who may manage everything. It also
+// returns the id to record as the author of a change.
+func commisionOwner(c echo.Context) (ownerID, actorID string, err error) {
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return "", "", fmt.Errorf("unauthorized")
+	}
+	if claims.UserRole == "admin" {
+		return "", claims.AdminID, nil
+	}
+	return claims.UserID, claims.UserID, nil
+}
+
+// checkSlabPackage lets a master distributor create and move slabs only
+// into its own packages, or as overrides for users in its downline;
+// default slabs are for admins.
+func (cr *commisionRepository) checkSlabPackage(ctx context.Context, c echo.Context, packageID *int64, userID *string) error {
+	ownerID, _, err := commisionOwner(c)
+	if err != nil || ownerID == "" {
+		return err
+	}
+	if packageID != nil {
+		return cr.db.CheckCommisionPackageOwnerQuery(ctx, *packageID, ownerID)
+	}
+	if userID == nil {
+		return fmt.Errorf("only admins can manage default slabs")
+	}
+	ok, err := cr.db.IsInDownlineQuery(ctx, ownerID, *userID)
+	if err != nil {
+		return err
+	}
+	if !ok || *userID == ownerID {
+		return fmt.Errorf("user is not in your downline")
+	}
+	return nil
+}
+
+// checkSlabOwner lets a master distributor change only the slabs of its
+// own packages and the overrides of users in its downline.
+func (cr *commisionRepository) checkSlabOwner(ctx context.Context, c echo.Context, slabID int64) error {
+	ownerID, _, err := commisionOwner(c)
+	if err != nil || ownerID == "" {
+		return err
+	}
+	slab, err := cr.db.GetCommisionSlabByIDQuery(ctx, slabID)
+	if err != nil {
+		return err
+	}
+	return cr.checkSlabPackage(ctx, c, slab.PackageID, slab.UserID)
+}
+
+func (cr *commisionRepository) CreateCommisionPackage(c echo.Context) (int64, error) {
+	var req models.CreateCommisionPackageRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+	_, actorID, err := commisionOwner(c)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.CreateCommisionPackageQuery(ctx, req, actorID)
+}
+
+func (cr *commisionRepository) GetCommisionPackageByID(c echo.Context) (*models.GetCommisionPackageResponseModel, error) {
+	packageID, err := parseInt64Param(c, "package_id")
+	if err != nil {
+		return nil, err
+	}
+	ownerID, _, err := commisionOwner(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.GetCommisionPackageByIDQuery(ctx, packageID, ownerID)
+}
+
+func (cr *commisionRepository) GetCommisionPackages(c echo.Context) ([]models.GetCommisionPackageResponseModel, error) {
+	ownerID, _, err := commisionOwner(c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return cr.db.GetCommisionPackagesQuery(ctx, ownerID, limit, offset)
+}
+
+func (cr *commisionRepository) UpdateCommisionPackage(c echo.Context) error {
+	var req models.UpdateCommisionPackageRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ownerID, _, err := commisionOwner(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.UpdateCommisionPackageQuery(ctx, req, ownerID)
+}
+
+func (cr *commisionRepository) DeleteCommisionPackage(c echo.Context) error {
+	packageID, err := parseInt64Param(c, "package_id")
+	if err != nil {
+		return err
+	}
+	ownerID, _, err := commisionOwner(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.DeleteCommisionPackageQuery(ctx, packageID, ownerID)
+}
+
+func (cr *commisionRepository) AssignCommisionPackage(c echo.Context) error {
+	var req models.AssignCommisionPackageRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	ownerID, actorID, err := commisionOwner(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.AssignCommisionPackageQuery(ctx, req, actorID, ownerID)
+}
+
+func (cr *commisionRepository) UnassignCommisionPackage(c echo.Context) (int64, error) {
+	var req models.UnassignCommisionPackageRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+	ownerID, _, err := commisionOwner(c)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.UnassignCommisionPackageQuery(ctx, req, ownerID)
+}
+
+func (cr *commisionRepository) ReassignCommisionPackage(c echo.Context) (int64, error) {
+	var req models.ReassignCommisionPackageRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+	ownerID, actorID, err := commisionOwner(c)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	return cr.db.ReassignCommisionPackageQuery(ctx, req, actorID, ownerID)
+}
+
+// GetEffectiveCommision explains the commission of the user in the path.
+// Admins see anyone; other users see themselves and their downline.
+func (cr *commisionRepository) GetEffectiveCommision(c echo.Context) (*models.EffectiveCommisionModel, error) {
+	userID := c.Param("user_id")
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	ctx, cancel := context.WithTimeout(
+		c.Request().Context(),
+		30*time.Second,
+	)
+	defer cancel()
+	if claims.UserRole != "admin" {
+		ok, err := cr.db.IsInDownlineQuery(ctx, claims.UserID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("user not found")
+		}
+	}
+	return cr.db.GetEffectiveCommisionQuery(ctx, userID)
+}
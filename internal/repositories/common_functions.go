@@ -1,20 +1,25 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 func bindAndValidate(c echo.Context, req any) error {
 	if c.Bind(req) != nil {
-		return fmt.Errorf("invalid request body")
+		return models.Rejectf("invalid request body")
 	}
 	if err := c.Validate(req); err != nil {
 		log.Println(err)
-		return fmt.Errorf("invalid request format")
+		return models.Rejectf("invalid request format")
 	}
 	return nil
 }
@@ -64,3 +69,68 @@ func parseInt64Param(c echo.Context, key string) (int64, error) {
 
 	return id, nil
 }
+
+// ownRetailerID returns the retailer_id path parameter for admins and the
+// caller's own id for retailers, so retailers only ever see their own data.
+func ownRetailerID(c echo.Context) (string, error) {
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return "", fmt.Errorf("unauthorized")
+	}
+	retailerID := c.Param("retailer_id")
+	if claims.UserRole != "admin" {
+		retailerID = claims.UserID
+	}
+	if retailerID == "" {
+		return "", fmt.Errorf("retailer id is required")
+	}
+	return retailerID, nil
+}
+
+// settlementContext returns a context for recording a provider's answer.
+// It outlives the request context, which may already have run out while
+// waiting on the provider, so a reserved transaction is not left unsettled.
+func settlementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+}
+
+// awaitStatusCheck leaves a transaction the provider may or may not have
+// carried out PENDING, with its funds still reserved, and queues it for the
+// status check worker, which settles or refunds it once the provider
+// reports the outcome.
+func awaitStatusCheck(ctx context.Context, db *database.Database, service, transactionID string, providerErr error) {
+	log.Printf("outcome of %s %s is unknown, waiting for a status check: %v", service, transactionID, providerErr)
+	recordCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := db.RecordStatusCheckQuery(recordCtx, service, transactionID, "PENDING", providerErr, time.Minute); err != nil {
+		log.Printf("failed to queue status check of %s %s: %v", service, transactionID, err)
+	}
+}
+
+// refundTransaction refunds a transaction on behalf of the admin making the
+// request. The optional amount query parameter makes it a partial refund,
+// and reason is recorded with the refund. Admins can only refund
+// transactions the provider has completed; pending ones are settled by the
+// provider first.
+func refundTransaction(c echo.Context, db *database.Database, service, transactionID string) error {
+	var amount models.Money
+	if a := c.QueryParam("amount"); a != "" {
+		var err error
+		if amount, err = models.ParseMoney(a); err != nil {
+			return err
+		}
+	}
+	actor := txstate.Admin("")
+	if claims, ok := c.Get("user").(*models.AccessTokenClaims); ok {
+		actor.ID = claims.AdminID
+	}
+	reason := c.QueryParam("reason")
+	if reason == "" {
+		reason = "manual refund"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	_, err := db.RefundTransactionQuery(ctx, service, transactionID, amount, actor, reason)
+	return err
+}
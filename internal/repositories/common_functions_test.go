@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func TestOwnRetailerID(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  *models.AccessTokenClaims
+		param   string
+		want    string
+		wantErr bool
+	}{
+		{"admin reads any retailer", &models.AccessTokenClaims{UserID: "A000001", UserRole: "admin"}, "R000002", "R000002", false},
+		{"admin without a retailer", &models.AccessTokenClaims{UserID: "A000001", UserRole: "admin"}, "", "", true},
+		{"retailer reads its own", &models.AccessTokenClaims{UserID: "R000001", UserRole: "retailer"}, "", "R000001", false},
+		{"retailer cannot read another retailer", &models.AccessTokenClaims{UserID: "R000001", UserRole: "retailer"}, "R000002", "R000001", false},
+		{"no claims", nil, "R000002", "", true},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := e.NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+			c.SetParamNames("retailer_id")
+			c.SetParamValues(tt.param)
+			if tt.claims != nil {
+				c.Set("user", tt.claims)
+			}
+			got, err := ownRetailerID(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ownRetailerID() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ownRetailerID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
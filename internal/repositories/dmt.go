@@ -1,19 +1,20 @@
 package repositories
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 type DMTInterface interface {
@@ -22,278 +23,363 @@ type DMTInterface interface {
 	VerifyDMTWallet(echo.Context) (*models.DMTWalletVerificationResponseModel, error)
 	AddDMTBeneficiary(echo.Context) (*models.DMTAddBeneficiaryResponseModel, error)
 	GetDmtBeneficiary(echo.Context) (*models.DMTGetBeneficiaryResponseModel, error)
+	VerifyDMTBeneficiary(echo.Context) (*models.DMTBeneficiaryModel, error)
 	GetDMTBankList(echo.Context) (*models.DMTBankListResponseModel, error)
+	SendDMTTransferOTP(echo.Context) (*models.DMTTransferOTPResponseModel, error)
+	CreateDMTTransaction(echo.Context) (string, error)
+	GetAllDMTTransactions(echo.Context) ([]models.GetAllDMTTransactionsResponseModel, error)
+	GetDMTTransactionsByRetailerID(echo.Context) ([]models.GetRetailerDMTTransactionsResponseModel, error)
+	DMTRefund(echo.Context) error
+	GetDMTRemitter(echo.Context) (*models.DMTRemitterModel, error)
+	UpdateDMTRemitter(echo.Context) error
 }
 
 type dmtRepository struct {
-	db *database.Database
+	db        *database.Database
+	providers *providers.Router
 }
 
-func NewDMTRepository(db *database.Database) *dmtRepository {
+func NewDMTRepository(db *database.Database, providerRouter *providers.Router) *dmtRepository {
 	return &dmtRepository{
 		db,
+		providerRouter,
 	}
 }
 
+// provider returns the DMT provider. Remitters are registered with the
+// provider, so DMT calls never fail over.
+func (dr *dmtRepository) provider(ctx context.Context) (providers.DMTProvider, error) {
+	return providers.First[providers.DMTProvider](ctx, dr.providers, providers.Route{Service: "DMT"})
+}
+
 func (dr *dmtRepository) CheckDMTWalletExists(c echo.Context) (*models.DMTWalletCheckResponseModel, error) {
 	var req models.DMTWalletCheckRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/checkWalletExist`
-	reqBody, err := json.Marshal(req)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return provider.DMTWalletExists(ctx, req)
+}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
+func (dr *dmtRepository) CreateDMTWallet(c echo.Context) (*models.DMTCreateWalletResponseModel, error) {
+	var req models.DMTCreateWalletRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	var apiResponse models.DMTWalletCheckResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return nil, err
-	}
-	return &apiResponse, nil
+	return provider.CreateDMTWallet(ctx, req)
 }
 
-func (dr *dmtRepository) CreateDMTWallet(c echo.Context) (*models.DMTCreateWalletResponseModel, error) {
-	var req models.DMTCreateWalletRequestModel
+// VerifyDMTWallet completes a remitter's registration with the provider
+// and records the remitter, linked to the retailer, in the local registry.
+func (dr *dmtRepository) VerifyDMTWallet(c echo.Context) (*models.DMTWalletVerificationResponseModel, error) {
+	var req models.DMTWalletVerificationRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/createWalletRequest`
-	reqBody, err := json.Marshal(map[string]any{
-		"mobile_no":      req.MobileNumber,
-		"lat":            req.Latitude,
-		"long":           req.Longitude,
-		"aadhaar_number": req.AadharNumber,
-		"pid_data":       req.PidData,
-		"is_iris":        req.IsIris,
-	})
-	if err != nil {
-		return nil, err
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
 	}
-
-	fmt.Printf("%s", string(reqBody))
-
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
+	req.RetailerID = claims.UserID
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
+	res, err := provider.VerifyDMTWallet(ctx, req)
+	if err != nil || res.Error == 1 {
+		return res, err
+	}
+	if err := dr.db.RegisterDMTRemitterQuery(ctx, req.MobileNumber, req.RemitterName, req.RetailerID); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return res, nil
+}
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
+func (dr *dmtRepository) AddDMTBeneficiary(c echo.Context) (*models.DMTAddBeneficiaryResponseModel, error) {
+	var req models.DMTAddBeneficiaryRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	log.Println(string(respBytes))
-
-	var apiResponse models.DMTCreateWalletResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
+	req.PartnerRequestID = uuid.NewString()
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return &apiResponse, nil
+	return provider.AddDMTBeneficiary(ctx, req)
 }
 
-func (dr *dmtRepository) VerifyDMTWallet(c echo.Context) (*models.DMTWalletVerificationResponseModel, error) {
-	var req models.DMTWalletVerificationRequestModel
+func (dr *dmtRepository) GetDmtBeneficiary(c echo.Context) (*models.DMTGetBeneficiaryResponseModel, error) {
+	var req models.DMTGetBeneficiaryRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/verifyWalletRequest`
-	reqBody, err := json.Marshal(req)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return provider.DMTBeneficiaries(ctx, req)
+}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
+// VerifyDMTBeneficiary has the provider penny-drop a beneficiary's account
+// and records the beneficiary for transfers when the account holder name
+// the bank returns matches the name the retailer gave. Transfers go to the
+// account, IFSC and name the bank returned, not to what the retailer typed.
+func (dr *dmtRepository) VerifyDMTBeneficiary(c echo.Context) (*models.DMTBeneficiaryModel, error) {
+	var req models.DMTVerifyBeneficiaryRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+	req.PartnerRequestID = uuid.NewString()
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	res, err := provider.VerifyDMTBeneficiary(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if res.Error != 0 || res.AccountNumber == "" || res.IFSCCode == "" {
+		return nil, models.Rejectf("beneficiary verification failed: %s", res.Message)
+	}
+	if !namesMatch(req.BeneficiaryName, res.BeneficiaryName) {
+		return nil, models.Rejectf("beneficiary name does not match the account holder name %q", res.BeneficiaryName)
+	}
 
-	var apiResponse models.DMTWalletVerificationResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
+	beneficiary := models.DMTBeneficiaryModel{
+		MobileNumber:    req.MobileNumber,
+		BeneficiaryID:   req.BeneficiaryID,
+		RetailerID:      req.RetailerID,
+		BeneficiaryName: res.BeneficiaryName,
+		BankName:        res.BankName,
+		AccountNumber:   res.AccountNumber,
+		IFSCCode:        res.IFSCCode,
+	}
+	if err := dr.db.RecordDMTBeneficiaryQuery(ctx, beneficiary); err != nil {
 		return nil, err
 	}
-	return &apiResponse, nil
+	return &beneficiary, nil
 }
 
-func (dr *dmtRepository) AddDMTBeneficiary(c echo.Context) (*models.DMTAddBeneficiaryResponseModel, error) {
-	var req models.DMTAddBeneficiaryRequestModel
-	if err := bindAndValidate(c, &req); err != nil {
-		return nil, err
+// namesMatch reports whether a name given for a beneficiary matches the
+// account holder name the bank returned. Case, punctuation, word order and
+// salutations are ignored, and every word of the shorter name has to appear
+// in the longer one.
+func namesMatch(given, holder string) bool {
+	a, b := nameWords(given), nameWords(holder)
+	if len(a) == 0 || len(b) == 0 {
+		return false
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/addBeneficiaryRequest`
-	req.PartnerRequestID = uuid.NewString()
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	if len(a) > len(b) {
+		a, b = b, a
 	}
-
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return nil, err
+	for w := range a {
+		if !b[w] {
+			return false
+		}
 	}
+	return true
+}
 
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
+var salutations = map[string]bool{"MR": true, "MRS": true, "MS": true, "MISS": true, "DR": true, "SHRI": true, "SMT": true, "KUMARI": true}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+func nameWords(name string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if !salutations[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
 
-	resp, err := client.Do(apiRequest)
+func (dr *dmtRepository) GetDMTBankList(c echo.Context) (*models.DMTBankListResponseModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return provider.DMTBankList(ctx)
+}
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
+func (dr *dmtRepository) SendDMTTransferOTP(c echo.Context) (*models.DMTTransferOTPResponseModel, error) {
+	var req models.DMTTransferOTPRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-
-	var apiResponse models.DMTAddBeneficiaryResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	provider, err := dr.provider(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return &apiResponse, nil
+	return provider.DMTTransferOTP(ctx, req)
 }
 
-func (dr *dmtRepository) GetDmtBeneficiary(c echo.Context) (*models.DMTGetBeneficiaryResponseModel, error) {
-	var req models.DMTGetBeneficiaryRequestModel
+// CreateDMTTransaction sends an OTP-confirmed transfer from the retailer's
+// wallet to a beneficiary of the remitter and returns its transaction id.
+// A transfer the provider leaves pending keeps its funds reserved until a
+// status check or callback settles it.
+func (dr *dmtRepository) CreateDMTTransaction(c echo.Context) (string, error) {
+	var req models.DMTTransactionRequestModel
 	if err := bindAndValidate(c, &req); err != nil {
-		return nil, err
+		return "", err
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/getUserDetails`
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return "", fmt.Errorf("unauthorized")
+	}
+	req.RetailerID = claims.UserID
+	if req.Amount < models.Rupees(100) {
+		return "", models.Rejectf("invalid amount minimum amount is 100")
 	}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+
+	limit, err := dr.db.GetLimitAmountByRetailerIDAndServiceQuery(ctx, req.RetailerID, "DMT")
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	if limit == 0 {
+		limit = models.Rupees(25000)
+	}
+	if req.Amount > limit {
+		return "", models.Rejectf("invalid amount cross the limit")
 	}
 
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
+	provider, err := dr.provider(ctx)
+	if err != nil {
+		return "", err
+	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+	req.PartnerRequestID = uuid.NewString()
 
-	resp, err := client.Do(apiRequest)
+	// The wallet is debited before the provider is called, so two requests
+	// cannot both spend the same balance.
+	transactionID, err := dr.db.ReserveDMTTransactionQuery(ctx, req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	respBytes, err := io.ReadAll(resp.Body)
+	if err := dr.db.AssignProviderQuery(ctx, "DMT", transactionID, provider.Name()); err != nil {
+		dr.settle(ctx, transactionID, "FAILED", "", "", txstate.System, err.Error())
+		return "", models.Reject(err)
+	}
+	res, err := provider.DMTTransfer(ctx, req)
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, dr.db, "DMT", transactionID, err)
+		return transactionID, nil
+	}
 	if err != nil {
-		return nil, err
+		dr.settle(ctx, transactionID, "FAILED", "", "", txstate.System, err.Error())
+		return "", models.Reject(err)
 	}
-	fmt.Println(string(respBytes))
 
-	var apiResponse models.DMTGetBeneficiaryResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return nil, err
+	dr.settle(ctx, transactionID, res.Status, res.OrderID, res.OperatorTransactionID, txstate.Provider(provider.Name()), res.Message)
+	if res.Status == "FAILED" {
+		return "", models.Rejectf("money transfer failed: %s", res.Message)
 	}
-	return &apiResponse, nil
+	return transactionID, nil
 }
 
-func (dr *dmtRepository) GetDMTBankList(c echo.Context) (*models.DMTBankListResponseModel, error) {
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitdmr/getBankList`
-	apiRequest, err := http.NewRequest(
-		http.MethodGet,
-		apiUrl,
-		nil,
-	)
-	if err != nil {
-		return nil, err
+// settle records the provider's answer for a reserved money transfer. An
+// answer that cannot be recorded leaves the transfer PENDING for the status
+// check.
+func (dr *dmtRepository) settle(
+	ctx context.Context,
+	transactionID string,
+	status string,
+	orderID string,
+	operatorTransactionID string,
+	actor txstate.Actor,
+	reason string,
+) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := dr.db.SettleDMTTransactionQuery(settleCtx, transactionID, status, orderID, operatorTransactionID, actor, reason); err != nil {
+		log.Printf("failed to settle dmt transaction %s as %s: %v", transactionID, status, err)
+		awaitStatusCheck(ctx, dr.db, "DMT", transactionID, err)
 	}
+}
 
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
+func (dr *dmtRepository) GetAllDMTTransactions(c echo.Context) ([]models.GetAllDMTTransactionsResponseModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return dr.db.GetAllDMTTransactionsQuery(ctx, limit, offset)
+}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+// GetDMTTransactionsByRetailerID lists a retailer's transfers. Retailers
+// only see their own, whatever retailer_id they ask for.
+func (dr *dmtRepository) GetDMTTransactionsByRetailerID(c echo.Context) ([]models.GetRetailerDMTTransactionsResponseModel, error) {
+	retailerID := c.Param("retailer_id")
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	if claims.UserRole != "admin" {
+		retailerID = claims.UserID
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	limit, offset := parsePagination(c)
+	return dr.db.GetDMTTransactionsByRetailerIDQuery(ctx, retailerID, limit, offset)
+}
 
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (dr *dmtRepository) DMTRefund(c echo.Context) error {
+	return refundTransaction(c, dr.db, "DMT", c.Param("transaction_id"))
+}
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// GetDMTRemitter looks up a remitter by mobile number, with the limits the
+// counter has left to transfer for it this month. Retailers only find the
+// remitters they registered.
+func (dr *dmtRepository) GetDMTRemitter(c echo.Context) (*models.DMTRemitterModel, error) {
+	claims, ok := c.Get("user").(*models.AccessTokenClaims)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	retailerID := ""
+	if claims.UserRole != "admin" {
+		retailerID = claims.UserID
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return dr.db.GetDMTRemitterQuery(ctx, c.Param("mobile_no"), retailerID)
+}
 
-	var apiResponse models.DMTBankListResponseModel
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return nil, err
+func (dr *dmtRepository) UpdateDMTRemitter(c echo.Context) error {
+	var req models.UpdateDMTRemitterRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
 	}
-	return &apiResponse, nil
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+	return dr.db.UpdateDMTRemitterQuery(ctx, c.Param("mobile_no"), req)
 }
-
-
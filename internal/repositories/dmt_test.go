@@ -0,0 +1,25 @@
+package repositories
+
+import "testing"
+
+func TestNamesMatch(t *testing.T) {
+	tests := []struct {
+		given, holder string
+		want          bool
+	}{
+		{"Ramesh Kumar", "RAMESH KUMAR", true},
+		{"ramesh kumar", "Mr. Ramesh Kumar", true},
+		{"Kumar Ramesh", "RAMESH KUMAR", true},
+		{"Ramesh", "RAMESH KUMAR SHARMA", true},
+		{"Ramesh Kumar Sharma", "RAMESH SHARMA", true},
+		{"Suresh Kumar", "RAMESH KUMAR", false},
+		{"Ramesh Verma", "RAMESH KUMAR SHARMA", false},
+		{"Mr", "MR RAMESH", false},
+		{"Ramesh", "", false},
+	}
+	for _, tt := range tests {
+		if got := namesMatch(tt.given, tt.holder); got != tt.want {
+			t.Errorf("namesMatch(%q, %q) = %v, want %v", tt.given, tt.holder, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,17 @@
 package repositories
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 type DTHRechargeInterface interface {
@@ -25,12 +23,14 @@ type DTHRechargeInterface interface {
 }
 
 type dthRechargeRepository struct {
-	db *database.Database
+	db        *database.Database
+	providers *providers.Router
 }
 
-func NewDTHRechargeRepository(db *database.Database) *dthRechargeRepository {
+func NewDTHRechargeRepository(db *database.Database, providerRouter *providers.Router) *dthRechargeRepository {
 	return &dthRechargeRepository{
 		db,
+		providerRouter,
 	}
 }
 
@@ -47,82 +47,56 @@ func (drr *dthRechargeRepository) CreateDTHRecharge(c echo.Context) error {
 	}
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
 	defer cancel()
-	err := drr.db.VerifyRetailerForTransactionQuery(ctx, req.RetailerID, req.Amount)
-	if err != nil {
-		return err
-	}
 	req.PartnerRequestID = uuid.NewString()
 
-	apiUrl := `https://v2a.rechargkit.biz/recharge/dth`
-	reqBody, err := json.Marshal(map[string]any{
-		"customer_id":        req.CustomerID,
-		"operator_code":      req.OperatorCode,
-		"amount":             req.Amount,
-		"partner_request_id": req.PartnerRequestID,
-	})
+	// The wallet is debited before the provider is called, so two requests
+	// cannot both spend the same balance.
+	transactionID, err := drr.db.ReserveDTHRechargeQuery(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return err
+	route := providers.Route{
+		Service:      "DTH_RECHARGE",
+		OperatorCode: req.OperatorCode,
+		Amount:       req.Amount,
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return err
+	res, err := providers.Send(ctx, drr.providers, route, func(p providers.DTHProvider) (*providers.Result, error) {
+		if err := drr.db.AssignProviderQuery(ctx, route.Service, transactionID, p.Name()); err != nil {
+			return nil, err
+		}
+		return p.DTHRecharge(ctx, req)
+	})
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, drr.db, route.Service, transactionID, err)
+		return nil
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
-	}
-
-	var apiResponse struct {
-		Status  int    `json:"status"`
-		Message string `json:"msg"`
-	}
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return err
+		drr.settle(ctx, transactionID, "FAILED", txstate.System, err.Error())
+		return models.Reject(err)
 	}
 
-	fmt.Println(apiResponse)
-	if apiResponse.Status == 1 {
-		req.Status = "SUCCESS"
-		if err := drr.db.CreateDTHRechargeSuccessOrPendingQuery(ctx, req); err != nil {
-			return err
-		}
+	switch res.Status {
+	case "SUCCESS":
+		drr.settle(ctx, transactionID, "SUCCESS", txstate.Provider(res.Provider), res.Message)
 		return nil
-	}
-
-	if apiResponse.Status == 2 {
-		req.Status = "PENDING"
-		if err := drr.db.CreateDTHRechargeSuccessOrPendingQuery(ctx, req); err != nil {
-			return err
-		}
+	case "PENDING":
 		return nil
+	default:
+		drr.settle(ctx, transactionID, "FAILED", txstate.Provider(res.Provider), res.Message)
+		return models.Rejectf("failed to recharge: %s", res.Message)
 	}
+}
 
-	if apiResponse.Status == 3 {
-		req.Status = "FAILED"
-		if err := drr.db.CreateDTHRechargeFailedQuery(ctx, req); err != nil {
-			return err
-		}
-		return fmt.Errorf("failed to recharge: %s", apiResponse.Message)
+// settle records the provider's answer for a reserved recharge. An answer
+// that cannot be recorded leaves the recharge PENDING for the status check.
+func (drr *dthRechargeRepository) settle(ctx context.Context, transactionID string, status string, actor txstate.Actor, reason string) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := drr.db.SettleDTHRechargeQuery(settleCtx, transactionID, status, actor, reason); err != nil {
+		log.Printf("failed to settle dth recharge %s as %s: %v", transactionID, status, err)
+		awaitStatusCheck(ctx, drr.db, "DTH_RECHARGE", transactionID, err)
 	}
-	return fmt.Errorf("invalid status from recharge kit")
-
 }
 
 func (drr *dthRechargeRepository) GetAllDTHRecharges(c echo.Context) ([]models.GetDTHRechargeHistoryResponseModel, error) {
@@ -142,7 +116,5 @@ func (drr *dthRechargeRepository) GetDTHRechargesByRetailerID(c echo.Context) ([
 
 func (dvr *dthRechargeRepository) DTHRechargeRefund(c echo.Context) error {
 	var transactionID = c.Param("transaction_id")
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*20)
-	defer cancel()
-	return dvr.db.DTHRechargeRefundQuery(ctx, transactionID)
+	return refundTransaction(c, dvr.db, "DTH_RECHARGE", transactionID)
 }
@@ -1,19 +1,17 @@
 package repositories
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 type MobileRechargeInterface interface {
@@ -27,12 +25,14 @@ type MobileRechargeInterface interface {
 }
 
 type mobileRechargeRepository struct {
-	db *database.Database
+	db        *database.Database
+	providers *providers.Router
 }
 
-func NewMobileRechargeRepository(db *database.Database) *mobileRechargeRepository {
+func NewMobileRechargeRepository(db *database.Database, providerRouter *providers.Router) *mobileRechargeRepository {
 	return &mobileRechargeRepository{
 		db,
+		providerRouter,
 	}
 }
 
@@ -43,82 +43,56 @@ func (mrr *mobileRechargeRepository) CreateMobileRecharge(c echo.Context) error
 	}
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
 	defer cancel()
-	err := mrr.db.VerifyRetailerForTransactionQuery(ctx, req.RetailerID, req.Amount)
-	if err != nil {
-		return err
-	}
 	req.PartnerRequestID = uuid.NewString()
 
-	apiUrl := `https://v2a.rechargkit.biz/recharge/prepaid`
-	reqBody, err := json.Marshal(map[string]any{
-		"mobile_no":          req.MobileNumber,
-		"operator_code":      req.OperatorCode,
-		"amount":             req.Amount,
-		"partner_request_id": req.PartnerRequestID,
-		"circle":             req.CircleCode,
-		"recharge_type":      1,
-	})
+	// The wallet is debited before the provider is called, so two requests
+	// cannot both spend the same balance.
+	transactionID, err := mrr.db.ReserveMobileRechargeQuery(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return err
+	route := providers.Route{
+		Service:      "MOBILE_RECHARGE",
+		OperatorCode: req.OperatorCode,
+		Amount:       req.Amount,
 	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return err
+	res, err := providers.Send(ctx, mrr.providers, route, func(p providers.PrepaidProvider) (*providers.Result, error) {
+		if err := mrr.db.AssignProviderQuery(ctx, route.Service, transactionID, p.Name()); err != nil {
+			return nil, err
+		}
+		return p.PrepaidRecharge(ctx, req)
+	})
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, mrr.db, route.Service, transactionID, err)
+		return nil
 	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
-	}
-
-	var apiResponse struct {
-		Status  int    `json:"status"`
-		Message string `json:"msg"`
-	}
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return err
+		mrr.settle(ctx, transactionID, "FAILED", txstate.System, err.Error())
+		return models.Reject(err)
 	}
 
-	if apiResponse.Status == 1 {
-		req.Status = "SUCCESS"
-		if err := mrr.db.CreateMobileRechargeSuccessOrPendingQuery(ctx, req); err != nil {
-			return err
-		}
+	switch res.Status {
+	case "SUCCESS":
+		mrr.settle(ctx, transactionID, "SUCCESS", txstate.Provider(res.Provider), res.Message)
 		return nil
-	}
-
-	if apiResponse.Status == 2 {
-		req.Status = "PENDING"
-		if err := mrr.db.CreateMobileRechargeSuccessOrPendingQuery(ctx, req); err != nil {
-			return err
-		}
+	case "PENDING":
 		return nil
+	default:
+		mrr.settle(ctx, transactionID, "FAILED", txstate.Provider(res.Provider), res.Message)
+		return models.Rejectf("failed to recharge: %s", res.Message)
 	}
+}
 
-	if apiResponse.Status == 3 {
-		req.Status = "FAILED"
-		if err := mrr.db.CreateMobileRechargeFailedQuery(ctx, req); err != nil {
-			return err
-		}
-		return fmt.Errorf("failed to recharge: %s", apiResponse.Message)
+// settle records the provider's answer for a reserved recharge. An answer
+// that cannot be recorded leaves the recharge PENDING for the status check.
+func (mrr *mobileRechargeRepository) settle(ctx context.Context, transactionID string, status string, actor txstate.Actor, reason string) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := mrr.db.SettleMobileRechargeQuery(settleCtx, transactionID, status, actor, reason); err != nil {
+		log.Printf("failed to settle mobile recharge %s as %s: %v", transactionID, status, err)
+		awaitStatusCheck(ctx, mrr.db, "MOBILE_RECHARGE", transactionID, err)
 	}
-	return fmt.Errorf("invalid status from recharge kit")
 }
 
 func (mrr *mobileRechargeRepository) GetAllMobileRechargeCircles(c echo.Context) ([]models.GetMobileRechargeCircleResponseModel, error) {
@@ -138,45 +112,16 @@ func (mrr *mobileRechargeRepository) GetAllPlansBasedOnCircleAndOperator(c echo.
 	if err := bindAndValidate(c, &req); err != nil {
 		return nil, err
 	}
-	apiUrl := `https://v2bapi.rechargkit.biz/recharge/prepaidPlanFetch`
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return nil, err
-	}
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var res models.GetMobileRechargePlansResponseModel
-	if err := json.Unmarshal(respBytes, &res); err != nil {
-		return nil, err
-	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*20)
+	defer cancel()
 
-	if res.Error == 1 {
-		return nil, fmt.Errorf("failed to fetch plan: %s", res.Message)
+	route := providers.Route{
+		Service:      "MOBILE_RECHARGE",
+		OperatorCode: req.OperatorCode,
 	}
-
-	return res, nil
+	return providers.Query(ctx, mrr.providers, route, func(p providers.PrepaidProvider) (*models.GetMobileRechargePlansResponseModel, error) {
+		return p.PrepaidPlans(ctx, req)
+	})
 }
 
 func (mrr *mobileRechargeRepository) GetAllMobileRecharges(c echo.Context) ([]models.GetMobileRechargeHistoryResponseModel, error) {
@@ -196,8 +141,5 @@ func (mrr *mobileRechargeRepository) GetMobileRechargesByRetailerID(c echo.Conte
 
 func (mrr *mobileRechargeRepository) MobileRechargeRefund(c echo.Context) error {
 	var transactionId = c.Param("transaction_id")
-
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	return mrr.db.MobileRechargeRefundQuery(ctx, transactionId)
+	return refundTransaction(c, mrr.db, "MOBILE_RECHARGE", transactionId)
 }
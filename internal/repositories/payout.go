@@ -1,20 +1,17 @@
 package repositories
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"log"
-	"net/http"
-	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/txstate"
 )
 
 type PayoutInterface interface {
@@ -25,12 +22,14 @@ type PayoutInterface interface {
 }
 
 type payoutRepository struct {
-	db *database.Database
+	db        *database.Database
+	providers *providers.Router
 }
 
-func NewPayoutRepository(db *database.Database) *payoutRepository {
+func NewPayoutRepository(db *database.Database, providerRouter *providers.Router) *payoutRepository {
 	return &payoutRepository{
 		db,
+		providerRouter,
 	}
 }
 
@@ -39,8 +38,8 @@ func (pr *payoutRepository) CreatePayoutTransaction(c echo.Context) error {
 	if err := bindAndValidate(c, &req); err != nil {
 		return err
 	}
-	if req.Amount < 1000 {
-		return fmt.Errorf("invalid amount minimum amount is 1000")
+	if req.Amount < models.Rupees(1000) {
+		return models.Rejectf("invalid amount minimum amount is 1000")
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
@@ -52,103 +51,62 @@ func (pr *payoutRepository) CreatePayoutTransaction(c echo.Context) error {
 	}
 
 	if limit == 0 {
-		limit = 25000
+		limit = models.Rupees(25000)
 	}
 
 	if req.Amount > limit {
-		return fmt.Errorf("invalid amount cross the limit")
+		return models.Rejectf("invalid amount cross the limit")
 	}
 
-	commision, err := pr.db.GetPayoutCommisionQuery(ctx, req.RetailerId, req.Amount)
-	if err != nil {
-		return err
-	}
-
-	if err := pr.db.VerifyRetailerForTransactionQuery(ctx, req.RetailerId, req.Amount+commision.TotalCommision); err != nil {
-		return err
-	}
 	req.PartnerRequestId = uuid.NewString()
 
-	apiUrl := `https://v2bapi.rechargkit.biz/rkitpayout/payoutTransfer`
-	reqBody, err := json.Marshal(map[string]any{
-		"mobile_no":          req.MobileNumber,
-		"account_no":         req.AccountNumber,
-		"ifsc":               req.IFSCCode,
-		"bank_name":          req.BankName,
-		"beneficiary_name":   req.BeneficiaryName,
-		"amount":             req.Amount,
-		"transfer_type":      req.TransferType,
-		"partner_request_id": req.PartnerRequestId,
-	})
-	if err != nil {
-		return err
-	}
-
-	apiRequest, err := http.NewRequest(
-		http.MethodPost,
-		apiUrl,
-		bytes.NewReader(reqBody),
-	)
-	if err != nil {
-		return err
-	}
-
-	apiRequest.Header.Set("Content-Type", "application/json")
-	apiRequest.Header.Set("Authorization", "Bearer "+os.Getenv("RKIT_API_TOKEN"))
-
-	client := &http.Client{Timeout: 20 * time.Second}
-
-	resp, err := client.Do(apiRequest)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := io.ReadAll(resp.Body)
+	// The wallet is debited before the provider is called, so two requests
+	// cannot both spend the same balance.
+	transactionId, err := pr.db.ReservePayoutQuery(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	var apiResponse struct {
-		Error                 int    `json:"error"`
-		Message               string `json:"msg"`
-		Status                int    `json:"status"`
-		OrderId               string `json:"orderid"`
-		OperatorTransactionId string `json:"optransid"`
-		PartnerRequestId      string `json:"partnerreqid"`
-	}
-
-	if err := json.Unmarshal(respBytes, &apiResponse); err != nil {
-		return err
+	route := providers.Route{
+		Service: "PAYOUT",
+		Amount:  req.Amount,
 	}
-	req.OrderId = apiResponse.OrderId
-	req.OperatorTransactionId = apiResponse.OperatorTransactionId
-
-	if apiResponse.Status == 1 {
-		req.TransactionStatus = "SUCCESS"
-		if err := pr.db.CreatePayoutSuccessOrPendingQuery(ctx, req, *commision); err != nil {
-			return err
+	res, err := providers.Send(ctx, pr.providers, route, func(p providers.PayoutProvider) (*providers.Result, error) {
+		if err := pr.db.AssignProviderQuery(ctx, route.Service, transactionId, p.Name()); err != nil {
+			return nil, err
 		}
+		return p.Payout(ctx, req)
+	})
+	if errors.Is(err, providers.ErrUnknownOutcome) {
+		awaitStatusCheck(ctx, pr.db, route.Service, transactionId, err)
 		return nil
 	}
-
-	if apiResponse.Status == 2 {
-		req.TransactionStatus = "PENDING"
-		if err := pr.db.CreatePayoutSuccessOrPendingQuery(ctx, req, *commision); err != nil {
-			return err
-		}
-		return nil
+	if err != nil {
+		pr.settle(ctx, transactionId, "FAILED", "", "", txstate.System, err.Error())
+		return models.Reject(err)
 	}
 
-	if apiResponse.Status == 3 {
-		req.TransactionStatus = "FAILED"
-		if err := pr.db.CreatePayoutFailureQuery(ctx, req, *commision); err != nil {
-			return err
-		}
-		return nil
-	}
+	pr.settle(ctx, transactionId, res.Status, res.OrderID, res.OperatorTransactionID, txstate.Provider(res.Provider), res.Message)
+	return nil
+}
 
-	return fmt.Errorf("invalid status from recharge kit")
+// settle records the provider's answer for a reserved payout. An answer
+// that cannot be recorded leaves the payout PENDING for the status check.
+func (pr *payoutRepository) settle(
+	ctx context.Context,
+	transactionId string,
+	status string,
+	orderId string,
+	operatorTransactionId string,
+	actor txstate.Actor,
+	reason string,
+) {
+	settleCtx, cancel := settlementContext(ctx)
+	defer cancel()
+	if err := pr.db.SettlePayoutQuery(settleCtx, transactionId, status, orderId, operatorTransactionId, actor, reason); err != nil {
+		log.Printf("failed to settle payout %s as %s: %v", transactionId, status, err)
+		awaitStatusCheck(ctx, pr.db, "PAYOUT", transactionId, err)
+	}
 }
 
 func (pr *payoutRepository) GetAllPayoutTransactions(c echo.Context) ([]models.GetAllPayoutTransactionsResponseModel, error) {
@@ -169,7 +127,5 @@ func (pr *payoutRepository) GetPayoutTransactionsByRetailerId(c echo.Context) ([
 
 func (pr *payoutRepository) PayoutRefund(c echo.Context) error {
 	var transactionId = c.Param("transaction_id")
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
-	defer cancel()
-	return pr.db.PayoutRefundQuery(ctx, transactionId)
+	return refundTransaction(c, pr.db, "PAYOUT", transactionId)
 }
@@ -112,7 +112,7 @@ func (r *beneficiaryRepo) VerifyBeneficiary(c echo.Context) (*models.VerifyBenef
 	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*20)
 	defer cancel()
 
-	if err := r.query.VerifyBenificary(ctx, 3, req.RetailerId); err != nil {
+	if err := r.query.VerifyBenificary(ctx, models.Rupees(3), req.RetailerId); err != nil {
 		return nil, err
 	}
 
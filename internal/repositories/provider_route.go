@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+)
+
+type ProviderRouteInterface interface {
+	CreateProviderRoute(echo.Context) error
+	UpdateProviderRoute(echo.Context) error
+	DeleteProviderRoute(echo.Context) error
+	GetAllProviderRoutes(echo.Context) ([]models.ProviderRouteModel, error)
+	GetProviders(echo.Context) []string
+}
+
+type providerRouteRepository struct {
+	db       *database.Database
+	registry *providers.Registry
+}
+
+func NewProviderRouteRepository(db *database.Database, registry *providers.Registry) *providerRouteRepository {
+	return &providerRouteRepository{
+		db,
+		registry,
+	}
+}
+
+func (prr *providerRouteRepository) CreateProviderRoute(c echo.Context) error {
+	var req models.CreateProviderRouteRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := prr.validateRoute(req.Provider, req.MinAmount, req.MaxAmount); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return prr.db.CreateProviderRouteQuery(ctx, req)
+}
+
+func (prr *providerRouteRepository) UpdateProviderRoute(c echo.Context) error {
+	var req models.UpdateProviderRouteRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+	if err := prr.validateRoute(req.Provider, req.MinAmount, req.MaxAmount); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return prr.db.UpdateProviderRouteQuery(ctx, req)
+}
+
+func (prr *providerRouteRepository) DeleteProviderRoute(c echo.Context) error {
+	var routeId = c.Param("route_id")
+	rtId, err := strconv.ParseInt(routeId, 10, 64)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return prr.db.DeleteProviderRouteQuery(ctx, int(rtId))
+}
+
+func (prr *providerRouteRepository) GetAllProviderRoutes(c echo.Context) ([]models.ProviderRouteModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return prr.db.GetAllProviderRoutesQuery(ctx)
+}
+
+// GetProviders returns the names rules can route to.
+func (prr *providerRouteRepository) GetProviders(c echo.Context) []string {
+	return prr.registry.Names()
+}
+
+func (prr *providerRouteRepository) validateRoute(provider string, minAmount, maxAmount models.Money) error {
+	if _, err := prr.registry.Get(provider); err != nil {
+		return err
+	}
+	if maxAmount != 0 && maxAmount < minAmount {
+		return fmt.Errorf("max amount must not be less than min amount")
+	}
+	return nil
+}
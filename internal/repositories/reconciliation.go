@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+type ReconciliationInterface interface {
+	RunReconciliation(echo.Context) (*models.ReconciliationRunModel, error)
+	GetReconciliationRuns(echo.Context) ([]models.ReconciliationRunModel, error)
+	GetReconciliationBreaks(echo.Context) ([]models.ReconciliationBreakModel, error)
+}
+
+type reconciliationRepository struct {
+	db *database.Database
+}
+
+func NewReconciliationRepository(db *database.Database) *reconciliationRepository {
+	return &reconciliationRepository{
+		db,
+	}
+}
+
+func (rr *reconciliationRepository) RunReconciliation(c echo.Context) (*models.ReconciliationRunModel, error) {
+	triggeredBy := "ADMIN"
+	if claims, ok := c.Get("user").(*models.AccessTokenClaims); ok && claims.AdminID != "" {
+		triggeredBy = claims.AdminID
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+	return rr.db.RunReconciliationQuery(ctx, triggeredBy)
+}
+
+func (rr *reconciliationRepository) GetReconciliationRuns(c echo.Context) ([]models.ReconciliationRunModel, error) {
+	limit, offset := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return rr.db.GetReconciliationRunsQuery(ctx, limit, offset)
+}
+
+func (rr *reconciliationRepository) GetReconciliationBreaks(c echo.Context) ([]models.ReconciliationBreakModel, error) {
+	runID, err := parseInt64Param(c, "run_id")
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return rr.db.GetReconciliationBreaksQuery(ctx, runID, limit, offset)
+}
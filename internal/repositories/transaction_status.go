@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+type TransactionStatusInterface interface {
+	GetTransactionStatusHistory(echo.Context) ([]models.TransactionStatusHistoryModel, error)
+	GetTransactionRefunds(echo.Context) ([]models.TransactionRefundModel, error)
+}
+
+type transactionStatusRepository struct {
+	db *database.Database
+}
+
+func NewTransactionStatusRepository(db *database.Database) *transactionStatusRepository {
+	return &transactionStatusRepository{
+		db,
+	}
+}
+
+func (tsr *transactionStatusRepository) GetTransactionStatusHistory(c echo.Context) ([]models.TransactionStatusHistoryModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return tsr.db.GetTransactionStatusHistoryQuery(ctx, c.Param("service"), c.Param("transaction_id"))
+}
+
+func (tsr *transactionStatusRepository) GetTransactionRefunds(c echo.Context) ([]models.TransactionRefundModel, error) {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	return tsr.db.GetTransactionRefundsQuery(ctx, c.Param("service"), c.Param("transaction_id"))
+}
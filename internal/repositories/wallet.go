@@ -10,15 +10,19 @@ import (
 )
 
 type WalletTransactionInterface interface {
-	CreateWalletTransaction(echo.Context) error
 	GetAdminWalletTransactions(echo.Context) ([]models.GetWalletTransactionResponseModel, error)
 	GetMasterDistributorWalletTransactions(echo.Context) ([]models.GetWalletTransactionResponseModel, error)
 	GetDistributorWalletTransactions(echo.Context) ([]models.GetWalletTransactionResponseModel, error)
 	GetRetailerWalletTransactions(echo.Context) ([]models.GetWalletTransactionResponseModel, error)
-	GetAdminWalletBalance(echo.Context) (float64, error)
-	GetMasterDistributorWalletBalance(echo.Context) (float64, error)
-	GetDistributorWalletBalance(echo.Context) (float64, error)
-	GetRetailerWalletBalance(echo.Context) (float64, error)
+	GetAdminWalletBalance(echo.Context) (*models.GetWalletBalanceResponseModel, error)
+	GetMasterDistributorWalletBalance(echo.Context) (*models.GetWalletBalanceResponseModel, error)
+	GetDistributorWalletBalance(echo.Context) (*models.GetWalletBalanceResponseModel, error)
+	GetRetailerWalletBalance(echo.Context) (*models.GetWalletBalanceResponseModel, error)
+	GetRetailerSettlementWalletTransactions(echo.Context) ([]models.GetWalletTransactionResponseModel, error)
+	GetRetailerSettlementWalletBalance(echo.Context) (*models.GetWalletBalanceResponseModel, error)
+	PlaceWalletHold(echo.Context) (int64, error)
+	ReleaseWalletHold(echo.Context) error
+	GetWalletHolds(echo.Context) ([]models.WalletHoldModel, error)
 }
 
 type walletTransactionRepository struct {
@@ -33,21 +37,6 @@ func NewWalletTransactionRepository(
 	}
 }
 
-func (wr *walletTransactionRepository) CreateWalletTransaction(
-	c echo.Context,
-) error {
-
-	var req models.CreateWalletTransactionRequestModel
-	if err := bindAndValidate(c, &req); err != nil {
-		return err
-	}
-
-	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*10)
-	defer cancel()
-
-	return wr.db.CreateWalletTransactionQuery(ctx, req)
-}
-
 func (wr *walletTransactionRepository) GetAdminWalletTransactions(
 	c echo.Context,
 ) ([]models.GetWalletTransactionResponseModel, error) {
@@ -122,7 +111,7 @@ func (wr *walletTransactionRepository) GetRetailerWalletTransactions(
 
 func (wr *walletTransactionRepository) GetAdminWalletBalance(
 	c echo.Context,
-) (float64, error) {
+) (*models.GetWalletBalanceResponseModel, error) {
 
 	adminID := c.Param("admin_id")
 
@@ -134,7 +123,7 @@ func (wr *walletTransactionRepository) GetAdminWalletBalance(
 
 func (wr *walletTransactionRepository) GetMasterDistributorWalletBalance(
 	c echo.Context,
-) (float64, error) {
+) (*models.GetWalletBalanceResponseModel, error) {
 
 	mdID := c.Param("master_distributor_id")
 
@@ -146,7 +135,7 @@ func (wr *walletTransactionRepository) GetMasterDistributorWalletBalance(
 
 func (wr *walletTransactionRepository) GetDistributorWalletBalance(
 	c echo.Context,
-) (float64, error) {
+) (*models.GetWalletBalanceResponseModel, error) {
 
 	distributorID := c.Param("distributor_id")
 
@@ -158,7 +147,7 @@ func (wr *walletTransactionRepository) GetDistributorWalletBalance(
 
 func (wr *walletTransactionRepository) GetRetailerWalletBalance(
 	c echo.Context,
-) (float64, error) {
+) (*models.GetWalletBalanceResponseModel, error) {
 
 	retailerID := c.Param("retailer_id")
 
@@ -167,3 +156,90 @@ func (wr *walletTransactionRepository) GetRetailerWalletBalance(
 
 	return wr.db.GetRetailerWalletBalanceQuery(ctx, retailerID)
 }
+
+func (wr *walletTransactionRepository) GetRetailerSettlementWalletTransactions(
+	c echo.Context,
+) ([]models.GetWalletTransactionResponseModel, error) {
+
+	retailerID, err := ownRetailerID(c)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := parsePagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*30)
+	defer cancel()
+
+	return wr.db.GetRetailerSettlementWalletTransactionsQuery(
+		ctx,
+		retailerID,
+		limit,
+		offset,
+	)
+}
+
+func (wr *walletTransactionRepository) GetRetailerSettlementWalletBalance(
+	c echo.Context,
+) (*models.GetWalletBalanceResponseModel, error) {
+
+	retailerID, err := ownRetailerID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*10)
+	defer cancel()
+
+	return wr.db.GetRetailerSettlementWalletBalanceQuery(ctx, retailerID)
+}
+
+func (wr *walletTransactionRepository) PlaceWalletHold(
+	c echo.Context,
+) (int64, error) {
+
+	var req models.CreateWalletHoldRequestModel
+	if err := bindAndValidate(c, &req); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*10)
+	defer cancel()
+
+	return wr.db.PlaceWalletHoldQuery(ctx, req, holdActor(c))
+}
+
+func (wr *walletTransactionRepository) ReleaseWalletHold(
+	c echo.Context,
+) error {
+
+	holdID, err := parseInt64Param(c, "hold_id")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*10)
+	defer cancel()
+
+	return wr.db.ReleaseWalletHoldQuery(ctx, holdID, holdActor(c))
+}
+
+func (wr *walletTransactionRepository) GetWalletHolds(
+	c echo.Context,
+) ([]models.WalletHoldModel, error) {
+
+	userID := c.Param("user_id")
+	limit, offset := parsePagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Second*10)
+	defer cancel()
+
+	return wr.db.GetWalletHoldsQuery(ctx, userID, limit, offset)
+}
+
+// holdActor is the admin placing or releasing a hold.
+func holdActor(c echo.Context) string {
+	if claims, ok := c.Get("user").(*models.AccessTokenClaims); ok && claims.AdminID != "" {
+		return claims.AdminID
+	}
+	return "ADMIN"
+}
@@ -6,10 +6,11 @@ import (
 	"github.com/levion-studio/paybazaar/internal/middlewares"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
 )
 
-func (r *routes) AdminRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
-	adminRepo := repositories.NewAdminRepository(db, jwtUtils)
+func (r *routes) AdminRoutes(db *database.Database, jwtUtils *pkg.JwtUtils, rechargeKit *rechargekit.Client) {
+	adminRepo := repositories.NewAdminRepository(db, jwtUtils, rechargeKit)
 	adminHandler := handlers.NewAdminHandler(adminRepo)
 
 	r.Router.POST("/admin/login", adminHandler.AdminLoginRequest)
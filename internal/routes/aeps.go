@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/handlers"
+	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+	"github.com/levion-studio/paybazaar/pkg"
+)
+
+func (r *routes) AEPSRoutes(db *database.Database, jutUtils *pkg.JwtUtils, providerRouter *providers.Router) {
+	aepsRepo := repositories.NewAEPSRepository(db, providerRouter)
+	aepsHandler := handlers.NewAEPSHandler(aepsRepo)
+	settlementRepo := repositories.NewAEPSSettlementRepository(db, providerRouter)
+	settlementHandler := handlers.NewAEPSSettlementHandler(settlementRepo)
+
+	arg := r.Router.Group("/aeps", middlewares.AuthorizationMiddleware(jutUtils))
+	arg.POST("/withdraw", aepsHandler.CashWithdrawalRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	arg.POST("/aadhaar/pay", aepsHandler.AadhaarPayRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	arg.POST("/balance", aepsHandler.BalanceEnquiryRequest, middlewares.RequireRoles("retailer"))
+	arg.POST("/statement", aepsHandler.MiniStatementRequest, middlewares.RequireRoles("retailer"))
+	arg.GET("/get/transactions", aepsHandler.GetAllAEPSTransactionsRequest, middlewares.RequireRoles("admin"))
+	arg.GET("/get/transactions/:retailer_id", aepsHandler.GetAEPSTransactionsByRetailerIDRequest, middlewares.RequireRoles("admin", "retailer"))
+	arg.GET("/get/settlement/settings", settlementHandler.GetAEPSSettlementSettingsRequest, middlewares.RequireRoles("admin"))
+	arg.PUT("/update/settlement/settings", settlementHandler.UpdateAEPSSettlementSettingsRequest, middlewares.RequireRoles("admin"))
+	arg.POST("/settlement/account/create", settlementHandler.CreateSettlementAccountRequest, middlewares.RequireRoles("retailer"))
+	arg.GET("/settlement/account/get/:retailer_id", settlementHandler.GetSettlementAccountsRequest, middlewares.RequireRoles("admin", "retailer"))
+	arg.PUT("/settlement/account/verify/:settlement_account_id", settlementHandler.VerifySettlementAccountRequest, middlewares.RequireRoles("admin"))
+	arg.DELETE("/settlement/account/delete/:settlement_account_id", settlementHandler.DeleteSettlementAccountRequest, middlewares.RequireRoles("retailer"))
+	arg.POST("/settlement/wallet", settlementHandler.SettleToWalletRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	arg.POST("/settlement/bank", settlementHandler.RequestBankSettlementRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	arg.PUT("/settlement/approve/:settlement_id", settlementHandler.ApproveBankSettlementRequest, middlewares.RequireRoles("admin"))
+	arg.PUT("/settlement/reject/:settlement_id", settlementHandler.RejectBankSettlementRequest, middlewares.RequireRoles("admin"))
+	arg.GET("/settlement/get", settlementHandler.GetAllAEPSSettlementsRequest, middlewares.RequireRoles("admin"))
+	arg.GET("/settlement/get/:retailer_id", settlementHandler.GetAEPSSettlementsByRetailerIDRequest, middlewares.RequireRoles("admin", "retailer"))
+}
@@ -4,25 +4,25 @@ import (
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/handlers"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
 )
 
-func (r *routes) BBPSRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
-	bbpsRepo := repositories.NewBBPSRepository(db)
+func (r *routes) BBPSRoutes(db *database.Database, jwtUtils *pkg.JwtUtils, providerRouter *providers.Router) {
+	bbpsRepo := repositories.NewBBPSRepository(db, providerRouter)
 	bbpsHandler := handlers.NewBBPSHandler(bbpsRepo)
 
 	bbpsrg := r.Router.Group("/bbps", middlewares.AuthorizationMiddleware(jwtUtils))
 
-	bbpsrg.POST("/create/postpaid", bbpsHandler.CreatePostpaidMobileRechargeRequest, middlewares.RequireRoles("retailer"))
-	bbpsrg.POST("/get/postpaid/balance", bbpsHandler.GetPostpaidMobileRechargeBalanceRequest, middlewares.RequireRoles("retailer"))
-	bbpsrg.GET("/recharge/get/all", bbpsHandler.GetAllPostpaidMobileRechargeRequest, middlewares.RequireRoles("admin"))
-	bbpsrg.GET("/recharge/get/:retailer_id", bbpsHandler.GetPostpaidMobileRechargeByRetailerIDRequest)
-	bbpsrg.POST("/create/electricity", bbpsHandler.CreateElectricityBillPaymentRequest, middlewares.RequireRoles("retailer"))
-	bbpsrg.GET("/get/electricity/operators", bbpsHandler.GetAllElectricityBillOperatorsRequest, middlewares.RequireRoles("retailer"))
-	bbpsrg.POST("/get/electricity/balance", bbpsHandler.GetElectricityBillBalanceRequest, middlewares.RequireRoles("retailer"))
-	bbpsrg.GET("/get/all/electricity/transactions", bbpsHandler.GetAllElectricityBillHistoryRequest, middlewares.RequireRoles("admin"))
-	bbpsrg.GET("/get/electricity/transactions/:retailer_id", bbpsHandler.GetElectricityBillHistoryByRetailerIDRequest, middlewares.RequireRoles("retailer", "admin"))
-	bbpsrg.PUT("/electricity/transaction/refund/:transaction_id", bbpsHandler.ElectricityBillPaymentRefundRequest, middlewares.RequireRoles("admin"))
-	bbpsrg.PUT("/postpaid/recharge/transaction/refund/:transaction_id", bbpsHandler.MobileRechargePostpaidRefundRequest, middlewares.RequireRoles("admin"))
+	bbpsrg.GET("/get/categories", bbpsHandler.GetBBPSCategoriesRequest, middlewares.RequireRoles("admin", "retailer"))
+	bbpsrg.GET("/get/billers/:category_code", bbpsHandler.GetBBPSBillersRequest, middlewares.RequireRoles("admin", "retailer"))
+	bbpsrg.GET("/get/biller/:biller_id", bbpsHandler.GetBBPSBillerRequest, middlewares.RequireRoles("admin", "retailer"))
+	bbpsrg.POST("/create/biller", bbpsHandler.CreateBBPSBillerRequest, middlewares.RequireRoles("admin"))
+	bbpsrg.PUT("/update/biller/:biller_id", bbpsHandler.UpdateBBPSBillerRequest, middlewares.RequireRoles("admin"))
+	bbpsrg.POST("/fetch", bbpsHandler.FetchBillRequest, middlewares.RequireRoles("retailer"))
+	bbpsrg.POST("/pay", bbpsHandler.PayBillRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	bbpsrg.GET("/get/transactions", bbpsHandler.GetAllBBPSTransactionsRequest, middlewares.RequireRoles("admin"))
+	bbpsrg.GET("/get/transactions/:retailer_id", bbpsHandler.GetBBPSTransactionsByRetailerIDRequest, middlewares.RequireRoles("admin", "retailer"))
+	bbpsrg.PUT("/transaction/refund/:transaction_id", bbpsHandler.BBPSTransactionRefundRequest, middlewares.RequireRoles("admin"))
 }
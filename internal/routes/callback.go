@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/levion-studio/paybazaar/internal/config"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/handlers"
+	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+)
+
+func (r *routes) CallbackRoutes(db *database.Database, rechargeKit *config.RechargeKitConfig) {
+	callbackRepo := repositories.NewCallbackRepository(db)
+	callbackHandler := handlers.NewCallbackHandler(callbackRepo)
+
+	crg := r.Router.Group("/callback", middlewares.RechargeKitCallbackMiddleware(rechargeKit))
+	crg.GET("/rechargekit", callbackHandler.RechargeKitCallbackRequest)
+	crg.POST("/rechargekit", callbackHandler.RechargeKitCallbackRequest)
+}
@@ -19,6 +19,29 @@ func (r *routes) CommisionRoutes(db *database.Database, jwtUtils *pkg.JwtUtils)
 	crg.GET("/get/commision/:user_id/:service", commisionHandler.GetCommisionByUserIDAndServiceRequest, middlewares.RequireRoles("admin"))
 	crg.GET("/get/commision/:user_id", commisionHandler.GetCommisionsByUserIDRequest, middlewares.RequireRoles("admin"))
 	crg.GET("/get/commisions/:commision_id", commisionHandler.GetCommisionDetailsByCommisionIDRequest, middlewares.RequireRoles("admin"))
+	crg.GET("/get/audit", commisionHandler.GetCommisionAuditRequest, middlewares.RequireRoles("admin"))
 	crg.GET("/get/tds/:user_id", commisionHandler.GetTDSCommisionByUserIDRequest, middlewares.RequireRoles("admin", "retailer" , "master_distributor" , "distributor"))
 	crg.GET("/get/tds", commisionHandler.GetAllTDSCommisionRequest, middlewares.RequireRoles("admin"))
+	crg.GET("/get/tds/settings", commisionHandler.GetTDSSettingsRequest, middlewares.RequireRoles("admin"))
+	crg.PUT("/update/tds/settings", commisionHandler.UpdateTDSSettingsRequest, middlewares.RequireRoles("admin"))
+	crg.GET("/get/tds/summary", commisionHandler.GetTDSQuarterlySummaryRequest, middlewares.RequireRoles("admin", "retailer", "master_distributor", "distributor"))
+	crg.GET("/get/settlement/settings", commisionHandler.GetCommisionSettlementSettingsRequest, middlewares.RequireRoles("admin"))
+	crg.PUT("/update/settlement/settings", commisionHandler.UpdateCommisionSettlementSettingsRequest, middlewares.RequireRoles("admin"))
+	crg.GET("/get/pending", commisionHandler.GetPendingCommisionsRequest, middlewares.RequireRoles("admin", "master_distributor", "distributor"))
+	crg.POST("/release", commisionHandler.ReleaseDueCommisionsRequest, middlewares.RequireRoles("admin"))
+	crg.POST("/create/slab", commisionHandler.CreateCommisionSlabRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.GET("/get/slabs", commisionHandler.GetCommisionSlabsRequest, middlewares.RequireRoles("admin"))
+	crg.GET("/get/slab/:slab_id", commisionHandler.GetCommisionSlabByIDRequest, middlewares.RequireRoles("admin"))
+	crg.PUT("/update/slab", commisionHandler.UpdateCommisionSlabRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.DELETE("/delete/slab/:slab_id", commisionHandler.DeleteCommisionSlabRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.POST("/create/package", commisionHandler.CreateCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.GET("/get/packages", commisionHandler.GetCommisionPackagesRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.GET("/get/package/:package_id", commisionHandler.GetCommisionPackageByIDRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.PUT("/update/package", commisionHandler.UpdateCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.DELETE("/delete/package/:package_id", commisionHandler.DeleteCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.POST("/assign/package", commisionHandler.AssignCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.POST("/unassign/package", commisionHandler.UnassignCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.POST("/reassign/package", commisionHandler.ReassignCommisionPackageRequest, middlewares.RequireRoles("admin", "master_distributor"))
+	crg.GET("/get/effective/:user_id", commisionHandler.GetEffectiveCommisionRequest, middlewares.RequireRoles("admin", "retailer", "master_distributor", "distributor"))
+	crg.GET("/get/quote", commisionHandler.GetTransactionQuoteRequest, middlewares.RequireRoles("admin", "retailer"))
 }
@@ -4,12 +4,13 @@ import (
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/handlers"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
 )
 
-func (r *routes) DMTRoutes(db *database.Database, jutUtils *pkg.JwtUtils) {
-	dmtRepo := repositories.NewDMTRepository(db)
+func (r *routes) DMTRoutes(db *database.Database, jutUtils *pkg.JwtUtils, providerRouter *providers.Router) {
+	dmtRepo := repositories.NewDMTRepository(db, providerRouter)
 	dmtHandler := handlers.NewDMTHandler(dmtRepo)
 
 	drg := r.Router.Group("/dmt", middlewares.AuthorizationMiddleware(jutUtils))
@@ -17,6 +18,14 @@ func (r *routes) DMTRoutes(db *database.Database, jutUtils *pkg.JwtUtils) {
 	drg.POST("/create/wallet", dmtHandler.CreateDMTWalletRequest, middlewares.RequireRoles("retailer"))
 	drg.POST("/verify/wallet", dmtHandler.VerifyDMTWalletRequest, middlewares.RequireRoles("retailer"))
 	drg.POST("/add/beneficiary", dmtHandler.AddDMTBeneficiaryRequest, middlewares.RequireRoles("retailer"))
+	drg.POST("/verify/beneficiary", dmtHandler.VerifyDMTBeneficiaryRequest, middlewares.RequireRoles("retailer"))
 	drg.GET("/get/banks", dmtHandler.GetDMTBankListRequest, middlewares.RequireRoles("retailer"))
 	drg.POST("/get/beneficiary", dmtHandler.GetDMTBeneficiariesRequest, middlewares.RequireRoles("retailer"))
+	drg.POST("/transfer/otp", dmtHandler.SendDMTTransferOTPRequest, middlewares.RequireRoles("retailer"))
+	drg.POST("/transfer", dmtHandler.CreateDMTTransactionRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
+	drg.GET("/get/transactions", dmtHandler.GetAllDMTTransactionsRequest, middlewares.RequireRoles("admin"))
+	drg.GET("/get/transactions/:retailer_id", dmtHandler.GetDMTTransactionsByRetailerIDRequest, middlewares.RequireRoles("admin", "retailer"))
+	drg.GET("/get/remitter/:mobile_no", dmtHandler.GetDMTRemitterRequest, middlewares.RequireRoles("admin", "retailer"))
+	drg.PUT("/update/remitter/:mobile_no", dmtHandler.UpdateDMTRemitterRequest, middlewares.RequireRoles("admin"))
+	drg.PUT("/refund/:transaction_id", dmtHandler.DMTRefundRequest, middlewares.RequireRoles("admin"))
 }
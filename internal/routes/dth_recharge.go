@@ -4,16 +4,17 @@ import (
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/handlers"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
 )
 
-func (r *routes) DTHRechargeRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
-	dthRechargeRepo := repositories.NewDTHRechargeRepository(db)
+func (r *routes) DTHRechargeRoutes(db *database.Database, jwtUtils *pkg.JwtUtils, providerRouter *providers.Router) {
+	dthRechargeRepo := repositories.NewDTHRechargeRepository(db, providerRouter)
 	dthRechargeHandler := handlers.NewDTHRechargeHandler(dthRechargeRepo)
 
 	mrrg := r.Router.Group("/dth_recharge", middlewares.AuthorizationMiddleware(jwtUtils))
-	mrrg.POST("/create", dthRechargeHandler.CreateDTHRechargeRequest, middlewares.RequireRoles("retailer"))
+	mrrg.POST("/create", dthRechargeHandler.CreateDTHRechargeRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
 	mrrg.GET("/get/operators", dthRechargeHandler.GetAllDTHOperatorsRequest, middlewares.RequireRoles("retailer", "admin"))
 	mrrg.GET("/get/admin", dthRechargeHandler.GetAllDTHRechargesRequest, middlewares.RequireRoles("admin"))
 	mrrg.GET("/get/:retailer_id", dthRechargeHandler.GetDTHRechargesByRetailerIDRequest, middlewares.RequireRoles("retailer", "admin"))
@@ -13,7 +13,7 @@ func (r *routes) FundTransferRoutes(db *database.Database, jwtUtils *pkg.JwtUtil
 	fundTransferHandler := handlers.NewFundTransferHandler(fundTransferRepo)
 
 	ftr := r.Router.Group("/fund_transfer", middlewares.AuthorizationMiddleware(jwtUtils))
-	ftr.POST("/create", fundTransferHandler.CreateFundTransfer)
+	ftr.POST("/create", fundTransferHandler.CreateFundTransfer, middlewares.IdempotencyMiddleware(db))
 	ftr.GET("/from", fundTransferHandler.GetFundTransfersByFromID)
 	ftr.GET("/to", fundTransferHandler.GetFundTransfersByToID)
 }
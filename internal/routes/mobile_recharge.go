@@ -4,16 +4,17 @@ import (
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/handlers"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
 )
 
-func (r *routes) MobileRechargeRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
-	mobileRechargeRepo := repositories.NewMobileRechargeRepository(db)
+func (r *routes) MobileRechargeRoutes(db *database.Database, jwtUtils *pkg.JwtUtils, providerRouter *providers.Router) {
+	mobileRechargeRepo := repositories.NewMobileRechargeRepository(db, providerRouter)
 	mobileRechargeHandler := handlers.NewMobileRechargeHandler(mobileRechargeRepo)
 
 	mrrg := r.Router.Group("/mobile_recharge", middlewares.AuthorizationMiddleware(jwtUtils))
-	mrrg.POST("/create", mobileRechargeHandler.CreateMobileRechargeRequest, middlewares.RequireRoles("retailer"))
+	mrrg.POST("/create", mobileRechargeHandler.CreateMobileRechargeRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
 	mrrg.GET("/get/operators", mobileRechargeHandler.GetMobileRechargeOperatorsRequest, middlewares.RequireRoles("retailer", "admin"))
 	mrrg.GET("/get/circle", mobileRechargeHandler.GetMobileRechargeCirclesRequest, middlewares.RequireRoles("retailer", "admin"))
 	mrrg.GET("/get/admin", mobileRechargeHandler.GetAllMobileRechargesRequest, middlewares.RequireRoles("admin"))
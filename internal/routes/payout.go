@@ -4,6 +4,7 @@ import (
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/handlers"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/internal/repositories"
 	"github.com/levion-studio/paybazaar/pkg"
 )
@@ -11,15 +12,16 @@ import (
 func (r *routes) PayoutRoutes(
 	db *database.Database,
 	jwtUtils *pkg.JwtUtils,
+	providerRouter *providers.Router,
 ) {
 
-	payoutRepo := repositories.NewPayoutRepository(db)
+	payoutRepo := repositories.NewPayoutRepository(db, providerRouter)
 	payoutHandler := handlers.NewPayoutHandler(payoutRepo)
 	pr := r.Router.Group(
 		"/payout",
 		middlewares.AuthorizationMiddleware(jwtUtils),
 	)
-	pr.POST("/create", payoutHandler.CreatePayoutRequest, middlewares.RequireRoles("retailer"))
+	pr.POST("/create", payoutHandler.CreatePayoutRequest, middlewares.RequireRoles("retailer"), middlewares.IdempotencyMiddleware(db))
 	pr.GET("/get/all", payoutHandler.GetAllPayoutTransactionsRequest, middlewares.RequireRoles("admin"))
 	pr.GET("/get/:retailer_id", payoutHandler.GetPayoutTransactionsByRetailerIdRequest, middlewares.RequireRoles("retailer", "admin"))
 	pr.PUT("/refund/:transaction_id", payoutHandler.PayoutRefundRequest, middlewares.RequireRoles("admin"))
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/handlers"
+	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+	"github.com/levion-studio/paybazaar/pkg"
+)
+
+func (r *routes) ProviderRouteRoutes(db *database.Database, jwtUtils *pkg.JwtUtils, registry *providers.Registry) {
+	providerRouteRepo := repositories.NewProviderRouteRepository(db, registry)
+	providerRouteHandler := handlers.NewProviderRouteHandler(providerRouteRepo)
+
+	prg := r.Router.Group("/provider", middlewares.AuthorizationMiddleware(jwtUtils))
+	prg.GET("/get/all", providerRouteHandler.GetProvidersRequest, middlewares.RequireRoles("admin"))
+	prg.POST("/route/create", providerRouteHandler.CreateProviderRouteRequest, middlewares.RequireRoles("admin"))
+	prg.PUT("/route/update", providerRouteHandler.UpdateProviderRouteRequest, middlewares.RequireRoles("admin"))
+	prg.DELETE("/route/delete/:route_id", providerRouteHandler.DeleteProviderRouteRequest, middlewares.RequireRoles("admin"))
+	prg.GET("/route/get/all", providerRouteHandler.GetAllProviderRoutesRequest, middlewares.RequireRoles("admin"))
+}
@@ -0,0 +1,264 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/levion-studio/paybazaar/internal/config"
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/dbtest"
+	"github.com/levion-studio/paybazaar/internal/ledger"
+	"github.com/levion-studio/paybazaar/internal/models"
+	"github.com/levion-studio/paybazaar/internal/providers"
+	"github.com/levion-studio/paybazaar/pkg"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
+)
+
+// The tests in this file run the whole reserve, send and settle flow, and
+// provider callbacks, through the router against the fake RechargeKit. They
+// need a PostgreSQL database (see package dbtest).
+
+const testCallbackSecret = "test-callback-secret"
+
+type flowEnv struct {
+	t          *testing.T
+	conn       *pgx.Conn
+	router     *routes
+	fake       *rechargekit.FakeServer
+	retailerID string
+	token      string
+}
+
+func newFlowEnv(t *testing.T) *flowEnv {
+	t.Helper()
+	ctx := context.Background()
+	databaseURL, conn := dbtest.Open(t)
+
+	db, err := database.NewDatabaseConnection(database.Config{DatabaseURL: databaseURL})
+	if err != nil {
+		t.Fatalf("database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	fake := rechargekit.NewFakeServer()
+	t.Cleanup(fake.Close)
+
+	jwtUtils := pkg.NewJwtUtils(pkg.JwtConfig{SecretKey: "test-secret", Expiry: time.Hour})
+	router := NewRoutes(Config{
+		ServerENV:         "test",
+		JWTUtils:          jwtUtils,
+		Database:          db,
+		RechargeKit:       &config.RechargeKitConfig{CallbackSecret: testCallbackSecret},
+		RechargeKitClient: fake.Client(),
+		ProviderRouter:    providers.NewRouter(db, providers.NewRegistry(providers.NewRechargeKit(fake.Client()))),
+	})
+
+	env := &flowEnv{t: t, conn: conn, router: router, fake: fake}
+	env.retailerID = dbtest.Seed(t, conn).RetailerID
+	dbtest.Fund(t, conn, env.retailerID, models.Rupees(1000))
+	env.token, err = jwtUtils.GenerateToken(ctx, models.AccessTokenClaims{
+		UserID:   env.retailerID,
+		UserName: "Test Retailer",
+		UserRole: "retailer",
+	})
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	return env
+}
+
+func (env *flowEnv) do(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	env.router.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (env *flowEnv) recharge(amount models.Money) (partnerRequestID string, code int) {
+	body, _ := json.Marshal(models.CreateMobileRechargeRequestModel{
+		RetailerID:   env.retailerID,
+		MobileNumber: 9876543210,
+		OperatorCode: 1,
+		OperatorName: "Test Operator",
+		Amount:       amount,
+		CircleCode:   5,
+		CircleName:   "Test Circle",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/mobile_recharge/create", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+env.token)
+	rec := env.do(req)
+
+	query := `
+		SELECT partner_request_id
+		FROM mobile_recharge
+		WHERE retailer_id = @retailer_id
+		ORDER BY mobile_recharge_transaction_id DESC
+		LIMIT 1;
+	`
+	if err := env.conn.QueryRow(context.Background(), query, pgx.NamedArgs{
+		"retailer_id": env.retailerID,
+	}).Scan(&partnerRequestID); err != nil {
+		env.t.Fatalf("recharge was not recorded (status %d, %s): %v", rec.Code, rec.Body, err)
+	}
+	return partnerRequestID, rec.Code
+}
+
+// callback posts the fake's current callback for a transaction and returns
+// what the server did with it.
+func (env *flowEnv) callback(partnerRequestID string) string {
+	body, _ := json.Marshal(env.fake.Callback(partnerRequestID))
+	req := httptest.NewRequest(http.MethodPost, "/callback/rechargekit", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Callback-Token", testCallbackSecret)
+	rec := env.do(req)
+	if rec.Code != http.StatusOK {
+		env.t.Fatalf("callback for %s: status %d, %s", partnerRequestID, rec.Code, rec.Body)
+	}
+	var res struct {
+		Data struct {
+			Result string `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		env.t.Fatalf("callback response: %v", err)
+	}
+	return res.Data.Result
+}
+
+func (env *flowEnv) status(partnerRequestID string) string {
+	var status string
+	if err := env.conn.QueryRow(context.Background(), `
+		SELECT status FROM mobile_recharge WHERE partner_request_id = @partner_request_id;
+	`, pgx.NamedArgs{"partner_request_id": partnerRequestID}).Scan(&status); err != nil {
+		env.t.Fatalf("status of %s: %v", partnerRequestID, err)
+	}
+	return status
+}
+
+func (env *flowEnv) balance() models.Money {
+	return dbtest.Balance(env.t, env.conn, env.retailerID)
+}
+
+func (env *flowEnv) systemBalance(account ledger.Account) models.Money {
+	return dbtest.SystemBalance(env.t, env.conn, account.ID)
+}
+
+func (env *flowEnv) expect(partnerRequestID, status string, balance models.Money) {
+	env.t.Helper()
+	if got := env.status(partnerRequestID); got != status {
+		env.t.Fatalf("status of %s = %s, want %s", partnerRequestID, got, status)
+	}
+	if got := env.balance(); got != balance {
+		env.t.Fatalf("retailer balance = %s, want %s", got, balance)
+	}
+	if got := env.systemBalance(ledger.Suspense); got != 0 && status != "PENDING" {
+		env.t.Fatalf("suspense balance = %s, want 0 once settled", got)
+	}
+}
+
+func TestRechargeSettledOnSuccess(t *testing.T) {
+	env := newFlowEnv(t)
+
+	id, code := env.recharge(models.Rupees(99))
+	if code != http.StatusOK {
+		t.Fatalf("recharge status = %d, want %d", code, http.StatusOK)
+	}
+	env.expect(id, "SUCCESS", models.Rupees(901))
+	if got := env.systemBalance(ledger.ProviderFloat); got != models.Rupees(99) {
+		t.Fatalf("provider float = %s, want 99.00", got)
+	}
+}
+
+func TestRechargeRefundedWhenDeclined(t *testing.T) {
+	env := newFlowEnv(t)
+	env.fake.SetResponseStatus(rechargekit.StatusFailed)
+
+	id, code := env.recharge(models.Rupees(99))
+	if code != http.StatusBadRequest {
+		t.Fatalf("recharge status = %d, want %d", code, http.StatusBadRequest)
+	}
+	env.expect(id, "FAILED", models.Rupees(1000))
+}
+
+func TestRechargeStaysPendingWhenOutcomeUnknown(t *testing.T) {
+	env := newFlowEnv(t)
+	env.fake.SetResponseStatus(rechargekit.Status(0))
+
+	id, code := env.recharge(models.Rupees(99))
+	if code != http.StatusOK {
+		t.Fatalf("recharge status = %d, want %d", code, http.StatusOK)
+	}
+	env.expect(id, "PENDING", models.Rupees(901))
+	if got := env.systemBalance(ledger.Suspense); got != models.Rupees(99) {
+		t.Fatalf("suspense balance = %s, want 99.00 while the outcome is unknown", got)
+	}
+
+	var attempts int
+	if err := env.conn.QueryRow(context.Background(), `
+		SELECT s.attempts
+		FROM status_checks s
+		JOIN mobile_recharge m
+			ON m.mobile_recharge_transaction_id::TEXT = s.transaction_id
+		WHERE s.service = 'MOBILE_RECHARGE'
+		AND m.partner_request_id = @partner_request_id;
+	`, pgx.NamedArgs{"partner_request_id": id}).Scan(&attempts); err != nil {
+		t.Fatalf("status check was not queued: %v", err)
+	}
+
+	env.fake.SetTransactionStatus(id, rechargekit.StatusSuccess)
+	if got := env.callback(id); got != "SUCCESS" {
+		t.Fatalf("callback result = %s, want SUCCESS", got)
+	}
+	env.expect(id, "SUCCESS", models.Rupees(901))
+}
+
+func TestPendingRechargeSettledByCallback(t *testing.T) {
+	env := newFlowEnv(t)
+	env.fake.SetResponseStatus(rechargekit.StatusPending)
+
+	succeeded, _ := env.recharge(models.Rupees(90))
+	failed, _ := env.recharge(models.Rupees(50))
+	env.expect(failed, "PENDING", models.Rupees(860))
+	if got := env.systemBalance(ledger.Suspense); got != models.Rupees(140) {
+		t.Fatalf("suspense balance = %s, want 140.00 while pending", got)
+	}
+
+	env.fake.SetTransactionStatus(succeeded, rechargekit.StatusSuccess)
+	if got := env.callback(succeeded); got != "SUCCESS" {
+		t.Fatalf("callback result = %s, want SUCCESS", got)
+	}
+	env.fake.SetTransactionStatus(failed, rechargekit.StatusFailed)
+	if got := env.callback(failed); got != "FAILED" {
+		t.Fatalf("callback result = %s, want FAILED", got)
+	}
+	env.expect(succeeded, "SUCCESS", models.Rupees(910))
+	env.expect(failed, "FAILED", models.Rupees(910))
+
+	// Callbacks are retried; a repeat must not move money again.
+	if got := env.callback(failed); got != "IGNORED" {
+		t.Fatalf("repeated callback result = %s, want IGNORED", got)
+	}
+	env.expect(failed, "FAILED", models.Rupees(910))
+}
+
+func TestReversalCallbackRefundsSuccess(t *testing.T) {
+	env := newFlowEnv(t)
+
+	id, _ := env.recharge(models.Rupees(99))
+	env.expect(id, "SUCCESS", models.Rupees(901))
+
+	env.fake.SetTransactionStatus(id, rechargekit.StatusFailed)
+	if got := env.callback(id); got != "REFUND" {
+		t.Fatalf("reversal callback result = %s, want REFUND", got)
+	}
+	env.expect(id, "REFUND", models.Rupees(1000))
+	if got := env.systemBalance(ledger.ProviderFloat); got != 0 {
+		t.Fatalf("provider float = %s, want 0 after the reversal", got)
+	}
+}
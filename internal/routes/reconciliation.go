@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/handlers"
+	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+	"github.com/levion-studio/paybazaar/pkg"
+)
+
+func (r *routes) ReconciliationRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
+	reconciliationRepo := repositories.NewReconciliationRepository(db)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationRepo)
+
+	rrg := r.Router.Group("/reconciliation", middlewares.AuthorizationMiddleware(jwtUtils))
+	rrg.POST("/run", reconciliationHandler.RunReconciliationRequest, middlewares.RequireRoles("admin"))
+	rrg.GET("/get/runs", reconciliationHandler.GetReconciliationRunsRequest, middlewares.RequireRoles("admin"))
+	rrg.GET("/get/breaks/:run_id", reconciliationHandler.GetReconciliationBreaksRequest, middlewares.RequireRoles("admin"))
+}
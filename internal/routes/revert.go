@@ -13,7 +13,7 @@ func (r *routes) RevertRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
 	revertHandler := handlers.NewRevertHandler(revertRepo)
 
 	rrg := r.Router.Group("/revert", middlewares.AuthorizationMiddleware(jwtUtils))
-	rrg.POST("/create", revertHandler.CreateRevertRequest)
+	rrg.POST("/create", revertHandler.CreateRevertRequest, middlewares.IdempotencyMiddleware(db))
 	rrg.POST("/get/revert/from", revertHandler.GetRevertsByFromID)
 	rrg.POST("/get/revert/on", revertHandler.GetRevertsByOnID)
 }
@@ -2,13 +2,16 @@ package routes
 
 import (
 	"log"
+	"net"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/levion-studio/paybazaar/internal/config"
 	"github.com/levion-studio/paybazaar/internal/database"
 	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/providers"
 	"github.com/levion-studio/paybazaar/pkg"
+	"github.com/levion-studio/paybazaar/pkg/rechargekit"
 )
 
 type routes struct {
@@ -20,6 +23,14 @@ type Config struct {
 	JWTUtils    *pkg.JwtUtils
 	Database    *database.Database
 	RechargeKit *config.RechargeKitConfig
+	// RechargeKitClient serves the admin RechargeKit balance lookups.
+	RechargeKitClient *rechargekit.Client
+	// ProviderRouter picks the provider for recharges, bill payments,
+	// payouts and DMT.
+	ProviderRouter *providers.Router
+	// TrustedProxies are the CIDR ranges whose X-Forwarded-For header is
+	// believed when resolving the client IP.
+	TrustedProxies []string
 }
 
 func NewRoutes(cfg Config) *routes {
@@ -27,6 +38,7 @@ func NewRoutes(cfg Config) *routes {
 	log.Printf("server is running in %s mode", cfg.ServerENV)
 
 	router.Validator = NewValidator()
+	router.IPExtractor = ipExtractor(cfg.TrustedProxies)
 	// Common Middlewares
 	router.Use(middleware.CORS())
 	router.Use(middlewares.APILockMiddleware())
@@ -36,7 +48,7 @@ func NewRoutes(cfg Config) *routes {
 	}
 
 	// Routes Functions
-	routes.AdminRoutes(cfg.Database, cfg.JWTUtils)
+	routes.AdminRoutes(cfg.Database, cfg.JWTUtils, cfg.RechargeKitClient)
 	routes.DistributorRoutes(cfg.Database, cfg.JWTUtils)
 	routes.FundRequestRoutes(cfg.Database, cfg.JWTUtils)
 	routes.MasterDistributorRoutes(cfg.Database, cfg.JWTUtils)
@@ -47,13 +59,42 @@ func NewRoutes(cfg Config) *routes {
 	routes.CommisionRoutes(cfg.Database, cfg.JWTUtils)
 	routes.TicketRoutes(cfg.Database, cfg.JWTUtils)
 	routes.FundTransferRoutes(cfg.Database, cfg.JWTUtils)
-	routes.PayoutRoutes(cfg.Database, cfg.JWTUtils)
+	routes.PayoutRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
 	routes.PayoutBeneficiaryRoutes(cfg.Database, cfg.JWTUtils)
-	routes.MobileRechargeRoutes(cfg.Database, cfg.JWTUtils)
-	routes.DTHRechargeRoutes(cfg.Database, cfg.JWTUtils)
-	routes.BBPSRoutes(cfg.Database, cfg.JWTUtils)
-	routes.DMTRoutes(cfg.Database, cfg.JWTUtils)
+	routes.MobileRechargeRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
+	routes.DTHRechargeRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
+	routes.BBPSRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
+	routes.DMTRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
+	routes.AEPSRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter)
 	routes.LimitRoutes(cfg.Database , cfg.JWTUtils)
+	routes.ReconciliationRoutes(cfg.Database, cfg.JWTUtils)
+	routes.CallbackRoutes(cfg.Database, cfg.RechargeKit)
+	routes.ProviderRouteRoutes(cfg.Database, cfg.JWTUtils, cfg.ProviderRouter.Registry())
+	routes.TransactionStatusRoutes(cfg.Database, cfg.JWTUtils)
 
 	return routes
 }
+
+// ipExtractor resolves c.RealIP(). Without trusted proxies the peer address
+// is used as is, so forwarding headers cannot be used to spoof the callback
+// allowlist. Behind a load balancer X-Forwarded-For is only followed
+// through the configured proxy ranges.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("ignoring invalid trusted proxy range %q", cidr)
+			continue
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	if len(options) == 3 {
+		return echo.ExtractIPDirect()
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
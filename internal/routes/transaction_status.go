@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/levion-studio/paybazaar/internal/database"
+	"github.com/levion-studio/paybazaar/internal/handlers"
+	"github.com/levion-studio/paybazaar/internal/middlewares"
+	"github.com/levion-studio/paybazaar/internal/repositories"
+	"github.com/levion-studio/paybazaar/pkg"
+)
+
+func (r *routes) TransactionStatusRoutes(db *database.Database, jwtUtils *pkg.JwtUtils) {
+	transactionStatusRepo := repositories.NewTransactionStatusRepository(db)
+	transactionStatusHandler := handlers.NewTransactionStatusHandler(transactionStatusRepo)
+
+	tsrg := r.Router.Group("/transaction", middlewares.AuthorizationMiddleware(jwtUtils))
+	tsrg.GET("/status/history/:service/:transaction_id", transactionStatusHandler.GetTransactionStatusHistoryRequest, middlewares.RequireRoles("admin"))
+	tsrg.GET("/refund/get/:service/:transaction_id", transactionStatusHandler.GetTransactionRefundsRequest, middlewares.RequireRoles("admin"))
+}
@@ -1,9 +1,11 @@
 package routes
 
 import (
+	"reflect"
 	"regexp"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/levion-studio/paybazaar/internal/models"
 )
 
 type CustomValidator struct {
@@ -19,6 +21,10 @@ func NewValidator() *CustomValidator {
 	v.RegisterValidation("aadhar", AadharNumber)
 	v.RegisterValidation("pan", PanNumber)
 
+	// money and rates are validated in rupees / as decimals so that tags
+	// like min=1 keep meaning one rupee rather than one paisa
+	v.RegisterCustomTypeFunc(decimalValue, models.Money(0), models.Rate(0))
+
 	return &CustomValidator{
 		validator: v,
 	}
@@ -28,6 +34,16 @@ func (cv *CustomValidator) Validate(i any) error {
 	return cv.validator.Struct(i)
 }
 
+func decimalValue(field reflect.Value) any {
+	switch v := field.Interface().(type) {
+	case models.Money:
+		return v.Float64()
+	case models.Rate:
+		return v.Float64()
+	}
+	return nil
+}
+
 // Strong password validator function
 func strongPassword(fl validator.FieldLevel) bool {
 	password := fl.Field().String()
@@ -13,7 +13,6 @@ func (r *routes) WalletTransactionRoutes(db *database.Database, jwtUtils *pkg.Jw
 	walletHandler := handlers.NewWalletTransactionHandler(walletRepo)
 
 	wtr := r.Router.Group("/wallet", middlewares.AuthorizationMiddleware(jwtUtils))
-	wtr.POST("/create", walletHandler.CreateWalletTransactionRequest, middlewares.RequireRoles("admin", "master_distributor", "distributor", "retailer"))
 	wtr.GET("/get/balance/admin/:admin_id", walletHandler.GetAdminWalletBalanceRequest, middlewares.RequireRoles("admin"))
 	wtr.GET("/get/balance/md/:master_distributor_id", walletHandler.GetMasterDistributorWalletBalanceRequest, middlewares.RequireRoles("master_distributor"))
 	wtr.GET("/get/balance/distributor/:distributor_id", walletHandler.GetDistributorWalletBalanceRequest, middlewares.RequireRoles("distributor"))
@@ -22,4 +21,9 @@ func (r *routes) WalletTransactionRoutes(db *database.Database, jwtUtils *pkg.Jw
 	wtr.GET("/get/transactions/md/:master_distributor_id", walletHandler.GetMasterDistributorWalletTransactionsRequest, middlewares.RequireRoles("master_distributor"))
 	wtr.GET("/get/transactions/distributor/:distributor_id", walletHandler.GetDistributorWalletTransactionsRequest, middlewares.RequireRoles("distributor"))
 	wtr.GET("/get/transaction/retailer/:retailer_id", walletHandler.GetRetailerWalletTransactionsRequest, middlewares.RequireRoles("retailer"))
+	wtr.GET("/get/balance/retailer/settlement/:retailer_id", walletHandler.GetRetailerSettlementWalletBalanceRequest, middlewares.RequireRoles("retailer"))
+	wtr.GET("/get/transaction/retailer/settlement/:retailer_id", walletHandler.GetRetailerSettlementWalletTransactionsRequest, middlewares.RequireRoles("retailer"))
+	wtr.POST("/hold/create", walletHandler.PlaceWalletHoldRequest, middlewares.RequireRoles("admin"))
+	wtr.PUT("/hold/release/:hold_id", walletHandler.ReleaseWalletHoldRequest, middlewares.RequireRoles("admin"))
+	wtr.GET("/hold/get/:user_id", walletHandler.GetWalletHoldsRequest, middlewares.RequireRoles("admin"))
 }
@@ -0,0 +1,98 @@
+// Package txstate is the state machine shared by payouts, recharges and bill
+// payments. Every status change of a service transaction goes through
+// Transition, which rejects changes the machine does not allow and records
+// each one with its actor and reason in transaction_status_history.
+//
+//	INITIATED -> PENDING   sent to a provider
+//	INITIATED -> FAILED    never sent, funds returned
+//	PENDING   -> SUCCESS   completed by the provider
+//	PENDING   -> FAILED    declined by the provider, funds returned
+//	PENDING   -> REFUND    refunded by the provider or system while pending
+//	SUCCESS   -> REFUND    reversed by the provider or refunded by an admin
+//
+// FAILED and REFUND are final. Settling a transaction to the status it
+// already has changes nothing: a callback can settle a transaction before
+// the request that sent it does, and the request's own answer must then
+// not fail.
+package txstate
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	Initiated = "INITIATED"
+	Pending   = "PENDING"
+	Success   = "SUCCESS"
+	Failed    = "FAILED"
+	Refund    = "REFUND"
+)
+
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+const (
+	ActorSystem   = "SYSTEM"
+	ActorProvider = "PROVIDER"
+	ActorAdmin    = "ADMIN"
+	ActorRetailer = "RETAILER"
+)
+
+// Actor is who changed a transaction's status.
+type Actor struct {
+	Type string
+	ID   string
+}
+
+var System = Actor{Type: ActorSystem}
+
+func Provider(name string) Actor {
+	return Actor{Type: ActorProvider, ID: name}
+}
+
+func Admin(adminID string) Actor {
+	return Actor{Type: ActorAdmin, ID: adminID}
+}
+
+func Retailer(retailerID string) Actor {
+	return Actor{Type: ActorRetailer, ID: retailerID}
+}
+
+// transitions lists the statuses each status may move to, and who may move
+// it there; a nil actor list allows anyone.
+var transitions = map[string]map[string][]string{
+	Initiated: {
+		Pending: nil,
+		Failed:  nil,
+	},
+	Pending: {
+		Success: nil,
+		Failed:  nil,
+		// The provider may still complete a pending transaction, so an
+		// admin has to wait for its final status before refunding it.
+		Refund: {ActorProvider, ActorSystem},
+	},
+	Success: {
+		// The money has reached the customer: the provider reverses it,
+		// or an admin refunds the retailer in full or in part.
+		Refund: {ActorProvider, ActorAdmin},
+	},
+}
+
+// Check returns an error wrapping ErrIllegalTransition unless actor may
+// move a transaction from one status to the other.
+func Check(from, to string, actor Actor) error {
+	actors, ok := transitions[from][to]
+	if !ok {
+		return fmt.Errorf("%w: transaction is %s and cannot become %s", ErrIllegalTransition, from, to)
+	}
+	if actors == nil {
+		return nil
+	}
+	for _, a := range actors {
+		if a == actor.Type {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: a %s transaction cannot become %s by %s", ErrIllegalTransition, from, to, actor.Type)
+}
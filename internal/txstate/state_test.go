@@ -0,0 +1,39 @@
+package txstate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		from, to string
+		actor    Actor
+		allowed  bool
+	}{
+		{Initiated, Pending, System, true},
+		{Initiated, Failed, System, true},
+		{Initiated, Success, Provider("RECHARGEKIT"), false},
+		{Pending, Success, Provider("RECHARGEKIT"), true},
+		{Pending, Failed, Admin("A000001"), true},
+		{Pending, Refund, Provider("RECHARGEKIT"), true},
+		{Pending, Refund, System, true},
+		{Pending, Refund, Admin("A000001"), false},
+		{Pending, Refund, Retailer("R000001"), false},
+		{Success, Refund, Provider("RECHARGEKIT"), true},
+		{Success, Refund, Admin("A000001"), true},
+		{Success, Refund, Retailer("R000001"), false},
+		{Success, Failed, Provider("RECHARGEKIT"), false},
+		{Failed, Refund, System, false},
+		{Refund, Success, Provider("RECHARGEKIT"), false},
+	}
+	for _, tt := range tests {
+		err := Check(tt.from, tt.to, tt.actor)
+		if tt.allowed && err != nil {
+			t.Errorf("%s -> %s by %s: %v", tt.from, tt.to, tt.actor.Type, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("%s -> %s by %s: error = %v, want ErrIllegalTransition", tt.from, tt.to, tt.actor.Type, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package txstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Change is a status change of one service transaction.
+type Change struct {
+	Service       string
+	TransactionID string
+	From          string
+	To            string
+	Actor         Actor
+	Reason        string
+}
+
+// table is where a service keeps its transactions and their status.
+type table struct {
+	name         string
+	idColumn     string
+	idType       string
+	statusColumn string
+	hasUpdatedAt bool
+}
+
+var tables = map[string]table{
+	"PAYOUT":          {"payout_transactions", "payout_transaction_id", "UUID", "payout_transaction_status", true},
+	"MOBILE_RECHARGE": {"mobile_recharge", "mobile_recharge_transaction_id", "BIGINT", "status", false},
+	"DTH_RECHARGE":    {"dth_recharge", "dth_transaction_id", "BIGINT", "status", false},
+	"BBPS":            {"bbps_transactions", "bbps_transaction_id", "UUID", "transaction_status", true},
+	"DMT":             {"dmt_transactions", "dmt_transaction_id", "UUID", "transaction_status", true},
+	"AEPS":            {"aeps_transactions", "aeps_transaction_id", "UUID", "transaction_status", true},
+	"AEPS_SETTLEMENT": {"aeps_settlements", "aeps_settlement_id", "UUID", "transaction_status", true},
+}
+
+func lookupTable(service string) (table, error) {
+	t, ok := tables[service]
+	if !ok {
+		return table{}, fmt.Errorf("unknown service %s", service)
+	}
+	return t, nil
+}
+
+// Transition moves a transaction from c.From to c.To and records the
+// change. The caller is expected to hold the transaction's row lock; the
+// update still fails if the status is no longer c.From.
+func Transition(ctx context.Context, tx pgx.Tx, c Change) error {
+	if err := Check(c.From, c.To, c.Actor); err != nil {
+		return err
+	}
+	t, err := lookupTable(c.Service)
+	if err != nil {
+		return err
+	}
+
+	set := fmt.Sprintf("%s = @to", t.statusColumn)
+	if t.hasUpdatedAt {
+		set += ", updated_at = NOW()"
+	}
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET %s
+		WHERE %s = @transaction_id::%s
+		AND %s = @from;
+	`, t.name, set, t.idColumn, t.idType, t.statusColumn)
+
+	res, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"to":             c.To,
+		"from":           c.From,
+		"transaction_id": c.TransactionID,
+	})
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s transaction %s is no longer %s", c.Service, c.TransactionID, c.From)
+	}
+	return record(ctx, tx, c)
+}
+
+// Lock locks a transaction's row and returns its status.
+func Lock(ctx context.Context, tx pgx.Tx, service, transactionID string) (string, error) {
+	t, err := lookupTable(service)
+	if err != nil {
+		return "", err
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE %s = @transaction_id::%s
+		FOR UPDATE;
+	`, t.statusColumn, t.name, t.idColumn, t.idType)
+
+	var status string
+	if err := tx.QueryRow(ctx, query, pgx.NamedArgs{
+		"transaction_id": transactionID,
+	}).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("no %s transaction %s", service, transactionID)
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+// Created records the status a transaction was inserted with.
+func Created(ctx context.Context, tx pgx.Tx, service, transactionID, status string, actor Actor) error {
+	return record(ctx, tx, Change{
+		Service:       service,
+		TransactionID: transactionID,
+		To:            status,
+		Actor:         actor,
+		Reason:        "created",
+	})
+}
+
+func record(ctx context.Context, tx pgx.Tx, c Change) error {
+	query := `
+		INSERT INTO transaction_status_history (
+			service,
+			transaction_id,
+			from_status,
+			to_status,
+			actor_type,
+			actor_id,
+			reason
+		) VALUES (
+			@service,
+			@transaction_id,
+			NULLIF(@from_status, ''),
+			@to_status,
+			@actor_type,
+			NULLIF(@actor_id, ''),
+			@reason
+		);
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{
+		"service":        c.Service,
+		"transaction_id": c.TransactionID,
+		"from_status":    c.From,
+		"to_status":      c.To,
+		"actor_type":     c.Actor.Type,
+		"actor_id":       c.Actor.ID,
+		"reason":         c.Reason,
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+// Package rechargekit is the client for the RechargeKit aggregator, which
+// provides recharges, bill payments, payouts and DMT.
+//
+// RechargeKit serves its API from two hosts: the recharge host
+// (v2a.rechargkit.biz) and the primary host (v2bapi.rechargkit.biz), each
+// with its own wallet. Requests are typed per endpoint. Responses that are
+// passed through to our own API unchanged use the response models of the
+// models package.
+package rechargekit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultRechargeBaseURL = "https://v2a.rechargkit.biz"
+	DefaultPrimaryBaseURL  = "https://v2bapi.rechargkit.biz"
+	DefaultTimeout         = 20 * time.Second
+)
+
+// ErrUnknownOutcome is wrapped by errors that leave it unknown whether
+// RechargeKit received and acted on a request: timeouts and connection
+// errors after the request was sent, and responses that cannot be read.
+var ErrUnknownOutcome = errors.New("recharge kit outcome unknown")
+
+type Config struct {
+	// RechargeBaseURL serves recharges, bill payments and status checks.
+	RechargeBaseURL string
+	// PrimaryBaseURL serves payouts, DMT and plan lookups.
+	PrimaryBaseURL string
+	APIToken       string
+	Timeout        time.Duration
+}
+
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+// New returns a client sharing one http.Client for all requests. Empty
+// config fields fall back to the production hosts and DefaultTimeout.
+func New(cfg Config) *Client {
+	if cfg.RechargeBaseURL == "" {
+		cfg.RechargeBaseURL = DefaultRechargeBaseURL
+	}
+	if cfg.PrimaryBaseURL == "" {
+		cfg.PrimaryBaseURL = DefaultPrimaryBaseURL
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Status is the transaction status code RechargeKit reports.
+type Status int
+
+const (
+	StatusSuccess Status = 1
+	StatusPending Status = 2
+	StatusFailed  Status = 3
+)
+
+// String returns the transaction status stored for the code, or "" for a
+// code RechargeKit does not document.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "SUCCESS"
+	case StatusPending:
+		return "PENDING"
+	case StatusFailed:
+		return "FAILED"
+	default:
+		return ""
+	}
+}
+
+// TransactionResponse is the answer to every money-moving request.
+type TransactionResponse struct {
+	Error                 int    `json:"error"`
+	Message               string `json:"msg"`
+	Status                Status `json:"status"`
+	OrderID               string `json:"orderid"`
+	OperatorTransactionID string `json:"optransid"`
+	PartnerRequestID      string `json:"partnerreqid"`
+}
+
+func (c *Client) rechargeURL(path string) string {
+	return c.cfg.RechargeBaseURL + path
+}
+
+func (c *Client) primaryURL(path string) string {
+	return c.cfg.PrimaryBaseURL + path
+}
+
+// do sends a request and decodes the JSON response into out. A nil body
+// sends no request body; query, when set, is added to the URL. Errors once
+// the request may have reached RechargeKit wrap ErrUnknownOutcome.
+func (c *Client) do(
+	ctx context.Context,
+	method string,
+	apiURL string,
+	query url.Values,
+	body any,
+	out any,
+) error {
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	apiRequest, err := http.NewRequestWithContext(ctx, method, apiURL, reqBody)
+	if err != nil {
+		return err
+	}
+	apiRequest.Header.Set("Content-Type", "application/json")
+	apiRequest.Header.Set("Authorization", "Bearer "+c.cfg.APIToken)
+
+	resp, err := c.httpClient.Do(apiRequest)
+	if err != nil {
+		// A failed dial never sent the request.
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnknownOutcome, err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnknownOutcome, err)
+	}
+
+	if err := json.Unmarshal(respBytes, out); err != nil {
+		return fmt.Errorf("%w: invalid response from recharge kit: %w", ErrUnknownOutcome, err)
+	}
+	return nil
+}
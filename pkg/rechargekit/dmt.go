@@ -0,0 +1,142 @@
+package rechargekit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+type mobileRequest struct {
+	MobileNumber string `json:"mobile_no"`
+}
+
+// DMTWalletExists checks whether a remitter wallet exists for the mobile
+// number.
+func (c *Client) DMTWalletExists(ctx context.Context, mobileNumber string) (*models.DMTWalletCheckResponseModel, error) {
+	var res models.DMTWalletCheckResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/checkWalletExist"), nil, mobileRequest{mobileNumber}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type CreateDMTWalletRequest struct {
+	MobileNumber  string `json:"mobile_no"`
+	Latitude      string `json:"lat"`
+	Longitude     string `json:"long"`
+	AadhaarNumber string `json:"aadhaar_number"`
+	PidData       string `json:"pid_data"`
+	IsIris        int    `json:"is_iris"`
+}
+
+func (c *Client) CreateDMTWallet(ctx context.Context, req CreateDMTWalletRequest) (*models.DMTCreateWalletResponseModel, error) {
+	var res models.DMTCreateWalletResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/createWalletRequest"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type VerifyDMTWalletRequest struct {
+	MobileNumber     string `json:"mobile_no"`
+	OTP              string `json:"otp"`
+	EKycID           string `json:"ekyc_id"`
+	StateResp        string `json:"stateresp"`
+	PartnerRequestID string `json:"partner_request_id"`
+}
+
+func (c *Client) VerifyDMTWallet(ctx context.Context, req VerifyDMTWalletRequest) (*models.DMTWalletVerificationResponseModel, error) {
+	var res models.DMTWalletVerificationResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/verifyWalletRequest"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type AddDMTBeneficiaryRequest struct {
+	MobileNumber     string `json:"mobile_no"`
+	BeneficiaryName  string `json:"beneficiaryName"`
+	AccountNumber    string `json:"accountNo"`
+	IFSCCode         string `json:"ifsc"`
+	BankID           string `json:"bankId"`
+	PartnerRequestID string `json:"partner_request_id"`
+}
+
+func (c *Client) AddDMTBeneficiary(ctx context.Context, req AddDMTBeneficiaryRequest) (*models.DMTAddBeneficiaryResponseModel, error) {
+	var res models.DMTAddBeneficiaryResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/addBeneficiaryRequest"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DMTBeneficiaries returns the remitter registered for the mobile number
+// with its beneficiaries.
+func (c *Client) DMTBeneficiaries(ctx context.Context, mobileNumber string) (*models.DMTGetBeneficiaryResponseModel, error) {
+	var res models.DMTGetBeneficiaryResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/getUserDetails"), nil, mobileRequest{mobileNumber}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type VerifyDMTBeneficiaryRequest struct {
+	MobileNumber     string `json:"mobile_no"`
+	BeneficiaryID    string `json:"beneficiaryId"`
+	PartnerRequestID string `json:"partner_request_id"`
+}
+
+// VerifyDMTBeneficiary penny-drops a beneficiary's account and returns the
+// account holder name the bank has for it.
+func (c *Client) VerifyDMTBeneficiary(ctx context.Context, req VerifyDMTBeneficiaryRequest) (*models.DMTVerifyBeneficiaryResponseModel, error) {
+	var res models.DMTVerifyBeneficiaryResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/verifyBeneficiary"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) DMTBankList(ctx context.Context) (*models.DMTBankListResponseModel, error) {
+	var res models.DMTBankListResponseModel
+	if err := c.do(ctx, http.MethodGet, c.primaryURL("/rkitdmr/getBankList"), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type DMTTransferOTPRequest struct {
+	MobileNumber  string       `json:"mobile_no"`
+	BeneficiaryID string       `json:"beneficiaryId"`
+	Amount        models.Money `json:"amount"`
+}
+
+// DMTTransferOTP sends the remitter the OTP that confirms a transfer. The
+// stateresp of the response goes with the OTP into the transfer.
+func (c *Client) DMTTransferOTP(ctx context.Context, req DMTTransferOTPRequest) (*models.DMTTransferOTPResponseModel, error) {
+	var res models.DMTTransferOTPResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/sendTransactionOtp"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type DMTTransferRequest struct {
+	MobileNumber     string       `json:"mobile_no"`
+	BeneficiaryID    string       `json:"beneficiaryId"`
+	Amount           models.Money `json:"amount"`
+	TransferType     int          `json:"transfer_type"`
+	OTP              string       `json:"otp"`
+	StateResp        string       `json:"stateresp"`
+	Pincode          string       `json:"pincode,omitempty"`
+	Address          string       `json:"address,omitempty"`
+	PartnerRequestID string       `json:"partner_request_id"`
+}
+
+func (c *Client) DMTTransfer(ctx context.Context, req DMTTransferRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitdmr/moneyTransfer"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,267 @@
+package rechargekit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+const fakeAPIToken = "fake-rechargekit-token"
+
+// FakeServer is an in-process RechargeKit for running service flows offline.
+// It answers every endpoint the client uses from memory: transactions get
+// the configured response status, which status checks then report until it
+// is changed with SetTransactionStatus.
+type FakeServer struct {
+	server *httptest.Server
+
+	mu             sync.Mutex
+	responseStatus Status
+	transactions   map[string]Status
+	orderIDs       map[string]string
+	requests       []FakeRequest
+	orderSeq       int
+}
+
+// FakeRequest is a request received by the fake.
+type FakeRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// NewFakeServer starts a fake that accepts every transaction.
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{
+		responseStatus: StatusSuccess,
+		transactions:   make(map[string]Status),
+		orderIDs:       make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	for _, path := range []string{
+		"/recharge/prepaid",
+		"/recharge/dth",
+		"/recharge/postpaid",
+		"/recharge/billpayment",
+		"/rkitpayout/payoutTransfer",
+		"/rkitdmr/moneyTransfer",
+	} {
+		mux.HandleFunc("POST "+path, f.handleTransaction)
+	}
+	mux.HandleFunc("POST /recharge/statusCheck", f.handleStatusCheck)
+	mux.HandleFunc("GET /recharge/balanceCheck", f.reply(models.RechargeKitWalletBalanceResponseModel{
+		Message:         "Success",
+		WalletAmount:    models.Rupees(100000),
+		DMRWalletAmount: models.Rupees(100000),
+	}))
+	mux.HandleFunc("POST /recharge/prepaidPlanFetch", f.reply(models.GetMobileRechargePlansResponseModel{
+		Message:  "Success",
+		Status:   int(StatusSuccess),
+		PlanData: []any{},
+	}))
+	mux.HandleFunc("GET /recharge/postPaidBillFetch", f.reply(models.BBPSBillFetchResponseModel{
+		Message:    "Success",
+		Status:     int(StatusSuccess),
+		BillAmount: "499.00",
+	}))
+	mux.HandleFunc("GET /recharge/electricityBillFetch", f.reply(models.BBPSBillFetchResponseModel{
+		Message:    "Success",
+		Status:     int(StatusSuccess),
+		BillAmount: "1250.00",
+	}))
+	mux.HandleFunc("POST /rkitdmr/checkWalletExist", f.reply(models.DMTWalletCheckResponseModel{
+		Message:       "Success",
+		AccountExists: 1,
+	}))
+	mux.HandleFunc("POST /rkitdmr/createWalletRequest", f.reply(models.DMTCreateWalletResponseModel{
+		Message: "OTP sent",
+	}))
+	mux.HandleFunc("POST /rkitdmr/verifyWalletRequest", f.reply(models.DMTWalletVerificationResponseModel{
+		Message: "Wallet verified",
+	}))
+	mux.HandleFunc("POST /rkitdmr/addBeneficiaryRequest", f.reply(models.DMTAddBeneficiaryResponseModel{
+		Message: "Beneficiary added",
+		Status:  int(StatusSuccess),
+	}))
+	mux.HandleFunc("POST /rkitdmr/getUserDetails", f.reply(models.DMTGetBeneficiaryResponseModel{
+		Message:         "Success",
+		BeneficiaryList: []any{},
+	}))
+	mux.HandleFunc("POST /rkitdmr/verifyBeneficiary", f.reply(models.DMTVerifyBeneficiaryResponseModel{
+		Message:         "Beneficiary verified",
+		BeneficiaryName: "FAKE BENEFICIARY",
+		BankName:        "FAKE BANK",
+		AccountNumber:   "000000000000",
+		IFSCCode:        "FAKE0000000",
+	}))
+	mux.HandleFunc("POST /rkitdmr/sendTransactionOtp", f.reply(models.DMTTransferOTPResponseModel{
+		Message:   "OTP sent",
+		StateResp: "FAKESTATE",
+	}))
+	mux.HandleFunc("GET /rkitdmr/getBankList", f.reply(models.DMTBankListResponseModel{
+		Message:  "Success",
+		BankList: []any{},
+	}))
+
+	f.server = httptest.NewServer(f.authorize(mux))
+	return f
+}
+
+// URL is the base URL of the fake; it serves both RechargeKit hosts.
+func (f *FakeServer) URL() string {
+	return f.server.URL
+}
+
+// Config returns a client config pointing at the fake.
+func (f *FakeServer) Config() Config {
+	return Config{
+		RechargeBaseURL: f.server.URL,
+		PrimaryBaseURL:  f.server.URL,
+		APIToken:        fakeAPIToken,
+	}
+}
+
+// Client returns a client talking to the fake.
+func (f *FakeServer) Client() *Client {
+	return New(f.Config())
+}
+
+func (f *FakeServer) Close() {
+	f.server.Close()
+}
+
+// SetResponseStatus sets the status new transactions are answered with.
+func (f *FakeServer) SetResponseStatus(status Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responseStatus = status
+}
+
+// SetTransactionStatus changes what status checks report for a
+// transaction, e.g. to complete a pending one.
+func (f *FakeServer) SetTransactionStatus(partnerRequestID string, status Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transactions[partnerRequestID] = status
+}
+
+// Callback returns the status callback RechargeKit would send for a
+// transaction in its current status, for posting to our callback endpoint.
+func (f *FakeServer) Callback(partnerRequestID string) models.RechargeKitCallbackModel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.transactions[partnerRequestID]
+	callback := models.RechargeKitCallbackModel{
+		Status:           int(status),
+		Message:          "Transaction " + status.String(),
+		OrderId:          f.orderIDs[partnerRequestID],
+		PartnerRequestId: partnerRequestID,
+	}
+	if status == StatusSuccess {
+		callback.OperatorTransactionId = "OP" + callback.OrderId
+	}
+	return callback
+}
+
+// Requests returns the requests received so far.
+func (f *FakeServer) Requests() []FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeRequest(nil), f.requests...)
+}
+
+func (f *FakeServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, FakeRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+			Body:   body,
+		})
+		f.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+fakeAPIToken {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"error": 1,
+				"msg":   "invalid token",
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *FakeServer) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerRequestID string `json:"partner_request_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PartnerRequestID == "" {
+		writeJSON(w, http.StatusOK, TransactionResponse{
+			Error:   1,
+			Message: "partner_request_id is required",
+		})
+		return
+	}
+
+	f.mu.Lock()
+	status := f.responseStatus
+	f.transactions[req.PartnerRequestID] = status
+	f.orderSeq++
+	orderID := fmt.Sprintf("FAKE%08d", f.orderSeq)
+	f.orderIDs[req.PartnerRequestID] = orderID
+	f.mu.Unlock()
+
+	res := TransactionResponse{
+		Message:          "Transaction " + status.String(),
+		Status:           status,
+		OrderID:          orderID,
+		PartnerRequestID: req.PartnerRequestID,
+	}
+	if status == StatusSuccess {
+		res.OperatorTransactionID = "OP" + orderID
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (f *FakeServer) handleStatusCheck(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerRequestID string `json:"partner_request_id"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	status, ok := f.transactions[req.PartnerRequestID]
+	f.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusOK, StatusCheckResponse{Message: "transaction not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, StatusCheckResponse{
+		Status:  status,
+		Message: "Transaction " + status.String(),
+	})
+}
+
+func (f *FakeServer) reply(res any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
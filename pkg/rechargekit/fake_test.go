@@ -0,0 +1,134 @@
+package rechargekit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+func newTestFake(t *testing.T) *FakeServer {
+	t.Helper()
+	f := NewFakeServer()
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestFakePrepaidRecharge(t *testing.T) {
+	f := newTestFake(t)
+	client := f.Client()
+	ctx := context.Background()
+
+	res, err := client.PrepaidRecharge(ctx, PrepaidRechargeRequest{
+		MobileNumber:     9876543210,
+		OperatorCode:     1,
+		Amount:           models.Rupees(199),
+		PartnerRequestID: "req-success",
+		Circle:           5,
+		RechargeType:     1,
+	})
+	if err != nil {
+		t.Fatalf("PrepaidRecharge: %v", err)
+	}
+	if res.Status != StatusSuccess || res.OrderID == "" || res.OperatorTransactionID == "" {
+		t.Fatalf("PrepaidRecharge = %+v, want a successful order", res)
+	}
+
+	requests := f.Requests()
+	if len(requests) != 1 || requests[0].Path != "/recharge/prepaid" {
+		t.Fatalf("requests = %+v, want one prepaid recharge", requests)
+	}
+	var sent map[string]any
+	if err := json.Unmarshal(requests[0].Body, &sent); err != nil {
+		t.Fatalf("request body: %v", err)
+	}
+	if sent["amount"] != 199.0 || sent["partner_request_id"] != "req-success" {
+		t.Fatalf("request body = %s", requests[0].Body)
+	}
+}
+
+func TestFakePendingThenStatusCheck(t *testing.T) {
+	f := newTestFake(t)
+	client := f.Client()
+	ctx := context.Background()
+
+	f.SetResponseStatus(StatusPending)
+	res, err := client.DTHRecharge(ctx, DTHRechargeRequest{
+		CustomerID:       "1234567890",
+		OperatorCode:     2,
+		Amount:           models.Rupees(300),
+		PartnerRequestID: "req-pending",
+	})
+	if err != nil {
+		t.Fatalf("DTHRecharge: %v", err)
+	}
+	if res.Status != StatusPending {
+		t.Fatalf("DTHRecharge status = %v, want pending", res.Status)
+	}
+
+	check, err := client.StatusCheck(ctx, "req-pending")
+	if err != nil {
+		t.Fatalf("StatusCheck: %v", err)
+	}
+	if check.Status != StatusPending {
+		t.Fatalf("StatusCheck status = %v, want pending", check.Status)
+	}
+
+	f.SetTransactionStatus("req-pending", StatusSuccess)
+	check, err = client.StatusCheck(ctx, "req-pending")
+	if err != nil {
+		t.Fatalf("StatusCheck: %v", err)
+	}
+	if check.Status != StatusSuccess {
+		t.Fatalf("StatusCheck status = %v, want success", check.Status)
+	}
+
+	callback := f.Callback("req-pending")
+	if callback.Status != int(StatusSuccess) || callback.OrderId != res.OrderID || callback.OperatorTransactionId == "" {
+		t.Fatalf("Callback = %+v, want the completed order %s", callback, res.OrderID)
+	}
+}
+
+func TestFakeUnknownStatusCheck(t *testing.T) {
+	f := newTestFake(t)
+
+	check, err := f.Client().StatusCheck(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("StatusCheck: %v", err)
+	}
+	if check.Status.String() != "" {
+		t.Fatalf("StatusCheck status = %v, want none", check.Status)
+	}
+}
+
+func TestFakeRejectsBadToken(t *testing.T) {
+	f := newTestFake(t)
+	cfg := f.Config()
+	cfg.APIToken = "wrong"
+
+	res, err := New(cfg).Payout(context.Background(), PayoutRequest{PartnerRequestID: "req-auth"})
+	if err != nil {
+		t.Fatalf("Payout: %v", err)
+	}
+	if res.Error != 1 || res.Status.String() != "" {
+		t.Fatalf("Payout = %+v, want an error response", res)
+	}
+}
+
+func TestClientUnknownOutcome(t *testing.T) {
+	f := newTestFake(t)
+	client := f.Client()
+	f.Close()
+
+	// A closed server refuses the connection, so the request was never
+	// sent and the outcome is known.
+	_, err := client.PrepaidRecharge(context.Background(), PrepaidRechargeRequest{PartnerRequestID: "req-down"})
+	if err == nil {
+		t.Fatal("PrepaidRecharge against a closed server returned no error")
+	}
+	if errors.Is(err, ErrUnknownOutcome) {
+		t.Fatalf("PrepaidRecharge error %v wraps ErrUnknownOutcome for a failed dial", err)
+	}
+}
@@ -0,0 +1,27 @@
+package rechargekit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+type PayoutRequest struct {
+	MobileNumber     string       `json:"mobile_no"`
+	AccountNumber    string       `json:"account_no"`
+	IFSCCode         string       `json:"ifsc"`
+	BankName         string       `json:"bank_name"`
+	BeneficiaryName  string       `json:"beneficiary_name"`
+	Amount           models.Money `json:"amount"`
+	TransferType     int          `json:"transfer_type"`
+	PartnerRequestID string       `json:"partner_request_id"`
+}
+
+func (c *Client) Payout(ctx context.Context, req PayoutRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/rkitpayout/payoutTransfer"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,162 @@
+package rechargekit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/levion-studio/paybazaar/internal/models"
+)
+
+type PrepaidRechargeRequest struct {
+	MobileNumber     int64        `json:"mobile_no"`
+	OperatorCode     int          `json:"operator_code"`
+	Amount           models.Money `json:"amount"`
+	PartnerRequestID string       `json:"partner_request_id"`
+	Circle           int          `json:"circle"`
+	RechargeType     int          `json:"recharge_type"`
+}
+
+func (c *Client) PrepaidRecharge(ctx context.Context, req PrepaidRechargeRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.rechargeURL("/recharge/prepaid"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type DTHRechargeRequest struct {
+	CustomerID       string       `json:"customer_id"`
+	OperatorCode     int          `json:"operator_code"`
+	Amount           models.Money `json:"amount"`
+	PartnerRequestID string       `json:"partner_request_id"`
+}
+
+func (c *Client) DTHRecharge(ctx context.Context, req DTHRechargeRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.rechargeURL("/recharge/dth"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type PostpaidRechargeRequest struct {
+	MobileNumber     string       `json:"mobile_no"`
+	PartnerRequestID string       `json:"partner_request_id"`
+	OperatorCode     int          `json:"operator_code"`
+	Circle           int          `json:"circle"`
+	Amount           models.Money `json:"amount"`
+	RechargeType     int          `json:"recharge_type"`
+}
+
+func (c *Client) PostpaidRecharge(ctx context.Context, req PostpaidRechargeRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.rechargeURL("/recharge/postpaid"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type BillPaymentRequest struct {
+	// ConsumerID is the biller's customer number, sent as p1, and Param2
+	// and Param3 are the biller's further customer parameters.
+	ConsumerID       string       `json:"p1"`
+	Param2           string       `json:"p2,omitempty"`
+	Param3           string       `json:"p3,omitempty"`
+	PartnerRequestID string       `json:"partner_request_id"`
+	OperatorCode     int          `json:"operator_code"`
+	CustomerEmail    string       `json:"customer_email"`
+	Amount           models.Money `json:"amount"`
+}
+
+func (c *Client) BillPayment(ctx context.Context, req BillPaymentRequest) (*TransactionResponse, error) {
+	var res TransactionResponse
+	if err := c.do(ctx, http.MethodPost, c.rechargeURL("/recharge/billpayment"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type StatusCheckResponse struct {
+	Status  Status `json:"status"`
+	Message string `json:"msg"`
+}
+
+// StatusCheck returns the current status of any transaction sent with the
+// given partner request id.
+func (c *Client) StatusCheck(ctx context.Context, partnerRequestID string) (*StatusCheckResponse, error) {
+	req := map[string]string{
+		"partner_request_id": partnerRequestID,
+	}
+	var res StatusCheckResponse
+	if err := c.do(ctx, http.MethodPost, c.rechargeURL("/recharge/statusCheck"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type PrepaidPlansRequest struct {
+	OperatorCode int `json:"operator_code"`
+	Circle       int `json:"circle"`
+}
+
+func (c *Client) PrepaidPlans(ctx context.Context, req PrepaidPlansRequest) (*models.GetMobileRechargePlansResponseModel, error) {
+	var res models.GetMobileRechargePlansResponseModel
+	if err := c.do(ctx, http.MethodPost, c.primaryURL("/recharge/prepaidPlanFetch"), nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) PostpaidBillFetch(
+	ctx context.Context,
+	mobileNumber string,
+	operatorCode int,
+) (*models.BBPSBillFetchResponseModel, error) {
+	query := url.Values{
+		"mobile_no":     {mobileNumber},
+		"operator_code": {strconv.Itoa(operatorCode)},
+	}
+	var res models.BBPSBillFetchResponseModel
+	if err := c.do(ctx, http.MethodGet, c.rechargeURL("/recharge/postPaidBillFetch"), query, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (c *Client) ElectricityBillFetch(
+	ctx context.Context,
+	consumerID string,
+	operatorCode int,
+) (*models.BBPSBillFetchResponseModel, error) {
+	query := url.Values{
+		"consumer_id":   {consumerID},
+		"operator_code": {strconv.Itoa(operatorCode)},
+	}
+	var res models.BBPSBillFetchResponseModel
+	if err := c.do(ctx, http.MethodGet, c.rechargeURL("/recharge/electricityBillFetch"), query, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// RechargeWalletBalance returns the balance of the wallet recharges and bill
+// payments are paid from.
+func (c *Client) RechargeWalletBalance(ctx context.Context) (*models.RechargeKitWalletBalanceResponseModel, error) {
+	var res models.RechargeKitWalletBalanceResponseModel
+	if err := c.do(ctx, http.MethodGet, c.rechargeURL("/recharge/balanceCheck"), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// PrimaryWalletBalance returns the balance of the wallet payouts and DMT are
+// paid from.
+func (c *Client) PrimaryWalletBalance(ctx context.Context) (*models.RechargeKitWalletBalanceResponseModel, error) {
+	var res models.RechargeKitWalletBalanceResponseModel
+	if err := c.do(ctx, http.MethodGet, c.primaryURL("/recharge/balanceCheck"), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}